@@ -0,0 +1,67 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+
+	"bender/internal/analysis"
+	"bender/internal/bender"
+)
+
+// runAdvise runs the advise subcommand with the given arguments:
+// it prints the smallest edits making a looping map terminate
+func runAdvise(args []string) error {
+	flags := flag.NewFlagSet("advise", flag.ContinueOnError)
+	mapFile := flags.String("map", "", "file of the looping map, as text rows or JSON, - reads it from stdin (default the built-in map)")
+	top := flags.Int("top", 10, "number of fixes printed, all of them if 0")
+	maxSteps := flags.Int("max-steps", 0, "maximum number of steps of every simulation, unlimited if 0")
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+	if err := noArgs(flags); err != nil {
+		return err
+	}
+	if *top < 0 {
+		return fmt.Errorf("invalid number of fixes %d", *top)
+	}
+	plan, err := loadPlan(*mapFile, os.Stdin)
+	if err != nil {
+		return err
+	}
+	// the simulations of the variants share their configurations
+	memo := bender.NewMemo()
+	opts := []bender.Option{bender.WithMemo(memo)}
+	if *maxSteps > 0 {
+		opts = append(opts, bender.WithMaxSteps(*maxSteps))
+	}
+	fixes, err := analysis.Advise(plan, opts...)
+	if err != nil {
+		return err
+	}
+	printMemoStats(memo)
+	return advise(os.Stdout, fixes, *top)
+}
+
+// advise prints the top fixes, all of them if top is 0
+func advise(w io.Writer, fixes []analysis.Fix, top int) error {
+	if len(fixes) == 0 {
+		_, err := fmt.Fprintln(w, "no fix of one or two cells")
+		return err
+	}
+	if top > 0 && len(fixes) > top {
+		fixes = fixes[:top]
+	}
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintf(tw, "size\tsteps\tfix\n")
+	for _, f := range fixes {
+		fmt.Fprintf(tw, "%d\t%d\t%s\n", f.Size(), f.Steps, f)
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"bender/internal/analysis"
+)
+
+func TestAdvise(t *testing.T) {
+	fixes, err := analysis.Advise([]string{"#######", "#  #  #", "#@ # $#", "#######"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	buf := &bytes.Buffer{}
+	if err := advise(buf, fixes, 0); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected := "size  steps  fix\n1     4      remove the wall at (3,2)\n"
+	if buf.String() != expected {
+		t.Fatalf("Wrong advice. Expected %q, got %q", expected, buf.String())
+	}
+
+	buf.Reset()
+	if err := advise(buf, nil, 0); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if buf.String() != "no fix of one or two cells\n" {
+		t.Fatalf("Wrong advice without fix: %q", buf.String())
+	}
+}
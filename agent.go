@@ -0,0 +1,91 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"bender/internal/agent"
+	"bender/internal/bender"
+	"bender/internal/render"
+)
+
+// runAgent runs the agent subcommand with the given arguments:
+// it simulates a map with the directions chosen by an external program, talking JSON lines on its stdin and stdout
+func runAgent(args []string) error {
+	flags := flag.NewFlagSet("agent", flag.ContinueOnError)
+	mapFile := flags.String("map", "", "file of the map to simulate, as text rows or JSON, - reads it from stdin (default the built-in map)")
+	radius := flags.Int("radius", 1, "distance of the cells around Bender sent to the agent")
+	maxSteps := flags.Int("max-steps", 100000, "stop the simulation after the given number of steps (0 means no limit)")
+	timeout := flags.Duration("timeout", time.Minute, "stop the simulation after the given duration (0 means no limit)")
+	labelConf := flags.String("labels", "words", "direction labels: words, letters, arrows or a list like SOUTH=S,NORTH=N")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: agent [flags] command [args...]")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+	if flags.NArg() == 0 {
+		return fmt.Errorf("expected the command of the agent")
+	}
+	if *radius < 1 {
+		return fmt.Errorf("invalid radius %d", *radius)
+	}
+	labels, err := render.ParseLabels(*labelConf)
+	if err != nil {
+		return err
+	}
+	plan, err := loadPlan(*mapFile, os.Stdin)
+	if err != nil {
+		return err
+	}
+	res, err := playAgent(plan, flags.Args(), *radius, bender.WithMaxSteps(*maxSteps), bender.WithTimeout(*timeout))
+	if err != nil {
+		return err
+	}
+	return printAgentResult(os.Stdout, res, labels)
+}
+
+// playAgent starts the agent command and simulates the plan with its directions
+// the agent is killed if it fails to answer, its stderr is the one of bender
+func playAgent(plan []string, command []string, radius int, opts ...bender.Option) (bender.Result, error) {
+	cmd := exec.Command(command[0], command[1:]...)
+	cmd.Stderr = os.Stderr
+	in, err := cmd.StdinPipe()
+	if err != nil {
+		return bender.Result{}, err
+	}
+	out, err := cmd.StdoutPipe()
+	if err != nil {
+		return bender.Result{}, err
+	}
+	if err := cmd.Start(); err != nil {
+		return bender.Result{}, err
+	}
+	res, err := agent.Play(plan, agent.New(out, in, radius), opts...)
+	in.Close()
+	if err != nil {
+		cmd.Process.Kill()
+		cmd.Wait()
+		return res, err
+	}
+	if err := cmd.Wait(); err != nil {
+		return res, fmt.Errorf("agent: %v", err)
+	}
+	return res, nil
+}
+
+// printAgentResult prints the outcome and the path of the agent
+func printAgentResult(w io.Writer, res bender.Result, labels render.Labels) error {
+	_, err := fmt.Fprintf(w, "%v after %d steps\n[%s]\n", res.Outcome, res.Steps, strings.Join(labels.Path(res.Path), " "))
+	return err
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"bytes"
+	"os/exec"
+	"testing"
+
+	"bender/internal/bender"
+	"bender/internal/render"
+)
+
+func TestPlayAgent(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("no shell to run the agents")
+	}
+	plan := []string{
+		"#####",
+		"#@  #",
+		"#   #",
+		"#  $#",
+		"#####",
+	}
+	testCases := []struct {
+		name     string
+		script   string
+		expected string
+		err      bool
+	}{
+		{name: "diagonal", script: `while read -r o; do case "$o" in *outcome*) exit 0;; *'"step":0,'*|*'"step":2,'*) echo E;; *) echo S;; esac; done`,
+			expected: "reached after 4 steps\n[E S E S]\n"},
+		{name: "stuck", script: `while read -r o; do echo N; done`, expected: "died after 0 steps\n[]\n"},
+		{name: "silent", script: `exit 0`, err: true},
+		{name: "failure", script: `while read -r o; do echo E; done; exit 3`, err: true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			res, err := playAgent(plan, []string{"sh", "-c", tc.script}, 1, bender.WithMaxSteps(100))
+			if tc.err {
+				if err == nil {
+					t.Fatalf("Expected an error, got %v", res.Outcome)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			buf := &bytes.Buffer{}
+			if err := printAgentResult(buf, res, render.LetterLabels); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if buf.String() != tc.expected {
+				t.Fatalf("Wrong result. Expected %q, got %q", tc.expected, buf.String())
+			}
+		})
+	}
+	if _, err := playAgent(plan, []string{"./no-such-agent"}, 1); err == nil {
+		t.Fatalf("Expected an error for a missing agent")
+	}
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"bender/internal/bender"
+)
+
+// memSampleInterval is the number of events between two samples of the heap
+const memSampleInterval = 1 << 16
+
+// benchStats are the measures of a benchmark run
+type benchStats struct {
+	steps    int
+	elapsed  time.Duration
+	peakHeap uint64
+	allocs   uint64
+	bytes    uint64
+}
+
+// runBench runs the bench subcommand with the given arguments:
+// it simulates a generated map and prints the throughput and the memory used
+func runBench(args []string) error {
+	flags := flag.NewFlagSet("bench", flag.ContinueOnError)
+	size := flags.Int("size", 1000, "number of rows and columns of the generated map")
+	runs := flags.Int("runs", 1, "number of simulations of the map, the best run is printed")
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+	if err := noArgs(flags); err != nil {
+		return err
+	}
+	return bench(os.Stdout, *size, *runs)
+}
+
+// bench simulates the generated map of the given size the given number of times and prints the fastest run
+func bench(w io.Writer, size, runs int) error {
+	if size < 5 {
+		return fmt.Errorf("size %d too small, the map needs at least 5 rows and columns", size)
+	}
+	if runs < 1 {
+		return fmt.Errorf("invalid number of runs %d", runs)
+	}
+	plan := benchPlan(size)
+	var best benchStats
+	for i := 0; i < runs; i++ {
+		st, err := benchRun(plan)
+		if err != nil {
+			return err
+		}
+		if i == 0 || st.elapsed < best.elapsed {
+			best = st
+		}
+	}
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintf(tw, "map\t%dx%d\n", size, size)
+	fmt.Fprintf(tw, "go\t%s %s/%s, %d CPUs\n", runtime.Version(), runtime.GOOS, runtime.GOARCH, runtime.NumCPU())
+	fmt.Fprintf(tw, "steps\t%d\n", best.steps)
+	fmt.Fprintf(tw, "time\t%v\n", best.elapsed)
+	fmt.Fprintf(tw, "states/s\t%.0f\n", float64(best.steps)/best.elapsed.Seconds())
+	fmt.Fprintf(tw, "peak heap\t%.1f MB\n", float64(best.peakHeap)/1e6)
+	fmt.Fprintf(tw, "allocs\t%d (%.1f MB)\n", best.allocs, float64(best.bytes)/1e6)
+	return tw.Flush()
+}
+
+// benchRun simulates the map once
+// the heap is sampled periodically during the run, its peak is approximate
+func benchRun(plan []string) (benchStats, error) {
+	runtime.GC()
+	var before, ms runtime.MemStats
+	runtime.ReadMemStats(&before)
+	st := benchStats{peakHeap: before.HeapAlloc}
+	events := 0
+	hook := func() error {
+		events++
+		if events%memSampleInterval == 0 {
+			runtime.ReadMemStats(&ms)
+			if ms.HeapAlloc > st.peakHeap {
+				st.peakHeap = ms.HeapAlloc
+			}
+		}
+		return nil
+	}
+	start := time.Now()
+	res, err := bender.Run(plan, bender.WithEventHook(hook))
+	st.elapsed = time.Since(start)
+	if err != nil {
+		return st, err
+	}
+	if res.Outcome != bender.Reached {
+		return st, fmt.Errorf("generated map ended with outcome %s", res.Outcome)
+	}
+	runtime.ReadMemStats(&ms)
+	if ms.HeapAlloc > st.peakHeap {
+		st.peakHeap = ms.HeapAlloc
+	}
+	st.steps = res.Steps
+	st.allocs = ms.Mallocs - before.Mallocs
+	st.bytes = ms.TotalAlloc - before.TotalAlloc
+	return st, nil
+}
+
+// benchPlan returns a square map where Bender zigzags through all the corridors,
+// breaking walls and crossing modifiers and inverters, before reaching the booth
+func benchPlan(size int) []string {
+	rows := make([][]byte, size)
+	for y := range rows {
+		rows[y] = []byte(strings.Repeat("#", size))
+		if y%2 == 1 && y < size-1 {
+			for x := 1; x < size-1; x++ {
+				switch {
+				case x%7 == 0:
+					rows[y][x] = 'X'
+				case x%11 == 0:
+					rows[y][x] = 'I'
+				default:
+					rows[y][x] = ' '
+				}
+			}
+		}
+	}
+	last := 1
+	for y := 1; y+2 < size-1; y += 2 {
+		last = y + 2
+		// the corridors go east and west in turn
+		end, dir := size-2, byte('W')
+		if (y/2)%2 == 1 {
+			end, dir = 1, 'E'
+		}
+		rows[y][end] = 'S'
+		rows[y+1][end] = ' '
+		rows[y+2][end] = dir
+	}
+	rows[1][1] = '@'
+	rows[1][2] = 'B'
+	rows[1][3] = 'E'
+	if (last/2)%2 == 1 {
+		rows[last][1] = '$'
+	} else {
+		rows[last][size-2] = '$'
+	}
+	plan := make([]string, size)
+	for y, r := range rows {
+		plan[y] = string(r)
+	}
+	return plan
+}
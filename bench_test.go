@@ -0,0 +1,24 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestBench(t *testing.T) {
+	for _, size := range []int{5, 6, 7, 8, 50, 51} {
+		buf := &bytes.Buffer{}
+		if err := bench(buf, size, 2); err != nil {
+			t.Fatalf("Size %d: unexpected error: %v", size, err)
+		}
+		for _, field := range []string{"map ", "steps ", "states/s ", "peak heap ", "allocs "} {
+			if !strings.Contains(buf.String(), field) {
+				t.Fatalf("Size %d: no %q in the output:\n%s", size, field, buf.String())
+			}
+		}
+	}
+	if err := bench(&bytes.Buffer{}, 4, 1); err == nil {
+		t.Fatalf("Expected an error for a too small map")
+	}
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+
+	"bender/internal/analysis"
+	"bender/internal/bender"
+)
+
+// runBreakers runs the breakers subcommand with the given arguments:
+// it reports the breakable walls of a map which are unnecessary to reach the booth
+func runBreakers(args []string) error {
+	flags := flag.NewFlagSet("breakers", flag.ContinueOnError)
+	mapFile := flags.String("map", "", "file of the map to analyze, as text rows or JSON, - reads it from stdin (default the built-in map)")
+	maxSteps := flags.Int("max-steps", 0, "maximum number of steps of every simulation, unlimited if 0")
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+	if err := noArgs(flags); err != nil {
+		return err
+	}
+	plan, err := loadPlan(*mapFile, os.Stdin)
+	if err != nil {
+		return err
+	}
+	// the simulations of the variants share their configurations
+	memo := bender.NewMemo()
+	opts := []bender.Option{bender.WithMemo(memo)}
+	if *maxSteps > 0 {
+		opts = append(opts, bender.WithMaxSteps(*maxSteps))
+	}
+	r, err := analysis.Breakers(plan, opts...)
+	if err != nil {
+		return err
+	}
+	printMemoStats(memo)
+	return breakers(os.Stdout, r)
+}
+
+// breakers prints the use of every breakable wall and the number of redundant ones
+func breakers(w io.Writer, r analysis.BreakerReport) error {
+	if len(r.Breakables) == 0 {
+		_, err := fmt.Fprintln(w, "no breakable wall")
+		return err
+	}
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintf(tw, "original\t\t%v\t%d\t\n", r.Outcome, r.Steps)
+	fmt.Fprintf(tw, "wall\tdestroyed\tunbreakable\tsteps\tverdict\n")
+	for _, b := range r.Breakables {
+		destroyed, verdict := "-", "redundant"
+		if b.Destroyed() {
+			destroyed = fmt.Sprintf("step %d", b.Step)
+		}
+		if b.Necessary {
+			verdict = "necessary"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%v\t%d\t%s\n", b.At, destroyed, b.Outcome, b.Steps, verdict)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintf(w, "%d of %d breakable walls are redundant\n", len(r.Redundant()), len(r.Breakables))
+	return err
+}
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"bender/internal/analysis"
+)
+
+func TestBreakers(t *testing.T) {
+	r, err := analysis.Breakers([]string{"########", "#@BX  $#", "##   X #", "########"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	buf := &bytes.Buffer{}
+	if err := breakers(buf, r); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected := "original             reached      5      \n" +
+		"wall      destroyed  unbreakable  steps  verdict\n" +
+		"(3,1)     step 2     reached      7      redundant\n" +
+		"(5,2)     -          reached      5      redundant\n" +
+		"2 of 2 breakable walls are redundant\n"
+	if buf.String() != expected {
+		t.Fatalf("Wrong report. Expected %q, got %q", expected, buf.String())
+	}
+
+	buf.Reset()
+	if err := breakers(buf, analysis.BreakerReport{}); err != nil || buf.String() != "no breakable wall\n" {
+		t.Fatalf("Wrong report without breakable wall %q: %v", buf.String(), err)
+	}
+}
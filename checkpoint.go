@@ -0,0 +1,41 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// checkpointConf tells how often and where the checkpoints are saved
+type checkpointConf struct {
+	every int
+	file  string
+}
+
+// parseCheckpointConf parses the checkpoint configuration like "every=1000 file=ckpt.json"
+// the settings can be separated by spaces or commas
+func parseCheckpointConf(conf string) (checkpointConf, error) {
+	c := checkpointConf{}
+	for _, kv := range strings.FieldsFunc(conf, func(r rune) bool { return r == ' ' || r == ',' }) {
+		parts := strings.SplitN(kv, "=", 2)
+		if len(parts) != 2 {
+			return c, fmt.Errorf("bad checkpoint setting %q, expected key=value", kv)
+		}
+		switch parts[0] {
+		case "every":
+			n, err := strconv.Atoi(parts[1])
+			if err != nil || n <= 0 {
+				return c, fmt.Errorf("bad checkpoint interval %q", parts[1])
+			}
+			c.every = n
+		case "file":
+			c.file = parts[1]
+		default:
+			return c, fmt.Errorf("unknown checkpoint setting %q", parts[0])
+		}
+	}
+	if c.every == 0 || c.file == "" {
+		return c, fmt.Errorf("checkpoint needs both every and file settings")
+	}
+	return c, nil
+}
@@ -0,0 +1,208 @@
+// Package client is the Go client of the API served by bender serve, described by schema/openapi.json
+package client
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"bender/internal/server"
+)
+
+// SimulateRequest is the body of a simulation request
+type SimulateRequest = server.SimulateRequest
+
+// SimulateResponse is the result of a simulation
+type SimulateResponse = server.SimulateResponse
+
+// DestroyedWall is a breakable wall destroyed by Bender
+type DestroyedWall = server.DestroyedWall
+
+// StepEvent is a step of a streamed simulation
+type StepEvent = server.StepEvent
+
+// ValidateResponse is the result of a validation
+type ValidateResponse = server.ValidateResponse
+
+// MapError is an error found in a map
+type MapError = server.MapError
+
+// codes of the errors
+const (
+	CodeMethodNotAllowed     = server.CodeMethodNotAllowed
+	CodeMalformedRequest     = server.CodeMalformedRequest
+	CodeUnsupportedMediaType = server.CodeUnsupportedMediaType
+	CodeInvalidMap           = server.CodeInvalidMap
+	CodeMapTooLarge          = server.CodeMapTooLarge
+	CodeStepsOverLimit       = server.CodeStepsOverLimit
+	CodeRateLimited          = server.CodeRateLimited
+)
+
+// Error is the error of a request refused by the server
+type Error struct {
+	// HTTP status of the response
+	Status int
+	// code of the error, one of the Code constants
+	Code string
+	// description of the error
+	Message string
+	// errors found in the map, if any
+	Details []MapError
+	// delay before retrying a rate limited request
+	RetryAfter time.Duration
+}
+
+// Error returns the description of the error
+func (e *Error) Error() string {
+	code := e.Code
+	if code == "" {
+		code = http.StatusText(e.Status)
+	}
+	return fmt.Sprintf("%s (%d): %s", code, e.Status, e.Message)
+}
+
+// Client calls the API of a server
+type Client struct {
+	base string
+	hc   *http.Client
+}
+
+// New returns the client of the server at the given base URL, like http://localhost:8080
+// the default HTTP client is used if hc is nil
+func New(baseURL string, hc *http.Client) *Client {
+	if hc == nil {
+		hc = http.DefaultClient
+	}
+	return &Client{base: strings.TrimSuffix(baseURL, "/"), hc: hc}
+}
+
+// NewUnix returns the client of the server listening on the Unix domain socket at the given path
+func NewUnix(socket string) *Client {
+	dialer := &net.Dialer{}
+	return New("http://bender", &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return dialer.DialContext(ctx, "unix", socket)
+		},
+	}})
+}
+
+// Simulate simulates the map of the request
+func (c *Client) Simulate(ctx context.Context, req SimulateRequest) (*SimulateResponse, error) {
+	resp := &SimulateResponse{}
+	if err := c.call(ctx, "/v1/simulate", req, resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// Validate validates the map
+func (c *Client) Validate(ctx context.Context, plan []string) (*ValidateResponse, error) {
+	resp := &ValidateResponse{}
+	if err := c.call(ctx, "/v1/validate", server.ValidateRequest{Plan: plan}, resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// Events simulates the map of the request and calls the given function for every step as it's received
+// the stream is stopped with the error returned by the function
+func (c *Client) Events(ctx context.Context, req SimulateRequest, step func(StepEvent) error) (*SimulateResponse, error) {
+	resp, err := c.post(ctx, "/v1/simulate/events", req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	event := ""
+	s := bufio.NewScanner(resp.Body)
+	s.Buffer(nil, 1<<26)
+	for s.Scan() {
+		line := s.Text()
+		switch {
+		case strings.HasPrefix(line, "event: "):
+			event = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			data := []byte(strings.TrimPrefix(line, "data: "))
+			switch event {
+			case "step":
+				e := StepEvent{}
+				if err := json.Unmarshal(data, &e); err != nil {
+					return nil, err
+				}
+				if err := step(e); err != nil {
+					return nil, err
+				}
+			case "result":
+				res := &SimulateResponse{}
+				if err := json.Unmarshal(data, res); err != nil {
+					return nil, err
+				}
+				return res, nil
+			case "error":
+				return nil, decodeError(resp.StatusCode, resp.Header, data)
+			}
+		}
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+	return nil, errors.New("event stream ended without a result")
+}
+
+// call posts the request as JSON and decodes the response
+func (c *Client) call(ctx context.Context, path string, req, resp interface{}) error {
+	r, err := c.post(ctx, path, req)
+	if err != nil {
+		return err
+	}
+	defer r.Body.Close()
+	return json.NewDecoder(r.Body).Decode(resp)
+}
+
+// post posts the request as JSON, the refused requests are returned as an Error
+func (c *Client) post(ctx context.Context, path string, req interface{}) (*http.Response, error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	r, err := http.NewRequestWithContext(ctx, http.MethodPost, c.base+path, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	r.Header.Set("Content-Type", "application/json")
+	resp, err := c.hc.Do(r)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		data, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return nil, err
+		}
+		return nil, decodeError(resp.StatusCode, resp.Header, data)
+	}
+	return resp, nil
+}
+
+// decodeError returns the error of a refused request
+func decodeError(status int, header http.Header, data []byte) error {
+	er := server.ErrorResponse{}
+	if err := json.Unmarshal(data, &er); err != nil || er.Code == "" {
+		return &Error{Status: status, Message: strings.TrimSpace(string(data))}
+	}
+	e := &Error{Status: status, Code: er.Code, Message: er.Error, Details: er.Details}
+	if s, err := strconv.Atoi(header.Get("Retry-After")); err == nil {
+		e.RetryAfter = time.Duration(s) * time.Second
+	}
+	return e
+}
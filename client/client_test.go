@@ -0,0 +1,99 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"bender/internal/server"
+)
+
+func TestClient(t *testing.T) {
+	srv := httptest.NewServer(server.NewHandler(server.Limits{MaxRows: 10}))
+	defer srv.Close()
+	c := New(srv.URL+"/", nil)
+	ctx := context.Background()
+	plan := []string{"#####", "#@ $#", "#####"}
+
+	res, err := c.Simulate(ctx, SimulateRequest{Plan: plan})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected := &SimulateResponse{Outcome: "reached", Path: []string{"EAST", "EAST"}, Steps: 2, X: 3, Y: 1, Destroyed: []DestroyedWall{}}
+	if !reflect.DeepEqual(res, expected) {
+		t.Fatalf("Wrong result. Expected %+v, got %+v", expected, res)
+	}
+
+	steps := []StepEvent{}
+	res, err = c.Events(ctx, SimulateRequest{Plan: plan}, func(e StepEvent) error {
+		steps = append(steps, e)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expectedSteps := []StepEvent{
+		{Step: 1, Direction: "EAST", X: 2, Y: 1, Tile: " ", Rule: "priority fallback"},
+		{Step: 2, Direction: "EAST", X: 3, Y: 1, Tile: "$", Rule: "forward", Effect: "booth"},
+	}
+	if !reflect.DeepEqual(res, expected) || !reflect.DeepEqual(steps, expectedSteps) {
+		t.Fatalf("Wrong events. Expected %+v and %+v, got %+v and %+v", expectedSteps, expected, steps, res)
+	}
+	stop := errors.New("stop")
+	if _, err := c.Events(ctx, SimulateRequest{Plan: plan}, func(StepEvent) error { return stop }); err != stop {
+		t.Fatalf("Wrong error of a stopped stream. Expected %v, got %v", stop, err)
+	}
+
+	v, err := c.Validate(ctx, []string{"###", "#T@", "###"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if v.Valid || len(v.Errors) != 1 || v.Errors[0].Row != 2 || v.Errors[0].Col != 2 {
+		t.Fatalf("Wrong validation, got %+v", v)
+	}
+
+	_, err = c.Simulate(ctx, SimulateRequest{Plan: make([]string, 11)})
+	var e *Error
+	if !errors.As(err, &e) || e.Status != http.StatusRequestEntityTooLarge || e.Code != CodeMapTooLarge {
+		t.Fatalf("Wrong error of a too large map, got %v", err)
+	}
+	_, err = c.Events(ctx, SimulateRequest{Plan: []string{"###", "#T@", "###"}}, func(StepEvent) error { return nil })
+	if !errors.As(err, &e) || e.Code != CodeInvalidMap || len(e.Details) != 1 {
+		t.Fatalf("Wrong error of an invalid map, got %v", err)
+	}
+}
+
+func TestClientRateLimited(t *testing.T) {
+	srv := httptest.NewServer(server.NewHandler(server.Limits{Rate: 0.5, Burst: 1}))
+	defer srv.Close()
+	c := New(srv.URL, nil)
+	plan := []string{"####", "#@$#", "####"}
+	if _, err := c.Simulate(context.Background(), SimulateRequest{Plan: plan}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	_, err := c.Simulate(context.Background(), SimulateRequest{Plan: plan})
+	var e *Error
+	if !errors.As(err, &e) || e.Code != CodeRateLimited || e.RetryAfter != 2*time.Second {
+		t.Fatalf("Wrong error of a rate limited request, got %+v", err)
+	}
+}
+
+func TestClientUnix(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "bender.sock")
+	l, err := server.ListenUnix(socket)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	srv := &http.Server{Handler: server.NewHandler(server.Limits{})}
+	go srv.Serve(l)
+	defer srv.Close()
+	res, err := NewUnix(socket).Simulate(context.Background(), SimulateRequest{Plan: []string{"####", "#@$#", "####"}})
+	if err != nil || res.Outcome != "reached" {
+		t.Fatalf("Wrong result on the Unix socket, got %+v, %v", res, err)
+	}
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"bender/internal/bender"
+)
+
+// errMapRead stops the reading of the input once the map of the puzzle is read
+var errMapRead = errors.New("map read")
+
+// runCodinGame simulates the map given like in the CodinGame puzzle and writes the answer of the puzzle:
+// the input is a line with the number of rows and columns followed by the rows,
+// the output is a direction per line or a single LOOP line
+func runCodinGame(r io.Reader, w io.Writer, opts ...bender.Option) error {
+	var plan []string
+	err := readMaps(r, "length", func(p []string) error {
+		plan = p
+		return errMapRead
+	})
+	if err != nil && err != errMapRead {
+		return err
+	}
+	if plan == nil {
+		return fmt.Errorf("no map in the input")
+	}
+	res, err := bender.Run(plan, opts...)
+	if err != nil {
+		return err
+	}
+	return writeAnswer(w, res)
+}
+
+// writeAnswer writes the answer of the puzzle for the result: a direction per line or a single LOOP line
+// an error is returned if Bender neither reached the booth nor looped
+func writeAnswer(w io.Writer, res bender.Result) error {
+	if err := res.Err(); err != nil && !errors.Is(err, bender.ErrLoop) {
+		return err
+	}
+	for _, dir := range res.ClassicPath() {
+		if _, err := fmt.Fprintln(w, dir); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestRunCodinGame(t *testing.T) {
+	testCases := []struct {
+		name     string
+		input    string
+		expected string
+		err      bool
+	}{
+		{name: "reached", input: "5 5\n#####\n#@  #\n#   #\n#  $#\n#####\n", expected: "SOUTH\nSOUTH\nEAST\nEAST\n"},
+		{name: "loop", input: "3 5\r\n#####\r\n#@EW#\r\n#####\r\n", expected: "LOOP\n"},
+		{name: "next maps ignored", input: "3 4\n####\n#@$#\n####\n3 4\n####\n#$@#\n####\n", expected: "EAST\n"},
+		{name: "escaped", input: "3 4\n####\n#@  \n####\n", err: true},
+		{name: "truncated", input: "5 5\n#####\n#@  #\n", err: true},
+		{name: "bad header", input: "five five\n", err: true},
+		{name: "empty", input: "", err: true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			out := &bytes.Buffer{}
+			err := runCodinGame(strings.NewReader(tc.input), out)
+			if (err != nil) != tc.err {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if out.String() != tc.expected {
+				t.Fatalf("Wrong answer. Expected %q, got %q", tc.expected, out.String())
+			}
+		})
+	}
+}
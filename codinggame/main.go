@@ -1,5 +1,6 @@
 package main
 
+import "errors"
 import "fmt"
 import "os"
 import "bufio"
@@ -9,19 +10,69 @@ import "bufio"
  * the standard input according to the problem statement.
  **/
 
+// Direction is a cardinal direction, the event of the transitions of the machine (same as fsm.Direction)
+type Direction string
+
 const (
 	// SOUTH direction
-	SOUTH = "SOUTH"
+	SOUTH Direction = "SOUTH"
 	// NORTH direction
-	NORTH = "NORTH"
+	NORTH Direction = "NORTH"
 	// EAST direction
-	EAST = "EAST"
+	EAST Direction = "EAST"
 	// WEST direction
-	WEST = "WEST"
+	WEST Direction = "WEST"
 	// LOOP indicator
 	LOOP = "LOOP"
 )
 
+// ParseDirection returns the direction of the given name, the error wraps ErrUnknownDirection for the other names
+func ParseDirection(s string) (Direction, error) {
+	if _, ok := Direction(s).Delta(); !ok {
+		return "", fmt.Errorf("%w %q", ErrUnknownDirection, s)
+	}
+	return Direction(s), nil
+}
+
+// Delta returns the offset of a step in the direction, false if the direction isn't valid
+func (d Direction) Delta() (Pair, bool) {
+	switch d {
+	case SOUTH:
+		return Pair{0, 1}, true
+	case NORTH:
+		return Pair{0, -1}, true
+	case EAST:
+		return Pair{1, 0}, true
+	case WEST:
+		return Pair{-1, 0}, true
+	}
+	return Pair{}, false
+}
+
+// Opposite returns the direction going back, empty if the direction isn't valid
+func (d Direction) Opposite() Direction {
+	switch d {
+	case SOUTH:
+		return NORTH
+	case NORTH:
+		return SOUTH
+	case EAST:
+		return WEST
+	case WEST:
+		return EAST
+	}
+	return ""
+}
+
+// errors of the maps and of the machine, the returned errors wrap them (same as the fsm package)
+var (
+	ErrNoStart          = errors.New("no start in the map")
+	ErrBadTeleports     = errors.New("teleports badly setup")
+	ErrInvalidSymbol    = errors.New("invalid tile")
+	ErrOutOfBounds      = errors.New("out of the board")
+	ErrUnknownDirection = errors.New("unknown direction")
+)
+
 // BenderSimulator simulates more rudimentary Bender
 type BenderSimulator struct {
 	done         bool
@@ -30,27 +81,40 @@ type BenderSimulator struct {
 	resetDir     bool
 	invertPrio   bool
 	currDir      int
-	priorities   []string
-	pathModifier string
+	priorities   []Direction
+	pathModifier Direction
 	path         []string
-	cache        map[string]bool
-	loopCnt      int
-	maxNumStates int
+	// configurations of Bender after every step, a repeated one is a loop
+	configs map[loopState]bool
+	loop    bool
+	// number of walls destroyed, the board of the configurations
+	breaks int
+}
+
+// loopState is the configuration of Bender after a step, it decides all his next steps
+type loopState struct {
+	state    StateKey
+	breaks   int
+	modifier Direction
+	currDir  int
+	// the priorities are only turned over, the first one tells their order
+	first    Direction
+	breaker  bool
+	inverted bool
+	resetDir bool
 }
 
 // NewBenderSimulator returns an instance of a bender simulator
-// the number of valid (without the frame) states is expected as parameter
-func NewBenderSimulator(stateNum int) *BenderSimulator {
+func NewBenderSimulator() *BenderSimulator {
 	return &BenderSimulator{
-		priorities: []string{
+		priorities: []Direction{
 			SOUTH,
 			EAST,
 			NORTH,
 			WEST,
 		},
-		path:         []string{},
-		cache:        map[string]bool{},
-		maxNumStates: stateNum,
+		path:    []string{},
+		configs: map[loopState]bool{},
 	}
 }
 
@@ -59,16 +123,13 @@ func (b *BenderSimulator) Done() bool {
 	return b.done
 }
 
-// Loop returns true if an endless cycle is found
+// Loop returns true if an endless cycle is found: Bender is back in a configuration he already was in
 func (b *BenderSimulator) Loop() bool {
-	if b.loopCnt > b.maxNumStates {
-		return true
-	}
-	return false
+	return b.loop
 }
 
 // Direction gives the direction to be followed
-func (b *BenderSimulator) Direction() string {
+func (b *BenderSimulator) Direction() Direction {
 	if b.pathModifier != "" {
 		return b.pathModifier
 	}
@@ -122,20 +183,28 @@ func (b *BenderSimulator) turnoverPriorities() {
 
 // Remember records the given direction and the state
 // of course, they are supposed to be passed and visited
-func (b *BenderSimulator) Remember(dir, state string) {
-	b.path = append(b.path, dir)
-	if _, exist := b.cache[state]; exist {
-		// already visited this state: increment the loop counter
-		b.loopCnt++
+// the simulation loops once the configuration of Bender in the state repeats
+func (b *BenderSimulator) Remember(dir Direction, state StateKey) {
+	b.path = append(b.path, string(dir))
+	k := loopState{
+		state:    state,
+		breaks:   b.breaks,
+		modifier: b.pathModifier,
+		currDir:  b.currDir,
+		first:    b.priorities[0],
+		breaker:  b.breaker,
+		inverted: b.invertPrio,
+		resetDir: b.resetDir,
+	}
+	if b.configs[k] {
+		b.loop = true
 	} else {
-		// unknown state: reset the loop counter
-		b.cache[state] = true
-		b.loopCnt = 0
+		b.configs[k] = true
 	}
 }
 
 // PathModifier unsets the priority directions with the given one
-func (b *BenderSimulator) PathModifier(dir string) {
+func (b *BenderSimulator) PathModifier(dir Direction) {
 	b.pathModifier = dir
 }
 
@@ -191,7 +260,7 @@ type Pair struct {
 // [1,1] EAST  [2,1]
 // [1,1] WEST  [0,1]
 type FSM struct {
-	states         [][]byte
+	states         [][]rune
 	curr           Pair
 	teleports      []Pair
 	beforeCallback Callback
@@ -201,24 +270,39 @@ type FSM struct {
 // NewFSM returns an instance of FSM from given map
 // before callback is called when the state is not yet entered
 // enter callback is called when the state is already entered
-func NewFSM(plan []string, beforeCB, enterCB Callback) *FSM {
-	states := make([][]byte, 0, len(plan))
-	start := Pair{}
+// an error is returned if the map has no start, unpaired teleports or unknown tiles
+// a state is a character of the map, the glyphs are replaced by their tiles (see tile)
+func NewFSM(plan []string, beforeCB, enterCB Callback) (*FSM, error) {
+	states := make([][]rune, 0, len(plan))
+	start, found := Pair{}, false
 	tp := []Pair{}
 
 	for i, s := range plan {
-		states = append(states, []byte(s))
-		for j, c := range s {
-			if len(tp) == 2 && (start != Pair{}) {
-				break
+		row := []rune{}
+		for _, r := range s {
+			if r == '\ufe0f' {
+				// variation selector of the emoji, not a character
+				continue
 			}
-			switch c {
+			j := len(row)
+			row = append(row, tile(r))
+			switch row[j] {
 			case '@':
-				start = Pair{j, i}
+				start, found = Pair{j, i}, true
 			case 'T':
 				tp = append(tp, Pair{j, i})
+			case ' ', '#', 'X', '$', 'S', 'N', 'E', 'W', 'I', 'B':
+			default:
+				return nil, fmt.Errorf("%w %q at (%d,%d)", ErrInvalidSymbol, r, j, i)
 			}
 		}
+		states = append(states, row)
+	}
+	if !found {
+		return nil, ErrNoStart
+	}
+	if len(tp) != 0 && len(tp) != 2 {
+		return nil, fmt.Errorf("%w: %d teleport(s) found", ErrBadTeleports, len(tp))
 	}
 
 	return &FSM{
@@ -227,26 +311,21 @@ func NewFSM(plan []string, beforeCB, enterCB Callback) *FSM {
 		teleports:      tp,
 		beforeCallback: beforeCB,
 		enterCallback:  enterCB,
-	}
+	}, nil
 }
 
 // Event changes the state according to the direction given
 // runs the before and enter callbacks passing the given arguments to them
-func (f *FSM) Event(evt string, args ...interface{}) error {
-	var dst Pair
-	switch evt {
-	case SOUTH:
-		dst = Pair{f.curr.x, f.curr.y + 1}
-	case NORTH:
-		dst = Pair{f.curr.x, f.curr.y - 1}
-	case EAST:
-		dst = Pair{f.curr.x + 1, f.curr.y}
-	case WEST:
-		dst = Pair{f.curr.x - 1, f.curr.y}
+// an unknown direction is an error, the machine doesn't move
+func (f *FSM) Event(evt Direction, args ...interface{}) error {
+	d, ok := evt.Delta()
+	if !ok {
+		return fmt.Errorf("%w %q", ErrUnknownDirection, string(evt))
 	}
+	dst := Pair{f.curr.x + d.x, f.curr.y + d.y}
 
-	if dst.x < 0 || dst.x >= len(f.states[0]) || dst.y < 0 || dst.y >= len(f.states) {
-		return fmt.Errorf("unknown state %v", dst)
+	if dst.y < 0 || dst.y >= len(f.states) || dst.x < 0 || dst.x >= len(f.states[dst.y]) {
+		return fmt.Errorf("%w: %v", ErrOutOfBounds, dst)
 	}
 
 	e := &Event{
@@ -264,7 +343,7 @@ func (f *FSM) Event(evt string, args ...interface{}) error {
 	}
 	f.curr = dst
 	f.enterCallback(e)
-	return nil
+	return e.err
 }
 
 // SetState sets the current state of the machine
@@ -273,15 +352,37 @@ func (f *FSM) SetState(p Pair) {
 }
 
 // TeleportDst gives the destination coordinates of the given teleport
-func (f *FSM) TeleportDst(ps Pair) Pair {
+func (f *FSM) TeleportDst(ps Pair) (Pair, error) {
 	if len(f.teleports) != 2 {
-		panic("teleports badly setup")
+		return Pair{}, fmt.Errorf("%w: %d teleport(s) found", ErrBadTeleports, len(f.teleports))
 	}
 
 	if f.teleports[0].x == ps.x && f.teleports[0].y == ps.y {
-		return f.teleports[1]
+		return f.teleports[1], nil
 	}
-	return f.teleports[0]
+	return f.teleports[0], nil
+}
+
+// glyphs are the tiles drawn with the glyphs of the unicode and emoji themes (same as fsm.Tile)
+var glyphs = map[rune]rune{
+	'█': '#', '▒': 'X', '☻': '@', '▣': '$',
+	'↓': 'S', '↑': 'N', '→': 'E', '←': 'W',
+	'⇅': 'I', 'β': 'B', '◎': 'T',
+	'🧱': '#', '📦': 'X', '🤖': '@', '🚪': '$',
+	'👇': 'S', '👆': 'N', '👉': 'E', '👈': 'W',
+	'🔃': 'I', '🍺': 'B', '🌀': 'T',
+}
+
+// tile returns the tile drawn by the character: the glyphs are their tiles,
+// the box drawing and block characters are walls and the other characters are themselves
+func tile(r rune) rune {
+	if t, exist := glyphs[r]; exist {
+		return t
+	}
+	if r >= '\u2500' && r <= '\u259f' {
+		return '#'
+	}
+	return r
 }
 
 // Callback type to handle state actions
@@ -292,15 +393,17 @@ type Event struct {
 	// pointer back to the finite state machine
 	FSM *FSM
 	// name of the event (direction)
-	Event string
+	Event Direction
 	// destination state
-	Dst byte
+	Dst rune
 	// destination state's coordinates
 	dstC Pair
 	// true if event was cancelled
 	Cancelled bool
 	// arguments for the callbacks
 	Args []interface{}
+	// error which aborted the event
+	err error
 }
 
 // Cancel cancels the event.
@@ -310,13 +413,20 @@ func (e *Event) Cancel() {
 }
 
 // ChangeDst sets the destination state with the given value
-func (e *Event) ChangeDst(dst byte) {
+func (e *Event) ChangeDst(dst rune) {
 	e.FSM.states[e.dstC.y][e.dstC.x] = dst
 }
 
-// UniqueDst generates the unique destination id (value+coordinates)
-func (e *Event) UniqueDst() string {
-	return fmt.Sprintf("%c%d%d", e.Dst, e.dstC.x, e.dstC.y)
+// StateKey is the unique id of a state, its value and coordinates
+// a comparable struct is a map key without the allocation of a formatted string
+type StateKey struct {
+	tile rune
+	at   Pair
+}
+
+// UniqueDst returns the unique destination id
+func (e *Event) UniqueDst() StateKey {
+	return StateKey{tile: e.Dst, at: e.dstC}
 }
 
 // before handles only obstacles
@@ -333,6 +443,7 @@ func beforeCallback(e *Event) {
 		if bender.Breaker() {
 			// destroy the obstacle
 			e.ChangeDst(' ')
+			bender.breaks++
 		} else {
 			bender.Boom()
 			bender.NextDirection()
@@ -364,20 +475,18 @@ func enterCallback(e *Event) {
 	case 'I':
 		bender.InvertPriorities()
 	case 'T':
-		e.FSM.SetState(e.FSM.TeleportDst(e.dstC))
+		dst, err := e.FSM.TeleportDst(e.dstC)
+		if err != nil {
+			e.err = err
+			return
+		}
+		e.FSM.SetState(dst)
 	case '$':
 		bender.Reached()
 	}
 	bender.Remember(e.Event, e.UniqueDst())
 }
 
-// returns the number of valid (frame excluded) states of a map
-func calcNumStates(plan []string) int {
-	l := len(plan[0])
-	w := len(plan)
-	return (w - 2) * (l - 2)
-}
-
 func main() {
 	scanner := bufio.NewScanner(os.Stdin)
 	scanner.Buffer(make([]byte, 1000000), 1000000)
@@ -393,8 +502,12 @@ func main() {
 		plan = append(plan, row)
 	}
 
-	fsm := NewFSM(plan, beforeCallback, enterCallback)
-	bender := NewBenderSimulator(calcNumStates(plan))
+	fsm, err := NewFSM(plan, beforeCallback, enterCallback)
+	if err != nil {
+		fmt.Println("Failed with error: ", err)
+		return
+	}
+	bender := NewBenderSimulator()
 
 	for !bender.Done() && !bender.Loop() {
 		err := fsm.Event(bender.Direction(), bender)
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+)
+
+// uniqueDstString is the former destination id, a formatted string
+// the coordinates are separated, (1,12) and (11,2) would collide otherwise
+func uniqueDstString(e *Event) string {
+	return fmt.Sprintf("%c%d,%d", e.Dst, e.dstC.x, e.dstC.y)
+}
+
+func BenchmarkUniqueDst(b *testing.B) {
+	// the destinations of a walk of 1000 steps, counted as the visited states
+	events := make([]Event, 1000)
+	for i := range events {
+		events[i] = Event{Dst: ' ', dstC: Pair{i, i / 2}}
+	}
+	b.Run("struct", func(b *testing.B) {
+		b.ReportAllocs()
+		visited := map[StateKey]int{}
+		for i := 0; i < b.N; i++ {
+			visited[events[i%len(events)].UniqueDst()]++
+		}
+	})
+	b.Run("string", func(b *testing.B) {
+		b.ReportAllocs()
+		visited := map[string]int{}
+		for i := 0; i < b.N; i++ {
+			visited[uniqueDstString(&events[i%len(events)])]++
+		}
+	})
+}
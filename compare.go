@@ -0,0 +1,61 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+
+	"bender/internal/render"
+	"bender/internal/replay"
+)
+
+// runCompare runs the compare subcommand with the given arguments:
+// it plays two replays of the same map side by side
+func runCompare(args []string) error {
+	flags := flag.NewFlagSet("compare", flag.ContinueOnError)
+	labelConf := flags.String("labels", "words", "direction labels: words, letters, arrows or a list like SOUTH=S,NORTH=N")
+	themeConf := flags.String("theme", "classic", "look of the tiles: classic, unicode, emoji, roguelike or a theme file like mine.json")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: compare [flags] a.bdr b.bdr")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+	if flags.NArg() != 2 {
+		return fmt.Errorf("expected two replays, got %d", flags.NArg())
+	}
+	labels, err := render.ParseLabels(*labelConf)
+	if err != nil {
+		return err
+	}
+	theme, err := render.ParseTheme(*themeConf)
+	if err != nil {
+		return err
+	}
+	a, err := replay.Open(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	defer a.Close()
+	b, err := replay.Open(flags.Arg(1))
+	if err != nil {
+		return err
+	}
+	defer b.Close()
+	return compare(os.Stdout, a, b, labels, theme)
+}
+
+// compare plays the two replays side by side, they must be replays of the same map
+func compare(w io.Writer, a, b *replay.Replay, labels render.Labels, theme *render.Theme) error {
+	if !reflect.DeepEqual(a.Plan(), b.Plan()) {
+		return fmt.Errorf("the replays are runs of different maps")
+	}
+	return render.Compare(w, a.Plan(), replayMoves(a), replayMoves(b), labels, theme)
+}
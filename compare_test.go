@@ -0,0 +1,43 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"bender/internal/bender"
+	"bender/internal/render"
+)
+
+func TestCompare(t *testing.T) {
+	plan := []string{
+		"#####",
+		"#@  #",
+		"#X  #",
+		"#$  #",
+		"#####",
+	}
+	canonical := recordReplay(t, plan, nil)
+	breaker := recordReplay(t, plan, func(b *bender.BenderSimulator) { b.InvertBreaker() })
+
+	buf := &bytes.Buffer{}
+	if err := compare(buf, canonical, breaker, render.LetterLabels, nil); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !strings.Contains(buf.String(), "*step 1: E | S\n") || !strings.HasSuffix(buf.String(), "diverge at step 1\n") {
+		t.Fatalf("Wrong comparison:\n%s", buf.String())
+	}
+
+	buf.Reset()
+	if err := compare(buf, canonical, canonical, render.LetterLabels, nil); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !strings.HasSuffix(buf.String(), "identical runs of 7 steps\n") {
+		t.Fatalf("Wrong comparison of a run with itself:\n%s", buf.String())
+	}
+
+	other := recordReplay(t, []string{"###", "#@#", "#$#", "###"}, nil)
+	if err := compare(buf, canonical, other, nil, nil); err == nil {
+		t.Fatalf("Expected an error for replays of different maps")
+	}
+}
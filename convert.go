@@ -0,0 +1,65 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"bender/internal/compress"
+	"bender/internal/replay"
+)
+
+// runConvert runs the convert subcommand with the given arguments:
+// it converts a replay between the binary and the text formats, the format of the output is given by its extension
+func runConvert(args []string) error {
+	flags := flag.NewFlagSet("convert", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: convert run.bdr run.bdt")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+	if flags.NArg() != 2 {
+		return fmt.Errorf("expected a replay and the converted file, got %d", flags.NArg())
+	}
+	r, err := replay.Open(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	return convert(r, flags.Arg(1))
+}
+
+// convert writes the replay to the file, in the text format if its extension is .bdt, in the binary format otherwise
+// both are gzip compressed if the file name has the .gz extension
+func convert(r *replay.Replay, name string) error {
+	if filepath.Ext(compress.TrimExt(name)) == replay.TextExt {
+		buf := &bytes.Buffer{}
+		if err := replay.WriteText(buf, r); err != nil {
+			return err
+		}
+		data, err := compress.Compress(name, buf.Bytes())
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(name, data, 0644)
+	}
+	w, err := replay.Create(name, r.Plan())
+	if err != nil {
+		return err
+	}
+	for i := 0; i < r.Len(); i++ {
+		if err := w.Append(r.Step(i)); err != nil {
+			w.Close(r.Outcome())
+			return err
+		}
+	}
+	return w.Close(r.Outcome())
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"bender/internal/compress"
+	"bender/internal/replay"
+)
+
+func TestConvert(t *testing.T) {
+	dir := t.TempDir()
+	orig := recordReplay(t, []string{"#####", "#@  #", "# B$#", "#####"}, nil)
+	expected := &bytes.Buffer{}
+	if err := replay.WriteText(expected, orig); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// every conversion is converted back to text
+	for _, name := range []string{"run.bdt", "run.bdt.gz", "run.bdr", "run.bdr.gz"} {
+		t.Run(name, func(t *testing.T) {
+			file := filepath.Join(dir, name)
+			if err := convert(orig, file); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if strings.HasPrefix(name, "run.bdt") {
+				data, err := compress.ReadFile(file)
+				if err != nil {
+					t.Fatalf("Unexpected error: %v", err)
+				}
+				if string(data) != expected.String() {
+					t.Fatalf("Wrong text replay. Expected:\n%s\ngot:\n%s", expected, data)
+				}
+			}
+			r, err := replay.Open(file)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			defer r.Close()
+			back := &bytes.Buffer{}
+			if err := replay.WriteText(back, r); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if back.String() != expected.String() {
+				t.Fatalf("Wrong conversion. Expected:\n%s\ngot:\n%s", expected, back)
+			}
+		})
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "bad.bdt"), []byte("bender replay 1\nboard 1\n"), 0644); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := runConvert([]string{filepath.Join(dir, "bad.bdt"), filepath.Join(dir, "bad.bdr")}); err == nil {
+		t.Fatalf("Expected an error for an invalid text replay")
+	}
+	if err := runConvert([]string{filepath.Join(dir, "run.bdr")}); err == nil {
+		t.Fatalf("Expected an error without the converted file")
+	}
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"bender/internal/bender"
+	"bender/internal/fsm"
+	"bender/internal/render"
+	"bender/internal/replay"
+)
+
+// runRender runs the render subcommand with the given arguments:
+// it writes a simulated map or a replay as a self-contained HTML page playing the run
+func runRender(args []string) error {
+	flags := flag.NewFlagSet("render", flag.ContinueOnError)
+	mapFile := flags.String("map", "", "file of the map to simulate, as text rows or JSON, - reads it from stdin (default the built-in map)")
+	replayFile := flags.String("replay", "", "replay file of the recorded run to play instead of a map, like run.bdr")
+	out := flags.String("html", "", "HTML file to write, like out.html (default stdout)")
+	labelConf := flags.String("labels", "words", "direction labels: words, letters, arrows or a list like SOUTH=S,NORTH=N")
+	themeConf := flags.String("theme", "classic", "look of the tiles: classic, unicode, emoji, roguelike or a theme file like mine.json")
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+	if err := noArgs(flags); err != nil {
+		return err
+	}
+	if *mapFile != "" && *replayFile != "" {
+		return fmt.Errorf("-map and -replay are exclusive")
+	}
+	labels, err := render.ParseLabels(*labelConf)
+	if err != nil {
+		return err
+	}
+	theme, err := render.ParseTheme(*themeConf)
+	if err != nil {
+		return err
+	}
+
+	var w io.Writer = os.Stdout
+	if *out != "" {
+		f, err := os.Create(*out)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+	r := render.NewHTMLRenderer(w, labels)
+	r.SetTheme(theme)
+
+	if *replayFile != "" {
+		rp, err := replay.Open(*replayFile)
+		if err != nil {
+			return err
+		}
+		defer rp.Close()
+		return renderReplay(r, rp)
+	}
+	plan, err := loadPlan(*mapFile, os.Stdin)
+	if err != nil {
+		return err
+	}
+	return renderPlan(r, plan)
+}
+
+// renderPlan simulates the plan with the given renderer and options
+func renderPlan(r render.Renderer, plan []string, opts ...bender.Option) error {
+	if err := r.RenderBoard(plan); err != nil {
+		return err
+	}
+	f, err := fsm.NewFSM(plan, bender.BeforeCallback, func(e *fsm.Event) {
+		bender.EnterCallback(e)
+		if err := r.RenderStep(e); err != nil {
+			e.Abort(err)
+		}
+	})
+	if err != nil {
+		return err
+	}
+	res, err := bender.Resume(f, bender.NewBenderSimulator(), opts...)
+	if err != nil {
+		return err
+	}
+	return r.RenderPath(res.ClassicPath())
+}
+
+// renderReplay plays the recorded steps of the replay with the given renderer
+func renderReplay(r *render.HTMLRenderer, rp *replay.Replay) error {
+	if err := r.RenderBoard(rp.Plan()); err != nil {
+		return err
+	}
+	moves := replayMoves(rp)
+	path := make([]string, 0, len(moves))
+	for _, m := range moves {
+		r.AddStep(m)
+		path = append(path, string(m.Direction))
+	}
+	return r.RenderPath(bender.Result{Outcome: rp.Outcome(), Path: path}.ClassicPath())
+}
+
+// replayMoves returns the recorded steps of the replay
+func replayMoves(rp *replay.Replay) []render.Move {
+	plan := rp.Plan()
+	moves := make([]render.Move, 0, rp.Len())
+	for i := 0; i < rp.Len(); i++ {
+		s := rp.Step(i)
+		at := s.At
+		if s.Effect == bender.RuleTeleport {
+			// Bender leaves from the other teleport
+			at = otherTeleport(plan, at)
+		}
+		moves = append(moves, render.Move{Direction: s.Direction, At: at, Destroyed: s.Tile == 'X'})
+	}
+	return moves
+}
+
+// otherTeleport returns the position of the teleport of the plan paired with the one at the given position
+// the position itself is returned if there is no other teleport
+func otherTeleport(plan []string, at fsm.Pair) fsm.Pair {
+	p, _ := fsm.PairedTeleport(fsm.NewBoard(plan), at)
+	return p
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"bender/internal/bender"
+	"bender/internal/fsm"
+	"bender/internal/render"
+	"bender/internal/replay"
+)
+
+func TestRenderReplay(t *testing.T) {
+	plan := []string{
+		"######",
+		"#@  T#",
+		"#B   #",
+		"#X   #",
+		"#T $ #",
+		"######",
+	}
+
+	// the page of the simulated map
+	simulated := &bytes.Buffer{}
+	if err := renderPlan(render.NewHTMLRenderer(simulated, nil), plan); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// the page of its replay
+	rp := recordReplay(t, plan, nil)
+	replayed := &bytes.Buffer{}
+	if err := renderReplay(render.NewHTMLRenderer(replayed, nil), rp); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if !strings.Contains(simulated.String(), `"destroyed":true`) || !strings.Contains(simulated.String(), `"x":4,"y":1`) {
+		t.Fatalf("The page misses the teleport or the destroyed wall:\n%s", simulated)
+	}
+	if replayed.String() != simulated.String() {
+		t.Fatalf("Wrong page of the replay. Expected:\n%s\ngot:\n%s", simulated, replayed)
+	}
+}
+
+// recordReplay simulates the plan and returns its replay, the simulator is set up by the given function if any
+func recordReplay(t *testing.T, plan []string, setup func(b *bender.BenderSimulator)) *replay.Replay {
+	buf := &bytes.Buffer{}
+	rec, err := replay.NewWriter(buf, plan)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	b := bender.NewBenderSimulator()
+	if setup != nil {
+		setup(b)
+	}
+	f, err := fsm.NewFSM(plan, bender.BeforeCallback, func(e *fsm.Event) {
+		bender.EnterCallback(e)
+		if err := rec.Record(e, b); err != nil {
+			e.Abort(err)
+		}
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	res, err := bender.Resume(f, b)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := rec.Close(res.Outcome); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	rp, err := replay.Parse(buf.Bytes())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	return rp
+}
+
+func TestOtherTeleport(t *testing.T) {
+	plan := []string{"#####", "#T@T#", "#####"}
+	testCases := []struct {
+		at       fsm.Pair
+		expected fsm.Pair
+	}{
+		{at: fsm.Pair{X: 1, Y: 1}, expected: fsm.Pair{X: 3, Y: 1}},
+		{at: fsm.Pair{X: 3, Y: 1}, expected: fsm.Pair{X: 1, Y: 1}},
+	}
+	for _, tc := range testCases {
+		if p := otherTeleport(plan, tc.at); !reflect.DeepEqual(p, tc.expected) {
+			t.Fatalf("Wrong teleport of %v. Expected %v, got %v", tc.at, tc.expected, p)
+		}
+	}
+}
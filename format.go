@@ -0,0 +1,123 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"bender/internal/bender"
+	"bender/internal/cbor"
+	"bender/internal/fsm"
+	"bender/internal/render"
+)
+
+// formats of the output: text for humans, json and cbor for programs
+var formats = map[string]bool{"text": true, "json": true, "cbor": true}
+
+// checkFormat returns an error if the output format is unknown
+func checkFormat(format string) error {
+	if !formats[format] {
+		return fmt.Errorf("unknown format %q, expected text, json or cbor", format)
+	}
+	return nil
+}
+
+// report is the result of a simulation in the machine-readable formats
+type report struct {
+	// number of the map in a stream
+	Map int `json:"map,omitempty"`
+	// how the simulation ended
+	Outcome string `json:"outcome,omitempty"`
+	// true if Bender loops
+	Loop bool `json:"loop"`
+	// resource whose budget was exceeded
+	Exceeded string `json:"exceeded,omitempty"`
+	// number of steps made by Bender
+	Steps int `json:"steps"`
+	// path followed by Bender
+	Path []string `json:"path,omitempty"`
+	// breakable walls destroyed by Bender, as (x,y)@step
+	Destroyed []string `json:"destroyed,omitempty"`
+	// step out of the board, as step <step> <direction> from (x,y) to (x,y)
+	Escape string `json:"escape,omitempty"`
+	// cells entered by Bender row by row, as (x,y)
+	Visited []string `json:"visited,omitempty"`
+	// rows of the map at the end of the simulation, without the destroyed walls
+	Board []string `json:"board,omitempty"`
+	// steps of Bender, with -steps
+	Trace []traceStep `json:"trace,omitempty"`
+	// error of the map
+	Error string `json:"error,omitempty"`
+}
+
+// traceStep is a step of the trace of a simulation
+type traceStep struct {
+	Step      int    `json:"step"`
+	Direction string `json:"direction"`
+	X         int    `json:"x"`
+	Y         int    `json:"y"`
+	Tile      string `json:"tile"`
+	// rule which chose the direction and rule applied by the tile, if any
+	Rule   string `json:"rule"`
+	Effect string `json:"effect,omitempty"`
+}
+
+// newTraceStep returns the step of the given number done by the given entered event of the simulator
+func newTraceStep(step int, e *fsm.Event, b *bender.BenderSimulator) traceStep {
+	p := e.DstPosition()
+	choice, effect := b.StepRules()
+	return traceStep{Step: step, Direction: string(e.Event), X: p.X, Y: p.Y, Tile: string(e.Dst), Rule: string(choice), Effect: string(effect)}
+}
+
+// newReport returns the report of the result, the directions are labelled
+func newReport(res bender.Result, labels render.Labels, trace []traceStep) report {
+	r := report{
+		Outcome:  res.Outcome.String(),
+		Loop:     res.Outcome == bender.Loop,
+		Exceeded: res.Exceeded,
+		Steps:    res.Steps,
+		Path:     labels.Path(res.Path),
+		Trace:    trace,
+	}
+	for _, d := range res.Destroyed {
+		r.Destroyed = append(r.Destroyed, d.String())
+	}
+	if res.Escape != nil {
+		e := *res.Escape
+		e.Direction = fsm.Direction(labels.Label(string(e.Direction)))
+		r.Escape = e.String()
+	}
+	for i := range r.Trace {
+		r.Trace[i].Direction = labels.Label(r.Trace[i].Direction)
+	}
+	return r
+}
+
+// addBoard adds the cells visited by the simulator and the final map of the machine to the report
+func (r *report) addBoard(f *fsm.FSM, b *bender.BenderSimulator) {
+	for _, p := range b.Visited() {
+		r.Visited = append(r.Visited, p.String())
+	}
+	r.Board = fsm.Rows(f.Snapshot())
+}
+
+// writeReport writes the report in the given format: a JSON line or a CBOR data item,
+// so the reports of a stream are JSON lines or a CBOR sequence (RFC 8742)
+func writeReport(w io.Writer, format string, r report) error {
+	var data []byte
+	var err error
+	switch format {
+	case "json":
+		data, err = json.Marshal(r)
+		data = append(data, '\n')
+	case "cbor":
+		data, err = cbor.Marshal(r)
+	default:
+		err = fmt.Errorf("no report in the %s format", format)
+	}
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"bender/internal/bender"
+	"bender/internal/fsm"
+	"bender/internal/render"
+)
+
+func TestWriteReport(t *testing.T) {
+	res := bender.Result{
+		Outcome:   bender.Reached,
+		Path:      []string{"SOUTH", "EAST"},
+		Steps:     2,
+		Destroyed: []bender.Destruction{{At: fsm.Pair{X: 1, Y: 2}, Step: 1}},
+	}
+	trace := []traceStep{{Step: 1, Direction: "SOUTH", X: 1, Y: 2, Tile: " ", Rule: string(bender.RuleForward), Effect: string(bender.RuleBreakerDestruction)}}
+	rep := newReport(res, render.LetterLabels, trace)
+
+	testCases := []struct {
+		format   string
+		expected string
+	}{
+		{
+			format:   "json",
+			expected: `{"outcome":"reached","loop":false,"steps":2,"path":["S","E"],"destroyed":["(1,2)@1"],"trace":[{"step":1,"direction":"S","x":1,"y":2,"tile":" ","rule":"forward","effect":"breaker destruction"}]}` + "\n",
+		},
+		{
+			format: "cbor",
+			expected: "\xa6" + "\x67outcome\x67reached" + "\x64loop\xf4" + "\x65steps\x02" + "\x64path\x82\x61S\x61E" + "\x69destroyed\x81\x67(1,2)@1" +
+				"\x65trace\x81\xa7\x64step\x01\x69direction\x61S\x61x\x01\x61y\x02\x64tile\x61 " +
+				"\x64rule\x67forward\x66effect\x73breaker destruction",
+		},
+	}
+
+	for _, tc := range testCases {
+		buf := &bytes.Buffer{}
+		if err := writeReport(buf, tc.format, rep); err != nil {
+			t.Fatalf("Unexpected error for %s: %v", tc.format, err)
+		}
+		if buf.String() != tc.expected {
+			t.Fatalf("Wrong %s report. Expected %q, got %q", tc.format, tc.expected, buf.String())
+		}
+	}
+
+	if err := writeReport(&bytes.Buffer{}, "text", rep); err == nil {
+		t.Fatalf("Expected an error for a report in text")
+	}
+	for format, valid := range map[string]bool{"text": true, "json": true, "cbor": true, "xml": false} {
+		if err := checkFormat(format); (err == nil) != valid {
+			t.Fatalf("Wrong check of the format %q: %v", format, err)
+		}
+	}
+}
+
+func TestReportBoard(t *testing.T) {
+	plan := []string{
+		"#####",
+		"#@ E#",
+		"#BX$#",
+		"#####",
+	}
+	f, err := fsm.NewFSM(plan, bender.BeforeCallback, bender.EnterCallback)
+	if err != nil {
+		t.Fatalf("Failed to create the FSM: %v", err)
+	}
+	b := bender.NewBenderSimulator()
+	res, err := bender.Resume(f, b)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	rep := newReport(res, nil, nil)
+	rep.addBoard(f, b)
+	data := &bytes.Buffer{}
+	if err := writeReport(data, "json", rep); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	// the breakable wall is destroyed on the final map, the start isn't entered
+	expected := `{"outcome":"reached","loop":false,"steps":3,"path":["SOUTH","EAST","EAST"],"destroyed":["(2,2)@2"],` +
+		`"visited":["(1,2)","(2,2)","(3,2)"],"board":["#####","#@ E#","#B $#","#####"]}` + "\n"
+	if data.String() != expected {
+		t.Fatalf("Wrong report. Expected %s, got %s", expected, data.String())
+	}
+}
+
+func TestRunStreamFormats(t *testing.T) {
+	input := "###\n#@$\n###\n\n###\n#T@\n###\n"
+	buf := &bytes.Buffer{}
+	if err := runStream(strings.NewReader(input), buf, streamConf{framing: "blank", format: "json", labels: render.Labels{}}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected := `{"map":1,"outcome":"reached","loop":false,"steps":1,"path":["EAST"]}` + "\n" +
+		`{"map":2,"loop":false,"steps":0,"error":"2:2: teleport 'T' appears 1 time(s), expected exactly 2"}` + "\n"
+	if buf.String() != expected {
+		t.Fatalf("Wrong output. Expected:\n%s\ngot:\n%s", expected, buf.String())
+	}
+
+	// a CBOR sequence of two maps
+	buf.Reset()
+	if err := runStream(strings.NewReader(input), buf, streamConf{framing: "blank", format: "cbor", labels: render.Labels{}}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !strings.HasPrefix(buf.String(), "\xa5\x63map\x01") || strings.Count(buf.String(), "\x63map") != 2 {
+		t.Fatalf("Wrong CBOR sequence: % x", buf.Bytes())
+	}
+}
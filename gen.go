@@ -0,0 +1,57 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"bender/internal/compress"
+)
+
+// runGen runs the gen subcommand with the given arguments:
+// it writes the generated map of the benchmarks, the output is gzip compressed if its name ends with .gz
+func runGen(args []string) error {
+	flags := flag.NewFlagSet("gen", flag.ContinueOnError)
+	size := flags.Int("size", 64, "width and height of the generated map, at least 5")
+	out := flags.String("out", "", "file to write the map to (default stdout)")
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+	if err := noArgs(flags); err != nil {
+		return err
+	}
+	if *size < 5 {
+		return fmt.Errorf("size %d too small, the map needs at least 5 rows and columns", *size)
+	}
+	if *out == "" {
+		return writePlan(os.Stdout, benchPlan(*size))
+	}
+	return genFile(*out, benchPlan(*size))
+}
+
+// genFile writes the rows of the map to the file, gzip compressed if its name ends with .gz
+func genFile(name string, plan []string) error {
+	buf := &bytes.Buffer{}
+	writePlan(buf, plan)
+	data, err := compress.Compress(name, buf.Bytes())
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(name, data, 0644)
+}
+
+// writePlan writes the rows of the map, one per line
+func writePlan(w io.Writer, plan []string) error {
+	for _, row := range plan {
+		if _, err := fmt.Fprintln(w, row); err != nil {
+			return err
+		}
+	}
+	return nil
+}
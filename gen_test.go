@@ -0,0 +1,33 @@
+package main
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"bender/internal/compress"
+	"bender/internal/mapfile"
+)
+
+func TestGenFile(t *testing.T) {
+	plan := benchPlan(9)
+	for _, name := range []string{"map.txt", "map.txt.gz"} {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), name)
+			if err := genFile(path, plan); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			data, err := compress.ReadFile(path)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			read, err := mapfile.ParsePlan(data)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(read, plan) {
+				t.Fatalf("Wrong map. Expected %q, got %q", plan, read)
+			}
+		})
+	}
+}
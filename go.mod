@@ -1,3 +1,3 @@
 module bender
 
-go 1.12
+go 1.18
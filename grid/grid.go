@@ -0,0 +1,204 @@
+// Package grid provides rectangular grids of cells for simulations on 2D maps
+package grid
+
+import (
+	"fmt"
+)
+
+// Point is a pair of coordinates on a grid
+type Point struct {
+	X, Y int
+}
+
+// Add returns the point moved by the given offset
+func (p Point) Add(d Point) Point {
+	return Point{p.X + d.X, p.Y + d.Y}
+}
+
+// String formats the coordinates as (x,y)
+func (p Point) String() string {
+	return fmt.Sprintf("(%d,%d)", p.X, p.Y)
+}
+
+// Bounds tells how the coordinates out of a grid are handled
+type Bounds int
+
+const (
+	// Frame the grid ends at its edges, there is nothing out of it
+	Frame Bounds = iota
+	// Wrap the opposite edges of the grid are connected
+	Wrap
+	// Void the grid is surrounded by empty cells holding the zero value
+	Void
+)
+
+// String returns the name of the policy
+func (b Bounds) String() string {
+	switch b {
+	case Frame:
+		return "frame"
+	case Wrap:
+		return "wrap"
+	case Void:
+		return "void"
+	}
+	return fmt.Sprintf("bounds(%d)", int(b))
+}
+
+// Grid is a rectangular grid of cells
+type Grid[T any] struct {
+	cells         []T
+	width, height int
+	bounds        Bounds
+}
+
+// New returns a grid of the given size with all the cells set to the zero value
+func New[T any](width, height int, bounds Bounds) *Grid[T] {
+	if width < 0 || height < 0 {
+		width, height = 0, 0
+	}
+	return &Grid[T]{
+		cells:  make([]T, width*height),
+		width:  width,
+		height: height,
+		bounds: bounds,
+	}
+}
+
+// FromRows returns a grid with the given rows
+// the grid is as wide as the longest row, the short rows are padded with the zero value
+func FromRows[T any](rows [][]T, bounds Bounds) *Grid[T] {
+	width := 0
+	for _, r := range rows {
+		if len(r) > width {
+			width = len(r)
+		}
+	}
+	g := New[T](width, len(rows), bounds)
+	for y, r := range rows {
+		copy(g.cells[y*width:], r)
+	}
+	return g
+}
+
+// Width returns the number of columns
+func (g *Grid[T]) Width() int {
+	return g.width
+}
+
+// Height returns the number of rows
+func (g *Grid[T]) Height() int {
+	return g.height
+}
+
+// Bounds returns the policy applied to the coordinates out of the grid
+func (g *Grid[T]) Bounds() Bounds {
+	return g.bounds
+}
+
+// Contains returns true if the point is a cell of the grid
+func (g *Grid[T]) Contains(p Point) bool {
+	return p.X >= 0 && p.X < g.width && p.Y >= 0 && p.Y < g.height
+}
+
+// Resolve applies the bounds policy to the given point
+// returns false if the point doesn't exist on the grid
+func (g *Grid[T]) Resolve(p Point) (Point, bool) {
+	if g.Contains(p) {
+		return p, true
+	}
+	switch g.bounds {
+	case Wrap:
+		if g.width == 0 || g.height == 0 {
+			return p, false
+		}
+		return Point{mod(p.X, g.width), mod(p.Y, g.height)}, true
+	case Void:
+		return p, true
+	}
+	return p, false
+}
+
+// At returns the value of the cell, the zero value if the cell is out of the grid
+func (g *Grid[T]) At(p Point) T {
+	var zero T
+	p, ok := g.Resolve(p)
+	if !ok || !g.Contains(p) {
+		return zero
+	}
+	return g.cells[p.Y*g.width+p.X]
+}
+
+// Set changes the value of the cell
+// returns false if the cell is out of the grid
+func (g *Grid[T]) Set(p Point, v T) bool {
+	p, ok := g.Resolve(p)
+	if !ok || !g.Contains(p) {
+		return false
+	}
+	g.cells[p.Y*g.width+p.X] = v
+	return true
+}
+
+// Clone returns a copy of the grid
+func (g *Grid[T]) Clone() *Grid[T] {
+	c := *g
+	c.cells = append([]T(nil), g.cells...)
+	return &c
+}
+
+// Row returns a copy of the given row
+func (g *Grid[T]) Row(y int) []T {
+	if y < 0 || y >= g.height {
+		return nil
+	}
+	return append([]T(nil), g.cells[y*g.width:(y+1)*g.width]...)
+}
+
+// Each calls the function for every cell, row by row
+// the iteration stops if the function returns false
+func (g *Grid[T]) Each(fn func(p Point, v T) bool) {
+	for i, v := range g.cells {
+		if !fn(Point{i % g.width, i / g.width}, v) {
+			return
+		}
+	}
+}
+
+// Find returns the cells matching the predicate, row by row
+func (g *Grid[T]) Find(pred func(v T) bool) []Point {
+	ps := []Point{}
+	g.Each(func(p Point, v T) bool {
+		if pred(v) {
+			ps = append(ps, p)
+		}
+		return true
+	})
+	return ps
+}
+
+// Count returns the number of the cells matching the predicate
+func (g *Grid[T]) Count(pred func(v T) bool) int {
+	n := 0
+	for _, v := range g.cells {
+		if pred(v) {
+			n++
+		}
+	}
+	return n
+}
+
+// Map returns a grid of the same size and bounds with the converted values
+func Map[T, U any](g *Grid[T], fn func(p Point, v T) U) *Grid[U] {
+	m := New[U](g.width, g.height, g.bounds)
+	g.Each(func(p Point, v T) bool {
+		m.cells[p.Y*g.width+p.X] = fn(p, v)
+		return true
+	})
+	return m
+}
+
+// mod returns the non negative remainder of the division
+func mod(a, b int) int {
+	return ((a % b) + b) % b
+}
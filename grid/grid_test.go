@@ -0,0 +1,101 @@
+package grid
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestGridBounds(t *testing.T) {
+	rows := [][]byte{
+		[]byte("abc"),
+		[]byte("de"),
+	}
+	testCases := []struct {
+		bounds   Bounds
+		p        Point
+		expected byte
+		exist    bool
+	}{
+		{bounds: Frame, p: Point{1, 0}, expected: 'b', exist: true},
+		{bounds: Frame, p: Point{2, 1}, expected: 0, exist: true},
+		{bounds: Frame, p: Point{-1, 0}, expected: 0, exist: false},
+		{bounds: Frame, p: Point{0, 2}, expected: 0, exist: false},
+		{bounds: Wrap, p: Point{-1, 0}, expected: 'c', exist: true},
+		{bounds: Wrap, p: Point{3, -1}, expected: 'd', exist: true},
+		{bounds: Wrap, p: Point{7, 4}, expected: 'b', exist: true},
+		{bounds: Void, p: Point{-1, 0}, expected: 0, exist: true},
+		{bounds: Void, p: Point{1, 1}, expected: 'e', exist: true},
+	}
+
+	for _, tc := range testCases {
+		g := FromRows(rows, tc.bounds)
+		if g.Width() != 3 || g.Height() != 2 {
+			t.Fatalf("Wrong grid size. Expected 3x2, got %dx%d", g.Width(), g.Height())
+		}
+		if v := g.At(tc.p); v != tc.expected {
+			t.Errorf("%s %s: wrong value. Expected %q, got %q", tc.bounds, tc.p, tc.expected, v)
+		}
+		if _, exist := g.Resolve(tc.p); exist != tc.exist {
+			t.Errorf("%s %s: wrong existence. Expected %t, got %t", tc.bounds, tc.p, tc.exist, exist)
+		}
+	}
+}
+
+func TestGridSet(t *testing.T) {
+	g := New[int](2, 2, Wrap)
+	if !g.Set(Point{-1, -1}, 5) {
+		t.Fatalf("Set with wrapped coordinates failed")
+	}
+	if v := g.At(Point{1, 1}); v != 5 {
+		t.Fatalf("Wrong wrapped value. Expected %d, got %d", 5, v)
+	}
+	c := g.Clone()
+	c.Set(Point{1, 1}, 6)
+	if v := g.At(Point{1, 1}); v != 5 {
+		t.Fatalf("Clone modified the original grid, got %d", v)
+	}
+
+	f := New[int](2, 2, Frame)
+	if f.Set(Point{2, 0}, 1) {
+		t.Fatalf("Set out of the frame succeeded")
+	}
+	v := New[int](2, 2, Void)
+	if v.Set(Point{2, 0}, 1) || v.At(Point{2, 0}) != 0 {
+		t.Fatalf("Set in the void succeeded")
+	}
+	if e := New[int](-1, 3, Wrap); e.Width() != 0 || e.Height() != 0 || e.At(Point{1, 1}) != 0 {
+		t.Fatalf("Grid of negative size is not empty")
+	}
+}
+
+func TestGridIteration(t *testing.T) {
+	g := FromRows([][]byte{[]byte("#X#"), []byte("X @")}, Frame)
+
+	walls := g.Find(func(v byte) bool { return v == 'X' })
+	if expected := []Point{{1, 0}, {0, 1}}; !reflect.DeepEqual(walls, expected) {
+		t.Fatalf("Wrong found cells. Expected %v, got %v", expected, walls)
+	}
+	if n := g.Count(func(v byte) bool { return v == '#' }); n != 2 {
+		t.Fatalf("Wrong count. Expected %d, got %d", 2, n)
+	}
+
+	visited := 0
+	g.Each(func(p Point, v byte) bool {
+		visited++
+		return v != 'X'
+	})
+	if visited != 2 {
+		t.Fatalf("Iteration didn't stop. Expected %d visited cells, got %d", 2, visited)
+	}
+
+	m := Map(g, func(p Point, v byte) bool { return v == ' ' })
+	if !m.At(Point{1, 1}) || m.At(Point{0, 0}) || m.Bounds() != Frame {
+		t.Fatalf("Wrong mapped grid: %v", m.Row(1))
+	}
+	if r := g.Row(1); string(r) != "X @" {
+		t.Fatalf("Wrong row. Expected %q, got %q", "X @", r)
+	}
+	if r := g.Row(2); r != nil {
+		t.Fatalf("Row out of the grid must be nil, got %q", r)
+	}
+}
@@ -0,0 +1,60 @@
+package grid
+
+// Connectivity tells which cells are adjacent
+type Connectivity int
+
+const (
+	// Four the cells sharing an edge: south, north, east and west
+	Four Connectivity = iota
+	// Eight the cells sharing an edge or a corner
+	Eight
+	// Hex the cells of a hexagonal grid with the odd rows shifted to the right
+	Hex
+)
+
+var (
+	// Orthogonal are the offsets of the neighbors sharing an edge, in the order south, north, east, west
+	Orthogonal = []Point{{0, 1}, {0, -1}, {1, 0}, {-1, 0}}
+	// Diagonal are the offsets of the neighbors sharing only a corner
+	Diagonal = []Point{{1, 1}, {-1, 1}, {1, -1}, {-1, -1}}
+
+	// hexEven are the offsets of the neighbors of a cell on an even row
+	hexEven = []Point{{1, 0}, {0, -1}, {-1, -1}, {-1, 0}, {-1, 1}, {0, 1}}
+	// hexOdd are the offsets of the neighbors of a cell on an odd row
+	hexOdd = []Point{{1, 0}, {1, -1}, {0, -1}, {-1, 0}, {0, 1}, {1, 1}}
+)
+
+// Offsets returns the offsets of the neighbors of the given point
+func Offsets(p Point, c Connectivity) []Point {
+	switch c {
+	case Eight:
+		return append(append([]Point{}, Orthogonal...), Diagonal...)
+	case Hex:
+		if p.Y&1 == 1 {
+			return append([]Point{}, hexOdd...)
+		}
+		return append([]Point{}, hexEven...)
+	}
+	return append([]Point{}, Orthogonal...)
+}
+
+// Neighbors returns the adjacent points, the grid is not taken into account
+func Neighbors(p Point, c Connectivity) []Point {
+	offsets := Offsets(p, c)
+	ns := make([]Point, 0, len(offsets))
+	for _, d := range offsets {
+		ns = append(ns, p.Add(d))
+	}
+	return ns
+}
+
+// Neighbors returns the adjacent cells existing according to the bounds policy
+func (g *Grid[T]) Neighbors(p Point, c Connectivity) []Point {
+	ns := []Point{}
+	for _, n := range Neighbors(p, c) {
+		if r, ok := g.Resolve(n); ok {
+			ns = append(ns, r)
+		}
+	}
+	return ns
+}
@@ -0,0 +1,80 @@
+package grid
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNeighbors(t *testing.T) {
+	testCases := []struct {
+		name     string
+		bounds   Bounds
+		p        Point
+		conn     Connectivity
+		expected []Point
+	}{
+		{
+			name:     "four inside",
+			bounds:   Frame,
+			p:        Point{1, 1},
+			conn:     Four,
+			expected: []Point{{1, 2}, {1, 0}, {2, 1}, {0, 1}},
+		},
+		{
+			name:     "four corner framed",
+			bounds:   Frame,
+			p:        Point{0, 0},
+			conn:     Four,
+			expected: []Point{{0, 1}, {1, 0}},
+		},
+		{
+			name:     "four corner wrapped",
+			bounds:   Wrap,
+			p:        Point{0, 0},
+			conn:     Four,
+			expected: []Point{{0, 1}, {0, 2}, {1, 0}, {2, 0}},
+		},
+		{
+			name:     "four corner void",
+			bounds:   Void,
+			p:        Point{0, 0},
+			conn:     Four,
+			expected: []Point{{0, 1}, {0, -1}, {1, 0}, {-1, 0}},
+		},
+		{
+			name:     "eight corner framed",
+			bounds:   Frame,
+			p:        Point{2, 2},
+			conn:     Eight,
+			expected: []Point{{2, 1}, {1, 2}, {1, 1}},
+		},
+		{
+			name:     "hex even row",
+			bounds:   Frame,
+			p:        Point{1, 0},
+			conn:     Hex,
+			expected: []Point{{2, 0}, {0, 0}, {0, 1}, {1, 1}},
+		},
+		{
+			name:     "hex odd row",
+			bounds:   Frame,
+			p:        Point{1, 1},
+			conn:     Hex,
+			expected: []Point{{2, 1}, {2, 0}, {1, 0}, {0, 1}, {1, 2}, {2, 2}},
+		},
+	}
+
+	for _, tc := range testCases {
+		g := New[byte](3, 3, tc.bounds)
+		if ns := g.Neighbors(tc.p, tc.conn); !reflect.DeepEqual(ns, tc.expected) {
+			t.Errorf("Test case %q: wrong neighbors. Expected %v, got %v", tc.name, tc.expected, ns)
+		}
+	}
+}
+
+func TestOffsetsAreCopies(t *testing.T) {
+	Offsets(Point{}, Four)[0] = Point{5, 5}
+	if Orthogonal[0] != (Point{0, 1}) {
+		t.Fatalf("Offsets modified the orthogonal offsets: %v", Orthogonal)
+	}
+}
@@ -0,0 +1,147 @@
+// Package agent lets external programs drive Bender, the strategies can be written in any language:
+// before every step the engine writes what Bender sees to the agent and reads the direction of the step,
+// the engine stays the referee applying the rules of the tiles
+//
+// The messages are JSON lines. The engine writes an Observation before every step,
+// the agent answers a line with the direction, like SOUTH or S. Once the simulation is over
+// the engine writes an End message and closes the input of the agent.
+package agent
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"bender/internal/bender"
+	"bender/internal/fsm"
+)
+
+// Observation is what Bender sees before a step
+type Observation struct {
+	// number of steps made so far
+	Step int `json:"step"`
+	// position of Bender
+	X int `json:"x"`
+	Y int `json:"y"`
+	// rows of the cells around Bender, in the radius of the agent, Bender is @ in the center
+	// the cells out of the board are walls
+	View []string `json:"view"`
+	// true if Bender is in breaker mode, he destroys the breakable walls X
+	Breaker bool `json:"breaker"`
+	// true if Bender went through an inverter, the priorities of the classic Bender would be inverted at the next obstacle
+	Inverted bool `json:"inverted"`
+	// direction of the last path modifier, empty if none
+	Modifier fsm.Direction `json:"modifier,omitempty"`
+	// directions in the order the classic Bender tries them after an obstacle, and the index of his current one
+	Priorities    []fsm.Direction `json:"priorities"`
+	PriorityIndex int             `json:"priorityIndex"`
+	// true if the last direction was blocked by an obstacle, Bender didn't move
+	Blocked bool `json:"blocked"`
+}
+
+// End is the last message sent to the agent
+type End struct {
+	Outcome string `json:"outcome"`
+	Steps   int    `json:"steps"`
+}
+
+// Agent is an external program choosing the directions of Bender
+type Agent struct {
+	r      *bufio.Reader
+	w      *bufio.Writer
+	radius int
+}
+
+// New returns the agent reading the observations from w and answering the directions on r
+// the agent sees the cells at the given distance of Bender, at least 1
+func New(r io.Reader, w io.Writer, radius int) *Agent {
+	if radius < 1 {
+		radius = 1
+	}
+	return &Agent{r: bufio.NewReader(r), w: bufio.NewWriter(w), radius: radius}
+}
+
+// Play simulates Bender on the map with the directions chosen by the agent, then tells it the outcome
+func Play(plan []string, a *Agent, opts ...bender.Option) (bender.Result, error) {
+	f, err := fsm.NewFSM(plan, bender.BeforeCallback, bender.EnterCallback)
+	if err != nil {
+		return bender.Result{}, err
+	}
+	opts = append(opts, bender.WithDirector(a.direct))
+	res, err := bender.Resume(f, bender.NewBenderSimulator(), opts...)
+	if err != nil {
+		return res, err
+	}
+	return res, a.send(End{Outcome: res.Outcome.String(), Steps: res.Steps})
+}
+
+// direct sends the observation of Bender to the agent and returns its direction
+func (a *Agent) direct(f *fsm.FSM, b *bender.BenderSimulator) (fsm.Direction, error) {
+	if err := a.send(observe(f, b, a.radius)); err != nil {
+		return "", err
+	}
+	line, err := a.r.ReadString('\n')
+	if err != nil && (!errors.Is(err, io.EOF) || line == "") {
+		return "", fmt.Errorf("no direction from the agent: %v", err)
+	}
+	return parseDirection(strings.TrimSpace(line))
+}
+
+// send writes the message to the agent as a JSON line
+func (a *Agent) send(msg interface{}) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	a.w.Write(data)
+	a.w.WriteByte('\n')
+	return a.w.Flush()
+}
+
+// observe returns what Bender sees in the given radius
+func observe(f *fsm.FSM, b *bender.BenderSimulator, radius int) Observation {
+	at, board := f.Position(), f.Board()
+	view := make([]string, 0, 2*radius+1)
+	row := make([]byte, 2*radius+1)
+	for y := at.Y - radius; y <= at.Y+radius; y++ {
+		for x := at.X - radius; x <= at.X+radius; x++ {
+			c := board.At(x, y)
+			switch {
+			case x == at.X && y == at.Y:
+				c = '@'
+			case c == 0:
+				c = '#'
+			case c == '@':
+				// the start is a floor once Bender left it
+				c = ' '
+			}
+			row[x-at.X+radius] = c
+		}
+		view = append(view, string(row))
+	}
+	return Observation{
+		Step:          f.Steps(),
+		X:             at.X,
+		Y:             at.Y,
+		View:          view,
+		Breaker:       b.Breaker(),
+		Inverted:      b.Inverted(),
+		Modifier:      b.Modifier(),
+		Priorities:    b.Priorities(),
+		PriorityIndex: b.PriorityIndex(),
+		Blocked:       b.Hurts(),
+	}
+}
+
+// parseDirection returns the direction answered by the agent, its name or its first letter in any case
+func parseDirection(s string) (fsm.Direction, error) {
+	for _, d := range fsm.Directions {
+		if strings.EqualFold(s, string(d)) || strings.EqualFold(s, string(d[:1])) {
+			return d, nil
+		}
+	}
+	return "", fmt.Errorf("invalid direction %q from the agent", s)
+}
@@ -0,0 +1,104 @@
+package agent
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+
+	"bender/internal/bender"
+)
+
+func TestPlay(t *testing.T) {
+	plan := []string{
+		"######",
+		"#@ B #",
+		"#  X$#",
+		"######",
+	}
+	out := &bytes.Buffer{}
+	// the agent goes east, then south through the breakable wall
+	res, err := Play(plan, New(strings.NewReader("e\nN\nEAST\nSOUTH\nE\n"), out, 1))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if res.Outcome != bender.Reached || !reflect.DeepEqual(res.Path, []string{"EAST", "EAST", "SOUTH", "EAST"}) {
+		t.Fatalf("Wrong result. Expected reached by EAST EAST SOUTH EAST, got %v by %v", res.Outcome, res.Path)
+	}
+	expected := []string{
+		`{"step":0,"x":1,"y":1,"view":["###","#@ ","#  "],"breaker":false,"inverted":false,"priorities":["SOUTH","EAST","NORTH","WEST"],"priorityIndex":0,"blocked":false}`,
+		`{"step":1,"x":2,"y":1,"view":["###"," @B","  X"],"breaker":false,"inverted":false,"priorities":["SOUTH","EAST","NORTH","WEST"],"priorityIndex":0,"blocked":false}`,
+		`{"step":1,"x":2,"y":1,"view":["###"," @B","  X"],"breaker":false,"inverted":false,"priorities":["SOUTH","EAST","NORTH","WEST"],"priorityIndex":1,"blocked":true}`,
+		`{"step":2,"x":3,"y":1,"view":["###"," @ "," X$"],"breaker":true,"inverted":false,"priorities":["SOUTH","EAST","NORTH","WEST"],"priorityIndex":1,"blocked":false}`,
+		`{"step":3,"x":3,"y":2,"view":[" B "," @$","###"],"breaker":true,"inverted":false,"priorities":["SOUTH","EAST","NORTH","WEST"],"priorityIndex":1,"blocked":false}`,
+		`{"outcome":"reached","steps":4}`,
+	}
+	lines := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+	if !reflect.DeepEqual(lines, expected) {
+		t.Fatalf("Wrong messages. Expected:\n%s\ngot:\n%s", strings.Join(expected, "\n"), out.String())
+	}
+}
+
+func TestPlayInteractive(t *testing.T) {
+	plan := []string{
+		"#####",
+		"#@  #",
+		"# W #",
+		"#  $#",
+		"#####",
+	}
+	obs, agentIn := io.Pipe()
+	agentOut, dirs := io.Pipe()
+	// the agent follows the path modifiers, and goes east or south when there's none
+	go func() {
+		defer dirs.Close()
+		sc := bufio.NewScanner(obs)
+		for sc.Scan() {
+			var o Observation
+			if err := json.Unmarshal(sc.Bytes(), &o); err != nil || o.View == nil {
+				return
+			}
+			d := o.Modifier
+			if d == "" {
+				d = "E"
+				if o.View[1][2] == '#' {
+					d = "S"
+				}
+			}
+			io.WriteString(dirs, string(d)+"\n")
+		}
+	}()
+	res, err := Play(plan, New(agentOut, agentIn, 1))
+	agentIn.Close()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if res.Outcome != bender.Reached || !reflect.DeepEqual(res.Path, []string{"EAST", "EAST", "SOUTH", "SOUTH"}) {
+		t.Fatalf("Wrong result. Expected reached by EAST EAST SOUTH SOUTH, got %v by %v", res.Outcome, res.Path)
+	}
+}
+
+func TestPlayErrors(t *testing.T) {
+	plan := []string{"####", "#@$#", "####"}
+	testCases := []struct {
+		name     string
+		answers  string
+		expected string
+	}{
+		{name: "no answer", answers: "", expected: "no direction from the agent: EOF"},
+		{name: "invalid direction", answers: "UP\n", expected: `invalid direction "UP" from the agent`},
+	}
+	for _, tc := range testCases {
+		_, err := Play(plan, New(strings.NewReader(tc.answers), io.Discard, 1))
+		if err == nil || err.Error() != tc.expected {
+			t.Fatalf("Wrong error for %q. Expected %q, got %v", tc.name, tc.expected, err)
+		}
+	}
+	// the last answer doesn't need a newline
+	if res, err := Play(plan, New(strings.NewReader("E"), io.Discard, 1)); err != nil || res.Outcome != bender.Reached {
+		t.Fatalf("Wrong result. Expected reached, got %v, %v", res.Outcome, err)
+	}
+}
@@ -0,0 +1,82 @@
+package analysis
+
+import (
+	"bender/internal/bender"
+	"bender/internal/fsm"
+)
+
+// Breakable is the use of a breakable wall by the simulation of the map
+type Breakable struct {
+	At fsm.Pair
+	// step destroying the wall, starting from 1, 0 if it's left standing
+	Step int
+	// how the simulation ends and its number of steps when the wall is unbreakable
+	Outcome bender.Outcome
+	Steps   int
+	// true if Bender reaches the booth only by destroying the wall
+	Necessary bool
+}
+
+// Destroyed returns true if Bender destroys the wall
+func (b Breakable) Destroyed() bool {
+	return b.Step > 0
+}
+
+// BreakerReport is the use of the breakable walls of a map
+type BreakerReport struct {
+	// how the simulation of the map ends and its number of steps
+	Outcome bender.Outcome
+	Steps   int
+	// breakable walls, row by row
+	Breakables []Breakable
+}
+
+// Redundant returns the breakable walls which are unnecessary to reach the booth: left standing or destroyed on a detour
+func (r BreakerReport) Redundant() []Breakable {
+	redundant := []Breakable{}
+	for _, b := range r.Breakables {
+		if !b.Necessary {
+			redundant = append(redundant, b)
+		}
+	}
+	return redundant
+}
+
+// Breakers simulates the map with the given options, then every destroyed breakable wall is made unbreakable in turn
+// to tell whether its destruction is necessary to reach the booth, the walls left standing are unnecessary
+// the edited maps are simulated incrementally
+func Breakers(plan []string, opts ...bender.Option) (BreakerReport, error) {
+	// the glyphs are decoded so a byte is a cell
+	plan = fsm.DecodePlan(plan)
+	en := bender.NewEngine(plan, opts...)
+	res, err := en.Run()
+	if err != nil {
+		return BreakerReport{}, err
+	}
+	r := BreakerReport{Outcome: res.Outcome, Steps: res.Steps}
+	destroyed := map[fsm.Pair]int{}
+	for _, d := range res.Destroyed {
+		destroyed[d.At] = d.Step
+	}
+	for y, row := range plan {
+		for x := 0; x < len(row); x++ {
+			if row[x] != 'X' {
+				continue
+			}
+			b := Breakable{At: fsm.Pair{X: x, Y: y}, Step: destroyed[fsm.Pair{X: x, Y: y}], Outcome: r.Outcome, Steps: r.Steps}
+			if b.Destroyed() {
+				without, err := en.Edit(x, y, '#')
+				if err != nil {
+					return BreakerReport{}, err
+				}
+				b.Outcome, b.Steps = without.Outcome, len(without.Path)
+				b.Necessary = r.Outcome == bender.Reached && without.Outcome != bender.Reached
+				if _, err := en.Edit(x, y, 'X'); err != nil {
+					return BreakerReport{}, err
+				}
+			}
+			r.Breakables = append(r.Breakables, b)
+		}
+	}
+	return r, nil
+}
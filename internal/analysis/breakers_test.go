@@ -0,0 +1,53 @@
+package analysis
+
+import (
+	"reflect"
+	"testing"
+
+	"bender/internal/bender"
+	"bender/internal/fsm"
+)
+
+func TestBreakers(t *testing.T) {
+	tests := []struct {
+		name     string
+		plan     []string
+		expected []Breakable
+	}{
+		{
+			name: "necessary and standing",
+			plan: []string{"#######", "#@    #", "#B    #", "#X    #", "#X    #", "#$ X  #", "#######"},
+			expected: []Breakable{
+				{At: fsm.Pair{X: 1, Y: 3}, Step: 2, Outcome: bender.Loop, Steps: 14, Necessary: true},
+				{At: fsm.Pair{X: 1, Y: 4}, Step: 3, Outcome: bender.Loop, Steps: 15, Necessary: true},
+				{At: fsm.Pair{X: 3, Y: 5}, Outcome: bender.Reached, Steps: 4},
+			},
+		},
+		{
+			// Bender can go around the wall
+			name: "detour",
+			plan: []string{"########", "#@BX  $#", "##     #", "########"},
+			expected: []Breakable{
+				{At: fsm.Pair{X: 3, Y: 1}, Step: 2, Outcome: bender.Reached, Steps: 7},
+			},
+		},
+	}
+	for _, test := range tests {
+		r, err := Breakers(test.plan)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", test.name, err)
+		}
+		if r.Outcome != bender.Reached || !reflect.DeepEqual(r.Breakables, test.expected) {
+			t.Fatalf("%s: wrong report. Expected %+v, got %+v", test.name, test.expected, r)
+		}
+		redundant := 0
+		for _, b := range test.expected {
+			if !b.Necessary {
+				redundant++
+			}
+		}
+		if n := len(r.Redundant()); n != redundant {
+			t.Fatalf("%s: wrong number of redundant walls. Expected %d, got %d", test.name, redundant, n)
+		}
+	}
+}
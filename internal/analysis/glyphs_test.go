@@ -0,0 +1,74 @@
+package analysis
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// unicodeGlyphs draws the tiles with the glyphs of the unicode theme
+var unicodeGlyphs = strings.NewReplacer(
+	"#", "█", "X", "▒", "@", "☻", "$", "▣",
+	"S", "↓", "N", "↑", "E", "→", "W", "←",
+	"I", "⇅", "B", "β", "T", "◎",
+)
+
+// glyphPlan returns the map drawn with glyphs
+func glyphPlan(plan []string) []string {
+	drawn := make([]string, 0, len(plan))
+	for _, row := range plan {
+		drawn = append(drawn, unicodeGlyphs.Replace(row))
+	}
+	return drawn
+}
+
+func TestGlyphMaps(t *testing.T) {
+	tests := []struct {
+		name    string
+		plan    []string
+		analyze func(plan []string) (interface{}, error)
+	}{
+		{
+			name: "breakers",
+			plan: []string{"######", "#@BX$#", "######"},
+			analyze: func(plan []string) (interface{}, error) {
+				return Breakers(plan)
+			},
+		},
+		{
+			name: "what if",
+			plan: []string{"#######", "#@  T #", "# X  $#", "#T  I #", "#######"},
+			analyze: func(plan []string) (interface{}, error) {
+				return WhatIf(plan)
+			},
+		},
+		{
+			name: "starts",
+			plan: []string{"#######", "#  #  #", "#@ # $#", "#######"},
+			analyze: func(plan []string) (interface{}, error) {
+				return Starts(plan)
+			},
+		},
+		{
+			name: "advise",
+			plan: []string{"######", "#@ W #", "#    #", "#N  W#", "#  #$#", "######"},
+			analyze: func(plan []string) (interface{}, error) {
+				return Advise(plan)
+			},
+		},
+	}
+	for _, test := range tests {
+		expected, err := test.analyze(test.plan)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", test.name, err)
+		}
+		// the glyphs are cells like the tiles they draw
+		got, err := test.analyze(glyphPlan(test.plan))
+		if err != nil {
+			t.Fatalf("%s: unexpected error with glyphs: %v", test.name, err)
+		}
+		if !reflect.DeepEqual(got, expected) {
+			t.Fatalf("%s: wrong analysis of the glyph map. Expected %+v, got %+v", test.name, expected, got)
+		}
+	}
+}
@@ -0,0 +1,146 @@
+package analysis
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"bender/internal/bender"
+	"bender/internal/fsm"
+)
+
+// movable are the tiles the loop advisor tries to move: the modifiers of the direction and the inverter and the beer
+const movable = "SNEWIB"
+
+// Edit is the change of a cell of the map
+type Edit struct {
+	At       fsm.Pair
+	From, To byte
+}
+
+// Fix is a set of edits making a looping map terminate with Bender in the booth
+type Fix struct {
+	Edits []Edit
+	// number of steps of Bender to the booth on the fixed map
+	Steps int
+}
+
+// Size returns the number of cells changed by the fix
+func (f Fix) Size() int {
+	return len(f.Edits)
+}
+
+// String describes the fix for the map designers, like "add a wall at (3,2)" or "move 'I' from (1,1) to (2,2)"
+func (f Fix) String() string {
+	if len(f.Edits) == 2 && f.Edits[0].From == f.Edits[1].To && f.Edits[0].To == ' ' && f.Edits[1].From == ' ' {
+		return fmt.Sprintf("move %q from %s to %s", f.Edits[0].From, f.Edits[0].At, f.Edits[1].At)
+	}
+	parts := make([]string, 0, len(f.Edits))
+	for _, e := range f.Edits {
+		switch {
+		case e.To == '#':
+			parts = append(parts, fmt.Sprintf("add a wall at %s", e.At))
+		case e.From == '#':
+			parts = append(parts, fmt.Sprintf("remove the wall at %s", e.At))
+		default:
+			parts = append(parts, fmt.Sprintf("remove %q at %s", e.From, e.At))
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// Advise returns the fixes of a looping map, the smallest first and then the shortest paths:
+// the single cell edits of the what-if analysis and the moves of the tiles changing the direction of Bender, the inverter and the beer
+// every candidate is simulated with the given options, it's a fix if Bender reaches the booth
+// it returns an error if the map doesn't loop
+func Advise(plan []string, opts ...bender.Option) ([]Fix, error) {
+	// the glyphs are decoded so a byte is a cell
+	plan = fsm.DecodePlan(plan)
+	r, err := WhatIf(plan, opts...)
+	if err != nil {
+		return nil, err
+	}
+	if r.Outcome != bender.Loop {
+		return nil, fmt.Errorf("the map doesn't loop: %v after %d steps", r.Outcome, r.Steps)
+	}
+
+	fixes := []Fix{}
+	for _, t := range r.Toggles {
+		if t.Outcome != bender.Reached {
+			continue
+		}
+		edits := []Edit{{At: t.At, From: t.From, To: t.To}}
+		if fsm.IsTeleport(t.From) {
+			edits = append(edits, Edit{At: otherTeleport(plan, t.At), From: t.From, To: ' '})
+		}
+		fixes = append(fixes, Fix{Edits: edits, Steps: t.Steps})
+	}
+
+	moves, err := moveFixes(plan, opts...)
+	if err != nil {
+		return nil, err
+	}
+	fixes = append(fixes, moves...)
+
+	sort.SliceStable(fixes, func(i, j int) bool {
+		if fixes[i].Size() != fixes[j].Size() {
+			return fixes[i].Size() < fixes[j].Size()
+		}
+		return fixes[i].Steps < fixes[j].Steps
+	})
+	return fixes, nil
+}
+
+// moveFixes returns the moves of the movable tiles to a floor cell making Bender reach the booth
+// every tile is removed once, its new positions are simulated incrementally
+func moveFixes(plan []string, opts ...bender.Option) ([]Fix, error) {
+	fixes := []Fix{}
+	floors := []fsm.Pair{}
+	for y := 1; y < len(plan)-1; y++ {
+		for x := 1; x < len(plan[y])-1; x++ {
+			if plan[y][x] == ' ' {
+				floors = append(floors, fsm.Pair{X: x, Y: y})
+			}
+		}
+	}
+	en := bender.NewEngine(plan, opts...)
+	if _, err := en.Run(); err != nil {
+		return nil, err
+	}
+	for y := 1; y < len(plan)-1; y++ {
+		for x := 1; x < len(plan[y])-1; x++ {
+			c := plan[y][x]
+			if strings.IndexByte(movable, c) < 0 {
+				continue
+			}
+			if _, err := en.Edit(x, y, ' '); err != nil {
+				return nil, err
+			}
+			for _, to := range floors {
+				res, err := en.Edit(to.X, to.Y, c)
+				if err != nil {
+					return nil, err
+				}
+				if res.Outcome == bender.Reached {
+					fixes = append(fixes, Fix{
+						Edits: []Edit{{At: fsm.Pair{X: x, Y: y}, From: c, To: ' '}, {At: to, From: ' ', To: c}},
+						Steps: res.Steps,
+					})
+				}
+				if _, err := en.Edit(to.X, to.Y, ' '); err != nil {
+					return nil, err
+				}
+			}
+			if _, err := en.Edit(x, y, c); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return fixes, nil
+}
+
+// otherTeleport returns the position of the teleport of the plan paired with the one at the given position
+func otherTeleport(plan []string, at fsm.Pair) fsm.Pair {
+	p, _ := fsm.PairedTeleport(fsm.NewBoard(plan), at)
+	return p
+}
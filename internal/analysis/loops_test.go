@@ -0,0 +1,77 @@
+package analysis
+
+import (
+	"reflect"
+	"testing"
+
+	"bender/internal/bender"
+	"bender/internal/fsm"
+)
+
+func TestAdvise(t *testing.T) {
+	tests := []struct {
+		name     string
+		plan     []string
+		expected Fix
+	}{
+		{
+			name:     "wall",
+			plan:     []string{"#######", "#  #  #", "#@ # $#", "#######"},
+			expected: Fix{Edits: []Edit{{At: fsm.Pair{X: 3, Y: 2}, From: '#', To: ' '}}, Steps: 4},
+		},
+		{
+			// no single cell edit breaks the loop
+			name: "move",
+			plan: []string{"######", "#@ W #", "#    #", "#N  W#", "#  #$#", "######"},
+			expected: Fix{Edits: []Edit{
+				{At: fsm.Pair{X: 4, Y: 3}, From: 'W', To: ' '},
+				{At: fsm.Pair{X: 1, Y: 2}, From: ' ', To: 'W'},
+			}, Steps: 6},
+		},
+	}
+	for _, test := range tests {
+		fixes, err := Advise(test.plan)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", test.name, err)
+		}
+		if len(fixes) == 0 || !reflect.DeepEqual(fixes[0], test.expected) {
+			t.Fatalf("%s: wrong best fix. Expected %v, got %v", test.name, test.expected, fixes)
+		}
+		for i, f := range fixes {
+			// every fix must make Bender reach the booth
+			edited := append([]string{}, test.plan...)
+			for _, e := range f.Edits {
+				row := []byte(edited[e.At.Y])
+				row[e.At.X] = e.To
+				edited[e.At.Y] = string(row)
+			}
+			if res, _ := bender.Run(edited); res.Outcome != bender.Reached || len(res.Path) != f.Steps {
+				t.Fatalf("%s: wrong fix %v. Expected %v after %d steps, got %v after %d steps", test.name, f, bender.Reached, f.Steps, res.Outcome, len(res.Path))
+			}
+			if i > 0 && (f.Size() < fixes[i-1].Size() || f.Size() == fixes[i-1].Size() && f.Steps < fixes[i-1].Steps) {
+				t.Fatalf("%s: wrong ranking. Expected %v before %v", test.name, f, fixes[i-1])
+			}
+		}
+	}
+
+	if _, err := Advise([]string{"#####", "#@ $#", "#####"}); err == nil {
+		t.Fatalf("Expected an error for a map which doesn't loop")
+	}
+}
+
+func TestFixString(t *testing.T) {
+	tests := []struct {
+		fix      Fix
+		expected string
+	}{
+		{Fix{Edits: []Edit{{At: fsm.Pair{X: 2, Y: 1}, From: ' ', To: '#'}}}, "add a wall at (2,1)"},
+		{Fix{Edits: []Edit{{At: fsm.Pair{X: 2, Y: 1}, From: 'X', To: ' '}}}, "remove 'X' at (2,1)"},
+		{Fix{Edits: []Edit{{At: fsm.Pair{X: 1, Y: 1}, From: 'I', To: ' '}, {At: fsm.Pair{X: 2, Y: 2}, From: ' ', To: 'I'}}}, "move 'I' from (1,1) to (2,2)"},
+		{Fix{Edits: []Edit{{At: fsm.Pair{X: 1, Y: 1}, From: 'T', To: ' '}, {At: fsm.Pair{X: 3, Y: 2}, From: 'T', To: ' '}}}, "remove 'T' at (1,1), remove 'T' at (3,2)"},
+	}
+	for _, test := range tests {
+		if s := test.fix.String(); s != test.expected {
+			t.Fatalf("Wrong description. Expected %q, got %q", test.expected, s)
+		}
+	}
+}
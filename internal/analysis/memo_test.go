@@ -0,0 +1,44 @@
+package analysis
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"bender/internal/bender"
+)
+
+func TestMemoizedAnalyses(t *testing.T) {
+	plans := [][]string{
+		{"######", "#@BX$#", "######"},
+		{"#######", "#@  T #", "# X  $#", "#T  I #", "#######"},
+		{"######", "#@ W #", "#    #", "#N  W#", "#  #$#", "######"},
+		{"######", "#@ W #", "#    #", "#N  W#", "#  # #", "#$####"},
+		{"##########", "#@  B   X#", "# ##   # #", "#  X  I  #", "# E   W $#", "##########"},
+	}
+	analyses := []struct {
+		name    string
+		analyze func(plan []string, opts ...bender.Option) (interface{}, error)
+	}{
+		{name: "breakers", analyze: func(plan []string, opts ...bender.Option) (interface{}, error) { return Breakers(plan, opts...) }},
+		{name: "what if", analyze: func(plan []string, opts ...bender.Option) (interface{}, error) { return WhatIf(plan, opts...) }},
+		{name: "starts", analyze: func(plan []string, opts ...bender.Option) (interface{}, error) { return Starts(plan, opts...) }},
+		{name: "advise", analyze: func(plan []string, opts ...bender.Option) (interface{}, error) { return Advise(plan, opts...) }},
+	}
+	for _, a := range analyses {
+		for _, limit := range []int{0, 12} {
+			memo := bender.NewMemo()
+			for i, plan := range plans {
+				expected, expectedErr := a.analyze(plan, bender.WithMaxSteps(limit))
+				// the memo is shared by the maps like by the variants of a map
+				got, err := a.analyze(plan, bender.WithMaxSteps(limit), bender.WithMemo(memo))
+				if fmt.Sprint(err) != fmt.Sprint(expectedErr) {
+					t.Fatalf("%s of map %d: wrong error with the memo. Expected %v, got %v", a.name, i, expectedErr, err)
+				}
+				if !reflect.DeepEqual(got, expected) {
+					t.Fatalf("%s of map %d with the limit %d: wrong memoized analysis. Expected %+v, got %+v", a.name, i, limit, expected, got)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package analysis
+
+import (
+	"bender/internal/bender"
+	"bender/internal/fsm"
+)
+
+// Start is the simulation of the map from a starting cell
+type Start struct {
+	At      fsm.Pair
+	Outcome bender.Outcome
+	Steps   int
+}
+
+// Starts simulates the map from every floor cell but the frame with the given options,
+// Bender is moved there from his start which becomes a floor, the starts are returned row by row
+// it characterizes the whole map: which regions reach the booth, loop or die
+func Starts(plan []string, opts ...bender.Option) ([]Start, error) {
+	// the glyphs are decoded so a byte is a cell
+	plan = fsm.DecodePlan(plan)
+	edited := make([]string, len(plan))
+	rows := make([][]byte, len(plan))
+	for y, row := range plan {
+		rows[y] = []byte(row)
+		for x := range rows[y] {
+			if rows[y][x] == '@' {
+				rows[y][x] = ' '
+			}
+		}
+		edited[y] = string(rows[y])
+	}
+
+	starts := []Start{}
+	for y := 1; y < len(rows)-1; y++ {
+		for x := 1; x < len(rows[y])-1; x++ {
+			if rows[y][x] != ' ' {
+				continue
+			}
+			rows[y][x] = '@'
+			edited[y] = string(rows[y])
+			res, err := bender.Run(edited, opts...)
+			rows[y][x] = ' '
+			edited[y] = string(rows[y])
+			if err != nil {
+				return nil, err
+			}
+			starts = append(starts, Start{At: fsm.Pair{X: x, Y: y}, Outcome: res.Outcome, Steps: res.Steps})
+		}
+	}
+	return starts, nil
+}
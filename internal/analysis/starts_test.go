@@ -0,0 +1,43 @@
+package analysis
+
+import (
+	"reflect"
+	"testing"
+
+	"bender/internal/bender"
+	"bender/internal/fsm"
+)
+
+func TestStarts(t *testing.T) {
+	plan := []string{
+		"#######",
+		"#  #  #",
+		"#@ # $#",
+		"#######",
+	}
+	starts, err := Starts(plan)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected := []Start{
+		{At: fsm.Pair{X: 1, Y: 1}, Outcome: bender.Loop, Steps: 5},
+		{At: fsm.Pair{X: 2, Y: 1}, Outcome: bender.Loop, Steps: 4},
+		{At: fsm.Pair{X: 4, Y: 1}, Outcome: bender.Reached, Steps: 2},
+		{At: fsm.Pair{X: 5, Y: 1}, Outcome: bender.Reached, Steps: 1},
+		{At: fsm.Pair{X: 1, Y: 2}, Outcome: bender.Loop, Steps: 4},
+		{At: fsm.Pair{X: 2, Y: 2}, Outcome: bender.Loop, Steps: 3},
+		{At: fsm.Pair{X: 4, Y: 2}, Outcome: bender.Reached, Steps: 1},
+	}
+	if !reflect.DeepEqual(starts, expected) {
+		t.Fatalf("Wrong starts. Expected %+v, got %+v", expected, starts)
+	}
+
+	// the limits apply to every simulation
+	starts, err = Starts(plan, bender.WithMaxSteps(1))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if starts[0].Outcome != bender.StepLimitExceeded || starts[3].Outcome != bender.Reached {
+		t.Fatalf("Wrong starts with a step limit: %+v", starts)
+	}
+}
@@ -0,0 +1,161 @@
+package analysis
+
+import (
+	"fmt"
+
+	"bender/internal/bender"
+	"bender/internal/fsm"
+)
+
+// synthBudget is the maximum number of simulations of a synthesis
+const synthBudget = 20000
+
+// modifiers are the modifier tiles of the directions
+var modifiers = map[fsm.Direction]byte{
+	fsm.SOUTH: 'S',
+	fsm.NORTH: 'N',
+	fsm.EAST:  'E',
+	fsm.WEST:  'W',
+}
+
+// synthesis is the search of a map producing a path
+type synthesis struct {
+	path          []string
+	width, height int
+	// positions of Bender before every step and at the end
+	positions []fsm.Pair
+	onPath    map[fsm.Pair]bool
+	// tiles set so far, the other cells are floors
+	tiles map[fsm.Pair]byte
+	runs  int
+}
+
+// Synthesize searches a map of the given size, frame included, whose simulation is exactly the given path ending in the booth
+// it's experimental: the map is repaired step by step from an empty board, adding a wall where Bender takes a wrong direction
+// or a modifier of the direction where he turns, with backtracking, so only the paths reachable with walls and modifiers are found
+// it returns an error if no map is found within a bounded number of simulations
+func Synthesize(path []string, width, height int) ([]string, error) {
+	if len(path) == 0 {
+		return nil, fmt.Errorf("empty path")
+	}
+	if width < 3 || height < 3 {
+		return nil, fmt.Errorf("board %dx%d too small", width, height)
+	}
+	for _, d := range path {
+		if _, err := fsm.ParseDirection(d); err != nil {
+			return nil, err
+		}
+	}
+	s := &synthesis{path: path, width: width, height: height}
+	for y := 1; y < height-1; y++ {
+		for x := 1; x < width-1; x++ {
+			if !s.start(fsm.Pair{X: x, Y: y}) {
+				continue
+			}
+			if plan, found := s.search(); found {
+				return plan, nil
+			}
+			if s.runs >= synthBudget {
+				return nil, fmt.Errorf("no map found for the path within %d simulations", synthBudget)
+			}
+		}
+	}
+	return nil, fmt.Errorf("no map found for the path")
+}
+
+// start resets the search for Bender starting at the given position
+// it returns false if the path leaves the board or goes through the booth before its end
+func (s *synthesis) start(at fsm.Pair) bool {
+	s.positions = []fsm.Pair{at}
+	s.onPath = map[fsm.Pair]bool{at: true}
+	for _, d := range s.path {
+		at = at.Add(fsm.Direction(d).Delta())
+		if at.X < 1 || at.Y < 1 || at.X >= s.width-1 || at.Y >= s.height-1 {
+			return false
+		}
+		s.positions = append(s.positions, at)
+		s.onPath[at] = true
+	}
+	end := s.positions[len(s.positions)-1]
+	for _, p := range s.positions[:len(s.positions)-1] {
+		if p == end {
+			return false
+		}
+	}
+	s.tiles = map[fsm.Pair]byte{s.positions[0]: '@', end: '$'}
+	return true
+}
+
+// plan returns the map of the tiles set so far
+func (s *synthesis) plan() []string {
+	plan := make([]string, s.height)
+	for y := range plan {
+		row := make([]byte, s.width)
+		for x := range row {
+			switch c, set := s.tiles[fsm.Pair{X: x, Y: y}]; {
+			case set:
+				row[x] = c
+			case x == 0 || y == 0 || x == s.width-1 || y == s.height-1:
+				row[x] = '#'
+			default:
+				row[x] = ' '
+			}
+		}
+		plan[y] = string(row)
+	}
+	return plan
+}
+
+// search simulates the current map and repairs the first wrong step, backtracking on the dead ends
+func (s *synthesis) search() ([]string, bool) {
+	if s.runs >= synthBudget {
+		return nil, false
+	}
+	s.runs++
+	plan := s.plan()
+	res, err := bender.Run(plan, bender.WithMaxSteps(len(s.path)))
+	if err != nil {
+		return nil, false
+	}
+	k := 0
+	for k < len(res.Path) && k < len(s.path) && res.Path[k] == s.path[k] {
+		k++
+	}
+	if k == len(s.path) {
+		return plan, res.Outcome == bender.Reached
+	}
+	if k == len(res.Path) {
+		// Bender stopped before the end of the path
+		return nil, false
+	}
+
+	at := s.positions[k]
+	// block the wrong direction
+	wall := at.Add(fsm.Direction(res.Path[k]).Delta())
+	if _, set := s.tiles[wall]; !set && !s.onPath[wall] && wall.X > 0 && wall.Y > 0 && wall.X < s.width-1 && wall.Y < s.height-1 {
+		s.tiles[wall] = '#'
+		if plan, found := s.search(); found {
+			return plan, true
+		}
+		delete(s.tiles, wall)
+	}
+	// turn on the cell, unless Bender went through it before
+	if _, set := s.tiles[at]; !set && !s.visitedBefore(k) {
+		s.tiles[at] = modifiers[fsm.Direction(s.path[k])]
+		if plan, found := s.search(); found {
+			return plan, true
+		}
+		delete(s.tiles, at)
+	}
+	return nil, false
+}
+
+// visitedBefore returns true if the position before the k-th step is visited earlier on the path
+func (s *synthesis) visitedBefore(k int) bool {
+	for _, p := range s.positions[:k] {
+		if p == s.positions[k] {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,56 @@
+package analysis
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"bender/internal/bender"
+)
+
+func TestSynthesize(t *testing.T) {
+	tests := []string{
+		"SOUTH",
+		"SOUTH SOUTH EAST EAST",
+		"EAST EAST NORTH NORTH WEST",
+		"EAST SOUTH EAST SOUTH EAST SOUTH",
+		"WEST WEST WEST",
+	}
+	for _, test := range tests {
+		path := strings.Fields(test)
+		plan, err := Synthesize(path, 7, 7)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", test, err)
+		}
+		if len(plan) != 7 || len(plan[0]) != 7 {
+			t.Fatalf("%s: wrong size of the map. Expected 7x7, got %dx%d", test, len(plan[0]), len(plan))
+		}
+		res, err := bender.Run(plan)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", test, err)
+		}
+		if res.Outcome != bender.Reached || !reflect.DeepEqual(res.Path, path) {
+			t.Fatalf("Wrong map:\n%s\nExpected %s, got %v %v", strings.Join(plan, "\n"), test, res.Outcome, res.Path)
+		}
+	}
+}
+
+func TestSynthesizeErrors(t *testing.T) {
+	tests := []struct {
+		path          string
+		width, height int
+	}{
+		{path: "", width: 7, height: 7},
+		{path: "SOUTH", width: 2, height: 7},
+		{path: "UP", width: 7, height: 7},
+		// longer than the board
+		{path: "EAST EAST EAST EAST EAST", width: 5, height: 5},
+		// back on the start
+		{path: "SOUTH NORTH", width: 5, height: 5},
+	}
+	for _, test := range tests {
+		if _, err := Synthesize(strings.Fields(test.path), test.width, test.height); err == nil {
+			t.Fatalf("Expected an error for the path %q in %dx%d", test.path, test.width, test.height)
+		}
+	}
+}
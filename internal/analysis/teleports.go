@@ -0,0 +1,159 @@
+package analysis
+
+import (
+	"bender/internal/fsm"
+)
+
+// Region is a connected area of the map: the cells Bender can walk between without teleport,
+// the breakable walls are walkable as they may be destroyed
+type Region struct {
+	// cells of the region, row by row
+	Cells []fsm.Pair
+	// true if the region holds the start or the booth
+	Start, Booth bool
+}
+
+// Link connects the regions of a pair of teleports, possibly the same one
+type Link struct {
+	A, B fsm.Pair
+	// indexes of the regions of the teleports
+	RegionA, RegionB int
+}
+
+// PingPong is a pair of teleports aligned with a straight corridor of floors between them:
+// once Bender walks the corridor in the direction, he goes through the teleport and is back at the start of the corridor, endlessly
+type PingPong struct {
+	// teleport Bender enters and teleport he leaves
+	Enter, Exit fsm.Pair
+	Direction   fsm.Direction
+}
+
+// TeleportGraph is the connectivity of the regions of a map through its teleports
+type TeleportGraph struct {
+	Regions []Region
+	Links   []Link
+	// indexes of the regions Bender can't reach from the start, whatever the rules
+	Unreachable []int
+	PingPongs   []PingPong
+}
+
+// BoothReachable returns false if the booth is in an unreachable region
+func (g TeleportGraph) BoothReachable() bool {
+	for _, i := range g.Unreachable {
+		if g.Regions[i].Booth {
+			return false
+		}
+	}
+	return true
+}
+
+// Teleports analyzes the map statically, without simulation: it splits it in regions linked by the teleports,
+// finds the regions which are unreachable from the start and the teleports which can make Bender ping-pong
+// it returns an error if the map is invalid
+func Teleports(plan []string) (TeleportGraph, error) {
+	if _, err := fsm.NewFSM(plan, nil, nil); err != nil {
+		return TeleportGraph{}, err
+	}
+	board := fsm.NewBoard(plan)
+	walkable := func(p fsm.Pair) bool {
+		// out of the board the tile is 0
+		c := board.At(p.X, p.Y)
+		return c != 0 && c != '#'
+	}
+
+	g := TeleportGraph{}
+	region := map[fsm.Pair]int{}
+	for y := 0; y < board.Height(); y++ {
+		for x := 0; x < board.Width(); x++ {
+			p := fsm.Pair{X: x, Y: y}
+			if _, seen := region[p]; seen || !walkable(p) {
+				continue
+			}
+			// flood fill of the region
+			i := len(g.Regions)
+			r := Region{}
+			region[p] = i
+			queue := []fsm.Pair{p}
+			for len(queue) > 0 {
+				c := queue[0]
+				queue = queue[1:]
+				r.Cells = append(r.Cells, c)
+				switch board.At(c.X, c.Y) {
+				case '@':
+					r.Start = true
+				case '$':
+					r.Booth = true
+				}
+				for _, d := range fsm.Directions {
+					n := c.Add(d.Delta())
+					if _, seen := region[n]; !seen && walkable(n) {
+						region[n] = i
+						queue = append(queue, n)
+					}
+				}
+			}
+			g.Regions = append(g.Regions, r)
+		}
+	}
+
+	// the teleports go by pair of the same label
+	for _, p := range fsm.TeleportPairs(board) {
+		a, b := p[0], p[1]
+		g.Links = append(g.Links, Link{A: a, B: b, RegionA: region[a], RegionB: region[b]})
+		g.PingPongs = append(g.PingPongs, pingPongs(board, a, b)...)
+	}
+
+	reached := map[int]bool{}
+	queue := []int{}
+	for i, r := range g.Regions {
+		if r.Start {
+			reached[i] = true
+			queue = append(queue, i)
+		}
+	}
+	for len(queue) > 0 {
+		i := queue[0]
+		queue = queue[1:]
+		for _, l := range g.Links {
+			for _, n := range []int{l.RegionA, l.RegionB} {
+				if (l.RegionA == i || l.RegionB == i) && !reached[n] {
+					reached[n] = true
+					queue = append(queue, n)
+				}
+			}
+		}
+	}
+	for i := range g.Regions {
+		if !reached[i] {
+			g.Unreachable = append(g.Unreachable, i)
+		}
+	}
+	return g, nil
+}
+
+// pingPongs returns the ping-pongs of the pair of teleports: if they're aligned with only floors between them,
+// Bender walking from one to the other leaves the latter towards the former again
+func pingPongs(board fsm.Board, a, b fsm.Pair) []PingPong {
+	pp := []PingPong{}
+	for _, d := range fsm.Directions {
+		if corridor(board, b, a, d) {
+			pp = append(pp, PingPong{Enter: a, Exit: b, Direction: d})
+		}
+		if corridor(board, a, b, d) {
+			pp = append(pp, PingPong{Enter: b, Exit: a, Direction: d})
+		}
+	}
+	return pp
+}
+
+// corridor returns true if walking in the direction from the cell leads straight to the other one, only over floors
+func corridor(board fsm.Board, from, to fsm.Pair, d fsm.Direction) bool {
+	o := d.Delta()
+	for p := from.Add(o); p != to; p = p.Add(o) {
+		// out of the board the tile is 0
+		if c := board.At(p.X, p.Y); c != ' ' && c != '@' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,80 @@
+package analysis
+
+import (
+	"reflect"
+	"testing"
+
+	"bender/internal/fsm"
+)
+
+func TestTeleports(t *testing.T) {
+	tests := []struct {
+		name        string
+		plan        []string
+		links       []Link
+		unreachable []int
+		booth       bool
+		pingPongs   []PingPong
+	}{
+		{
+			name:  "teleport to the booth",
+			plan:  []string{"#########", "#@ T# $ #", "#   #  T#", "#########"},
+			links: []Link{{A: fsm.Pair{X: 3, Y: 1}, B: fsm.Pair{X: 7, Y: 2}, RegionA: 0, RegionB: 1}},
+			booth: true,
+		},
+		{
+			name:        "walled booth",
+			plan:        []string{"#######", "#@ #$ #", "#  #  #", "#######"},
+			unreachable: []int{1},
+		},
+		{
+			name:        "ping-pong",
+			plan:        []string{"########", "#@ T  T#", "#   #  #", "####   #", "#  #  $#", "########"},
+			links:       []Link{{A: fsm.Pair{X: 3, Y: 1}, B: fsm.Pair{X: 6, Y: 1}}},
+			unreachable: []int{1},
+			booth:       true,
+			pingPongs: []PingPong{
+				{Enter: fsm.Pair{X: 6, Y: 1}, Exit: fsm.Pair{X: 3, Y: 1}, Direction: fsm.EAST},
+				{Enter: fsm.Pair{X: 3, Y: 1}, Exit: fsm.Pair{X: 6, Y: 1}, Direction: fsm.WEST},
+			},
+		},
+		{
+			// the teleports are aligned but a tile breaks the corridor
+			name:  "no ping-pong",
+			plan:  []string{"########", "#@ TB T#", "#     $#", "########"},
+			links: []Link{{A: fsm.Pair{X: 3, Y: 1}, B: fsm.Pair{X: 6, Y: 1}}},
+			booth: true,
+		},
+		{
+			name: "labelled pairs",
+			plan: []string{"##########", "#@1#12#2$#", "##########"},
+			links: []Link{
+				{A: fsm.Pair{X: 2, Y: 1}, B: fsm.Pair{X: 4, Y: 1}, RegionA: 0, RegionB: 1},
+				{A: fsm.Pair{X: 5, Y: 1}, B: fsm.Pair{X: 7, Y: 1}, RegionA: 1, RegionB: 2},
+			},
+			booth: true,
+		},
+	}
+	for _, test := range tests {
+		g, err := Teleports(test.plan)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", test.name, err)
+		}
+		if !reflect.DeepEqual(g.Links, test.links) && (len(g.Links) != 0 || len(test.links) != 0) {
+			t.Fatalf("%s: wrong links. Expected %+v, got %+v", test.name, test.links, g.Links)
+		}
+		if !reflect.DeepEqual(g.Unreachable, test.unreachable) {
+			t.Fatalf("%s: wrong unreachable regions. Expected %v, got %v", test.name, test.unreachable, g.Unreachable)
+		}
+		if g.BoothReachable() != test.booth {
+			t.Fatalf("%s: wrong reachability of the booth. Expected %v, got %v", test.name, test.booth, g.BoothReachable())
+		}
+		if !reflect.DeepEqual(g.PingPongs, test.pingPongs) && (len(g.PingPongs) != 0 || len(test.pingPongs) != 0) {
+			t.Fatalf("%s: wrong ping-pongs. Expected %+v, got %+v", test.name, test.pingPongs, g.PingPongs)
+		}
+	}
+
+	if _, err := Teleports([]string{"#####", "#@T$#", "#####"}); err == nil {
+		t.Fatalf("Expected an error for a single teleport")
+	}
+}
@@ -0,0 +1,155 @@
+// Package analysis studies the maps by simulating their variants, to guide the map designers
+package analysis
+
+import (
+	"sort"
+
+	"bender/internal/bender"
+	"bender/internal/fsm"
+)
+
+// Toggle is a what-if edit of a cell of the map and its effect on the simulation
+type Toggle struct {
+	// cell edited, the first teleport of the pair for the removal of teleports
+	At fsm.Pair
+	// tile of the cell and tile replacing it: a wall becomes a floor, a floor a wall and the other tiles a floor
+	From, To byte
+	// how the simulation of the edited map ends and its number of steps
+	Outcome bender.Outcome
+	Steps   int
+	// difference of the number of steps with the original map
+	Delta int
+}
+
+// Changed returns true if the edit changes how the simulation ends
+func (t Toggle) Changed(original bender.Outcome) bool {
+	return t.Outcome != original
+}
+
+// WhatIfReport is the effect of the edits of every cell of a map
+type WhatIfReport struct {
+	// how the simulation of the original map ends and its number of steps
+	Outcome bender.Outcome
+	Steps   int
+	// edits of the cells, the most impactful first:
+	// the edits changing the outcome, then by decreasing difference of steps
+	Toggles []Toggle
+}
+
+// toggled returns the tile replacing the given one in the what-if edits
+func toggled(c byte) byte {
+	if c == ' ' {
+		return '#'
+	}
+	return ' '
+}
+
+// WhatIf edits every cell of the map but the frame and the start in turn and simulates the edited map with the given options:
+// the walls become floors, the floors walls and the other tiles floors, the teleports are removed by pair
+// the edits are simulated incrementally, only from the first step reaching the edited cell
+func WhatIf(plan []string, opts ...bender.Option) (WhatIfReport, error) {
+	// the glyphs are decoded so a byte is a cell
+	plan = fsm.DecodePlan(plan)
+	en := bender.NewEngine(plan, opts...)
+	res, err := en.Run()
+	if err != nil {
+		return WhatIfReport{}, err
+	}
+	r := WhatIfReport{Outcome: res.Outcome, Steps: res.Steps}
+
+	for y := 1; y < len(plan)-1; y++ {
+		for x := 1; x < len(plan[y])-1; x++ {
+			from := plan[y][x]
+			if from == '@' || fsm.IsTeleport(from) {
+				continue
+			}
+			to := toggled(from)
+			res, err := en.Edit(x, y, to)
+			if err != nil {
+				// the edited map is invalid, the engine is unchanged
+				continue
+			}
+			r.Toggles = append(r.Toggles, r.toggle(fsm.Pair{X: x, Y: y}, from, to, res))
+			if _, err := en.Edit(x, y, from); err != nil {
+				return WhatIfReport{}, err
+			}
+		}
+	}
+	for _, pair := range fsm.TeleportPairs(fsm.NewBoard(plan)) {
+		// a single teleport is invalid, the pair is removed at once
+		label := plan[pair[0].Y][pair[0].X]
+		edited := append([]string{}, plan...)
+		for _, p := range pair {
+			row := []byte(edited[p.Y])
+			row[p.X] = ' '
+			edited[p.Y] = string(row)
+		}
+		if res, err := bender.Run(edited, opts...); err == nil {
+			r.Toggles = append(r.Toggles, r.toggle(pair[0], label, ' ', res))
+		}
+	}
+
+	sort.SliceStable(r.Toggles, func(i, j int) bool {
+		a, b := r.Toggles[i], r.Toggles[j]
+		if a.Changed(r.Outcome) != b.Changed(r.Outcome) {
+			return a.Changed(r.Outcome)
+		}
+		return abs(a.Delta) > abs(b.Delta)
+	})
+	return r, nil
+}
+
+// toggle returns the edit of the cell with the result of the edited map
+func (r WhatIfReport) toggle(at fsm.Pair, from, to byte, res bender.Result) Toggle {
+	return Toggle{At: at, From: from, To: to, Outcome: res.Outcome, Steps: res.Steps, Delta: res.Steps - r.Steps}
+}
+
+// abs returns the absolute value of n
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// Heat returns the sensitivity of the map to the removal of each of its walls, breakable or not, between 0 and 1:
+// if Bender reaches the booth it's the share of the steps saved by the removal relative to the removal saving the most,
+// otherwise the removals making him reach the booth are hot, the most those with the shortest path
+// the walls whose removal doesn't help are left out
+func (r WhatIfReport) Heat() map[fsm.Pair]float64 {
+	scores := map[fsm.Pair]int{}
+	longest := 0
+	for _, t := range r.Toggles {
+		if r.helps(t) && t.Steps > longest {
+			longest = t.Steps
+		}
+	}
+	best := 0
+	for _, t := range r.Toggles {
+		if !r.helps(t) {
+			continue
+		}
+		score := -t.Delta
+		if r.Outcome != bender.Reached {
+			// the shortest paths score the most, every path scores at least 1
+			score = longest - t.Steps + 1
+		}
+		scores[t.At] = score
+		if score > best {
+			best = score
+		}
+	}
+	heat := make(map[fsm.Pair]float64, len(scores))
+	for at, score := range scores {
+		heat[at] = float64(score) / float64(best)
+	}
+	return heat
+}
+
+// helps returns true if the edit is the removal of a wall shortening the path or making Bender reach the booth
+func (r WhatIfReport) helps(t Toggle) bool {
+	if (t.From != '#' && t.From != 'X') || t.Outcome != bender.Reached {
+		return false
+	}
+	return r.Outcome != bender.Reached || t.Delta < 0
+}
@@ -0,0 +1,114 @@
+package analysis
+
+import (
+	"testing"
+
+	"bender/internal/bender"
+	"bender/internal/fsm"
+)
+
+func TestWhatIf(t *testing.T) {
+	plan := []string{
+		"#######",
+		"#@  T #",
+		"# X  $#",
+		"#T  I #",
+		"#######",
+	}
+	r, err := WhatIf(plan)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	original, _ := bender.Run(plan)
+	if r.Outcome != original.Outcome || r.Steps != len(original.Path) {
+		t.Fatalf("Wrong original run. Expected %v after %d steps, got %v after %d steps", original.Outcome, len(original.Path), r.Outcome, r.Steps)
+	}
+
+	teleports := 0
+	for i, tg := range r.Toggles {
+		// every edit must match the full simulation of the edited map
+		edited := append([]string{}, plan...)
+		for y, row := range plan {
+			for x := 0; x < len(row); x++ {
+				p := fsm.Pair{X: x, Y: y}
+				if p == tg.At || (tg.From == 'T' && row[x] == 'T') {
+					b := []byte(edited[y])
+					b[x] = tg.To
+					edited[y] = string(b)
+				}
+			}
+		}
+		expected, err := bender.Run(edited)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if tg.Outcome != expected.Outcome || tg.Steps != len(expected.Path) || tg.Delta != tg.Steps-r.Steps {
+			t.Fatalf("Wrong edit %+v. Expected %v after %d steps", tg, expected.Outcome, len(expected.Path))
+		}
+		if tg.From == 'T' {
+			teleports++
+		}
+		if i > 0 {
+			prev := r.Toggles[i-1]
+			if tg.Changed(r.Outcome) && !prev.Changed(r.Outcome) ||
+				tg.Changed(r.Outcome) == prev.Changed(r.Outcome) && abs(tg.Delta) > abs(prev.Delta) {
+				t.Fatalf("Wrong ranking. Expected %+v after %+v", prev, tg)
+			}
+		}
+	}
+	if teleports != 1 {
+		t.Fatalf("Wrong number of teleport removals. Expected 1, got %d", teleports)
+	}
+	// every cell inside the frame but the start is edited
+	if expected := 5*3 - 1 - 1; len(r.Toggles) != expected {
+		t.Fatalf("Wrong number of edits. Expected %d, got %d", expected, len(r.Toggles))
+	}
+}
+
+func TestWhatIfInvalid(t *testing.T) {
+	if _, err := WhatIf([]string{"###", "# #", "###"}); err == nil {
+		t.Fatalf("Expected an error for a map without start")
+	}
+}
+
+func TestHeat(t *testing.T) {
+	tests := []struct {
+		name string
+		plan []string
+		// the hottest wall and a wall left out
+		hottest, cold fsm.Pair
+	}{
+		{
+			// the wall between Bender and the booth saves a detour
+			name:    "shortcut",
+			plan:    []string{"######", "#@   #", "#### #", "#$  W#", "######"},
+			hottest: fsm.Pair{X: 1, Y: 2},
+			cold:    fsm.Pair{X: 2, Y: 2},
+		},
+		{
+			// Bender bounces back and forth unless the wall is removed
+			name:    "loop",
+			plan:    []string{"#######", "#  #  #", "#@ # $#", "#######"},
+			hottest: fsm.Pair{X: 3, Y: 2},
+			cold:    fsm.Pair{X: 3, Y: 1},
+		},
+	}
+	for _, test := range tests {
+		r, err := WhatIf(test.plan)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", test.name, err)
+		}
+		heat := r.Heat()
+		for at, h := range heat {
+			if h <= 0 || h > 1 {
+				t.Fatalf("%s: wrong heat of %v. Expected in (0, 1], got %v", test.name, at, h)
+			}
+		}
+		if heat[test.hottest] != 1 {
+			t.Fatalf("%s: wrong heat of %v. Expected 1, got %v", test.name, test.hottest, heat[test.hottest])
+		}
+		if _, hot := heat[test.cold]; hot {
+			t.Fatalf("%s: unexpected heat of %v", test.name, test.cold)
+		}
+	}
+}
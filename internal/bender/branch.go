@@ -0,0 +1,59 @@
+package bender
+
+import (
+	"sync"
+
+	"bender/internal/fsm"
+)
+
+// Branch is an alternative continuation of a simulation
+type Branch struct {
+	// name of the branch, reported in its result
+	Name string
+	// Setup changes the forked simulation before it runs, can be nil
+	Setup func(f *fsm.FSM, b *BenderSimulator)
+	// options of the run of the branch
+	Options []Option
+}
+
+// BranchResult is the result of a branch
+type BranchResult struct {
+	// name of the branch
+	Name string
+	// result of the simulation of the branch
+	Result Result
+	// error which stopped the simulation of the branch
+	Err error
+}
+
+// Fork returns an independent copy of the simulation
+// the copy can run in another goroutine, only the board is shared and it's never modified
+// the callbacks are reset to the rules of Bender as the original ones may not be safe for concurrent use
+func Fork(f *fsm.FSM, b *BenderSimulator) (*fsm.FSM, *BenderSimulator) {
+	ff := f.Clone()
+	if ff != nil {
+		ff.SetCallbacks(BeforeCallback, EnterCallback)
+	}
+	return ff, b.Clone()
+}
+
+// Explore forks the simulation for every branch and runs the branches concurrently
+// the results are in the order of the branches, the given simulation is not modified
+func Explore(f *fsm.FSM, b *BenderSimulator, branches []Branch) []BranchResult {
+	results := make([]BranchResult, len(branches))
+	wg := sync.WaitGroup{}
+	for i, br := range branches {
+		bf, bb := Fork(f, b)
+		wg.Add(1)
+		go func(i int, br Branch) {
+			defer wg.Done()
+			if br.Setup != nil && bf != nil && bb != nil {
+				br.Setup(bf, bb)
+			}
+			res, err := Resume(bf, bb, br.Options...)
+			results[i] = BranchResult{Name: br.Name, Result: res, Err: err}
+		}(i, br)
+	}
+	wg.Wait()
+	return results
+}
@@ -0,0 +1,78 @@
+package bender
+
+import (
+	"reflect"
+	"testing"
+
+	"bender/internal/fsm"
+)
+
+func TestExplore(t *testing.T) {
+	m, err := fsm.NewFSM(statePlan, BeforeCallback, EnterCallback)
+	if err != nil {
+		t.Fatalf("Failed to create the FSM: %v", err)
+	}
+	bender := NewBenderSimulator()
+	// stop on the breaker
+	simulate(t, m, bender, 1)
+	before := &Checkpoint{Plan: statePlan, Events: 1, FSM: m, Simulator: bender}
+	dump := before.DumpState()
+
+	results := Explore(m, bender, []Branch{
+		{Name: "breaker"},
+		{
+			Name: "no breaker",
+			Setup: func(f *fsm.FSM, b *BenderSimulator) {
+				b.InvertBreaker()
+			},
+		},
+		{Name: "limited", Options: []Option{WithMaxSteps(3)}},
+	})
+
+	if dump != before.DumpState() {
+		t.Fatalf("Explored simulation was modified:\n%s", before.DumpState())
+	}
+	names := []string{}
+	for _, r := range results {
+		if r.Err != nil {
+			t.Fatalf("Branch %q failed: %v", r.Name, r.Err)
+		}
+		names = append(names, r.Name)
+	}
+	if !reflect.DeepEqual(names, []string{"breaker", "no breaker", "limited"}) {
+		t.Fatalf("Wrong order of the results: %v", names)
+	}
+	if res, _ := Run(statePlan); !reflect.DeepEqual(results[0].Result, res) {
+		t.Fatalf("Wrong result of the unchanged branch. Expected %+v, got %+v", res, results[0].Result)
+	}
+	if r := results[1].Result; reflect.DeepEqual(r.Path, results[0].Result.Path) || len(r.Destroyed) != 0 {
+		t.Fatalf("Branch without breaker followed the breaker path: %+v", r)
+	}
+	if r := results[2].Result; r.Outcome != StepLimitExceeded || len(r.Path) != 3 {
+		t.Fatalf("Wrong limited branch: %+v", r)
+	}
+
+	if res := Explore(nil, nil, []Branch{{Name: "nothing"}}); res[0].Err == nil {
+		t.Fatalf("Exploring nothing succeeded")
+	}
+}
+
+func TestFork(t *testing.T) {
+	m, err := fsm.NewFSM(statePlan, BeforeCallback, EnterCallback)
+	if err != nil {
+		t.Fatalf("Failed to create the FSM: %v", err)
+	}
+	bender := NewBenderSimulator()
+	simulate(t, m, bender, 2)
+
+	fm, fb := Fork(m, bender)
+	a := &Checkpoint{Plan: statePlan, Events: 2, FSM: m, Simulator: bender}
+	b := &Checkpoint{Plan: statePlan, Events: 2, FSM: fm, Simulator: fb}
+	if !StateEqual(a, b) {
+		t.Fatalf("Fork differs: %v", StateDiff(a, b))
+	}
+	simulate(t, fm, fb, -1)
+	if m.Steps() != 2 || len(bender.ShowPath()) != 2 || m.Board().At(2, 5) != 'X' {
+		t.Fatalf("Fork modified the original simulation:\n%s", a.DumpState())
+	}
+}
@@ -0,0 +1,70 @@
+package bender
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"bender/internal/compress"
+	"bender/internal/fsm"
+)
+
+// Checkpoint is the saved state of a running simulation
+type Checkpoint struct {
+	// initial map
+	Plan []string `json:"plan"`
+	// number of events sent to the machine so far
+	Events int `json:"events"`
+	// state of the machine
+	FSM *fsm.FSM `json:"fsm"`
+	// state of the simulator
+	Simulator *BenderSimulator `json:"simulator"`
+}
+
+// SaveCheckpoint writes the checkpoint to the given file
+// the file is replaced atomically so a crash never leaves a truncated checkpoint
+// it's gzip compressed if its name has the .gz extension
+func SaveCheckpoint(file string, c *Checkpoint) error {
+	data, err := json.Marshal(c)
+	if err != nil {
+		return err
+	}
+	if data, err = compress.Compress(file, data); err != nil {
+		return err
+	}
+	tmp, err := ioutil.TempFile(filepath.Dir(file), filepath.Base(file)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), file)
+}
+
+// LoadCheckpoint reads the checkpoint from the given file, possibly gzip compressed
+// the machine of the checkpoint has no callbacks, they need to be set with SetCallbacks
+func LoadCheckpoint(file string) (*Checkpoint, error) {
+	data, err := compress.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	c := &Checkpoint{
+		FSM:       &fsm.FSM{},
+		Simulator: &BenderSimulator{},
+	}
+	if err := json.Unmarshal(data, c); err != nil {
+		return nil, fmt.Errorf("malformed checkpoint %s: %v", file, err)
+	}
+	if c.FSM == nil || c.FSM.Board().Height() == 0 || c.Simulator == nil {
+		return nil, fmt.Errorf("malformed checkpoint %s: missing state", file)
+	}
+	return c, nil
+}
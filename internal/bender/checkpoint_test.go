@@ -0,0 +1,58 @@
+package bender
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"bender/internal/fsm"
+)
+
+func TestCheckpoint(t *testing.T) {
+	m, err := fsm.NewFSM(statePlan, BeforeCallback, EnterCallback)
+	if err != nil {
+		t.Fatalf("Failed to create the FSM: %v", err)
+	}
+	bender := NewBenderSimulator()
+	simulate(t, m, bender, 2)
+
+	file := filepath.Join(t.TempDir(), "ckpt.json")
+	if err := SaveCheckpoint(file, &Checkpoint{Plan: statePlan, Events: 2, FSM: m, Simulator: bender}); err != nil {
+		t.Fatalf("Failed to save the checkpoint: %v", err)
+	}
+	c, err := LoadCheckpoint(file)
+	if err != nil {
+		t.Fatalf("Failed to load the checkpoint: %v", err)
+	}
+	if c.Events != 2 || !reflect.DeepEqual(c.Plan, statePlan) {
+		t.Fatalf("Wrong checkpoint: %+v", c)
+	}
+	c.FSM.SetCallbacks(BeforeCallback, EnterCallback)
+
+	// the checkpoints are compressed by extension
+	gz := filepath.Join(t.TempDir(), "ckpt.json.gz")
+	if err := SaveCheckpoint(gz, &Checkpoint{Plan: statePlan, Events: 2, FSM: m, Simulator: bender}); err != nil {
+		t.Fatalf("Failed to save the checkpoint: %v", err)
+	}
+	if data, err := os.ReadFile(gz); err != nil || len(data) < 2 || data[0] != 0x1f || data[1] != 0x8b {
+		t.Fatalf("Checkpoint isn't gzip compressed: %v", err)
+	}
+	cz, err := LoadCheckpoint(gz)
+	if err != nil {
+		t.Fatalf("Failed to load the compressed checkpoint: %v", err)
+	}
+	if cz.Events != 2 || cz.FSM.Position() != c.FSM.Position() || !reflect.DeepEqual(cz.Simulator.ShowPath(), c.Simulator.ShowPath()) {
+		t.Fatalf("Wrong compressed checkpoint: %+v", cz)
+	}
+
+	simulate(t, m, bender, -1)
+	simulate(t, c.FSM, c.Simulator, -1)
+	if !reflect.DeepEqual(c.Simulator.ShowPath(), bender.ShowPath()) {
+		t.Fatalf("Wrong path after resume. Expected %v, got %v", bender.ShowPath(), c.Simulator.ShowPath())
+	}
+
+	if _, err := LoadCheckpoint(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Fatalf("Missing checkpoint was loaded")
+	}
+}
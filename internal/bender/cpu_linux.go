@@ -0,0 +1,19 @@
+package bender
+
+import (
+	"syscall"
+	"time"
+	"unsafe"
+)
+
+// clockThreadCPUTime is CLOCK_THREAD_CPUTIME_ID, the CPU time clock of the calling thread
+const clockThreadCPUTime = 3
+
+// threadCPUTime returns the CPU time used by the calling thread
+func threadCPUTime() time.Duration {
+	var ts syscall.Timespec
+	if _, _, errno := syscall.RawSyscall(syscall.SYS_CLOCK_GETTIME, clockThreadCPUTime, uintptr(unsafe.Pointer(&ts)), 0); errno != 0 {
+		return 0
+	}
+	return time.Duration(ts.Nano())
+}
@@ -0,0 +1,14 @@
+//go:build !linux
+
+package bender
+
+import "time"
+
+// processStart is the origin of the elapsed time standing for the CPU time
+var processStart = time.Now()
+
+// threadCPUTime returns the elapsed time as the CPU time of the threads isn't available
+// the simulation is CPU bound so both are close on a machine which isn't overloaded
+func threadCPUTime() time.Duration {
+	return time.Since(processStart)
+}
@@ -0,0 +1,88 @@
+package bender
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"bender/internal/fsm"
+)
+
+// StateEqual returns true if the given simulations are in the same state
+func StateEqual(a, b *Checkpoint) bool {
+	return len(StateDiff(a, b)) == 0
+}
+
+// StateDiff returns the human readable list of differences between the given simulations
+// each item names a field or a cell with its value in a and in b
+func StateDiff(a, b *Checkpoint) []string {
+	diff := []string{}
+	add := func(name string, va, vb interface{}) {
+		if !reflect.DeepEqual(va, vb) {
+			diff = append(diff, fmt.Sprintf("%s: %v != %v", name, va, vb))
+		}
+	}
+
+	add("events", a.Events, b.Events)
+
+	fa, fb := a.FSM.State(), b.FSM.State()
+	if len(fa.States) != len(fb.States) {
+		add("board rows", len(fa.States), len(fb.States))
+	}
+	for y := 0; y < len(fa.States) && y < len(fb.States); y++ {
+		ra, rb := fa.States[y], fb.States[y]
+		if len(ra) != len(rb) {
+			add(fmt.Sprintf("board row %d length", y), len(ra), len(rb))
+		}
+		for x := 0; x < len(ra) && x < len(rb); x++ {
+			if ra[x] != rb[x] {
+				add(fmt.Sprintf("cell %s", fsm.Pair{X: x, Y: y}), fmt.Sprintf("%q", ra[x]), fmt.Sprintf("%q", rb[x]))
+			}
+		}
+	}
+	add("position", fsm.Pair{X: fa.Curr[0], Y: fa.Curr[1]}, fsm.Pair{X: fb.Curr[0], Y: fb.Curr[1]})
+	add("steps", fa.Steps, fb.Steps)
+	add("teleports", fa.Teleports, fb.Teleports)
+	add("changes", a.FSM.Changes(), b.FSM.Changes())
+
+	sa, sb := a.Simulator.state(), b.Simulator.state()
+	add("done", sa.Done, sb.Done)
+	add("breaker", sa.Breaker, sb.Breaker)
+	add("hurts", sa.Boom, sb.Boom)
+	add("reset direction", sa.ResetDir, sb.ResetDir)
+	add("inverted", sa.InvertPrio, sb.InvertPrio)
+	add("priority index", sa.CurrDir, sb.CurrDir)
+	add("priorities", sa.Priorities, sb.Priorities)
+	add("modifier", sa.PathModifier, sb.PathModifier)
+	add("path", sa.Path, sb.Path)
+	if onlyA, onlyB := setDiff(sa.Cache, sb.Cache); len(onlyA) > 0 || len(onlyB) > 0 {
+		diff = append(diff, fmt.Sprintf("cache: only in a [%s], only in b [%s]", strings.Join(onlyA, " "), strings.Join(onlyB, " ")))
+	}
+	if onlyA, onlyB := setDiff(sa.Configs, sb.Configs); len(onlyA) > 0 || len(onlyB) > 0 {
+		diff = append(diff, fmt.Sprintf("configurations: only in a [%s], only in b [%s]", strings.Join(onlyA, " "), strings.Join(onlyB, " ")))
+	}
+	add("loop", sa.Loop, sb.Loop)
+	add("destroyed walls", sa.Breaks, sb.Breaks)
+	add("hits", sa.Hits, sb.Hits)
+	add("breaker rules", a.Simulator.BreakerRules(), b.Simulator.BreakerRules())
+	return diff
+}
+
+// setDiff returns the items of the sorted lists which are only in a and only in b
+func setDiff(a, b []string) (onlyA, onlyB []string) {
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case j >= len(b) || (i < len(a) && a[i] < b[j]):
+			onlyA = append(onlyA, fmt.Sprintf("%q", a[i]))
+			i++
+		case i >= len(a) || b[j] < a[i]:
+			onlyB = append(onlyB, fmt.Sprintf("%q", b[j]))
+			j++
+		default:
+			i++
+			j++
+		}
+	}
+	return onlyA, onlyB
+}
@@ -0,0 +1,45 @@
+package bender
+
+import (
+	"reflect"
+	"testing"
+
+	"bender/internal/fsm"
+)
+
+// checkpointAt runs the simulation of the plan for the given number of events
+func checkpointAt(t *testing.T, plan []string, events int) *Checkpoint {
+	m, err := fsm.NewFSM(plan, BeforeCallback, EnterCallback)
+	if err != nil {
+		t.Fatalf("Failed to create the FSM: %v", err)
+	}
+	bender := NewBenderSimulator()
+	simulate(t, m, bender, events)
+	return &Checkpoint{Plan: plan, Events: events, FSM: m, Simulator: bender}
+}
+
+func TestStateDiff(t *testing.T) {
+	a := checkpointAt(t, statePlan, 1)
+	if !StateEqual(a, checkpointAt(t, statePlan, 1)) {
+		t.Fatalf("Same simulations are not equal: %v", StateDiff(a, checkpointAt(t, statePlan, 1)))
+	}
+
+	b := checkpointAt(t, statePlan, 2)
+	if StateEqual(a, b) {
+		t.Fatalf("Different simulations are equal")
+	}
+	expected := []string{
+		"events: 1 != 2",
+		`cell (2,3): 'X' != ' '`,
+		"position: (2,2) != (2,3)",
+		"steps: 1 != 2",
+		`changes: [] != [(2,3):'X'->' '@2]`,
+		"path: [SOUTH] != [SOUTH SOUTH]",
+		`cache: only in a [], only in b ["X(2,3)"]`,
+		`configurations: only in a [], only in b ["(2,3) 1 - SOUTH 0 b--"]`,
+		"destroyed walls: 0 != 1",
+	}
+	if diff := StateDiff(a, b); !reflect.DeepEqual(diff, expected) {
+		t.Fatalf("Wrong diff. Expected:\n%q\ngot:\n%q", expected, diff)
+	}
+}
@@ -0,0 +1,40 @@
+package bender
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DumpState returns a deterministic textual representation of the simulator:
+// the flags, the priorities and the path length
+func (b *BenderSimulator) DumpState() string {
+	sb := &strings.Builder{}
+	fmt.Fprintf(sb, "direction: %s\n", b.Direction())
+	fmt.Fprintf(sb, "priorities: %s\n", strings.Join(directionNames(b.priorities), " "))
+	modifier := b.pathModifier
+	if modifier == "" {
+		modifier = "-"
+	}
+	fmt.Fprintf(sb, "modifier: %s\n", modifier)
+	fmt.Fprintf(sb, "done: %t\n", b.done)
+	fmt.Fprintf(sb, "breaker: %t\n", b.breaker)
+	fmt.Fprintf(sb, "inverted: %t\n", b.invertPrio)
+	fmt.Fprintf(sb, "hurts: %t (%d hit(s))\n", b.boom, b.hits)
+	fmt.Fprintf(sb, "steps: %d\n", len(b.path))
+	fmt.Fprintf(sb, "configurations: %d\n", len(b.configs))
+	fmt.Fprintf(sb, "destroyed walls: %d\n", b.breaks)
+	return sb.String()
+}
+
+// DumpState returns a deterministic textual representation of the saved simulation
+func (c *Checkpoint) DumpState() string {
+	sb := &strings.Builder{}
+	fmt.Fprintf(sb, "events: %d\n%s", c.Events, c.FSM.DumpState())
+	fmt.Fprint(sb, "destroyed:")
+	for _, d := range destroyedWalls(c.FSM) {
+		fmt.Fprintf(sb, " %s", d)
+	}
+	fmt.Fprintln(sb)
+	fmt.Fprint(sb, c.Simulator.DumpState())
+	return sb.String()
+}
@@ -0,0 +1,40 @@
+package bender
+
+import (
+	"flag"
+	"io/ioutil"
+	"testing"
+
+	"bender/internal/fsm"
+)
+
+var update = flag.Bool("update", false, "update the golden files")
+
+func TestDumpState(t *testing.T) {
+	m, err := fsm.NewFSM(statePlan, BeforeCallback, EnterCallback)
+	if err != nil {
+		t.Fatalf("Failed to create the FSM: %v", err)
+	}
+	bender := NewBenderSimulator()
+	simulate(t, m, bender, 4)
+
+	c := &Checkpoint{Plan: statePlan, Events: 4, FSM: m, Simulator: bender}
+	dump := c.DumpState()
+	if dump != c.DumpState() {
+		t.Fatalf("Dump is not deterministic")
+	}
+
+	golden := "testdata/dump.golden"
+	if *update {
+		if err := ioutil.WriteFile(golden, []byte(dump), 0644); err != nil {
+			t.Fatalf("Failed to update the golden file: %v", err)
+		}
+	}
+	expected, err := ioutil.ReadFile(golden)
+	if err != nil {
+		t.Fatalf("Failed to read the golden file: %v", err)
+	}
+	if dump != string(expected) {
+		t.Fatalf("Dump doesn't match the golden file. Expected:\n%s\ngot:\n%s", expected, dump)
+	}
+}
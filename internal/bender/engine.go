@@ -0,0 +1,220 @@
+package bender
+
+import (
+	"fmt"
+
+	"bender/internal/fsm"
+)
+
+const (
+	// defaultSnapshotInterval is the initial number of events between two snapshots of an engine
+	defaultSnapshotInterval = 64
+	// maxSnapshots is the number of snapshots above which an engine keeps one snapshot out of two
+	maxSnapshots = 256
+)
+
+// engineSnapshot is the state of the simulation after a number of events
+// the changes of the machine, the path, the cache and the configurations of the simulator are only appended to during a run,
+// so the simulation is rewound from its last state rather than copied
+type engineSnapshot struct {
+	events int
+	// steps and position of the machine
+	steps int
+	curr  fsm.Pair
+	// simulator without its path, cache and configurations
+	bender BenderSimulator
+	// lengths of the path, of the cache and of the configurations
+	pathLen, cacheLen, configsLen int
+}
+
+// Engine runs a simulation and reruns it incrementally after single tile edits of the map
+// only the steps from the first one affected by the edit are simulated again
+type Engine struct {
+	plan []string
+	opts []Option
+	// machine and simulator of the last run
+	fsm    *fsm.FSM
+	bender *BenderSimulator
+	// states added to the cache of the simulator, in order
+	added []fsm.StateID
+	// configurations added to the loop detection of the simulator, in order
+	addedConfigs []loopState
+	// destination of every event of the last run, in order
+	visits []fsm.Pair
+	// snapshots of the last run, in order of the events
+	snapshots []engineSnapshot
+	// number of events between two snapshots
+	interval int
+	// number of events reused from the previous run by the last run
+	reused int
+}
+
+// NewEngine returns an engine simulating the given map with the given options
+// the glyphs of the map are replaced by their tiles so the edits are at the coordinates of the cells
+func NewEngine(plan []string, opts ...Option) *Engine {
+	return &Engine{
+		plan: fsm.DecodePlan(plan),
+		opts: opts,
+	}
+}
+
+// Plan returns the current map of the engine
+func (en *Engine) Plan() []string {
+	return append([]string{}, en.plan...)
+}
+
+// Reused returns the number of events of the previous run reused by the last run
+func (en *Engine) Reused() int {
+	return en.reused
+}
+
+// Run simulates the current map from scratch
+func (en *Engine) Run() (Result, error) {
+	f, err := fsm.NewFSM(en.plan, nil, nil)
+	if err != nil {
+		return Result{}, err
+	}
+	en.fsm = f
+	en.bender = NewBenderSimulator()
+	en.added = en.added[:0]
+	en.addedConfigs = en.addedConfigs[:0]
+	en.visits = en.visits[:0]
+	en.interval = defaultSnapshotInterval
+	en.snapshots = en.snapshots[:0]
+	en.snapshot()
+	en.reused = 0
+	return en.resume()
+}
+
+// Edit changes the tile at the given coordinates and reruns the simulation
+// the previous run is reused up to the first step whose destination is the edited tile,
+// edits of the start position or of the teleports rerun more as they affect more steps
+// the engine is left unchanged if the edited map is invalid
+func (en *Engine) Edit(x, y int, tile byte) (Result, error) {
+	if y < 0 || y >= len(en.plan) || x < 0 || x >= len(en.plan[y]) {
+		return Result{}, fmt.Errorf("edit out of the map at %s", fsm.Pair{X: x, Y: y})
+	}
+	plan := append([]string{}, en.plan...)
+	row := []byte(plan[y])
+	old := row[x]
+	row[x] = tile
+	plan[y] = string(row)
+
+	if en.fsm == nil || old == '@' || tile == '@' {
+		prev := en.plan
+		en.plan = plan
+		res, err := en.Run()
+		if err != nil {
+			en.plan = prev
+		}
+		return res, err
+	}
+
+	// first event reading the edited tile
+	at := fsm.Pair{X: x, Y: y}
+	affected := len(en.visits)
+	for i, v := range en.visits {
+		if v == at || ((fsm.IsTeleport(old) || fsm.IsTeleport(tile)) && fsm.IsTeleport(en.fsm.Board().At(v.X, v.Y))) {
+			affected = i
+			break
+		}
+	}
+
+	// the configurations before the affected event stay valid: the steps from them are the same on both maps
+	if err := en.fsm.Rebase(fsm.NewBoard(plan)); err != nil {
+		return Result{}, err
+	}
+	en.plan = plan
+
+	// latest snapshot before the affected event
+	i := 0
+	for i+1 < len(en.snapshots) && en.snapshots[i+1].events <= affected {
+		i++
+	}
+	snap := en.snapshots[i]
+	en.rewind(snap)
+	en.snapshots = en.snapshots[:i+1]
+	en.visits = en.visits[:snap.events]
+	en.reused = snap.events
+	return en.resume()
+}
+
+// resume continues the simulation recording the visits, the cache and configuration additions and the snapshots
+func (en *Engine) resume() (Result, error) {
+	f, b := en.fsm, en.bender
+	f.SetCallbacks(func(e *fsm.Event) {
+		en.visits = append(en.visits, e.DstPosition())
+		BeforeCallback(e)
+	}, func(e *fsm.Event) {
+		n, m := len(b.cache), len(b.configs)
+		EnterCallback(e)
+		if len(b.cache) > n {
+			en.added = append(en.added, e.DstID())
+		}
+		if len(b.configs) > m {
+			en.addedConfigs = append(en.addedConfigs, b.loopState(e.DstID().At))
+		}
+	})
+
+	c := &runConfig{}
+	for _, o := range en.opts {
+		o(c)
+	}
+	hook := func() error {
+		if len(en.visits)%en.interval == 0 {
+			en.snapshot()
+		}
+		if c.eventHook != nil {
+			return c.eventHook()
+		}
+		return nil
+	}
+	return Resume(f, b, append(en.opts, WithEventHook(hook))...)
+}
+
+// snapshot records the state of the simulation
+// the snapshots are thinned out when there are too many of them to bound the memory
+func (en *Engine) snapshot() {
+	b := *en.bender
+	b.priorities = append([]fsm.Direction(nil), b.priorities...)
+	b.path, b.cache, b.configs = nil, nil, nil
+	en.snapshots = append(en.snapshots, engineSnapshot{
+		events:     len(en.visits),
+		steps:      en.fsm.Steps(),
+		curr:       en.fsm.Position(),
+		bender:     b,
+		pathLen:    len(en.bender.path),
+		cacheLen:   len(en.added),
+		configsLen: len(en.addedConfigs),
+	})
+	if len(en.snapshots) <= maxSnapshots {
+		return
+	}
+	kept := en.snapshots[:0]
+	for i, s := range en.snapshots {
+		if i%2 == 0 {
+			kept = append(kept, s)
+		}
+	}
+	en.snapshots = kept
+	en.interval *= 2
+}
+
+// rewind brings the machine and the simulator of the last run back to the given snapshot
+func (en *Engine) rewind(s engineSnapshot) {
+	en.fsm.Rewind(s.steps, s.curr)
+	b := en.bender
+	for _, k := range en.added[s.cacheLen:] {
+		delete(b.cache, k)
+	}
+	en.added = en.added[:s.cacheLen]
+	for _, k := range en.addedConfigs[s.configsLen:] {
+		delete(b.configs, k)
+	}
+	en.addedConfigs = en.addedConfigs[:s.configsLen]
+	// the path of the previous result must not be overwritten
+	path, cache, configs := b.path[:s.pathLen:s.pathLen], b.cache, b.configs
+	*b = s.bender
+	b.priorities = append([]fsm.Direction(nil), s.bender.priorities...)
+	b.path, b.cache, b.configs = path, cache, configs
+}
@@ -0,0 +1,176 @@
+package bender
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+func TestEngineEdit(t *testing.T) {
+	plan := snakePlan(40)
+	en := NewEngine(plan)
+	res, err := en.Run()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if res.Outcome != Reached {
+		t.Fatalf("Wrong outcome. Expected %v, got %v", Reached, res.Outcome)
+	}
+
+	rnd := rand.New(rand.NewSource(42))
+	reused := 0
+	for i := 0; i < 200; i++ {
+		x, y := 1+rnd.Intn(len(plan[0])-2), 1+rnd.Intn(len(plan)-2)
+		tile := " #XIBSNEW$"[rnd.Intn(10)]
+		if en.Plan()[y][x] == '@' {
+			continue
+		}
+		res, err := en.Edit(x, y, tile)
+		if err != nil {
+			t.Fatalf("Edit #%d: unexpected error: %v", i, err)
+		}
+		expected, err := Run(en.Plan())
+		if err != nil {
+			t.Fatalf("Edit #%d: unexpected error: %v", i, err)
+		}
+		if !reflect.DeepEqual(res, expected) {
+			t.Fatalf("Edit #%d of %q at (%d,%d): wrong result. Expected %+v, got %+v", i, tile, x, y, expected, res)
+		}
+		reused += en.Reused()
+	}
+	if reused == 0 {
+		t.Fatalf("No step was reused")
+	}
+}
+
+func TestEngineEditLoop(t *testing.T) {
+	// the loop is detected once Bender is back in a configuration, whatever the cells he doesn't visit
+	plan := loopPlan(12)
+	en := NewEngine(plan)
+	if _, err := en.Run(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	// the edits are never visited
+	for i, edit := range []struct {
+		x, y int
+		tile byte
+	}{{5, 8, '#'}, {6, 8, '#'}, {5, 8, ' '}, {6, 8, ' '}} {
+		res, err := en.Edit(edit.x, edit.y, edit.tile)
+		if err != nil {
+			t.Fatalf("Edit #%d: unexpected error: %v", i, err)
+		}
+		expected, err := Run(en.Plan())
+		if err != nil {
+			t.Fatalf("Edit #%d: unexpected error: %v", i, err)
+		}
+		if res.Outcome != Loop || !reflect.DeepEqual(res, expected) {
+			t.Fatalf("Edit #%d: wrong result. Expected %v after %d steps, got %v after %d steps", i, expected.Outcome, len(expected.Path), res.Outcome, len(res.Path))
+		}
+	}
+}
+
+func TestEngineEditSpecialTiles(t *testing.T) {
+	plan := []string{
+		"##########",
+		"#@   X   #",
+		"#B   T   #",
+		"#        #",
+		"#  T     #",
+		"#       $#",
+		"##########",
+	}
+	en := NewEngine(plan)
+	if _, err := en.Run(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	edits := []struct {
+		x, y    int
+		tile    byte
+		invalid bool
+	}{
+		{x: 3, y: 4, tile: ' ', invalid: true},
+		{x: 8, y: 3, tile: 'T', invalid: true},
+		{x: 7, y: 1, tile: 'X'},
+		{x: 2, y: 3, tile: '@'},
+		{x: 1, y: 1, tile: ' '},
+		{x: 5, y: 1, tile: ' '},
+	}
+	for _, e := range edits {
+		before := en.Plan()
+		res, err := en.Edit(e.x, e.y, e.tile)
+		if e.invalid {
+			if err == nil || !reflect.DeepEqual(en.Plan(), before) {
+				t.Fatalf("Edit of %q at (%d,%d): invalid map was accepted", e.tile, e.x, e.y)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("Edit of %q at (%d,%d): unexpected error %v", e.tile, e.x, e.y, err)
+		}
+		expected, err := Run(en.Plan())
+		if err != nil {
+			t.Fatalf("Edit of %q at (%d,%d): unexpected error %v", e.tile, e.x, e.y, err)
+		}
+		if !reflect.DeepEqual(res, expected) {
+			t.Fatalf("Edit of %q at (%d,%d): wrong result. Expected %+v, got %+v", e.tile, e.x, e.y, expected, res)
+		}
+	}
+
+	if _, err := en.Edit(42, 0, ' '); err == nil {
+		t.Fatalf("Edit out of the map succeeded")
+	}
+}
+
+func TestEngineSnapshots(t *testing.T) {
+	plan := snakePlan(200)
+	en := NewEngine(plan)
+	if _, err := en.Run(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(en.snapshots) > maxSnapshots {
+		t.Fatalf("Too many snapshots: %d", len(en.snapshots))
+	}
+
+	first, err := Run(plan)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// block the last corridor
+	y := len(plan) - 3
+	res, err := en.Edit(len(plan[y])/2, y, '#')
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected, err := Run(en.Plan())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(res, expected) {
+		t.Fatalf("Wrong result. Expected %v after %d steps, got %v after %d steps", expected.Outcome, len(expected.Path), res.Outcome, len(res.Path))
+	}
+	if en.Reused() < len(first.Path)/2 {
+		t.Fatalf("Too few reused events: %d of %d", en.Reused(), len(first.Path))
+	}
+}
+
+func BenchmarkEngineEdit(b *testing.B) {
+	plan := snakePlan(300)
+	// toggle a breakable wall of the last corridor
+	y := len(plan) - 3
+	b.Run("rerun", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			plan[y] = plan[y][:14] + string("X "[i%2]) + plan[y][15:]
+			Run(plan)
+		}
+	})
+	b.Run("edit", func(b *testing.B) {
+		en := NewEngine(plan)
+		en.Run()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			en.Edit(14, y, "X "[i%2])
+		}
+	})
+}
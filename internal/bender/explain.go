@@ -0,0 +1,100 @@
+package bender
+
+import (
+	"strings"
+
+	"bender/internal/fsm"
+	"bender/internal/i18n"
+)
+
+// Rule identifies a rule of the game applied by a step
+type Rule string
+
+// rules choosing the direction of a step
+const (
+	// Bender keeps its direction
+	RuleForward Rule = "forward"
+	// an obstacle blocks the way, Bender takes the first free direction of its priorities
+	RulePriorityFallback Rule = "priority fallback"
+	// Bender follows the direction of the last path modifier
+	RulePathModifier Rule = "path modifier"
+)
+
+// rules applied by the tile entered by a step
+const (
+	// Bender destroys a breakable wall in breaker mode
+	RuleBreakerDestruction Rule = "breaker destruction"
+	// Bender toggles the breaker mode
+	RuleBreakerToggle Rule = "breaker toggle"
+	// Bender inverts its priorities
+	RuleInversion Rule = "inversion"
+	// Bender is teleported to the other teleport
+	RuleTeleport Rule = "teleport"
+	// Bender reaches the suicide booth
+	RuleBooth Rule = "booth"
+)
+
+// Explanation tells why Bender made its last step
+type Explanation struct {
+	// direction of the step, empty before the first step
+	Direction fsm.Direction
+	// rule which chose the direction
+	Choice Rule
+	// priorities of Bender after the step
+	Priorities []fsm.Direction
+	// rule applied by the entered tile, empty if none
+	Effect Rule
+}
+
+// StepRules returns the rules of the last step of the simulator: the rule which chose its direction
+// and the rule applied by the entered tile, if any, both are empty before the first step
+func (b *BenderSimulator) StepRules() (choice, effect Rule) {
+	return b.choice, b.effect
+}
+
+// Explain returns the explanation of the last step of the simulator
+func (b *BenderSimulator) Explain() Explanation {
+	if len(b.path) == 0 {
+		return Explanation{}
+	}
+	return Explanation{
+		Direction:  fsm.Direction(b.path[len(b.path)-1]),
+		Choice:     b.choice,
+		Priorities: append([]fsm.Direction(nil), b.priorities...),
+		Effect:     b.effect,
+	}
+}
+
+// String explains the step in a sentence
+func (x Explanation) String() string {
+	return x.Localize(nil)
+}
+
+// Localize explains the step like String in the language of the given catalog
+func (x Explanation) Localize(c i18n.Catalog) string {
+	if x.Direction == "" {
+		return c.Sprintf("Bender didn't move yet.")
+	}
+	var sentences []string
+	switch x.Choice {
+	case RulePriorityFallback:
+		sentences = append(sentences, c.Sprintf("An obstacle blocked the way, Bender took %s, the first free direction of its priorities %s.", x.Direction, strings.Join(directionNames(x.Priorities), ", ")))
+	case RulePathModifier:
+		sentences = append(sentences, c.Sprintf("Bender followed the path modifier to the %s.", x.Direction))
+	default:
+		sentences = append(sentences, c.Sprintf("Nothing blocked the way, Bender kept going %s.", x.Direction))
+	}
+	switch x.Effect {
+	case RuleBreakerDestruction:
+		sentences = append(sentences, c.Sprintf("In breaker mode, it destroyed the breakable wall."))
+	case RuleBreakerToggle:
+		sentences = append(sentences, c.Sprintf("It drank a beer and toggled its breaker mode."))
+	case RuleInversion:
+		sentences = append(sentences, c.Sprintf("It crossed an inverter, its priorities are inverted at the next obstacle."))
+	case RuleTeleport:
+		sentences = append(sentences, c.Sprintf("It was teleported to the other teleport."))
+	case RuleBooth:
+		sentences = append(sentences, c.Sprintf("It reached the suicide booth."))
+	}
+	return strings.Join(sentences, " ")
+}
@@ -0,0 +1,117 @@
+package bender
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"bender/internal/fsm"
+	"bender/internal/i18n"
+)
+
+func TestExplain(t *testing.T) {
+	testCases := []struct {
+		name     string
+		plan     []string
+		expected []Explanation
+	}{
+		{
+			name: "priority fallback",
+			plan: []string{"#####", "#@ $#", "#####"},
+			expected: []Explanation{
+				{Direction: fsm.EAST, Choice: RulePriorityFallback},
+				{Direction: fsm.EAST, Choice: RuleForward, Effect: RuleBooth},
+			},
+		},
+		{
+			name: "breaker",
+			plan: []string{"###", "#@#", "#B#", "#X#", "#$#", "###"},
+			expected: []Explanation{
+				{Direction: fsm.SOUTH, Choice: RuleForward, Effect: RuleBreakerToggle},
+				{Direction: fsm.SOUTH, Choice: RuleForward, Effect: RuleBreakerDestruction},
+				{Direction: fsm.SOUTH, Choice: RuleForward, Effect: RuleBooth},
+			},
+		},
+		{
+			name: "path modifier and teleport",
+			plan: []string{"#####", "#@  #", "#E T#", "#T$##", "#####"},
+			expected: []Explanation{
+				{Direction: fsm.SOUTH, Choice: RuleForward},
+				{Direction: fsm.EAST, Choice: RulePathModifier},
+				{Direction: fsm.EAST, Choice: RulePathModifier, Effect: RuleTeleport},
+				{Direction: fsm.EAST, Choice: RulePathModifier, Effect: RuleBooth},
+			},
+		},
+		{
+			name: "inversion",
+			plan: []string{"######", "#@I $#", "######"},
+			expected: []Explanation{
+				{Direction: fsm.EAST, Choice: RulePriorityFallback, Effect: RuleInversion},
+				{Direction: fsm.EAST, Choice: RuleForward},
+				{Direction: fsm.EAST, Choice: RuleForward, Effect: RuleBooth},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		f, err := fsm.NewFSM(tc.plan, BeforeCallback, EnterCallback)
+		if err != nil {
+			t.Fatalf("Test case %q: unexpected error: %v", tc.name, err)
+		}
+		b := NewBenderSimulator()
+		if x := b.Explain(); !reflect.DeepEqual(x, Explanation{}) {
+			t.Fatalf("Test case %q: wrong explanation before the first step, got %+v", tc.name, x)
+		}
+		got := []Explanation{}
+		for !b.Over() {
+			if _, err := Resume(f, b, WithMaxSteps(f.Steps()+1)); err != nil {
+				t.Fatalf("Test case %q: unexpected error: %v", tc.name, err)
+			}
+			x := b.Explain()
+			if !reflect.DeepEqual(x.Priorities, b.priorities) {
+				t.Fatalf("Test case %q: wrong priorities. Expected %q, got %q", tc.name, b.priorities, x.Priorities)
+			}
+			x.Priorities = nil
+			got = append(got, x)
+		}
+		if !reflect.DeepEqual(got, tc.expected) {
+			t.Fatalf("Test case %q: wrong explanations. Expected %#v, got %#v", tc.name, tc.expected, got)
+		}
+	}
+}
+
+func TestExplanationString(t *testing.T) {
+	testCases := []struct {
+		x        Explanation
+		expected string
+	}{
+		{x: Explanation{}, expected: "Bender didn't move yet."},
+		{
+			x:        Explanation{Direction: fsm.NORTH, Choice: RulePriorityFallback, Priorities: []fsm.Direction{fsm.SOUTH, fsm.EAST, fsm.NORTH, fsm.WEST}},
+			expected: "An obstacle blocked the way, Bender took NORTH, the first free direction of its priorities SOUTH, EAST, NORTH, WEST.",
+		},
+		{
+			x:        Explanation{Direction: fsm.WEST, Choice: RulePathModifier, Effect: RuleTeleport},
+			expected: "Bender followed the path modifier to the WEST. It was teleported to the other teleport.",
+		},
+	}
+	for _, tc := range testCases {
+		if s := tc.x.String(); s != tc.expected {
+			t.Fatalf("Wrong explanation. Expected %q, got %q", tc.expected, s)
+		}
+	}
+	if s := (Explanation{Direction: fsm.SOUTH, Choice: RuleForward, Effect: RuleBooth}).String(); !strings.HasSuffix(s, "booth.") {
+		t.Fatalf("Wrong explanation of the booth, got %q", s)
+	}
+}
+
+func TestExplanationLocalize(t *testing.T) {
+	c := i18n.Catalog{
+		"Nothing blocked the way, Bender kept going %s.": "Rien ne bloquait le chemin, Bender a continué vers %s.",
+	}
+	x := Explanation{Direction: fsm.SOUTH, Choice: RuleForward, Effect: RuleBooth}
+	expected := "Rien ne bloquait le chemin, Bender a continué vers SOUTH. It reached the suicide booth."
+	if s := x.Localize(c); s != expected {
+		t.Fatalf("Wrong localized explanation. Expected %q, got %q", expected, s)
+	}
+}
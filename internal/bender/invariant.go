@@ -0,0 +1,123 @@
+package bender
+
+import (
+	"fmt"
+	"strings"
+
+	"bender/internal/fsm"
+)
+
+// Invariant is a property of the simulation which holds after every step,
+// its check returns the violation, nil if the property holds
+type Invariant struct {
+	Name  string
+	Check func(f *fsm.FSM, b *BenderSimulator) error
+}
+
+// Invariants are the properties of the rules of Bender, checked with WithInvariants to catch the bugs of the engine early
+var Invariants = []Invariant{
+	{Name: "never inside a wall", Check: checkNotInWall},
+	{Name: "path length equals step count", Check: checkPathLength},
+}
+
+// WithInvariants checks the given invariants after every step, the simulation is aborted
+// with an InvariantError at the first violation
+func WithInvariants(invariants ...Invariant) Option {
+	return func(c *runConfig) {
+		c.invariants = append(c.invariants, invariants...)
+	}
+}
+
+// InvariantError is the violation of an invariant, with the state of the simulation at the violation
+type InvariantError struct {
+	// name of the violated invariant
+	Invariant string
+	// number of steps made, position and direction of Bender
+	Step      int
+	Position  fsm.Pair
+	Direction fsm.Direction
+	Breaker   bool
+	Inverted  bool
+	// rows of the board at the violation
+	Board []string
+	// violation returned by the check
+	Err error
+}
+
+// Error formats the violation on a single line
+func (e *InvariantError) Error() string {
+	return fmt.Sprintf("invariant %q violated at step %d at (%d,%d) going %s: %v", e.Invariant, e.Step, e.Position.X, e.Position.Y, e.Direction, e.Err)
+}
+
+// Unwrap returns the violation returned by the check
+func (e *InvariantError) Unwrap() error {
+	return e.Err
+}
+
+// Report returns the violation followed by the state of Bender and the board, with a caret under Bender
+func (e *InvariantError) Report() string {
+	sb := &strings.Builder{}
+	fmt.Fprintf(sb, "%v\nbreaker=%t inverted=%t\n", e, e.Breaker, e.Inverted)
+	for y, row := range e.Board {
+		sb.WriteString(row)
+		sb.WriteByte('\n')
+		if y == e.Position.Y && e.Position.X >= 0 {
+			sb.WriteString(strings.Repeat(" ", e.Position.X))
+			sb.WriteString("^\n")
+		}
+	}
+	return sb.String()
+}
+
+// checkInvariants returns the violation of the first invariant which doesn't hold, nil if they all hold
+func checkInvariants(f *fsm.FSM, b *BenderSimulator, invariants []Invariant) error {
+	for _, inv := range invariants {
+		err := inv.Check(f, b)
+		if err == nil {
+			continue
+		}
+		var dir fsm.Direction
+		for i := len(b.path) - 1; i >= 0; i-- {
+			if b.path[i] != BREAK {
+				dir = fsm.Direction(b.path[i])
+				break
+			}
+		}
+		return &InvariantError{
+			Invariant: inv.Name,
+			Step:      f.Steps(),
+			Position:  f.Position(),
+			Direction: dir,
+			Breaker:   b.Breaker(),
+			Inverted:  b.Inverted(),
+			Board:     fsm.Rows(f.Board()),
+			Err:       err,
+		}
+	}
+	return nil
+}
+
+// checkNotInWall verifies that Bender stands on a tile he can enter
+func checkNotInWall(f *fsm.FSM, b *BenderSimulator) error {
+	switch c := f.Board().At(f.Position().X, f.Position().Y); c {
+	case 0:
+		return fmt.Errorf("out of the board")
+	case '#', 'X':
+		return fmt.Errorf("inside the wall %q", c)
+	}
+	return nil
+}
+
+// checkPathLength verifies that the path holds a direction per step, besides its BREAK entries
+func checkPathLength(f *fsm.FSM, b *BenderSimulator) error {
+	n := 0
+	for _, dir := range b.path {
+		if dir != BREAK {
+			n++
+		}
+	}
+	if n != f.Steps() {
+		return fmt.Errorf("%d direction(s) in the path for %d step(s)", n, f.Steps())
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+package bender
+
+import (
+	"fmt"
+
+	"bender/internal/fsm"
+)
+
+// loopState is the configuration of Bender after a step, it decides all his next steps:
+// the same configuration seen twice is an endless cycle
+type loopState struct {
+	At fsm.Pair
+	// the walls are only destroyed, the same number of destructions is the same board
+	Breaks int
+	// path modifier and priority index giving the next direction
+	Modifier fsm.Direction
+	CurrDir  int
+	// the priorities are only turned over, the first one tells their order
+	First    fsm.Direction
+	Breaker  bool
+	Inverted bool
+	ResetDir bool
+}
+
+// loopState returns the configuration of the simulator at the given position
+func (b *BenderSimulator) loopState(at fsm.Pair) loopState {
+	k := loopState{
+		At:       at,
+		Breaks:   b.breaks,
+		Modifier: b.pathModifier,
+		CurrDir:  b.currDir,
+		Breaker:  b.breaker,
+		Inverted: b.invertPrio,
+		ResetDir: b.resetDir,
+	}
+	if len(b.priorities) > 0 {
+		k.First = b.priorities[0]
+	}
+	return k
+}
+
+// String returns the configuration as (x,y) breaks modifier priority index flags,
+// the empty directions are - and the flags are b for the breaker mode, i for inverted and r for the reset direction
+func (s loopState) String() string {
+	flags := []byte("---")
+	if s.Breaker {
+		flags[0] = 'b'
+	}
+	if s.Inverted {
+		flags[1] = 'i'
+	}
+	if s.ResetDir {
+		flags[2] = 'r'
+	}
+	return fmt.Sprintf("%s %d %s %s %d %s", s.At, s.Breaks, dashed(s.Modifier), dashed(s.First), s.CurrDir, flags)
+}
+
+// parseLoopState returns the configuration formatted by String
+func parseLoopState(str string) (loopState, error) {
+	var s loopState
+	var modifier, first, flags string
+	if _, err := fmt.Sscanf(str, "(%d,%d) %d %s %s %d %s", &s.At.X, &s.At.Y, &s.Breaks, &modifier, &first, &s.CurrDir, &flags); err != nil {
+		return s, fmt.Errorf("malformed configuration %q", str)
+	}
+	s.Modifier, s.First = undashed(modifier), undashed(first)
+	s.Breaker, s.Inverted, s.ResetDir = flags[0] == 'b', len(flags) > 1 && flags[1] == 'i', len(flags) > 2 && flags[2] == 'r'
+	if s.String() != str {
+		return s, fmt.Errorf("malformed configuration %q", str)
+	}
+	return s, nil
+}
+
+// dashed returns the direction, - if empty
+func dashed(d fsm.Direction) string {
+	if d == "" {
+		return "-"
+	}
+	return string(d)
+}
+
+// undashed returns the direction formatted by dashed
+func undashed(s string) fsm.Direction {
+	if s == "-" {
+		return ""
+	}
+	return fsm.Direction(s)
+}
@@ -0,0 +1,260 @@
+package bender
+
+import (
+	"fmt"
+	"sync"
+
+	"bender/internal/fsm"
+)
+
+// memoKey identifies a configuration of a simulation: the board, the position and the state of Bender,
+// the same configuration has the same next steps whatever the steps before it
+type memoKey struct {
+	// hash of the board with its changes, the start is a floor
+	board [2]uint64
+	// position, priorities, path modifier and modes, the breaks are left to the board
+	config loopState
+	hits   int
+	boom   bool
+	rules  BreakerRules
+	// steps left before the step limit for the loops and the step limits, -1 for the simulations ending by themselves
+	remaining int
+}
+
+// memoEntry is a memoized simulation continuing from a configuration, the entries of a simulation share its result
+type memoEntry struct {
+	res *Result
+	// steps and length of the path at the configuration
+	steps, pathLen int
+	// true if configurations were visited before this one, a loop may then be detected earlier
+	history bool
+}
+
+// Memo memoizes the results of the simulations across runs
+// a simulation reaching a configuration already simulated, from any route, is completed with the memoized steps
+// it's safe for concurrent use
+type Memo struct {
+	mu      sync.Mutex
+	results map[memoKey]memoEntry
+	hits    int
+	misses  int
+}
+
+// MemoStats are the statistics of a memo
+type MemoStats struct {
+	// number of simulations completed from the memo
+	Hits int
+	// number of simulations done to the end
+	Misses int
+	// number of memoized configurations
+	Entries int
+}
+
+// String formats the statistics with the hit rate
+func (s MemoStats) String() string {
+	rate := 0.0
+	if s.Hits+s.Misses > 0 {
+		rate = 100 * float64(s.Hits) / float64(s.Hits+s.Misses)
+	}
+	return fmt.Sprintf("hits=%d misses=%d entries=%d hit rate=%.1f%%", s.Hits, s.Misses, s.Entries, rate)
+}
+
+// NewMemo returns an empty memo
+func NewMemo() *Memo {
+	return &Memo{
+		results: map[memoKey]memoEntry{},
+	}
+}
+
+// Stats returns the statistics of the memo
+func (m *Memo) Stats() MemoStats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return MemoStats{Hits: m.hits, Misses: m.misses, Entries: len(m.results)}
+}
+
+// WithMemo completes the simulation from the given memo once it reaches a configuration already simulated
+// the machine and the simulator are not advanced past the configuration and the event hook is not called then,
+// the machines with handlers per tile or middlewares aren't memoized
+func WithMemo(m *Memo) Option {
+	return func(c *runConfig) {
+		c.memo = m
+	}
+}
+
+// memoInterval is the number of steps between two configurations of a simulation memoized
+// the simulations reaching one of the steps in between reach the next memoized configuration
+const memoInterval = 16
+
+// memoTrail follows the configurations of a simulation to look them up and to memoize them
+type memoTrail struct {
+	memo *Memo
+	// step limit of the simulation, zero without limit
+	maxSteps int
+	// hash of the board, number of changes and of destructions it includes
+	board           [2]uint64
+	changes, breaks int
+	// configurations to memoize with the result
+	marks []memoMark
+	hit   bool
+}
+
+// memoMark is a configuration of the simulation to memoize
+type memoMark struct {
+	key   memoKey
+	entry memoEntry
+}
+
+// trail returns the trail of the simulation done by the given machine and simulator
+func (m *Memo) trail(f *fsm.FSM, b *BenderSimulator, maxSteps int) *memoTrail {
+	t := &memoTrail{memo: m, maxSteps: maxSteps, changes: len(f.Changes()), breaks: b.breaks}
+	board := f.Board()
+	for y := 0; y < board.Height(); y++ {
+		for x := 0; x < board.Width(); x++ {
+			t.toggle(x, y, board.At(x, y))
+		}
+	}
+	return t
+}
+
+// toggle adds the tile at the given coordinates to the hash of the board, or removes it if it's already there
+func (t *memoTrail) toggle(x, y int, tile byte) {
+	if tile == 0 {
+		return
+	}
+	if tile == '@' {
+		// the start is a floor once left
+		tile = ' '
+	}
+	v := uint64(x)<<36 | uint64(y)<<8 | uint64(tile)
+	t.board[0] ^= mix(v)
+	t.board[1] ^= mix(v | 1<<63)
+}
+
+// mix returns the hash of the value (splitmix64)
+func mix(z uint64) uint64 {
+	z += 0x9e3779b97f4a7c15
+	z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+	z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+	return z ^ (z >> 31)
+}
+
+// key returns the key of the current configuration of the simulation
+func (t *memoTrail) key(f *fsm.FSM, b *BenderSimulator) memoKey {
+	if b.breaks != t.breaks {
+		// the walls are changed by the destructions only
+		changes := f.Changes()
+		for _, c := range changes[t.changes:] {
+			t.toggle(c.At.X, c.At.Y, c.From)
+			t.toggle(c.At.X, c.At.Y, c.To)
+		}
+		t.changes, t.breaks = len(changes), b.breaks
+	}
+	config := b.loopState(f.Position())
+	config.Breaks = 0
+	return memoKey{board: t.board, config: config, hits: b.hits, boom: b.boom, rules: b.breakerRules, remaining: -1}
+}
+
+// visit looks up the current configuration of the simulation, it returns the result completed from the memo if any,
+// otherwise the configuration is memoized with the result every memoInterval steps
+func (t *memoTrail) visit(f *fsm.FSM, b *BenderSimulator, i int) (Result, bool) {
+	k := t.key(f, b)
+	remaining := 0
+	if t.maxSteps > 0 {
+		remaining = t.maxSteps - f.Steps()
+	}
+	history := len(b.configs) > 0
+	if e, exist := t.memo.lookup(k, remaining, history); exist {
+		t.hit = true
+		return e.complete(f, b), true
+	}
+	if i%memoInterval == 0 {
+		k.remaining = remaining
+		t.marks = append(t.marks, memoMark{key: k, entry: memoEntry{steps: f.Steps(), pathLen: len(b.path), history: history}})
+	}
+	return Result{}, false
+}
+
+// terminal returns true if the simulation ended by itself without looping, its steps from any of its configurations
+// are the same whatever the configurations before
+func terminal(o Outcome) bool {
+	return o == Reached || o == Died || o == Escaped
+}
+
+// complete returns the result of the simulation continued from the memoized configuration
+func (e memoEntry) complete(f *fsm.FSM, b *BenderSimulator) Result {
+	r := NewResult(f, b)
+	offset := f.Steps() - e.steps
+	r.Outcome = e.res.Outcome
+	r.Path = append(append([]string{}, r.Path...), e.res.Path[e.pathLen:]...)
+	r.Steps = e.res.Steps + offset
+	r.Position = e.res.Position
+	r.Exceeded = e.res.Exceeded
+	for _, d := range e.res.Destroyed {
+		if d.Step > e.steps {
+			d.Step += offset
+			r.Destroyed = append(r.Destroyed, d)
+		}
+	}
+	if e.res.Escape != nil {
+		escape := *e.res.Escape
+		escape.Step += offset
+		r.Escape = &escape
+	}
+	return r
+}
+
+// done memoizes the configurations of the trail with the result of the simulation
+// the errors and the results depending on the moment of the simulation aren't memoized,
+// the loops and the step limits are only memoized from the configurations without history
+func (t *memoTrail) done(res Result, err error) {
+	t.memo.mu.Lock()
+	defer t.memo.mu.Unlock()
+	if !t.hit {
+		t.memo.misses++
+	}
+	switch {
+	case err != nil, res.Outcome == TimeLimitExceeded, res.Outcome == BudgetExceeded, res.Outcome == Interrupted:
+		return
+	}
+	res = copyResult(res)
+	for _, m := range t.marks {
+		if terminal(res.Outcome) {
+			m.key.remaining = -1
+		} else if m.entry.history {
+			continue
+		}
+		m.entry.res = &res
+		t.memo.results[m.key] = m.entry
+	}
+}
+
+// lookup returns the memoized entry of the configuration for a simulation with the given steps left and history:
+// the simulations ending by themselves fit in the steps left, the others need the same steps left and no history
+func (m *Memo) lookup(k memoKey, remaining int, history bool) (memoEntry, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	e, exist := m.results[k]
+	if exist && remaining > 0 && remaining < e.res.Steps-e.steps {
+		exist = false
+	}
+	if !exist && !history {
+		k.remaining = remaining
+		e, exist = m.results[k]
+	}
+	if exist {
+		m.hits++
+	}
+	return e, exist
+}
+
+// copyResult returns a copy of the result not sharing its slices and its escape
+func copyResult(r Result) Result {
+	r.Path = append([]string{}, r.Path...)
+	r.Destroyed = append([]Destruction{}, r.Destroyed...)
+	if r.Escape != nil {
+		escape := *r.Escape
+		r.Escape = &escape
+	}
+	return r
+}
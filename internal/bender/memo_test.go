@@ -0,0 +1,165 @@
+package bender
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"bender/internal/fsm"
+)
+
+func TestMemo(t *testing.T) {
+	memo := NewMemo()
+	first, err := Run(statePlan, WithMemo(memo))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	first.Path[0] = "UP"
+	second, err := Run(statePlan, WithMemo(memo))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected, _ := Run(statePlan)
+	if !reflect.DeepEqual(second, expected) {
+		t.Fatalf("Wrong memoized result. Expected %+v, got %+v", expected, second)
+	}
+	if s := memo.Stats(); s != (MemoStats{Hits: 1, Misses: 1, Entries: 1}) {
+		t.Fatalf("Wrong statistics: %v", s)
+	}
+
+	// the limit of steps is a part of the configuration
+	if res, _ := Run(statePlan, WithMemo(memo), WithMaxSteps(2)); res.Outcome != StepLimitExceeded {
+		t.Fatalf("Wrong outcome. Expected %v, got %v", StepLimitExceeded, res.Outcome)
+	}
+	// so is the state of the simulation
+	m, err := fsm.NewFSM(statePlan, BeforeCallback, EnterCallback)
+	if err != nil {
+		t.Fatalf("Failed to create the FSM: %v", err)
+	}
+	b := NewBenderSimulator()
+	simulate(t, m, b, 2)
+	if res, _ := Resume(m, b, WithMemo(memo)); !reflect.DeepEqual(res, expected) {
+		t.Fatalf("Wrong resumed result. Expected %+v, got %+v", expected, res)
+	}
+	if s := memo.Stats(); s != (MemoStats{Hits: 1, Misses: 3, Entries: 3}) {
+		t.Fatalf("Wrong statistics: %v", s)
+	}
+
+	// timed out simulations are not memoized
+	Run(loopPlan(300), WithMemo(memo), WithTimeout(time.Nanosecond))
+	if s := memo.Stats(); s.Entries != 3 {
+		t.Fatalf("Timed out simulation was memoized: %v", s)
+	}
+	if s := memo.Stats().String(); s != "hits=1 misses=4 entries=3 hit rate=20.0%" {
+		t.Fatalf("Wrong formatted statistics: %q", s)
+	}
+}
+
+func TestMemoExplore(t *testing.T) {
+	m, err := fsm.NewFSM(statePlan, BeforeCallback, EnterCallback)
+	if err != nil {
+		t.Fatalf("Failed to create the FSM: %v", err)
+	}
+	b := NewBenderSimulator()
+	memo := NewMemo()
+	branches := []Branch{{Name: "first", Options: []Option{WithMemo(memo)}}}
+	Explore(m, b, branches)
+	results := Explore(m, b, branches)
+	if expected, _ := Run(statePlan); !reflect.DeepEqual(results[0].Result, expected) {
+		t.Fatalf("Wrong memoized result. Expected %+v, got %+v", expected, results[0].Result)
+	}
+	if s := memo.Stats(); s.Hits != 1 {
+		t.Fatalf("Identical branch was not memoized: %v", s)
+	}
+}
+
+func TestMemoEscape(t *testing.T) {
+	plan := []string{"####", "#@  ", "####"}
+	memo := NewMemo()
+	first, err := Run(plan, WithMemo(memo))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	first.Escape.Step = 100
+	second, err := Run(plan, WithMemo(memo))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if expected, _ := Run(plan); !reflect.DeepEqual(second, expected) {
+		t.Fatalf("Wrong memoized result. Expected %+v, got %+v", expected, second)
+	}
+}
+
+func TestMemoCustomized(t *testing.T) {
+	memo := NewMemo()
+	if _, err := Run(statePlan, WithMemo(memo)); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	testCases := []struct {
+		name      string
+		customize func(m *fsm.FSM)
+	}{
+		{name: "handler", customize: func(m *fsm.FSM) { m.OnBefore('$', Obstacle) }},
+		{name: "middleware", customize: func(m *fsm.FSM) {
+			m.Use(func(next fsm.Callback) fsm.Callback {
+				return func(e *fsm.Event) {
+					if !e.Entered() && e.Dst == '$' {
+						Obstacle(e)
+						return
+					}
+					next(e)
+				}
+			})
+		}},
+	}
+	for _, tc := range testCases {
+		m, err := fsm.NewFSM(statePlan, BeforeCallback, EnterCallback)
+		if err != nil {
+			t.Fatalf("Failed to create the FSM: %v", err)
+		}
+		tc.customize(m)
+		expected, _ := Resume(m.Clone(), NewBenderSimulator())
+		res, err := Resume(m, NewBenderSimulator(), WithMemo(memo))
+		if err != nil {
+			t.Fatalf("Unexpected error for %q: %v", tc.name, err)
+		}
+		if !reflect.DeepEqual(res, expected) {
+			t.Fatalf("Wrong result for %q. Expected %+v, got %+v", tc.name, expected, res)
+		}
+	}
+	if s := memo.Stats(); s != (MemoStats{Misses: 1, Entries: 1}) {
+		t.Fatalf("Wrong statistics: %v", s)
+	}
+}
+
+func TestMemoRoutes(t *testing.T) {
+	// the second start joins the path of the first one, the steps after are memoized
+	corridor := "#" + strings.Repeat(" ", 21) + "B     X   $#"
+	plan := func(start int) []string {
+		row := []byte(corridor)
+		row[start] = '@'
+		return []string{strings.Repeat("#", len(row)), string(row), strings.Repeat("#", len(row))}
+	}
+	memo := NewMemo()
+	for _, start := range []int{1, 5} {
+		expected, err := Run(plan(start))
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		res, err := Run(plan(start), WithMemo(memo))
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if !reflect.DeepEqual(res, expected) {
+			t.Fatalf("Wrong result from %d. Expected %+v, got %+v", start, expected, res)
+		}
+	}
+	if s := memo.Stats(); s.Hits != 1 || s.Misses != 1 {
+		t.Fatalf("Wrong statistics: %v", s)
+	}
+	// the route doesn't matter but the step limit does
+	if res, _ := Run(plan(5), WithMemo(memo), WithMaxSteps(10)); res.Outcome != StepLimitExceeded {
+		t.Fatalf("Wrong outcome. Expected %v, got %v", StepLimitExceeded, res.Outcome)
+	}
+}
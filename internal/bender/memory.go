@@ -0,0 +1,22 @@
+package bender
+
+import "unsafe"
+
+const (
+	// pathStepSize is the size of a step of the path, the directions are shared constants
+	pathStepSize = int(unsafe.Sizeof(""))
+	// cacheEntrySize is the estimated size of a visited state: its key and the overhead of the map
+	cacheEntrySize = 64
+	// configEntrySize is the estimated size of a configuration of the loop detection
+	configEntrySize = 128
+)
+
+// memoryUsage returns the estimated memory held by the path and the visited states of the simulator
+func memoryUsage(b *BenderSimulator) int {
+	return cap(b.path)*pathStepSize + cacheSize(b)
+}
+
+// cacheSize returns the estimated size of the visited states and configurations of the simulator
+func cacheSize(b *BenderSimulator) int {
+	return len(b.cache)*cacheEntrySize + len(b.configs)*configEntrySize
+}
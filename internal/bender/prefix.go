@@ -0,0 +1,40 @@
+package bender
+
+import (
+	"fmt"
+
+	"bender/internal/fsm"
+)
+
+// FastForward simulates the given moves on the map and returns the checkpoint of the simulation after them,
+// to resume it deep inside a long run without saving checkpoints beforehand
+// the machine of the checkpoint has the callbacks of the rules
+// it returns an error if the simulation takes another direction or ends before the last move
+func FastForward(plan []string, moves []string) (*Checkpoint, error) {
+	f, err := fsm.NewFSM(plan, BeforeCallback, EnterCallback)
+	if err != nil {
+		return nil, err
+	}
+	b := NewBenderSimulator()
+	events := 0
+	count := WithEventHook(func() error {
+		events++
+		return nil
+	})
+	for i, move := range moves {
+		if b.Over() {
+			return nil, fmt.Errorf("the simulation ended after %d moves: %s", i, NewResult(f, b).Outcome)
+		}
+		if _, err := Resume(f, b, WithMaxSteps(f.Steps()+1), count); err != nil {
+			return nil, err
+		}
+		path := b.ShowPath()
+		if len(path) <= i {
+			return nil, fmt.Errorf("the simulation ended after %d moves: %s", i, NewResult(f, b).Outcome)
+		}
+		if path[i] != move {
+			return nil, fmt.Errorf("move %d is %s but the simulation goes %s", i+1, move, path[i])
+		}
+	}
+	return &Checkpoint{Plan: plan, Events: events, FSM: f, Simulator: b}, nil
+}
@@ -0,0 +1,48 @@
+package bender
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFastForward(t *testing.T) {
+	plan := snakePlan(10)
+	expected, err := Run(plan)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	for k := 0; k <= len(expected.Path); k++ {
+		c, err := FastForward(plan, expected.Path[:k])
+		if err != nil {
+			t.Fatalf("Prefix of %d moves: unexpected error: %v", k, err)
+		}
+		if c.FSM.Steps() != k || c.Events < k {
+			t.Fatalf("Prefix of %d moves: wrong checkpoint after %d steps and %d events", k, c.FSM.Steps(), c.Events)
+		}
+		res, err := Resume(c.FSM, c.Simulator)
+		if err != nil {
+			t.Fatalf("Prefix of %d moves: unexpected error: %v", k, err)
+		}
+		if !reflect.DeepEqual(res, expected) {
+			t.Fatalf("Prefix of %d moves: wrong result. Expected %v after %d steps, got %v after %d steps", k, expected.Outcome, len(expected.Path), res.Outcome, len(res.Path))
+		}
+	}
+}
+
+func TestFastForwardErrors(t *testing.T) {
+	plan := []string{"#####", "#@  #", "#  $#", "#####"}
+	tests := []struct {
+		moves    []string
+		expected string
+	}{
+		{moves: []string{"EAST"}, expected: "move 1 is EAST but the simulation goes SOUTH"},
+		{moves: []string{"SOUTH", "EAST", "EAST", "EAST"}, expected: "the simulation ended after 3 moves: reached"},
+	}
+	for _, test := range tests {
+		_, err := FastForward(plan, test.moves)
+		if err == nil || !strings.Contains(err.Error(), test.expected) {
+			t.Fatalf("Wrong error for %v. Expected %q, got %v", test.moves, test.expected, err)
+		}
+	}
+}
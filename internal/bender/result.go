@@ -0,0 +1,202 @@
+package bender
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"bender/internal/fsm"
+)
+
+// Outcome tells how a simulation ended
+type Outcome int
+
+const (
+	// Interrupted simulation was stopped before its end
+	Interrupted Outcome = iota
+	// Reached simulation ended in the suicide booth
+	Reached
+	// Loop simulation would never end
+	Loop
+	// Died simulation ended with Bender unable to move
+	Died
+	// BudgetExceeded simulation was stopped as it used more resources than allowed
+	BudgetExceeded
+	// StepLimitExceeded simulation was stopped as Bender made too many steps
+	StepLimitExceeded
+	// TimeLimitExceeded simulation was stopped as it ran for too long
+	TimeLimitExceeded
+	// Escaped simulation ended with Bender leaving the board, the map has no frame or a hole
+	Escaped
+)
+
+// String returns the name of the outcome
+func (o Outcome) String() string {
+	switch o {
+	case Interrupted:
+		return "interrupted"
+	case Reached:
+		return "reached"
+	case Loop:
+		return "loop"
+	case Died:
+		return "died"
+	case BudgetExceeded:
+		return "budget exceeded"
+	case StepLimitExceeded:
+		return "step limit exceeded"
+	case TimeLimitExceeded:
+		return "time limit exceeded"
+	case Escaped:
+		return "escaped board"
+	}
+	return fmt.Sprintf("outcome(%d)", int(o))
+}
+
+// Destruction is a breakable wall destroyed by Bender in breaker mode
+type Destruction struct {
+	// coordinates of the wall
+	At fsm.Pair
+	// step of the path which entered the wall, starting from 1
+	Step int
+}
+
+// String formats the destruction as (x,y)@step
+func (d Destruction) String() string {
+	return fmt.Sprintf("%s@%d", d.At, d.Step)
+}
+
+// Escape is the step which would have taken Bender out of the board
+type Escape struct {
+	// number of the step, starting from 1
+	Step int
+	// direction of the step
+	Direction fsm.Direction
+	// position of Bender and destination out of the board
+	From, To fsm.Pair
+}
+
+// String formats the escape as step <step> <direction> from (x,y) to (x,y)
+func (e Escape) String() string {
+	return fmt.Sprintf("step %d %s from %s to %s", e.Step, e.Direction, e.From, e.To)
+}
+
+// Result is the result of a simulation
+type Result struct {
+	// how the simulation ended
+	Outcome Outcome
+	// path followed by Bender
+	Path []string
+	// number of steps made by Bender, the length of the path without its BREAK entries
+	Steps int
+	// position of Bender at the end of the simulation
+	Position fsm.Pair
+	// breakable walls destroyed by Bender, in order
+	Destroyed []Destruction
+	// resource whose budget was exceeded if the outcome is BudgetExceeded
+	Exceeded string
+	// step out of the board if the outcome is Escaped
+	Escape *Escape
+}
+
+// NewResult returns the result of the simulation done by the given machine and simulator
+func NewResult(f *fsm.FSM, b *BenderSimulator) Result {
+	o := Interrupted
+	switch {
+	case b.Done():
+		o = Reached
+	case b.Loop():
+		o = Loop
+	case b.Stuck():
+		o = Died
+	}
+	return Result{
+		Outcome:   o,
+		Path:      b.ShowPath(),
+		Steps:     f.Steps(),
+		Position:  f.Position(),
+		Destroyed: destroyedWalls(f),
+	}
+}
+
+// ErrLoop is the error of the simulations where Bender loops forever, returned by Result.Err
+var ErrLoop = errors.New("endless loop")
+
+// Err returns nil if Bender reached the booth, ErrLoop if he loops, an error wrapping fsm.ErrOutOfBounds
+// if he escaped the board or an error with the outcome otherwise
+func (r Result) Err() error {
+	switch r.Outcome {
+	case Reached:
+		return nil
+	case Loop:
+		return ErrLoop
+	case Escaped:
+		if r.Escape != nil {
+			return fmt.Errorf("%w: %v", fsm.ErrOutOfBounds, *r.Escape)
+		}
+		return fsm.ErrOutOfBounds
+	}
+	return fmt.Errorf("simulation ended: %v", r.Outcome)
+}
+
+// ClassicPath returns the path in the format of the puzzle:
+// the directions or a single LOOP if Bender loops
+func (r Result) ClassicPath() []string {
+	if r.Outcome == Loop {
+		return []string{LOOP}
+	}
+	return r.Path
+}
+
+// Visited returns the positions entered by the steps of the result on the given map, row by row,
+// like BenderSimulator.Visited for the results completed from a memo which didn't advance the simulator
+func (r Result) Visited(plan []string) []fsm.Pair {
+	plan = fsm.DecodePlan(plan)
+	paired := map[fsm.Pair]fsm.Pair{}
+	for _, p := range fsm.TeleportPairs(fsm.NewBoard(plan)) {
+		paired[p[0]], paired[p[1]] = p[1], p[0]
+	}
+	var at fsm.Pair
+	for y, row := range plan {
+		if x := strings.IndexByte(row, '@'); x >= 0 {
+			at = fsm.Pair{X: x, Y: y}
+			break
+		}
+	}
+	seen := map[fsm.Pair]bool{}
+	visited := []fsm.Pair{}
+	for _, step := range r.Path {
+		d := fsm.Direction(step)
+		if !d.Valid() {
+			// a BREAK entry
+			continue
+		}
+		at = at.Add(d.Delta())
+		if !seen[at] {
+			seen[at] = true
+			visited = append(visited, at)
+		}
+		if pair, ok := paired[at]; ok {
+			at = pair
+		}
+	}
+	sort.Slice(visited, func(i, j int) bool {
+		if visited[i].Y != visited[j].Y {
+			return visited[i].Y < visited[j].Y
+		}
+		return visited[i].X < visited[j].X
+	})
+	return visited
+}
+
+// destroyedWalls returns the breakable walls destroyed on the board of the machine
+func destroyedWalls(f *fsm.FSM) []Destruction {
+	d := []Destruction{}
+	for _, c := range f.Changes() {
+		if c.From == 'X' {
+			d = append(d, Destruction{At: c.At, Step: c.Step})
+		}
+	}
+	return d
+}
@@ -0,0 +1,163 @@
+package bender
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"bender/internal/fsm"
+)
+
+func TestNewResult(t *testing.T) {
+	m, err := fsm.NewFSM(statePlan, BeforeCallback, EnterCallback)
+	if err != nil {
+		t.Fatalf("Failed to create the FSM: %v", err)
+	}
+	bender := NewBenderSimulator()
+	simulate(t, m, bender, -1)
+
+	res := NewResult(m, bender)
+	expected := Result{
+		Outcome:  Reached,
+		Path:     []string{"SOUTH", "SOUTH", "SOUTH", "SOUTH", "WEST"},
+		Steps:    5,
+		Position: fsm.Pair{X: 1, Y: 5},
+		Destroyed: []Destruction{
+			{At: fsm.Pair{X: 2, Y: 3}, Step: 2},
+			{At: fsm.Pair{X: 2, Y: 5}, Step: 4},
+		},
+	}
+	if !reflect.DeepEqual(res, expected) {
+		t.Fatalf("Wrong result. Expected %+v, got %+v", expected, res)
+	}
+}
+
+func TestOutcome(t *testing.T) {
+	testCases := []struct {
+		name     string
+		plan     []string
+		events   int
+		outcome  Outcome
+		expected []string
+	}{
+		{
+			name: "reached",
+			plan: []string{
+				"#####",
+				"#@ $#",
+				"#####",
+			},
+			events:   -1,
+			outcome:  Reached,
+			expected: []string{"EAST", "EAST"},
+		},
+		{
+			name: "loop",
+			plan: []string{
+				"#####",
+				"#@EW#",
+				"#####",
+			},
+			events:   -1,
+			outcome:  Loop,
+			expected: []string{LOOP},
+		},
+		{
+			name: "died",
+			plan: []string{
+				"###",
+				"#@#",
+				"###",
+			},
+			events:   -1,
+			outcome:  Died,
+			expected: []string{},
+		},
+		{
+			name: "interrupted",
+			plan: []string{
+				"#####",
+				"#@ $#",
+				"#####",
+			},
+			events:   2,
+			outcome:  Interrupted,
+			expected: []string{"EAST"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			m, err := fsm.NewFSM(tc.plan, BeforeCallback, EnterCallback)
+			if err != nil {
+				t.Fatalf("Test case %q: failed to create the FSM: %v", tc.name, err)
+			}
+			bender := NewBenderSimulator()
+			simulate(t, m, bender, tc.events)
+
+			res := NewResult(m, bender)
+			if res.Outcome != tc.outcome {
+				t.Fatalf("Test case %q: wrong outcome. Expected %v, got %v", tc.name, tc.outcome, res.Outcome)
+			}
+			if !reflect.DeepEqual(res.ClassicPath(), tc.expected) {
+				t.Fatalf("Test case %q: wrong classic path. Expected %v, got %v", tc.name, tc.expected, res.ClassicPath())
+			}
+		})
+	}
+}
+
+func TestResultErr(t *testing.T) {
+	testCases := []struct {
+		result   Result
+		sentinel error
+		expected string
+	}{
+		{result: Result{Outcome: Reached}},
+		{result: Result{Outcome: Loop}, sentinel: ErrLoop, expected: "endless loop"},
+		{result: Result{Outcome: Escaped, Escape: &Escape{Step: 2, Direction: fsm.EAST, From: fsm.Pair{X: 2, Y: 1}, To: fsm.Pair{X: 3, Y: 1}}}, sentinel: fsm.ErrOutOfBounds, expected: "out of the board: step 2 EAST from (2,1) to (3,1)"},
+		{result: Result{Outcome: Died}, expected: "simulation ended: died"},
+	}
+	for _, tc := range testCases {
+		err := tc.result.Err()
+		if tc.expected == "" {
+			if err != nil {
+				t.Fatalf("Unexpected error for %v: %v", tc.result.Outcome, err)
+			}
+			continue
+		}
+		if err == nil || err.Error() != tc.expected {
+			t.Fatalf("Wrong error for %v. Expected %q, got %v", tc.result.Outcome, tc.expected, err)
+		}
+		if tc.sentinel != nil && !errors.Is(err, tc.sentinel) {
+			t.Fatalf("Wrong error for %v. Expected %v to wrap %v", tc.result.Outcome, err, tc.sentinel)
+		}
+	}
+}
+
+func TestResultVisited(t *testing.T) {
+	testCases := []struct {
+		name  string
+		plan  []string
+		rules BreakerRules
+	}{
+		{name: "breaker", plan: statePlan},
+		{name: "recorded breaks", plan: statePlan, rules: BreakerRules{RecordBreaks: true}},
+		{name: "loop", plan: loopPlan(8)},
+		{name: "snake", plan: snakePlan(20)},
+		{name: "teleports", plan: []string{"#######", "#@1  1#", "#2  2$#", "#######"}},
+	}
+	for _, tc := range testCases {
+		m, err := fsm.NewFSM(tc.plan, BeforeCallback, EnterCallback)
+		if err != nil {
+			t.Fatalf("Failed to create the FSM for %q: %v", tc.name, err)
+		}
+		b := NewBenderSimulator()
+		res, err := Resume(m, b, WithBreakerRules(tc.rules))
+		if err != nil {
+			t.Fatalf("Unexpected error for %q: %v", tc.name, err)
+		}
+		if visited := res.Visited(tc.plan); !reflect.DeepEqual(visited, b.Visited()) {
+			t.Fatalf("Wrong visited positions for %q. Expected %v, got %v", tc.name, b.Visited(), visited)
+		}
+	}
+}
@@ -0,0 +1,162 @@
+package bender
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math/rand"
+	"path/filepath"
+	"testing"
+
+	"bender/internal/fsm"
+)
+
+// noPanic fails the test if the given function panics
+func noPanic(t *testing.T, name string, fn func()) {
+	t.Helper()
+	defer func() {
+		if r := recover(); r != nil {
+			t.Fatalf("%s panicked: %v", name, r)
+		}
+	}()
+	fn()
+}
+
+// randomPlan returns a map of random size with random tiles, rows may be ragged or empty
+func randomPlan(rnd *rand.Rand) []string {
+	tiles := "  ##X@$SNEWIBT?\x00\xff"
+	plan := make([]string, rnd.Intn(7))
+	for i := range plan {
+		row := make([]byte, rnd.Intn(9))
+		for j := range row {
+			row[j] = tiles[rnd.Intn(len(tiles))]
+		}
+		plan[i] = string(row)
+	}
+	return plan
+}
+
+func TestRunAdversarialMaps(t *testing.T) {
+	plans := [][]string{
+		nil,
+		{},
+		{""},
+		{"@"},
+		{"#", "@", "#"},
+		{"###", "#@", "#"},
+		{"#####", "#@T #", "#####"},
+		{"#####", "#@TT#", "#T  #", "#####"},
+	}
+	rnd := rand.New(rand.NewSource(42))
+	for i := 0; i < 5000; i++ {
+		plans = append(plans, randomPlan(rnd))
+	}
+
+	for _, plan := range plans {
+		noPanic(t, fmt.Sprintf("Run(%q)", plan), func() {
+			Run(plan, WithMaxSteps(100))
+		})
+	}
+}
+
+func TestZeroValues(t *testing.T) {
+	noPanic(t, "zero FSM", func() {
+		f := &fsm.FSM{}
+		if err := f.Event(fsm.SOUTH, NewBenderSimulator()); err == nil {
+			t.Errorf("Event on a machine without board succeeded")
+		}
+		if _, err := f.TeleportDst(fsm.Pair{}); err == nil {
+			t.Errorf("Teleport without teleports succeeded")
+		}
+		f.Board()
+		f.Snapshot()
+	})
+	noPanic(t, "zero simulator", func() {
+		b := &BenderSimulator{}
+		if d := b.Direction(); d != "" {
+			t.Errorf("Zero simulator has a direction %q", d)
+		}
+		b.Remember(fsm.SOUTH, fsm.StateID{Tile: ' ', At: fsm.Pair{X: 1, Y: 1}})
+		b.Boom()
+		b.NextDirection()
+		b.InvertPriorities()
+		b.Boom()
+		b.ShowPath()
+	})
+	noPanic(t, "zero resume", func() {
+		if _, err := Resume(&fsm.FSM{}, &BenderSimulator{}); err == nil {
+			t.Errorf("Resume of zero values succeeded")
+		}
+		if _, err := Resume(nil, nil); err == nil {
+			t.Errorf("Resume of nothing succeeded")
+		}
+	})
+	noPanic(t, "event without simulator", func() {
+		f, err := fsm.NewFSM(statePlan, BeforeCallback, EnterCallback)
+		if err != nil {
+			t.Fatalf("Failed to create the FSM: %v", err)
+		}
+		if err := f.Event(fsm.SOUTH); err == nil {
+			t.Errorf("Event without simulator succeeded")
+		}
+		if err := f.Event(fsm.SOUTH, "bender"); err == nil {
+			t.Errorf("Event with a wrong argument succeeded")
+		}
+		for _, evt := range []fsm.Direction{"SOTUH", "S", ""} {
+			if err := f.Event(evt, NewBenderSimulator()); !errors.Is(err, fsm.ErrUnknownDirection) {
+				t.Errorf("Wrong error of the unknown event %q. Expected %v, got %v", evt, fsm.ErrUnknownDirection, err)
+			}
+		}
+	})
+	noPanic(t, "zero parse error", func() {
+		(&fsm.ParseError{}).Excerpt()
+	})
+}
+
+func TestCorruptedStates(t *testing.T) {
+	states := []string{
+		`{}`,
+		`{"states":[],"curr":[0,0]}`,
+		`{"states":["###","#T#","###"],"curr":[1,0],"teleports":[[1,1]]}`,
+		`{"states":["#####","#@T #","#####"],"curr":[1,1],"teleports":[[2,1]]}`,
+		`{"states":["###","# #","###"],"curr":[-5,99]}`,
+	}
+	simulators := []string{
+		`{}`,
+		`{"priorities":["SOTUH"]}`,
+		`{"priorities":["SOUTH"],"currDir":7}`,
+		`{"priorities":[],"currDir":-1,"pathModifier":"UP"}`,
+		`{"priorities":["SOUTH","EAST","NORTH","WEST"],"maxNumStates":-3}`,
+	}
+	for _, fs := range states {
+		for _, bs := range simulators {
+			noPanic(t, fmt.Sprintf("Resume(%s, %s)", fs, bs), func() {
+				f, b := &fsm.FSM{}, &BenderSimulator{}
+				if err := json.Unmarshal([]byte(fs), f); err != nil {
+					t.Fatalf("Failed to decode the FSM: %v", err)
+				}
+				if err := json.Unmarshal([]byte(bs), b); err != nil {
+					t.Fatalf("Failed to decode the simulator: %v", err)
+				}
+				f.SetCallbacks(BeforeCallback, EnterCallback)
+				Resume(f, b, WithMaxSteps(100))
+				f.DumpState()
+				b.DumpState()
+			})
+		}
+	}
+
+	dir := t.TempDir()
+	for i, c := range []string{`null`, `{}`, `{"fsm":null,"simulator":{}}`, `[`} {
+		file := filepath.Join(dir, fmt.Sprintf("ckpt%d.json", i))
+		if err := ioutil.WriteFile(file, []byte(c), 0644); err != nil {
+			t.Fatalf("Failed to write the checkpoint: %v", err)
+		}
+		noPanic(t, fmt.Sprintf("LoadCheckpoint(%s)", c), func() {
+			if _, err := LoadCheckpoint(file); err == nil {
+				t.Errorf("Corrupted checkpoint %s was loaded", c)
+			}
+		})
+	}
+}
@@ -0,0 +1,202 @@
+package bender
+
+import (
+	"fmt"
+
+	"bender/internal/fsm"
+)
+
+// beforeRules are the before callbacks of the obstacles, the other tiles are entered
+var beforeRules = [256]fsm.Callback{
+	'#': Obstacle,
+	'X': BreakableObstacle,
+}
+
+// TileRule is the effect of an entered tile on Bender, EnterRule turns it into an enter callback
+// the event can be aborted, the step is then not remembered
+type TileRule func(b *BenderSimulator, e *fsm.Event)
+
+// enterRules are the effects of the tiles, a lookup replaces the comparisons with every tile
+// the tiles without effect are open
+var enterRules = [256]TileRule{
+	'B': toggleBreaker,
+	'S': followModifier,
+	'N': followModifier,
+	'E': followModifier,
+	'W': followModifier,
+	'I': invertPriorities,
+	'T': teleport,
+	'1': teleport,
+	'2': teleport,
+	'3': teleport,
+	'4': teleport,
+	'5': teleport,
+	'6': teleport,
+	'7': teleport,
+	'8': teleport,
+	'9': teleport,
+	'$': reachBooth,
+}
+
+// modifierDirections maps the modifier tiles to their direction
+var modifierDirections = [256]fsm.Direction{
+	'S': fsm.SOUTH,
+	'N': fsm.NORTH,
+	'E': fsm.EAST,
+	'W': fsm.WEST,
+}
+
+// BreakerRules are the variants of the breaker mode, the zero value is the rules of the puzzle:
+// the breaker tiles toggle the mode, the path only holds directions and the mode survives the teleports
+type BreakerRules struct {
+	// the breaker tiles only enable the breaker mode, it's never disabled
+	EnableOnly bool `json:"enableOnly,omitempty"`
+	// a BREAK entry is recorded in the path before the direction of every step destroying a wall,
+	// the path is no longer a list of directions
+	RecordBreaks bool `json:"recordBreaks,omitempty"`
+	// the teleports disable the breaker mode
+	TeleportEnds bool `json:"teleportEnds,omitempty"`
+}
+
+// BeforeCallback handles only obstacles
+// we cancel the event before entering it
+// the machines can replace the rule of a tile with OnBefore
+func BeforeCallback(e *fsm.Event) {
+	if simulatorArg(e) == nil {
+		return
+	}
+	if r := beforeRules[e.Dst]; r != nil {
+		r(e)
+	}
+}
+
+// EnterCallback handles all non obstacle states
+// the machines can replace the rule of a tile with OnEnter and EnterRule
+func EnterCallback(e *fsm.Event) {
+	enter(e, enterRules[e.Dst])
+}
+
+// Obstacle is the before callback of the walls: Bender is blocked and looks for another direction
+// register it with OnBefore to add a kind of wall
+func Obstacle(e *fsm.Event) {
+	bender := simulatorArg(e)
+	if bender == nil {
+		return
+	}
+	bender.Boom()
+	bender.NextDirection()
+	e.Cancel()
+}
+
+// BreakableObstacle is the before callback of the obstacles destroyed in breaker mode
+func BreakableObstacle(e *fsm.Event) {
+	bender := simulatorArg(e)
+	if bender == nil {
+		return
+	}
+	if !bender.Breaker() {
+		Obstacle(e)
+		return
+	}
+	// destroy the obstacle
+	e.ChangeDst(' ')
+	bender.destroyed = true
+}
+
+// EnterRule returns the enter callback running the given rule once Bender entered the tile,
+// to register with OnEnter to add a kind of tile, nil is an open tile
+func EnterRule(r TileRule) fsm.Callback {
+	return func(e *fsm.Event) {
+		enter(e, r)
+	}
+}
+
+// enter updates Bender entering the destination of the event with the rule of the tile
+func enter(e *fsm.Event, r TileRule) {
+	bender := simulatorArg(e)
+	if bender == nil {
+		return
+	}
+
+	switch {
+	case bender.Hurts():
+		bender.choice = RulePriorityFallback
+	case bender.pathModifier != "":
+		bender.choice = RulePathModifier
+	default:
+		bender.choice = RuleForward
+	}
+	bender.effect = ""
+	if bender.destroyed {
+		bender.effect = RuleBreakerDestruction
+		bender.destroyed = false
+		bender.breaks++
+	}
+	if bender.Hurts() {
+		// managed to enter the state: obstacle is behind
+		bender.BackOnTrack()
+	}
+
+	if r != nil {
+		if r(bender, e); e.Cancelled {
+			return
+		}
+	}
+	if bender.effect == RuleBreakerDestruction && bender.breakerRules.RecordBreaks {
+		bender.path = append(bender.path, BREAK)
+	}
+	bender.Remember(e.Event, e.DstID())
+}
+
+// toggleBreaker is the rule of the breaker tiles
+func toggleBreaker(bender *BenderSimulator, e *fsm.Event) {
+	if bender.breakerRules.EnableOnly {
+		bender.breaker = true
+	} else {
+		bender.InvertBreaker()
+	}
+	bender.effect = RuleBreakerToggle
+}
+
+// followModifier is the rule of the path modifiers
+func followModifier(bender *BenderSimulator, e *fsm.Event) {
+	bender.PathModifier(modifierDirections[e.Dst])
+}
+
+// invertPriorities is the rule of the inverters
+func invertPriorities(bender *BenderSimulator, e *fsm.Event) {
+	bender.InvertPriorities()
+	bender.effect = RuleInversion
+}
+
+// teleport is the rule of the teleports, Bender moves to the other one of the same label
+func teleport(bender *BenderSimulator, e *fsm.Event) {
+	dst, err := e.TeleportDst()
+	if err != nil {
+		e.Abort(err)
+		return
+	}
+	e.SetState(dst)
+	if bender.breakerRules.TeleportEnds {
+		bender.breaker = false
+	}
+	bender.effect = RuleTeleport
+}
+
+// reachBooth is the rule of the suicide booth
+func reachBooth(bender *BenderSimulator, e *fsm.Event) {
+	bender.Reached()
+	bender.effect = RuleBooth
+}
+
+// simulatorArg returns the simulator passed as the first argument of the event
+// the event is aborted if there is no simulator
+func simulatorArg(e *fsm.Event) *BenderSimulator {
+	if len(e.Args) > 0 {
+		if bender, ok := e.Args[0].(*BenderSimulator); ok && bender != nil {
+			return bender
+		}
+	}
+	e.Abort(fmt.Errorf("no simulator given to the event"))
+	return nil
+}
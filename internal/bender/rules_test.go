@@ -0,0 +1,244 @@
+package bender
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"bender/internal/fsm"
+)
+
+// rows returns the rows of the given board
+func rows(b fsm.Board) []string {
+	rs := make([]string, b.Height())
+	for y := range rs {
+		row := make([]byte, b.Width())
+		for x := range row {
+			row[x] = b.At(x, y)
+		}
+		rs[y] = string(row)
+	}
+	return rs
+}
+
+func TestSharedBoard(t *testing.T) {
+	plan := append([]string{}, statePlan...)
+	board := fsm.NewBoard(plan)
+
+	var snapshot fsm.Board
+	paths := [][]string{}
+	for i := 0; i < 2; i++ {
+		m, err := fsm.NewFSMFromBoard(board, BeforeCallback, EnterCallback)
+		if err != nil {
+			t.Fatalf("Run #%d: failed to create the FSM: %v", i, err)
+		}
+		bender := NewBenderSimulator()
+		// break the first wall
+		simulate(t, m, bender, 2)
+		if i == 0 {
+			snapshot = m.Snapshot()
+		}
+		simulate(t, m, bender, -1)
+		if !bender.Done() {
+			t.Fatalf("Run #%d: booth not reached: %v", i, bender.ShowPath())
+		}
+		if c := m.Board().At(2, 5); c != ' ' {
+			t.Fatalf("Run #%d: second wall not destroyed, got %q", i, c)
+		}
+		paths = append(paths, bender.ShowPath())
+	}
+
+	if !reflect.DeepEqual(paths[0], paths[1]) {
+		t.Fatalf("Runs on the same board differ: %v and %v", paths[0], paths[1])
+	}
+	if !reflect.DeepEqual(rows(board), statePlan) {
+		t.Fatalf("Shared board was modified: %q", rows(board))
+	}
+	if !reflect.DeepEqual(plan, statePlan) {
+		t.Fatalf("Plan was modified: %q", plan)
+	}
+	if snapshot.At(2, 3) != ' ' || snapshot.At(2, 5) != 'X' {
+		t.Fatalf("Snapshot was modified: %q", rows(snapshot))
+	}
+}
+
+func TestBreakerRules(t *testing.T) {
+	// Bender goes through two breakers before a breakable wall
+	breakers := []string{
+		"#######",
+		"#@BBX$#",
+		"#######",
+	}
+	// Bender is teleported in breaker mode in front of a breakable wall
+	teleport := []string{
+		"#########",
+		"#@BT#TX$#",
+		"#########",
+	}
+	testCases := []struct {
+		name     string
+		plan     []string
+		rules    BreakerRules
+		expected Result
+	}{
+		{
+			name:  "toggle",
+			plan:  breakers,
+			rules: BreakerRules{},
+			expected: Result{
+				Outcome:   Reached,
+				Path:      []string{"EAST", "EAST", "WEST", "WEST", "EAST", "EAST", "EAST", "EAST"},
+				Steps:     8,
+				Position:  fsm.Pair{X: 5, Y: 1},
+				Destroyed: []Destruction{{At: fsm.Pair{X: 4, Y: 1}, Step: 7}},
+			},
+		},
+		{
+			name:  "enable only",
+			plan:  breakers,
+			rules: BreakerRules{EnableOnly: true},
+			expected: Result{
+				Outcome:   Reached,
+				Path:      []string{"EAST", "EAST", "EAST", "EAST"},
+				Steps:     4,
+				Position:  fsm.Pair{X: 5, Y: 1},
+				Destroyed: []Destruction{{At: fsm.Pair{X: 4, Y: 1}, Step: 3}},
+			},
+		},
+		{
+			name:  "record breaks",
+			plan:  breakers,
+			rules: BreakerRules{EnableOnly: true, RecordBreaks: true},
+			expected: Result{
+				Outcome:   Reached,
+				Path:      []string{"EAST", "EAST", BREAK, "EAST", "EAST"},
+				Steps:     4,
+				Position:  fsm.Pair{X: 5, Y: 1},
+				Destroyed: []Destruction{{At: fsm.Pair{X: 4, Y: 1}, Step: 3}},
+			},
+		},
+		{
+			name:  "survives teleports",
+			plan:  teleport,
+			rules: BreakerRules{},
+			expected: Result{
+				Outcome:   Reached,
+				Path:      []string{"EAST", "EAST", "EAST", "EAST"},
+				Steps:     4,
+				Position:  fsm.Pair{X: 7, Y: 1},
+				Destroyed: []Destruction{{At: fsm.Pair{X: 6, Y: 1}, Step: 3}},
+			},
+		},
+		{
+			name:  "teleport ends",
+			plan:  teleport,
+			rules: BreakerRules{TeleportEnds: true},
+			expected: Result{
+				Outcome:   Died,
+				Path:      []string{"EAST", "EAST"},
+				Steps:     2,
+				Position:  fsm.Pair{X: 5, Y: 1},
+				Destroyed: []Destruction{},
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			res, err := Run(tc.plan, WithBreakerRules(tc.rules), WithInvariants(Invariants...))
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(res, tc.expected) {
+				t.Fatalf("Wrong result. Expected %+v, got %+v", tc.expected, res)
+			}
+			if tc.rules != (BreakerRules{}) {
+				return
+			}
+			// the rules of the puzzle are the default ones
+			if res, err = Run(tc.plan); err != nil || !reflect.DeepEqual(res, tc.expected) {
+				t.Fatalf("Wrong default result. Expected %+v, got %+v: %v", tc.expected, res, err)
+			}
+		})
+	}
+
+	// the rules are kept by the state of the simulator
+	b := NewBenderSimulator()
+	b.SetBreakerRules(BreakerRules{TeleportEnds: true})
+	data, err := json.Marshal(b)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	restored := &BenderSimulator{}
+	if err := json.Unmarshal(data, restored); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if restored.BreakerRules() != b.BreakerRules() {
+		t.Fatalf("Wrong restored rules. Expected %+v, got %+v", b.BreakerRules(), restored.BreakerRules())
+	}
+}
+
+func TestTileRules(t *testing.T) {
+	testCases := []struct {
+		name     string
+		plan     []string
+		tiles    []byte
+		register func(f *fsm.FSM)
+		outcome  Outcome
+		steps    int
+	}{
+		{
+			name:    "new tile without rule is open",
+			plan:    []string{"#####", "#@Z$#", "#####"},
+			tiles:   []byte{'Z'},
+			outcome: Reached,
+			steps:   2,
+		},
+		{
+			name:  "new wall",
+			plan:  []string{"#####", "#   #", "#@Z$#", "#####"},
+			tiles: []byte{'Z'},
+			register: func(f *fsm.FSM) {
+				f.OnBefore('Z', Obstacle)
+			},
+			outcome: Loop,
+			steps:   3,
+		},
+		{
+			name:  "new breaker",
+			plan:  []string{"######", "#@KX$#", "######"},
+			tiles: []byte{'K'},
+			register: func(f *fsm.FSM) {
+				f.OnEnter('K', EnterRule(func(b *BenderSimulator, e *fsm.Event) {
+					b.InvertBreaker()
+				}))
+			},
+			outcome: Reached,
+			steps:   3,
+		},
+		{
+			name: "booth replaced",
+			plan: []string{"######", "#@ $ #", "######"},
+			register: func(f *fsm.FSM) {
+				f.OnEnter('$', EnterRule(nil))
+			},
+			outcome: Loop,
+			steps:   7,
+		},
+	}
+	for _, tc := range testCases {
+		f, err := fsm.NewFSM(tc.plan, BeforeCallback, EnterCallback, tc.tiles...)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tc.name, err)
+		}
+		if tc.register != nil {
+			tc.register(f)
+		}
+		res, err := Resume(f, NewBenderSimulator())
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tc.name, err)
+		}
+		if res.Outcome != tc.outcome || res.Steps != tc.steps {
+			t.Fatalf("%s: wrong result. Expected %v in %d steps, got %v in %d steps", tc.name, tc.outcome, tc.steps, res.Outcome, res.Steps)
+		}
+	}
+}
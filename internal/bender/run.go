@@ -0,0 +1,309 @@
+package bender
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime"
+	"time"
+
+	"bender/internal/fsm"
+)
+
+// timeCheckInterval is the number of events between two checks of the clock, the context and the budget
+const timeCheckInterval = 1024
+
+// runConfig is the configuration of a simulation run
+type runConfig struct {
+	maxSteps  int
+	timeout   time.Duration
+	eventHook func() error
+	memo      *Memo
+	ctx       context.Context
+	budget    Budget
+	// interval between two statistics reports
+	statsInterval time.Duration
+	stats         func(Stats)
+	director      func(f *fsm.FSM, b *BenderSimulator) (fsm.Direction, error)
+	breakerRules  *BreakerRules
+	invariants    []Invariant
+}
+
+// Budget bounds the resources used by a simulation, the zero values disable the bounds
+type Budget struct {
+	// CPU time used by the simulation, it's the elapsed time on the platforms without thread CPU time
+	CPUTime time.Duration
+	// estimated memory held by the path and the visited states of the simulation, in bytes
+	Memory int
+	// steps made by Bender
+	Steps int
+}
+
+// resources of a budget
+const (
+	ResourceCPUTime = "cpu time"
+	ResourceMemory  = "memory"
+	ResourceSteps   = "steps"
+)
+
+// Stats are the statistics of a running simulation
+type Stats struct {
+	// time since the start of the run
+	Elapsed time.Duration
+	// steps made by Bender since the start of the simulation
+	Steps int
+	// steps made per second since the previous report
+	StepsPerSecond float64
+	// unique states visited by Bender
+	States int
+	// estimated size of the visited states and configurations, in bytes
+	CacheSize int
+	// memory allocated on the heap of the process, in bytes
+	HeapAlloc uint64
+}
+
+// String returns the statistics on a single line
+func (s Stats) String() string {
+	return fmt.Sprintf("elapsed=%v steps=%d steps/s=%.0f states=%d cache=%dB heap=%dB",
+		s.Elapsed.Round(time.Millisecond), s.Steps, s.StepsPerSecond, s.States, s.CacheSize, s.HeapAlloc)
+}
+
+// Option configures a simulation run
+type Option func(*runConfig)
+
+// WithMaxSteps stops the simulation once Bender made the given number of steps
+// the outcome is StepLimitExceeded, zero means no limit
+func WithMaxSteps(n int) Option {
+	return func(c *runConfig) {
+		c.maxSteps = n
+	}
+}
+
+// WithTimeout stops the simulation once it ran for the given duration
+// the outcome is TimeLimitExceeded, zero means no limit
+func WithTimeout(d time.Duration) Option {
+	return func(c *runConfig) {
+		c.timeout = d
+	}
+}
+
+// WithEventHook calls the given function after every event sent to the machine
+// the simulation is aborted with the error returned by the hook
+func WithEventHook(hook func() error) Option {
+	return func(c *runConfig) {
+		c.eventHook = hook
+	}
+}
+
+// WithContext stops the simulation once the context is done
+// the outcome is Interrupted
+func WithContext(ctx context.Context) Option {
+	return func(c *runConfig) {
+		c.ctx = ctx
+	}
+}
+
+// WithBudget stops the simulation once it used more resources than the budget
+// the outcome is BudgetExceeded and the result tells the exceeded resource
+func WithBudget(b Budget) Option {
+	return func(c *runConfig) {
+		c.budget = b
+	}
+}
+
+// WithStats reports the statistics of the simulation to the given function periodically
+// the function is called by the goroutine of the simulation which waits for it
+func WithStats(interval time.Duration, report func(Stats)) Option {
+	return func(c *runConfig) {
+		c.statsInterval = interval
+		c.stats = report
+	}
+}
+
+// WithDirector asks the direction of every step to the given function instead of the priorities of Bender,
+// the rules of the tiles still apply: the obstacles cancel the step, and Bender is stuck after hitting them 5 times in a row
+// the simulation is aborted with the error returned by the director, the results aren't memoized
+func WithDirector(direct func(f *fsm.FSM, b *BenderSimulator) (fsm.Direction, error)) Option {
+	return func(c *runConfig) {
+		c.director = direct
+	}
+}
+
+// WithBreakerRules makes the simulator follow the given variants of the breaker mode instead of its own,
+// the rules of the puzzle by default
+func WithBreakerRules(r BreakerRules) Option {
+	return func(c *runConfig) {
+		c.breakerRules = &r
+	}
+}
+
+// Run simulates Bender on the given map
+func Run(plan []string, opts ...Option) (Result, error) {
+	f, err := fsm.NewFSM(plan, BeforeCallback, EnterCallback)
+	if err != nil {
+		return Result{}, err
+	}
+	return Resume(f, NewBenderSimulator(), opts...)
+}
+
+// RunContext simulates Bender on the given map until the context is done, the outcome is Interrupted then
+// the context takes precedence over a WithContext option
+func RunContext(ctx context.Context, plan []string, opts ...Option) (Result, error) {
+	return Run(plan, append(opts[:len(opts):len(opts)], WithContext(ctx))...)
+}
+
+// Resume continues the simulation done by the given machine and simulator
+// the result is partial if a limit is exceeded
+func Resume(f *fsm.FSM, b *BenderSimulator, opts ...Option) (Result, error) {
+	if f == nil || b == nil {
+		return Result{}, fmt.Errorf("nothing to resume")
+	}
+	c := &runConfig{}
+	for _, o := range opts {
+		o(c)
+	}
+
+	if c.breakerRules != nil {
+		b.SetBreakerRules(*c.breakerRules)
+	}
+	if c.director != nil {
+		// the directions depend on the director
+		c.memo = nil
+	}
+	if len(c.invariants) > 0 {
+		// a memoized result wouldn't be checked
+		c.memo = nil
+	}
+	if f.Customized() {
+		// the handlers and the middlewares aren't a part of the configuration
+		c.memo = nil
+	}
+	var trail *memoTrail
+	if c.memo != nil {
+		trail = c.memo.trail(f, b, c.maxSteps)
+	}
+	res, err := resume(f, b, c, trail)
+	if trail != nil {
+		trail.done(res, err)
+	}
+	return res, err
+}
+
+// resume runs the simulation with the given configuration
+// the simulation is completed from the memo of the trail once it reaches a memoized configuration
+func resume(f *fsm.FSM, b *BenderSimulator, c *runConfig, trail *memoTrail) (Result, error) {
+	var deadline time.Time
+	if c.timeout > 0 {
+		deadline = time.Now().Add(c.timeout)
+	}
+	var cpuStart time.Duration
+	if c.budget.CPUTime > 0 {
+		// the CPU time of the thread is the one of the simulation
+		runtime.LockOSThread()
+		defer runtime.UnlockOSThread()
+		cpuStart = threadCPUTime()
+	}
+
+	if c.ctx != nil && c.ctx.Err() != nil {
+		// don't start a simulation already cancelled
+		return limitResult(f, b, Interrupted), nil
+	}
+	start := time.Now()
+	statsSteps, statsTime := f.Steps(), start
+
+	// the arguments are shared by all the events to avoid an allocation per step
+	args := []interface{}{b}
+	for i := 1; !b.Over(); i++ {
+		if c.maxSteps > 0 && f.Steps() >= c.maxSteps {
+			return limitResult(f, b, StepLimitExceeded), nil
+		}
+		if c.budget.Steps > 0 && f.Steps() >= c.budget.Steps {
+			return budgetResult(f, b, ResourceSteps), nil
+		}
+		if trail != nil {
+			if res, hit := trail.visit(f, b, i-1); hit {
+				return res, nil
+			}
+		}
+		if i%timeCheckInterval == 0 {
+			if c.timeout > 0 && time.Now().After(deadline) {
+				return limitResult(f, b, TimeLimitExceeded), nil
+			}
+			if c.ctx != nil && c.ctx.Err() != nil {
+				return limitResult(f, b, Interrupted), nil
+			}
+			if c.budget.CPUTime > 0 && threadCPUTime()-cpuStart > c.budget.CPUTime {
+				return budgetResult(f, b, ResourceCPUTime), nil
+			}
+			if c.budget.Memory > 0 && memoryUsage(b) > c.budget.Memory {
+				return budgetResult(f, b, ResourceMemory), nil
+			}
+			if c.stats != nil && c.statsInterval > 0 {
+				if now := time.Now(); now.Sub(statsTime) >= c.statsInterval {
+					c.stats(newStats(f, b, now.Sub(start), f.Steps()-statsSteps, now.Sub(statsTime)))
+					statsSteps, statsTime = f.Steps(), now
+				}
+			}
+		}
+		dir := b.Direction()
+		if c.director != nil {
+			var err error
+			if dir, err = c.director(f, b); err != nil {
+				return NewResult(f, b), err
+			}
+		}
+		if err := f.Event(dir, args...); err != nil {
+			var oob *fsm.OutOfBoardError
+			if errors.As(err, &oob) {
+				return escapeResult(f, b, oob), nil
+			}
+			return NewResult(f, b), err
+		}
+		if c.eventHook != nil {
+			if err := c.eventHook(); err != nil {
+				return NewResult(f, b), err
+			}
+		}
+		if len(c.invariants) > 0 {
+			if err := checkInvariants(f, b, c.invariants); err != nil {
+				return NewResult(f, b), err
+			}
+		}
+	}
+	return NewResult(f, b), nil
+}
+
+// limitResult returns the partial result of a simulation stopped by a limit
+func limitResult(f *fsm.FSM, b *BenderSimulator, o Outcome) Result {
+	r := NewResult(f, b)
+	r.Outcome = o
+	return r
+}
+
+// budgetResult returns the partial result of a simulation stopped as it exceeded its budget of the given resource
+func budgetResult(f *fsm.FSM, b *BenderSimulator, resource string) Result {
+	r := limitResult(f, b, BudgetExceeded)
+	r.Exceeded = resource
+	return r
+}
+
+// escapeResult returns the result of a simulation ended by a step out of the board
+func escapeResult(f *fsm.FSM, b *BenderSimulator, oob *fsm.OutOfBoardError) Result {
+	r := limitResult(f, b, Escaped)
+	r.Escape = &Escape{Step: f.Steps() + 1, Direction: oob.Event, From: oob.From, To: oob.To}
+	return r
+}
+
+// newStats returns the statistics of the simulation which made the given steps during the given period
+func newStats(f *fsm.FSM, b *BenderSimulator, elapsed time.Duration, steps int, period time.Duration) Stats {
+	var ms runtime.MemStats
+	runtime.ReadMemStats(&ms)
+	return Stats{
+		Elapsed:        elapsed,
+		Steps:          f.Steps(),
+		StepsPerSecond: float64(steps) / period.Seconds(),
+		States:         len(b.cache),
+		CacheSize:      cacheSize(b),
+		HeapAlloc:      ms.HeapAlloc,
+	}
+}
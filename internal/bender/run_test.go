@@ -0,0 +1,525 @@
+package bender
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"bender/internal/fsm"
+)
+
+// loopPlan is a map where Bender loops between E and W in a large room
+// so the loop is detected after many steps
+func loopPlan(size int) []string {
+	plan := []string{strings.Repeat("#", size)}
+	plan = append(plan, "#@EW"+strings.Repeat(" ", size-5)+"#")
+	for i := 0; i < size-3; i++ {
+		plan = append(plan, "#"+strings.Repeat(" ", size-2)+"#")
+	}
+	return append(plan, strings.Repeat("#", size))
+}
+
+func TestRun(t *testing.T) {
+	res, err := Run(statePlan, WithInvariants(Invariants...))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected := Result{
+		Outcome:  Reached,
+		Path:     []string{"SOUTH", "SOUTH", "SOUTH", "SOUTH", "WEST"},
+		Steps:    5,
+		Position: fsm.Pair{X: 1, Y: 5},
+		Destroyed: []Destruction{
+			{At: fsm.Pair{X: 2, Y: 3}, Step: 2},
+			{At: fsm.Pair{X: 2, Y: 5}, Step: 4},
+		},
+	}
+	if !reflect.DeepEqual(res, expected) {
+		t.Fatalf("Wrong result. Expected %+v, got %+v", expected, res)
+	}
+
+	res, err = Run(loopPlan(50), WithInvariants(Invariants...))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if res.Outcome != Loop {
+		t.Fatalf("Wrong outcome. Expected %v, got %v", Loop, res.Outcome)
+	}
+
+	if _, err := Run([]string{"#T#", "#@#", "###"}); err == nil {
+		t.Fatalf("Bad map was simulated")
+	}
+}
+
+func TestRunLabelledTeleports(t *testing.T) {
+	// the teleports 1 and 2 are two pairs
+	res, err := Run([]string{
+		"#######",
+		"#@# # #",
+		"#1#1#2#",
+		"###2#$#",
+		"#######",
+	}, WithInvariants(Invariants...))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if res.Outcome != Reached || res.Steps != 3 || res.Position != (fsm.Pair{X: 5, Y: 3}) {
+		t.Fatalf("Wrong result. Expected %v in 3 steps at %v, got %v in %d steps at %v", Reached, fsm.Pair{X: 5, Y: 3}, res.Outcome, res.Steps, res.Position)
+	}
+}
+
+func TestRunEscaped(t *testing.T) {
+	// the map has no frame on its right side
+	res, err := Run([]string{
+		"####",
+		"#@  ",
+		"####",
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected := Result{
+		Outcome:   Escaped,
+		Path:      []string{"EAST", "EAST"},
+		Steps:     2,
+		Position:  fsm.Pair{X: 3, Y: 1},
+		Destroyed: []Destruction{},
+		Escape:    &Escape{Step: 3, Direction: fsm.EAST, From: fsm.Pair{X: 3, Y: 1}, To: fsm.Pair{X: 4, Y: 1}},
+	}
+	if !reflect.DeepEqual(res, expected) {
+		t.Fatalf("Wrong result. Expected %+v, got %+v", expected, res)
+	}
+	if s := res.Escape.String(); s != "step 3 EAST from (3,1) to (4,1)" {
+		t.Fatalf("Wrong escape. Expected step 3 EAST from (3,1) to (4,1), got %s", s)
+	}
+
+	if _, err := Run([]string{"###", "# #", "###"}); err == nil {
+		t.Fatalf("Map without start was simulated")
+	}
+}
+
+func TestRunLoops(t *testing.T) {
+	testCases := []struct {
+		name    string
+		plan    []string
+		outcome Outcome
+		steps   int
+	}{
+		{
+			// the inverters send Bender back and forth over the same cells, which was reported as a loop
+			name:    "revisits",
+			plan:    []string{"###################", "#      $S I     @I#", "###################"},
+			outcome: Reached,
+			steps:   29,
+		},
+		{
+			name:    "ping pong",
+			plan:    []string{"######", "#@EW #", "######"},
+			outcome: Loop,
+			steps:   3,
+		},
+		{
+			// the destroyed wall changes the board, the configurations before it don't repeat
+			name:    "breaker",
+			plan:    []string{"######", "#@BXW#", "######"},
+			outcome: Loop,
+			steps:   8,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			res, err := Run(tc.plan, WithInvariants(Invariants...))
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if res.Outcome != tc.outcome || res.Steps != tc.steps {
+				t.Fatalf("Wrong result. Expected %v after %d steps, got %v after %d steps %v", tc.outcome, tc.steps, res.Outcome, res.Steps, res.Path)
+			}
+		})
+	}
+}
+
+func TestRunRaggedMaps(t *testing.T) {
+	testCases := []struct {
+		name     string
+		plan     []string
+		outcome  Outcome
+		position fsm.Pair
+	}{
+		{
+			name: "rows longer than the first",
+			plan: []string{
+				"###",
+				"#@    $#",
+				"########",
+			},
+			outcome:  Reached,
+			position: fsm.Pair{X: 6, Y: 1},
+		},
+		{
+			// the missing cells of the short rows are out of the board
+			name: "hole of a short row",
+			plan: []string{
+				"######",
+				"#@  ",
+				"####$#",
+			},
+			outcome:  Escaped,
+			position: fsm.Pair{X: 3, Y: 1},
+		},
+		{
+			name: "empty row",
+			plan: []string{
+				"#####",
+				"#@",
+				"",
+				"#####",
+			},
+			outcome:  Escaped,
+			position: fsm.Pair{X: 1, Y: 1},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			res, err := Run(tc.plan, WithInvariants(Invariants...))
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if res.Outcome != tc.outcome || res.Position != tc.position {
+				t.Fatalf("Wrong result. Expected %v at %v, got %v at %v", tc.outcome, tc.position, res.Outcome, res.Position)
+			}
+		})
+	}
+}
+
+func TestRunLimits(t *testing.T) {
+	res, err := Run(statePlan, WithMaxSteps(3))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if res.Outcome != StepLimitExceeded {
+		t.Fatalf("Wrong outcome. Expected %v, got %v", StepLimitExceeded, res.Outcome)
+	}
+	if expected := []string{"SOUTH", "SOUTH", "SOUTH"}; !reflect.DeepEqual(res.Path, expected) {
+		t.Fatalf("Wrong partial path. Expected %v, got %v", expected, res.Path)
+	}
+
+	// the loops are detected before the first check of the clock
+	res, err = Run(snakePlan(100), WithTimeout(time.Nanosecond))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if res.Outcome != TimeLimitExceeded {
+		t.Fatalf("Wrong outcome. Expected %v, got %v", TimeLimitExceeded, res.Outcome)
+	}
+	if len(res.Path) == 0 {
+		t.Fatalf("Partial path is missing")
+	}
+
+	hookErr := errors.New("stop")
+	events := 0
+	_, err = Run(statePlan, WithEventHook(func() error {
+		events++
+		if events == 2 {
+			return hookErr
+		}
+		return nil
+	}))
+	if err != hookErr || events != 2 {
+		t.Fatalf("Hook didn't abort the simulation: %v after %d event(s)", err, events)
+	}
+}
+
+func TestRunBudget(t *testing.T) {
+	testCases := []struct {
+		name     string
+		budget   Budget
+		exceeded string
+	}{
+		{name: "steps", budget: Budget{Steps: 3}, exceeded: ResourceSteps},
+		{name: "cpu time", budget: Budget{CPUTime: time.Nanosecond}, exceeded: ResourceCPUTime},
+		{name: "memory", budget: Budget{Memory: 1024}, exceeded: ResourceMemory},
+		{name: "enough", budget: Budget{Steps: 1 << 30, CPUTime: time.Hour, Memory: 1 << 30}},
+	}
+
+	for _, tc := range testCases {
+		res, err := Run(snakePlan(100), WithBudget(tc.budget))
+		if err != nil {
+			t.Fatalf("Unexpected error for %q: %v", tc.name, err)
+		}
+		expected := BudgetExceeded
+		if tc.exceeded == "" {
+			expected = Reached
+		}
+		if res.Outcome != expected || res.Exceeded != tc.exceeded {
+			t.Fatalf("Wrong outcome for %q. Expected %v (%q), got %v (%q)", tc.name, expected, tc.exceeded, res.Outcome, res.Exceeded)
+		}
+		if expected == BudgetExceeded && len(res.Path) == 0 {
+			t.Fatalf("Partial path is missing for %q", tc.name)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	res, err := Run(loopPlan(50), WithContext(ctx))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if res.Outcome != Interrupted {
+		t.Fatalf("Wrong outcome. Expected %v, got %v", Interrupted, res.Outcome)
+	}
+}
+
+func TestRunContext(t *testing.T) {
+	opts := make([]Option, 1, 2)
+	opts[0] = WithMaxSteps(10)
+	res, err := RunContext(context.Background(), loopPlan(50), opts...)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if res.Outcome != StepLimitExceeded || res.Steps != 10 {
+		t.Fatalf("Wrong outcome. Expected %v after 10 steps, got %v after %d", StepLimitExceeded, res.Outcome, res.Steps)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	res, err = RunContext(ctx, loopPlan(50), opts...)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if res.Outcome != Interrupted {
+		t.Fatalf("Wrong outcome. Expected %v, got %v", Interrupted, res.Outcome)
+	}
+	// the context of the call wins over the one of the options
+	res, err = RunContext(context.Background(), loopPlan(50), WithContext(ctx))
+	if err != nil || res.Outcome != Loop {
+		t.Fatalf("Wrong outcome. Expected %v, got %v: %v", Loop, res.Outcome, err)
+	}
+}
+
+func TestRunDirector(t *testing.T) {
+	plan := []string{
+		"######",
+		"#@  $#",
+		"######",
+	}
+	testCases := []struct {
+		name       string
+		directions []fsm.Direction
+		expected   Outcome
+		path       []string
+	}{
+		{name: "straight", directions: []fsm.Direction{fsm.EAST}, expected: Reached, path: []string{"EAST", "EAST", "EAST"}},
+		{name: "blocked", directions: []fsm.Direction{fsm.NORTH, fsm.EAST, fsm.SOUTH, fsm.EAST, fsm.EAST}, expected: Reached, path: []string{"EAST", "EAST", "EAST"}},
+		{name: "stuck", directions: []fsm.Direction{fsm.NORTH}, expected: Died, path: []string{}},
+		{name: "loop", directions: []fsm.Direction{fsm.EAST, fsm.WEST}, expected: Loop},
+	}
+	for _, tc := range testCases {
+		i := 0
+		res, err := Run(plan, WithDirector(func(f *fsm.FSM, b *BenderSimulator) (fsm.Direction, error) {
+			// the last direction is repeated
+			d := tc.directions[i%len(tc.directions)]
+			if tc.expected != Loop && i >= len(tc.directions) {
+				d = tc.directions[len(tc.directions)-1]
+			}
+			i++
+			return d, nil
+		}), WithInvariants(Invariants...))
+		if err != nil {
+			t.Fatalf("Unexpected error for %q: %v", tc.name, err)
+		}
+		if res.Outcome != tc.expected {
+			t.Fatalf("Wrong outcome for %q. Expected %v, got %v", tc.name, tc.expected, res.Outcome)
+		}
+		if tc.path != nil && !reflect.DeepEqual(res.Path, tc.path) {
+			t.Fatalf("Wrong path for %q. Expected %v, got %v", tc.name, tc.path, res.Path)
+		}
+	}
+
+	if _, err := Run(plan, WithDirector(func(f *fsm.FSM, b *BenderSimulator) (fsm.Direction, error) {
+		return "", errors.New("no direction")
+	})); err == nil {
+		t.Fatalf("Expected the error of the director")
+	}
+}
+
+func TestRunInvariants(t *testing.T) {
+	plan := []string{
+		"######",
+		"#@  $#",
+		"######",
+	}
+	third := Invariant{Name: "less than 3 steps", Check: func(f *fsm.FSM, b *BenderSimulator) error {
+		if f.Steps() >= 3 {
+			return errors.New("too many steps")
+		}
+		return nil
+	}}
+	res, err := Run(plan, WithInvariants(third))
+	var ierr *InvariantError
+	if !errors.As(err, &ierr) {
+		t.Fatalf("Expected an invariant error, got %v", err)
+	}
+	if res.Steps != 3 || ierr.Step != 3 || ierr.Position != (fsm.Pair{X: 4, Y: 1}) || ierr.Direction != fsm.EAST {
+		t.Fatalf("Wrong violation %+v of the result %+v", ierr, res)
+	}
+	expected := "invariant \"less than 3 steps\" violated at step 3 at (4,1) going EAST: too many steps\n" +
+		"breaker=false inverted=false\n" +
+		"######\n#@  $#\n    ^\n######\n"
+	if ierr.Report() != expected {
+		t.Fatalf("Wrong report. Expected %q, got %q", expected, ierr.Report())
+	}
+
+	// a simulator with a corrupted path violates the invariants of the rules
+	f, err := fsm.NewFSM(plan, BeforeCallback, EnterCallback)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	b := NewBenderSimulator()
+	b.path = append(b.path, "WEST")
+	_, err = Resume(f, b, WithInvariants(Invariants...))
+	if !errors.As(err, &ierr) || ierr.Invariant != "path length equals step count" || ierr.Step != 0 {
+		t.Fatalf("Expected the violation of the path length before the first step, got %v", err)
+	}
+}
+
+func TestRunStats(t *testing.T) {
+	stats := []Stats{}
+	res, err := Run(snakePlan(100), WithStats(time.Nanosecond, func(s Stats) { stats = append(stats, s) }))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(stats) == 0 {
+		t.Fatalf("No statistics reported for %d steps", len(res.Path))
+	}
+	for i, s := range stats {
+		if s.Steps <= 0 || s.Steps > len(res.Path) || s.States <= 0 || s.States > s.Steps || s.CacheSize < s.States*cacheEntrySize || s.HeapAlloc == 0 {
+			t.Fatalf("Wrong statistics %d: %v", i, s)
+		}
+		if i > 0 && s.Steps <= stats[i-1].Steps {
+			t.Fatalf("Wrong steps of the statistics %d. Expected more than %d, got %d", i, stats[i-1].Steps, s.Steps)
+		}
+	}
+}
+
+// snakePlan is a map where Bender zigzags through corridors, breaking walls
+// and crossing modifiers and inverters, before reaching the booth
+func snakePlan(size int) []string {
+	rows := [][]byte{}
+	for y := 0; y < size; y++ {
+		row := []byte(strings.Repeat("#", size))
+		if y%2 == 1 && y < size-1 {
+			for x := 1; x < size-1; x++ {
+				row[x] = ' '
+				if x%7 == 0 {
+					row[x] = 'X'
+				} else if x%11 == 0 {
+					row[x] = 'I'
+				}
+			}
+		}
+		rows = append(rows, row)
+	}
+	last := 1
+	for y := 1; y+2 < size-1; y += 2 {
+		last = y + 2
+		// odd corridors go east, even ones go west
+		end, dir := size-2, byte('W')
+		if (y/2)%2 == 1 {
+			end, dir = 1, 'E'
+		}
+		rows[y][end] = 'S'
+		rows[y+1][end] = ' '
+		rows[y+2][end] = dir
+	}
+	rows[1][1] = '@'
+	rows[1][2] = 'B'
+	rows[1][3] = 'E'
+	if (last/2)%2 == 1 {
+		rows[last][1] = '$'
+	} else {
+		rows[last][size-2] = '$'
+	}
+	plan := make([]string, 0, size)
+	for _, r := range rows {
+		plan = append(plan, string(r))
+	}
+	return plan
+}
+
+func BenchmarkRun(b *testing.B) {
+	benchmarks := []struct {
+		name string
+		plan []string
+	}{
+		{name: "loop-100", plan: loopPlan(100)},
+		{name: "loop-300", plan: loopPlan(300)},
+		{name: "snake-100", plan: snakePlan(100)},
+		{name: "snake-300", plan: snakePlan(300)},
+	}
+	for _, bm := range benchmarks {
+		b.Run(bm.name, func(b *testing.B) {
+			b.ReportAllocs()
+			steps := 0
+			for i := 0; i < b.N; i++ {
+				res, err := Run(bm.plan)
+				if err != nil {
+					b.Fatalf("Unexpected error: %v", err)
+				}
+				steps = len(res.Path)
+			}
+			b.ReportMetric(float64(steps), "steps/op")
+		})
+	}
+}
+
+// steadyState returns a simulation looping between two states
+// every state of the loop is already known so the next steps are the steady state
+func steadyState(t testing.TB) (*fsm.FSM, *BenderSimulator, []interface{}) {
+	plan := loopPlan(50)
+	f, err := fsm.NewFSM(plan, BeforeCallback, EnterCallback)
+	if err != nil {
+		t.Fatalf("Failed to create the FSM: %v", err)
+	}
+	b := NewBenderSimulator()
+	args := []interface{}{b}
+	for i := 0; i < 10; i++ {
+		if err := f.Event(b.Direction(), args...); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+	return f, b, args
+}
+
+func TestHotPathAllocs(t *testing.T) {
+	f, b, args := steadyState(t)
+	id := fsm.StateID{Tile: ' ', At: fsm.Pair{X: 2, Y: 1}}
+	hotPaths := []struct {
+		name string
+		fn   func()
+	}{
+		{name: "Event", fn: func() { f.Event(b.Direction(), args...) }},
+		{name: "Direction", fn: func() { b.Direction() }},
+		{name: "Remember", fn: func() { b.Remember(fsm.EAST, id) }},
+	}
+	for _, hp := range hotPaths {
+		// the growth of the path is amortized over the runs
+		if n := testing.AllocsPerRun(1000, hp.fn); n != 0 {
+			t.Errorf("%s allocates in the steady state: %v allocs/op", hp.name, n)
+		}
+	}
+}
+
+func BenchmarkStep(b *testing.B) {
+	f, bender, args := steadyState(b)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := f.Event(bender.Direction(), args...); err != nil {
+			b.Fatalf("Unexpected error: %v", err)
+		}
+	}
+}
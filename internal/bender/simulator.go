@@ -0,0 +1,273 @@
+package bender
+
+import (
+	"sort"
+
+	"bender/internal/fsm"
+)
+
+// LOOP indicator of the classic output
+const LOOP = "LOOP"
+
+// BREAK entry of the path before the steps destroying a wall, recorded with BreakerRules.RecordBreaks
+const BREAK = "BREAK"
+
+// BenderSimulator simulates more rudimentary Bender
+type BenderSimulator struct {
+	done         bool
+	breaker      bool
+	boom         bool
+	resetDir     bool
+	invertPrio   bool
+	currDir      int
+	priorities   []fsm.Direction
+	pathModifier fsm.Direction
+	path         []string
+	cache        map[fsm.StateID]bool
+	// configurations of Bender after every step, a repeated one is a loop
+	configs map[loopState]bool
+	loop    bool
+	// number of walls destroyed, the board of the configurations
+	breaks int
+	hits   int
+	// rules of the last step
+	choice    Rule
+	effect    Rule
+	destroyed bool
+	// variants of the breaker mode
+	breakerRules BreakerRules
+}
+
+// NewBenderSimulator returns an instance of a bender simulator
+func NewBenderSimulator() *BenderSimulator {
+	return &BenderSimulator{
+		priorities: []fsm.Direction{
+			fsm.SOUTH,
+			fsm.EAST,
+			fsm.NORTH,
+			fsm.WEST,
+		},
+		path:    []string{},
+		cache:   map[fsm.StateID]bool{},
+		configs: map[loopState]bool{},
+	}
+}
+
+// Clone returns an independent copy of the simulator
+func (b *BenderSimulator) Clone() *BenderSimulator {
+	if b == nil {
+		return nil
+	}
+	c := *b
+	c.priorities = append([]fsm.Direction(nil), b.priorities...)
+	c.path = append([]string{}, b.path...)
+	c.cache = make(map[fsm.StateID]bool, len(b.cache))
+	for s := range b.cache {
+		c.cache[s] = true
+	}
+	c.configs = make(map[loopState]bool, len(b.configs))
+	for s := range b.configs {
+		c.configs[s] = true
+	}
+	return &c
+}
+
+// Done returns true if the suicide booth is reached
+func (b *BenderSimulator) Done() bool {
+	return b.done
+}
+
+// Loop returns true if an endless cycle is found: Bender is back in a configuration he already was in
+func (b *BenderSimulator) Loop() bool {
+	return b.loop
+}
+
+// Direction gives the direction to be followed
+// an empty direction is returned if the priorities are badly setup
+func (b *BenderSimulator) Direction() fsm.Direction {
+	if b.pathModifier != "" {
+		return b.pathModifier
+	}
+	if b.currDir < 0 || b.currDir >= len(b.priorities) {
+		return ""
+	}
+	return b.priorities[b.currDir]
+}
+
+// Stuck returns true if all the directions are blocked
+func (b *BenderSimulator) Stuck() bool {
+	// the first hit may come from a path modifier
+	return b.hits > len(b.priorities)+1
+}
+
+// Over returns true if the simulation can't go further
+func (b *BenderSimulator) Over() bool {
+	return b.Done() || b.Loop() || b.Stuck()
+}
+
+// ShowPath returns the recorded path
+func (b *BenderSimulator) ShowPath() []string {
+	return b.path
+}
+
+// Breaker returns true if the simulator went to the breaker mode
+func (b *BenderSimulator) Breaker() bool {
+	return b.breaker
+}
+
+// Inverted returns true if the priorities are inverted at the next obstacle
+func (b *BenderSimulator) Inverted() bool {
+	return b.invertPrio
+}
+
+// Modifier returns the direction of the last path modifier, empty if Bender follows his priorities
+func (b *BenderSimulator) Modifier() fsm.Direction {
+	return b.pathModifier
+}
+
+// Visited returns the positions visited by Bender, row by row
+func (b *BenderSimulator) Visited() []fsm.Pair {
+	seen := make(map[fsm.Pair]bool, len(b.cache))
+	visited := make([]fsm.Pair, 0, len(b.cache))
+	for id := range b.cache {
+		// a position is visited again with another tile once its wall is destroyed
+		if !seen[id.At] {
+			seen[id.At] = true
+			visited = append(visited, id.At)
+		}
+	}
+	sort.Slice(visited, func(i, j int) bool {
+		if visited[i].Y != visited[j].Y {
+			return visited[i].Y < visited[j].Y
+		}
+		return visited[i].X < visited[j].X
+	})
+	return visited
+}
+
+// Priorities returns a copy of the directions in the order Bender tries them after an obstacle
+func (b *BenderSimulator) Priorities() []fsm.Direction {
+	return append([]fsm.Direction(nil), b.priorities...)
+}
+
+// directionNames returns the names of the directions, like in the path
+func directionNames(dirs []fsm.Direction) []string {
+	names := make([]string, 0, len(dirs))
+	for _, d := range dirs {
+		names = append(names, string(d))
+	}
+	return names
+}
+
+// PriorityIndex returns the index in Priorities of the direction Bender follows without path modifier
+// the next obstacle starts again from the first priority once Bender moved after the previous one
+func (b *BenderSimulator) PriorityIndex() int {
+	return b.currDir
+}
+
+// BreakerRules returns the variants of the breaker mode followed by the simulator
+func (b *BenderSimulator) BreakerRules() BreakerRules {
+	return b.breakerRules
+}
+
+// SetBreakerRules changes the variants of the breaker mode followed by the simulator
+func (b *BenderSimulator) SetBreakerRules(r BreakerRules) {
+	b.breakerRules = r
+}
+
+// InvertBreaker inverts the breaker mode
+func (b *BenderSimulator) InvertBreaker() {
+	if b.breaker {
+		b.breaker = false
+		return
+	}
+	b.breaker = true
+}
+
+// Reached signals that the suicide booth is reached
+func (b *BenderSimulator) Reached() {
+	b.done = true
+}
+
+// InvertPriorities signals that the priorities needs to be inverted
+// when next obstacle is reached
+func (b *BenderSimulator) InvertPriorities() {
+	if b.invertPrio {
+		b.invertPrio = false
+		return
+	}
+	b.invertPrio = true
+}
+
+// turnoverPriorities turn the list of priorities up side down
+func (b *BenderSimulator) turnoverPriorities() {
+	for i, j := 0, len(b.priorities)-1; i < len(b.priorities)/2; i, j = i+1, j-1 {
+		b.priorities[i], b.priorities[j] = b.priorities[j], b.priorities[i]
+	}
+	b.invertPrio = false
+}
+
+// Remember records the given direction and the state
+// of course, they are supposed to be passed and visited
+// the simulation loops once the configuration of Bender in the state repeats
+func (b *BenderSimulator) Remember(dir fsm.Direction, state fsm.StateID) {
+	if b.cache == nil {
+		b.cache = map[fsm.StateID]bool{}
+	}
+	if b.configs == nil {
+		b.configs = map[loopState]bool{}
+	}
+	b.path = append(b.path, string(dir))
+	b.cache[state] = true
+	k := b.loopState(state.At)
+	if b.configs[k] {
+		b.loop = true
+	} else {
+		b.configs[k] = true
+	}
+}
+
+// PathModifier unsets the priority directions with the given one
+func (b *BenderSimulator) PathModifier(dir fsm.Direction) {
+	b.pathModifier = dir
+}
+
+// NextDirection calculates the next direction to be given after an obstacle is hit
+func (b *BenderSimulator) NextDirection() {
+	if b.resetDir {
+		b.currDir = 0
+		b.resetDir = false
+	} else {
+		if b.currDir+1 >= len(b.priorities) {
+			b.currDir = 0
+		} else {
+			b.currDir++
+		}
+	}
+}
+
+// Boom signals a hit against an obstacle
+func (b *BenderSimulator) Boom() {
+	b.boom = true
+	b.hits++
+	// back to priorities
+	b.pathModifier = ""
+	// turnover the priorities if passed by an inverted before
+	if b.invertPrio {
+		b.turnoverPriorities()
+		// we need to start from the top
+		b.resetDir = true
+	}
+}
+
+// Hurts returns true if the simulator just hit the obstacle
+func (b *BenderSimulator) Hurts() bool {
+	return b.boom
+}
+
+// BackOnTrack signals that the way out of the obstacles is found
+func (b *BenderSimulator) BackOnTrack() {
+	b.boom = false
+	b.hits = 0
+	b.resetDir = true
+}
@@ -0,0 +1,194 @@
+package bender
+
+import (
+	"reflect"
+	"testing"
+
+	"bender/internal/fsm"
+)
+
+func TestBenderSimulator(t *testing.T) {
+	bender := NewBenderSimulator()
+
+	// start from the first priority
+	dir := bender.Direction()
+	if dir != fsm.SOUTH {
+		t.Fatalf("Wrong priority direction. Expected %s, got %s", fsm.SOUTH, dir)
+	}
+	// must continue the same direction if no path modifier or next direction
+	dir = bender.Direction()
+	if dir != fsm.SOUTH {
+		t.Fatalf("Wrong continuation of priority direction. Expected %s, got %s", fsm.SOUTH, dir)
+	}
+	// must choose the next priority direction
+	bender.NextDirection()
+	dir = bender.Direction()
+	if dir != fsm.EAST {
+		t.Fatalf("Wrong next priority direction. Expected %s, got %s", fsm.EAST, dir)
+	}
+	// must get back to the first priority
+	bender.NextDirection()
+	bender.NextDirection()
+	bender.NextDirection()
+	dir = bender.Direction()
+	if dir != fsm.SOUTH {
+		t.Fatalf("No cycle in priority direction. Expected %s, got %s", fsm.SOUTH, dir)
+	}
+	// must stick with the path modifier
+	bender.PathModifier(fsm.NORTH)
+	dir = bender.Direction()
+	if dir != fsm.NORTH {
+		t.Fatalf("Wrong path modifier. Expected %s, got %s", fsm.NORTH, dir)
+	}
+	// obstacle case, must get back to the priorities
+	bender.Boom()
+	dir = bender.Direction()
+	if dir != fsm.SOUTH {
+		t.Fatalf("Failed to get back to priorities. Expected %s, got %s", fsm.SOUTH, dir)
+	}
+	// looking for a way out of the obstacles
+	bender.NextDirection()
+	if !bender.Hurts() {
+		t.Fatalf("Obstacle is not recorded")
+	}
+	if bender.Hurts() {
+		bender.BackOnTrack()
+	}
+	bender.NextDirection()
+	dir = bender.Direction()
+	if dir != fsm.SOUTH {
+		t.Fatalf("Priorities not reset. Expected %s, got %s", fsm.SOUTH, dir)
+	}
+	// invert priorities
+	bender.InvertPriorities()
+	bender.Boom()
+	bender.NextDirection()
+	dir = bender.Direction()
+	if dir != fsm.WEST {
+		t.Fatalf("Failed to invert priorities. Expected %s, got %s", fsm.WEST, dir)
+	}
+	bender.NextDirection()
+	bender.NextDirection()
+	dir = bender.Direction()
+	if dir != fsm.EAST {
+		t.Fatalf("Failed to continue on inverted priorities. Expected %s, got %s", fsm.EAST, dir)
+	}
+	bender.InvertPriorities()
+	bender.Boom()
+	bender.NextDirection()
+	dir = bender.Direction()
+	if dir != fsm.SOUTH {
+		t.Fatalf("Failed to invert back the priorities. Expected %s, got %s", fsm.SOUTH, dir)
+	}
+	// path
+	dirs := []fsm.Direction{
+		fsm.SOUTH,
+		fsm.SOUTH,
+		fsm.EAST,
+		fsm.EAST,
+	}
+	bender.Remember(dirs[0], fsm.StateID{Tile: ' ', At: fsm.Pair{X: 1, Y: 1}})
+	bender.Remember(dirs[1], fsm.StateID{Tile: ' ', At: fsm.Pair{X: 1, Y: 2}})
+	bender.Remember(dirs[2], fsm.StateID{Tile: ' ', At: fsm.Pair{X: 2, Y: 2}})
+	bender.Remember(dirs[3], fsm.StateID{Tile: 'B', At: fsm.Pair{X: 3, Y: 2}})
+	for i, p := range bender.ShowPath() {
+		if string(dirs[i]) != p {
+			t.Fatalf("Wrong path. Expected %s, got %s", dirs[i], p)
+		}
+	}
+	// the same cells in another configuration aren't a loop
+	bender.InvertBreaker()
+	bender.Remember(dirs[0], fsm.StateID{Tile: ' ', At: fsm.Pair{X: 1, Y: 1}})
+	bender.Remember(dirs[1], fsm.StateID{Tile: ' ', At: fsm.Pair{X: 1, Y: 2}})
+	bender.Remember(dirs[2], fsm.StateID{Tile: ' ', At: fsm.Pair{X: 2, Y: 2}})
+	if bender.Loop() {
+		t.Fatalf("False positive loop detection")
+	}
+	bender.InvertBreaker()
+	bender.Remember(dirs[3], fsm.StateID{Tile: 'B', At: fsm.Pair{X: 3, Y: 2}})
+	if !bender.Loop() {
+		t.Fatalf("Loop was not detected")
+	}
+	// breaker mode
+	br := bender.Breaker()
+	bender.InvertBreaker()
+	ibr := bender.Breaker()
+	if br != !ibr {
+		t.Fatalf("Failed to invert the breaker mode #1")
+	}
+	bender.InvertBreaker()
+	ibr = bender.Breaker()
+	if br != ibr {
+		t.Fatalf("Failed to invert the breaker mode #2")
+	}
+	// booth reached
+	bender.Reached()
+	if !bender.Done() {
+		t.Fatalf("Failed to become done")
+	}
+}
+
+func TestBenderSimulatorIntrospection(t *testing.T) {
+	bender := NewBenderSimulator()
+	if p := bender.Priorities(); !reflect.DeepEqual(p, []fsm.Direction{fsm.SOUTH, fsm.EAST, fsm.NORTH, fsm.WEST}) {
+		t.Fatalf("Wrong priorities, got %v", p)
+	}
+	// the priorities can't be changed through the copy
+	bender.Priorities()[0] = fsm.WEST
+	bender.NextDirection()
+	bender.InvertPriorities()
+	bender.PathModifier(fsm.NORTH)
+	if bender.PriorityIndex() != 1 || !bender.Inverted() || bender.Modifier() != fsm.NORTH || bender.Direction() != fsm.NORTH {
+		t.Fatalf("Wrong decision context. Expected index 1, inverted and NORTH modifier, got %d, %v and %q", bender.PriorityIndex(), bender.Inverted(), bender.Modifier())
+	}
+
+	// the obstacle inverts the priorities and starts again from the first one
+	bender.Boom()
+	bender.NextDirection()
+	if p := bender.Priorities(); !reflect.DeepEqual(p, []fsm.Direction{fsm.WEST, fsm.NORTH, fsm.EAST, fsm.SOUTH}) {
+		t.Fatalf("Wrong inverted priorities, got %v", p)
+	}
+	if bender.PriorityIndex() != 0 || bender.Inverted() || bender.Modifier() != "" || bender.Direction() != fsm.WEST {
+		t.Fatalf("Wrong decision context after the obstacle. Expected index 0 without inversion nor modifier, got %d, %v and %q", bender.PriorityIndex(), bender.Inverted(), bender.Modifier())
+	}
+}
+
+func TestBenderSimulatorClone(t *testing.T) {
+	plan := []string{
+		"#######",
+		"#@ I  #",
+		"#     #",
+		"#    $#",
+		"#######",
+	}
+	f, err := fsm.NewFSM(plan, BeforeCallback, EnterCallback)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	b := NewBenderSimulator()
+	for _, d := range []fsm.Direction{fsm.EAST, fsm.EAST} {
+		if err := f.Event(d, b); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+	c := b.Clone()
+	if !reflect.DeepEqual(c, b) {
+		t.Fatalf("Wrong clone. Expected %+v, got %+v", b, c)
+	}
+	// the branch hits the wall with inverted priorities and goes on
+	cf := f.Clone()
+	for i := 0; i < 3 && !c.Over(); i++ {
+		if err := cf.Event(c.Direction(), c); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+	if len(b.ShowPath()) != 2 || len(b.cache) != 2 || len(b.configs) != 2 || !b.Inverted() {
+		t.Fatalf("Original changed by its clone: path %v, %d states, %d configurations", b.ShowPath(), len(b.cache), len(b.configs))
+	}
+	if expected := []fsm.Direction{fsm.SOUTH, fsm.EAST, fsm.NORTH, fsm.WEST}; !reflect.DeepEqual(b.Priorities(), expected) {
+		t.Fatalf("Wrong priorities of the original. Expected %v, got %v", expected, b.Priorities())
+	}
+	if c.Inverted() || reflect.DeepEqual(c.Priorities(), b.Priorities()) || len(c.ShowPath()) <= 2 {
+		t.Fatalf("Wrong branch: inverted %t, priorities %v, path %v", c.Inverted(), c.Priorities(), c.ShowPath())
+	}
+}
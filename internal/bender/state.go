@@ -0,0 +1,146 @@
+package bender
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"sort"
+
+	"bender/internal/fsm"
+)
+
+// simulatorState is the serializable state of BenderSimulator
+type simulatorState struct {
+	Done         bool            `json:"done"`
+	Breaker      bool            `json:"breaker"`
+	Boom         bool            `json:"boom"`
+	ResetDir     bool            `json:"resetDir"`
+	InvertPrio   bool            `json:"invertPrio"`
+	CurrDir      int             `json:"currDir"`
+	Priorities   []fsm.Direction `json:"priorities"`
+	PathModifier fsm.Direction   `json:"pathModifier"`
+	Path         []string        `json:"path"`
+	Cache        []string        `json:"cache"`
+	Configs      []string        `json:"configs"`
+	Loop         bool            `json:"loop"`
+	Breaks       int             `json:"breaks"`
+	Hits         int             `json:"hits"`
+	Choice       Rule            `json:"choice,omitempty"`
+	Effect       Rule            `json:"effect,omitempty"`
+	// nil for the rules of the puzzle
+	BreakerRules *BreakerRules `json:"breakerRules,omitempty"`
+}
+
+// state returns the serializable state of the simulator
+func (b *BenderSimulator) state() *simulatorState {
+	cache := make([]string, 0, len(b.cache))
+	for id := range b.cache {
+		cache = append(cache, id.String())
+	}
+	// keep the encoding deterministic
+	sort.Strings(cache)
+	configs := make([]string, 0, len(b.configs))
+	for k := range b.configs {
+		configs = append(configs, k.String())
+	}
+	sort.Strings(configs)
+	var rules *BreakerRules
+	if r := b.breakerRules; r != (BreakerRules{}) {
+		rules = &r
+	}
+	return &simulatorState{
+		Done:         b.done,
+		Breaker:      b.breaker,
+		Boom:         b.boom,
+		ResetDir:     b.resetDir,
+		InvertPrio:   b.invertPrio,
+		CurrDir:      b.currDir,
+		Priorities:   append([]fsm.Direction{}, b.priorities...),
+		PathModifier: b.pathModifier,
+		Path:         append([]string{}, b.path...),
+		Cache:        cache,
+		Configs:      configs,
+		Loop:         b.loop,
+		Breaks:       b.breaks,
+		Hits:         b.hits,
+		Choice:       b.choice,
+		Effect:       b.effect,
+		BreakerRules: rules,
+	}
+}
+
+// setState restores the simulator from the given state
+func (b *BenderSimulator) setState(s *simulatorState) {
+	b.done = s.Done
+	b.breaker = s.Breaker
+	b.boom = s.Boom
+	b.resetDir = s.ResetDir
+	b.invertPrio = s.InvertPrio
+	b.currDir = s.CurrDir
+	b.priorities = append([]fsm.Direction{}, s.Priorities...)
+	b.pathModifier = s.PathModifier
+	b.path = append([]string{}, s.Path...)
+	b.cache = make(map[fsm.StateID]bool, len(s.Cache))
+	for _, c := range s.Cache {
+		// the ids of the older versions are ambiguous, their states are forgotten
+		// and the loops are detected once they're visited again
+		if id, err := fsm.ParseStateID(c); err == nil {
+			b.cache[id] = true
+		}
+	}
+	b.configs = make(map[loopState]bool, len(s.Configs))
+	for _, c := range s.Configs {
+		// the older versions counted the revisits, their loops are detected once a configuration repeats
+		if k, err := parseLoopState(c); err == nil {
+			b.configs[k] = true
+		}
+	}
+	b.loop = s.Loop
+	b.breaks = s.Breaks
+	b.hits = s.Hits
+	b.choice = s.Choice
+	b.effect = s.Effect
+	b.breakerRules = BreakerRules{}
+	if s.BreakerRules != nil {
+		b.breakerRules = *s.BreakerRules
+	}
+}
+
+// MarshalJSON encodes the whole state of the simulator
+func (b *BenderSimulator) MarshalJSON() ([]byte, error) {
+	return json.Marshal(b.state())
+}
+
+// UnmarshalJSON restores the simulator from its JSON encoding
+func (b *BenderSimulator) UnmarshalJSON(data []byte) error {
+	s := &simulatorState{}
+	if err := json.Unmarshal(data, s); err != nil {
+		return err
+	}
+	b.setState(s)
+	return nil
+}
+
+// GobEncode encodes the whole state of the simulator
+func (b *BenderSimulator) GobEncode() ([]byte, error) {
+	return gobEncode(b.state())
+}
+
+// GobDecode restores the simulator from its gob encoding
+func (b *BenderSimulator) GobDecode(data []byte) error {
+	s := &simulatorState{}
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(s); err != nil {
+		return err
+	}
+	b.setState(s)
+	return nil
+}
+
+// gobEncode encodes the given value with gob
+func gobEncode(v interface{}) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	if err := gob.NewEncoder(buf).Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
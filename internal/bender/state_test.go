@@ -0,0 +1,121 @@
+package bender
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"bender/internal/fsm"
+)
+
+// statePlan goes through the breaker, the breakable walls and the inverter
+var statePlan = []string{
+	"#######",
+	"# @   #",
+	"# B   #",
+	"# X   #",
+	"# I   #",
+	"#$X   #",
+	"#######",
+}
+
+// simulate runs the simulation until the end or until the given number of events
+func simulate(t *testing.T, m *fsm.FSM, bender *BenderSimulator, events int) {
+	for i := 0; !bender.Over() && (events < 0 || i < events); i++ {
+		if err := m.Event(bender.Direction(), bender); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+}
+
+func TestStateSerialization(t *testing.T) {
+	// uninterrupted run
+	m, err := fsm.NewFSM(statePlan, BeforeCallback, EnterCallback)
+	if err != nil {
+		t.Fatalf("Failed to create the FSM: %v", err)
+	}
+	bender := NewBenderSimulator()
+	simulate(t, m, bender, -1)
+	if !bender.Done() {
+		t.Fatalf("Booth not reached: %v", bender.ShowPath())
+	}
+	expected := bender.ShowPath()
+
+	testCases := []struct {
+		name   string
+		encode func(v interface{}) ([]byte, error)
+		decode func(data []byte, v interface{}) error
+	}{
+		{
+			name:   "json",
+			encode: json.Marshal,
+			decode: json.Unmarshal,
+		},
+		{
+			name:   "gob",
+			encode: gobEncode,
+			decode: func(data []byte, v interface{}) error {
+				return gob.NewDecoder(bytes.NewReader(data)).Decode(v)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			m, err := fsm.NewFSM(statePlan, BeforeCallback, EnterCallback)
+			if err != nil {
+				t.Fatalf("Failed to create the FSM: %v", err)
+			}
+			bender := NewBenderSimulator()
+			// break the first wall
+			simulate(t, m, bender, 2)
+			if m.Board().At(2, 3) != ' ' {
+				t.Fatalf("Test case %q: wall is not destroyed yet", tc.name)
+			}
+
+			mData, err := tc.encode(m)
+			if err != nil {
+				t.Fatalf("Test case %q: failed to encode the FSM: %v", tc.name, err)
+			}
+			benderData, err := tc.encode(bender)
+			if err != nil {
+				t.Fatalf("Test case %q: failed to encode the simulator: %v", tc.name, err)
+			}
+
+			rm := &fsm.FSM{}
+			if err := tc.decode(mData, rm); err != nil {
+				t.Fatalf("Test case %q: failed to decode the FSM: %v", tc.name, err)
+			}
+			rm.SetCallbacks(BeforeCallback, EnterCallback)
+			rbender := &BenderSimulator{}
+			if err := tc.decode(benderData, rbender); err != nil {
+				t.Fatalf("Test case %q: failed to decode the simulator: %v", tc.name, err)
+			}
+			if !reflect.DeepEqual(rbender, bender) {
+				t.Fatalf("Test case %q: wrong simulator. Expected %+v, got %+v", tc.name, bender, rbender)
+			}
+			if !reflect.DeepEqual(rm.State(), m.State()) {
+				t.Fatalf("Test case %q: wrong FSM. Expected %+v, got %+v", tc.name, m.State(), rm.State())
+			}
+
+			simulate(t, rm, rbender, -1)
+			if !reflect.DeepEqual(rbender.ShowPath(), expected) {
+				t.Fatalf("Test case %q: wrong path after resume. Expected %v, got %v", tc.name, expected, rbender.ShowPath())
+			}
+		})
+	}
+}
+
+func TestStateLegacyCache(t *testing.T) {
+	// the ids of the older versions concatenated the coordinates, they can't be told apart
+	b := &BenderSimulator{}
+	if err := json.Unmarshal([]byte(`{"cache":[" 112", "B(3,2)"]}`), b); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected := map[fsm.StateID]bool{{Tile: 'B', At: fsm.Pair{X: 3, Y: 2}}: true}
+	if !reflect.DeepEqual(b.cache, expected) {
+		t.Fatalf("Wrong cache. Expected %v, got %v", expected, b.cache)
+	}
+}
@@ -0,0 +1,144 @@
+package bender
+
+import (
+	"runtime"
+	"time"
+
+	"bender/internal/fsm"
+)
+
+// Step is a move of Bender made by Stepper.Next
+type Step struct {
+	// number of the step, starting from 1
+	Number int
+	// direction of the step
+	Direction fsm.Direction
+	// position of Bender before and after the step, after is the other teleport if Bender was teleported
+	From, To fsm.Pair
+	// tile entered by the step, a destroyed wall is X
+	Tile byte
+	// true if the step destroyed a breakable wall
+	Destroyed bool
+	// breaker mode and inverted priorities of Bender after the step
+	Breaker, Inverted bool
+	// rule which chose the direction and rule applied by the entered tile, if any
+	Choice, Effect Rule
+}
+
+// Stepper runs a simulation one step at a time, for the visualizers and the debuggers driving the engine
+type Stepper struct {
+	f    *fsm.FSM
+	b    *BenderSimulator
+	opts []Option
+	// step limit of the options
+	maxSteps int
+	// time limit and CPU time budget of the options, and the time spent by the steps so far
+	timeout, cpuBudget time.Duration
+	elapsed, cpuTime   time.Duration
+	// tile entered by the last event
+	entered byte
+	res     Result
+	err     error
+	over    bool
+}
+
+// NewStepper returns the stepper simulating Bender on the given map with the given options
+// the time limit and the budget apply to the whole simulation, the time between the steps isn't counted,
+// the results aren't memoized
+func NewStepper(plan []string, opts ...Option) (*Stepper, error) {
+	s := &Stepper{b: NewBenderSimulator()}
+	f, err := fsm.NewFSM(plan, BeforeCallback, func(e *fsm.Event) {
+		s.entered = e.Dst
+		EnterCallback(e)
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.f = f
+	c := &runConfig{}
+	for _, o := range opts {
+		o(c)
+	}
+	s.maxSteps = c.maxSteps
+	s.timeout, s.cpuBudget = c.timeout, c.budget.CPUTime
+	// the time limit and the CPU time are checked by Next across the steps
+	budget := c.budget
+	budget.CPUTime = 0
+	s.opts = append(opts[:len(opts):len(opts)], WithMemo(nil), WithTimeout(0), WithBudget(budget))
+	s.res = NewResult(f, s.b)
+	return s, nil
+}
+
+// Next makes the next move of Bender, false once the simulation is over: see Result and Err
+func (s *Stepper) Next() (Step, bool) {
+	if s.over {
+		return Step{}, false
+	}
+	if s.timeout > 0 && s.elapsed > s.timeout {
+		s.res, s.over = limitResult(s.f, s.b, TimeLimitExceeded), true
+		return Step{}, false
+	}
+	if s.cpuBudget > 0 && s.cpuTime > s.cpuBudget {
+		s.res, s.over = budgetResult(s.f, s.b, ResourceCPUTime), true
+		return Step{}, false
+	}
+	from, steps := s.f.Position(), s.f.Steps()
+	limit := steps + 1
+	if s.maxSteps > 0 && s.maxSteps < limit {
+		limit = s.maxSteps
+	}
+	start := time.Now()
+	var cpuStart time.Duration
+	if s.cpuBudget > 0 {
+		// the CPU time of the thread is the one of the step
+		runtime.LockOSThread()
+		cpuStart = threadCPUTime()
+	}
+	s.res, s.err = Resume(s.f, s.b, append(s.opts, WithMaxSteps(limit))...)
+	if s.cpuBudget > 0 {
+		s.cpuTime += threadCPUTime() - cpuStart
+		runtime.UnlockOSThread()
+	}
+	s.elapsed += time.Since(start)
+	if s.err != nil || s.f.Steps() == steps {
+		s.over = true
+		return Step{}, false
+	}
+	if s.res.Outcome == StepLimitExceeded && s.f.Steps() != s.maxSteps {
+		// the limit of the step, not the one of the options
+		s.res.Outcome = Interrupted
+	}
+	choice, effect := s.b.StepRules()
+	return Step{
+		Number:    s.f.Steps(),
+		Direction: fsm.Direction(s.b.path[len(s.b.path)-1]),
+		From:      from,
+		To:        s.f.Position(),
+		Tile:      s.entered,
+		Destroyed: effect == RuleBreakerDestruction,
+		Breaker:   s.b.Breaker(),
+		Inverted:  s.b.Inverted(),
+		Choice:    choice,
+		Effect:    effect,
+	}, true
+}
+
+// Result returns the result of the simulation so far, its outcome is Interrupted until the simulation is over
+func (s *Stepper) Result() Result {
+	return s.res
+}
+
+// Err returns the error which ended the simulation, if any
+func (s *Stepper) Err() error {
+	return s.err
+}
+
+// FSM returns the machine of the simulation, to inspect the board between the steps
+func (s *Stepper) FSM() *fsm.FSM {
+	return s.f
+}
+
+// Simulator returns the simulator of Bender, to inspect his state between the steps
+func (s *Stepper) Simulator() *BenderSimulator {
+	return s.b
+}
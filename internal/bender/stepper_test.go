@@ -0,0 +1,123 @@
+package bender
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"bender/internal/fsm"
+)
+
+func TestStepper(t *testing.T) {
+	testCases := []struct {
+		name string
+		plan []string
+		opts []Option
+	}{
+		{name: "breaker", plan: statePlan},
+		{name: "loop", plan: loopPlan(8)},
+		{name: "snake", plan: snakePlan(20)},
+		{name: "step limit", plan: loopPlan(8), opts: []Option{WithMaxSteps(7)}},
+		{name: "teleports", plan: []string{"#######", "#@T  T#", "#    $#", "#######"}},
+		{name: "stuck", plan: []string{"###", "#@#", "###"}},
+	}
+	for _, tc := range testCases {
+		expected, err := Run(tc.plan, tc.opts...)
+		if err != nil {
+			t.Fatalf("Unexpected error for %q: %v", tc.name, err)
+		}
+		s, err := NewStepper(tc.plan, tc.opts...)
+		if err != nil {
+			t.Fatalf("Unexpected error for %q: %v", tc.name, err)
+		}
+		path := []string{}
+		for {
+			step, ok := s.Next()
+			if !ok {
+				break
+			}
+			if step.Number != len(path)+1 {
+				t.Fatalf("Wrong number of the step for %q. Expected %d, got %d", tc.name, len(path)+1, step.Number)
+			}
+			path = append(path, string(step.Direction))
+		}
+		if s.Err() != nil {
+			t.Fatalf("Unexpected error for %q: %v", tc.name, s.Err())
+		}
+		if !reflect.DeepEqual(s.Result(), expected) {
+			t.Fatalf("Wrong result for %q. Expected %+v, got %+v", tc.name, expected, s.Result())
+		}
+		if !reflect.DeepEqual(path, expected.Path) {
+			t.Fatalf("Wrong steps for %q. Expected %v, got %v", tc.name, expected.Path, path)
+		}
+		if _, ok := s.Next(); ok {
+			t.Fatalf("Step after the end for %q", tc.name)
+		}
+	}
+}
+
+func TestStepperSteps(t *testing.T) {
+	s, err := NewStepper([]string{
+		"######",
+		"#@BXT#",
+		"####T#",
+		"####$#",
+		"######",
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if res := s.Result(); res.Outcome != Interrupted || res.Steps != 0 {
+		t.Fatalf("Wrong result before the first step. Expected %v after 0 steps, got %v after %d", Interrupted, res.Outcome, res.Steps)
+	}
+	// Bender is blocked south, takes the breaker, breaks the wall and is teleported
+	expected := []Step{
+		{Number: 1, Direction: fsm.EAST, From: fsm.Pair{X: 1, Y: 1}, To: fsm.Pair{X: 2, Y: 1}, Tile: 'B', Breaker: true, Choice: RulePriorityFallback, Effect: RuleBreakerToggle},
+		{Number: 2, Direction: fsm.EAST, From: fsm.Pair{X: 2, Y: 1}, To: fsm.Pair{X: 3, Y: 1}, Tile: 'X', Destroyed: true, Breaker: true, Choice: RuleForward, Effect: RuleBreakerDestruction},
+		{Number: 3, Direction: fsm.EAST, From: fsm.Pair{X: 3, Y: 1}, To: fsm.Pair{X: 4, Y: 2}, Tile: 'T', Breaker: true, Choice: RuleForward, Effect: RuleTeleport},
+		{Number: 4, Direction: fsm.SOUTH, From: fsm.Pair{X: 4, Y: 2}, To: fsm.Pair{X: 4, Y: 3}, Tile: '$', Breaker: true, Choice: RulePriorityFallback, Effect: RuleBooth},
+	}
+	for i, e := range expected {
+		step, ok := s.Next()
+		if !ok || !reflect.DeepEqual(step, e) {
+			t.Fatalf("Wrong step %d. Expected %+v, got %+v", i+1, e, step)
+		}
+	}
+	if _, ok := s.Next(); ok || s.Result().Outcome != Reached {
+		t.Fatalf("Wrong end. Expected %v, got %v", Reached, s.Result().Outcome)
+	}
+}
+
+func TestStepperTimeout(t *testing.T) {
+	// a corridor long enough to outlast the time limit, which none of the steps exceeds alone
+	n := 200000
+	plan := []string{
+		strings.Repeat("#", n+4),
+		"#@" + strings.Repeat(" ", n) + "$#",
+		strings.Repeat("#", n+4),
+	}
+	s, err := NewStepper(plan, WithTimeout(10*time.Millisecond))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	steps := 0
+	for {
+		if _, ok := s.Next(); !ok {
+			break
+		}
+		steps++
+	}
+	if s.Err() != nil {
+		t.Fatalf("Unexpected error: %v", s.Err())
+	}
+	if s.Result().Outcome != TimeLimitExceeded {
+		t.Fatalf("Wrong outcome. Expected %v, got %v", TimeLimitExceeded, s.Result().Outcome)
+	}
+	if steps == 0 || steps > n {
+		t.Fatalf("Wrong number of steps. Expected between 1 and %d, got %d", n, steps)
+	}
+	if _, ok := s.Next(); ok {
+		t.Fatalf("Step after the time limit")
+	}
+}
@@ -0,0 +1,181 @@
+// Package cbor encodes values in CBOR (RFC 8949), a compact binary equivalent of JSON
+// the structs are encoded as maps keyed by their JSON field names so both encodings hold the same documents
+package cbor
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// major types of the data items
+const (
+	majorUint   = 0
+	majorNegint = 1
+	majorBytes  = 2
+	majorText   = 3
+	majorArray  = 4
+	majorMap    = 5
+	majorSimple = 7
+)
+
+// simple values and floats
+const (
+	simpleFalse = 20
+	simpleTrue  = 21
+	simpleNull  = 22
+	float64Info = 27
+)
+
+// Marshal returns the CBOR encoding of the value
+// the maps are encoded with their keys sorted so the encoding is deterministic
+func Marshal(v interface{}) ([]byte, error) {
+	return appendValue(nil, reflect.ValueOf(v))
+}
+
+// appendHead appends the head of a data item: its major type and its argument
+func appendHead(b []byte, major byte, arg uint64) []byte {
+	m := major << 5
+	switch {
+	case arg < 24:
+		return append(b, m|byte(arg))
+	case arg <= math.MaxUint8:
+		return append(b, m|24, byte(arg))
+	case arg <= math.MaxUint16:
+		return append(b, m|25, byte(arg>>8), byte(arg))
+	case arg <= math.MaxUint32:
+		return append(b, m|26, byte(arg>>24), byte(arg>>16), byte(arg>>8), byte(arg))
+	}
+	return append(b, m|27, byte(arg>>56), byte(arg>>48), byte(arg>>40), byte(arg>>32), byte(arg>>24), byte(arg>>16), byte(arg>>8), byte(arg))
+}
+
+// appendValue appends the encoding of the value
+func appendValue(b []byte, v reflect.Value) ([]byte, error) {
+	if !v.IsValid() {
+		return append(b, majorSimple<<5|simpleNull), nil
+	}
+	switch v.Kind() {
+	case reflect.Bool:
+		if v.Bool() {
+			return append(b, majorSimple<<5|simpleTrue), nil
+		}
+		return append(b, majorSimple<<5|simpleFalse), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if i := v.Int(); i < 0 {
+			return appendHead(b, majorNegint, uint64(-1-i)), nil
+		}
+		return appendHead(b, majorUint, uint64(v.Int())), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return appendHead(b, majorUint, v.Uint()), nil
+	case reflect.Float32, reflect.Float64:
+		f := math.Float64bits(v.Float())
+		return append(b, majorSimple<<5|float64Info, byte(f>>56), byte(f>>48), byte(f>>40), byte(f>>32), byte(f>>24), byte(f>>16), byte(f>>8), byte(f)), nil
+	case reflect.String:
+		return append(appendHead(b, majorText, uint64(v.Len())), v.String()...), nil
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return append(b, majorSimple<<5|simpleNull), nil
+		}
+		return appendValue(b, v.Elem())
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			return append(b, majorSimple<<5|simpleNull), nil
+		}
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			b = appendHead(b, majorBytes, uint64(v.Len()))
+			for i := 0; i < v.Len(); i++ {
+				b = append(b, byte(v.Index(i).Uint()))
+			}
+			return b, nil
+		}
+		b = appendHead(b, majorArray, uint64(v.Len()))
+		for i := 0; i < v.Len(); i++ {
+			var err error
+			if b, err = appendValue(b, v.Index(i)); err != nil {
+				return nil, err
+			}
+		}
+		return b, nil
+	case reflect.Map:
+		if v.IsNil() {
+			return append(b, majorSimple<<5|simpleNull), nil
+		}
+		if v.Type().Key().Kind() != reflect.String {
+			return nil, fmt.Errorf("cbor: unsupported map key type %s", v.Type().Key())
+		}
+		keys := v.MapKeys()
+		sort.Slice(keys, func(i, j int) bool { return keys[i].String() < keys[j].String() })
+		b = appendHead(b, majorMap, uint64(len(keys)))
+		for _, k := range keys {
+			var err error
+			if b, err = appendValue(b, k); err != nil {
+				return nil, err
+			}
+			if b, err = appendValue(b, v.MapIndex(k)); err != nil {
+				return nil, err
+			}
+		}
+		return b, nil
+	case reflect.Struct:
+		return appendStruct(b, v)
+	}
+	return nil, fmt.Errorf("cbor: unsupported type %s", v.Type())
+}
+
+// appendStruct appends the struct as a map keyed by the JSON names of its exported fields
+func appendStruct(b []byte, v reflect.Value) ([]byte, error) {
+	type field struct {
+		name  string
+		value reflect.Value
+	}
+	fields := []field{}
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" {
+			continue
+		}
+		name, opts, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" && opts == "" {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		fv := v.Field(i)
+		if strings.Contains(","+opts+",", ",omitempty,") && isEmpty(fv) {
+			continue
+		}
+		fields = append(fields, field{name: name, value: fv})
+	}
+	b = appendHead(b, majorMap, uint64(len(fields)))
+	for _, f := range fields {
+		b = append(appendHead(b, majorText, uint64(len(f.name))), f.name...)
+		var err error
+		if b, err = appendValue(b, f.value); err != nil {
+			return nil, err
+		}
+	}
+	return b, nil
+}
+
+// isEmpty returns true if the value is omitted by the omitempty option, like with encoding/json
+func isEmpty(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool:
+		return !v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return v.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return v.Float() == 0
+	case reflect.Interface, reflect.Ptr:
+		return v.IsNil()
+	}
+	return false
+}
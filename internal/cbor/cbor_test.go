@@ -0,0 +1,68 @@
+package cbor
+
+import (
+	"bytes"
+	"math"
+	"testing"
+)
+
+func TestMarshal(t *testing.T) {
+	type step struct {
+		Direction string `json:"direction"`
+		Tile      string `json:"tile,omitempty"`
+		Hidden    int    `json:"-"`
+		Number    int
+		private   int
+	}
+
+	// examples of the appendix A of RFC 8949
+	testCases := []struct {
+		name     string
+		value    interface{}
+		expected []byte
+	}{
+		{name: "0", value: 0, expected: []byte{0x00}},
+		{name: "23", value: 23, expected: []byte{0x17}},
+		{name: "24", value: 24, expected: []byte{0x18, 0x18}},
+		{name: "1000", value: 1000, expected: []byte{0x19, 0x03, 0xe8}},
+		{name: "1000000", value: uint32(1000000), expected: []byte{0x1a, 0x00, 0x0f, 0x42, 0x40}},
+		{name: "max uint64", value: uint64(math.MaxUint64), expected: []byte{0x1b, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}},
+		{name: "-1", value: -1, expected: []byte{0x20}},
+		{name: "-1000", value: int64(-1000), expected: []byte{0x39, 0x03, 0xe7}},
+		{name: "1.1", value: 1.1, expected: []byte{0xfb, 0x3f, 0xf1, 0x99, 0x99, 0x99, 0x99, 0x99, 0x9a}},
+		{name: "0.0", value: 0.0, expected: []byte{0xfb, 0, 0, 0, 0, 0, 0, 0, 0}},
+		{name: "false", value: false, expected: []byte{0xf4}},
+		{name: "true", value: true, expected: []byte{0xf5}},
+		{name: "null", value: nil, expected: []byte{0xf6}},
+		{name: "nil slice", value: []string(nil), expected: []byte{0xf6}},
+		{name: "bytes", value: []byte{1, 2, 3, 4}, expected: []byte{0x44, 0x01, 0x02, 0x03, 0x04}},
+		{name: "empty string", value: "", expected: []byte{0x60}},
+		{name: "IETF", value: "IETF", expected: []byte{0x64, 0x49, 0x45, 0x54, 0x46}},
+		{name: "unicode", value: "ü", expected: []byte{0x62, 0xc3, 0xbc}},
+		{name: "array", value: []interface{}{1, []int{2, 3}, [2]int{4, 5}}, expected: []byte{0x83, 0x01, 0x82, 0x02, 0x03, 0x82, 0x04, 0x05}},
+		{name: "map", value: map[string]interface{}{"b": []int{2, 3}, "a": 1}, expected: []byte{0xa2, 0x61, 0x61, 0x01, 0x61, 0x62, 0x82, 0x02, 0x03}},
+		{
+			name:     "struct",
+			value:    &step{Direction: "EAST", Hidden: 1, Number: 2, private: 3},
+			expected: []byte{0xa2, 0x69, 'd', 'i', 'r', 'e', 'c', 't', 'i', 'o', 'n', 0x64, 'E', 'A', 'S', 'T', 0x66, 'N', 'u', 'm', 'b', 'e', 'r', 0x02},
+		},
+	}
+
+	for _, tc := range testCases {
+		actual, err := Marshal(tc.value)
+		if err != nil {
+			t.Fatalf("Unexpected error for %s: %v", tc.name, err)
+		}
+		if !bytes.Equal(actual, tc.expected) {
+			t.Fatalf("Wrong encoding of %s. Expected % x, got % x", tc.name, tc.expected, actual)
+		}
+	}
+}
+
+func TestMarshalUnsupported(t *testing.T) {
+	for _, v := range []interface{}{map[int]int{1: 1}, make(chan int), []interface{}{func() {}}} {
+		if _, err := Marshal(v); err == nil {
+			t.Fatalf("Expected an error for %T", v)
+		}
+	}
+}
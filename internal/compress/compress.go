@@ -0,0 +1,86 @@
+// Package compress reads and writes compressed files transparently
+// gzip is detected by its magic bytes when reading and chosen by the .gz extension when writing
+package compress
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"os"
+	"strings"
+)
+
+// magic bytes of the compressed formats
+var (
+	gzipMagic = []byte{0x1f, 0x8b}
+	zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+)
+
+// ErrZstd is returned for zstd compressed data, there's no zstd decoder in the standard library
+var ErrZstd = errors.New("zstd compression is not supported, recompress with gzip")
+
+// Ext is the extension of the gzip compressed files
+const Ext = ".gz"
+
+// NewReader returns a reader of the data of r, decompressed if it's gzip compressed
+func NewReader(r io.Reader) (io.Reader, error) {
+	br := bufio.NewReader(r)
+	magic, err := br.Peek(len(zstdMagic))
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	switch {
+	case bytes.HasPrefix(magic, gzipMagic):
+		return gzip.NewReader(br)
+	case bytes.HasPrefix(magic, zstdMagic):
+		return nil, ErrZstd
+	}
+	return br, nil
+}
+
+// Decompress returns the data, decompressed if it's gzip compressed
+func Decompress(data []byte) ([]byte, error) {
+	switch {
+	case bytes.HasPrefix(data, gzipMagic):
+		zr, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		return io.ReadAll(zr)
+	case bytes.HasPrefix(data, zstdMagic):
+		return nil, ErrZstd
+	}
+	return data, nil
+}
+
+// ReadFile reads the file, decompressed if it's gzip compressed
+func ReadFile(name string) ([]byte, error) {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	return Decompress(data)
+}
+
+// Compress returns the data gzip compressed if the file name has the .gz extension, as is otherwise
+func Compress(name string, data []byte) ([]byte, error) {
+	if !strings.HasSuffix(name, Ext) {
+		return data, nil
+	}
+	buf := &bytes.Buffer{}
+	zw := gzip.NewWriter(buf)
+	if _, err := zw.Write(data); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// TrimExt returns the file name without its compression extension
+func TrimExt(name string) string {
+	return strings.TrimSuffix(name, Ext)
+}
@@ -0,0 +1,73 @@
+package compress
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRoundTrip(t *testing.T) {
+	data := []byte("#####\n#@ $#\n#####\n")
+	testCases := []struct {
+		name       string
+		compressed bool
+	}{
+		{name: "map.txt"},
+		{name: "map.txt.gz", compressed: true},
+		{name: "ckpt.json.gz", compressed: true},
+	}
+
+	for _, tc := range testCases {
+		c, err := Compress(tc.name, data)
+		if err != nil {
+			t.Fatalf("Unexpected error for %q: %v", tc.name, err)
+		}
+		if bytes.Equal(c, data) == tc.compressed {
+			t.Fatalf("Wrong compression for %q. Expected compressed %v", tc.name, tc.compressed)
+		}
+		file := filepath.Join(t.TempDir(), tc.name)
+		if err := os.WriteFile(file, c, 0644); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		actual, err := ReadFile(file)
+		if err != nil || !bytes.Equal(actual, data) {
+			t.Fatalf("Wrong data read from %q. Expected %q, got %q: %v", tc.name, data, actual, err)
+		}
+		r, err := NewReader(bytes.NewReader(c))
+		if err != nil {
+			t.Fatalf("Unexpected error for %q: %v", tc.name, err)
+		}
+		if actual, err := io.ReadAll(r); err != nil || !bytes.Equal(actual, data) {
+			t.Fatalf("Wrong data read from the reader of %q. Expected %q, got %q: %v", tc.name, data, actual, err)
+		}
+		if TrimExt(tc.name) != "map.txt" && TrimExt(tc.name) != "ckpt.json" {
+			t.Fatalf("Wrong name without extension for %q, got %q", tc.name, TrimExt(tc.name))
+		}
+	}
+}
+
+func TestUnsupported(t *testing.T) {
+	zstd := []byte{0x28, 0xb5, 0x2f, 0xfd, 0x00}
+	if _, err := Decompress(zstd); err != ErrZstd {
+		t.Fatalf("Wrong error. Expected %v, got %v", ErrZstd, err)
+	}
+	if _, err := NewReader(bytes.NewReader(zstd)); err != ErrZstd {
+		t.Fatalf("Wrong error. Expected %v, got %v", ErrZstd, err)
+	}
+	// short or empty data isn't compressed
+	for _, data := range [][]byte{{}, {0x1f}} {
+		r, err := NewReader(bytes.NewReader(data))
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if actual, _ := io.ReadAll(r); !bytes.Equal(actual, data) {
+			t.Fatalf("Wrong data. Expected %q, got %q", data, actual)
+		}
+	}
+	// truncated gzip
+	if _, err := Decompress([]byte{0x1f, 0x8b, 0x08}); err == nil {
+		t.Fatalf("Expected an error for truncated gzip data")
+	}
+}
@@ -0,0 +1,73 @@
+package fsm
+
+import "sort"
+
+// Annotations are the key/value pairs attached to the cells of a board, for what the tiles can't tell
+// like the links of the doors, the directions of the conveyors or the outputs of the analyses
+type Annotations map[Pair]map[string]string
+
+// Get returns the value of the key on the cell, empty if none
+func (a Annotations) Get(p Pair, key string) string {
+	return a[p][key]
+}
+
+// Set attaches the value of the key to the cell, an empty value removes the key
+func (a Annotations) Set(p Pair, key, value string) {
+	if value == "" {
+		delete(a[p], key)
+		if len(a[p]) == 0 {
+			delete(a, p)
+		}
+		return
+	}
+	if a[p] == nil {
+		a[p] = map[string]string{}
+	}
+	a[p][key] = value
+}
+
+// Cells returns the annotated cells sorted by row then by column
+func (a Annotations) Cells() []Pair {
+	cells := make([]Pair, 0, len(a))
+	for p := range a {
+		cells = append(cells, p)
+	}
+	sort.Slice(cells, func(i, j int) bool {
+		if cells[i].Y != cells[j].Y {
+			return cells[i].Y < cells[j].Y
+		}
+		return cells[i].X < cells[j].X
+	})
+	return cells
+}
+
+// Annotated is a board carrying annotations
+type Annotated interface {
+	Board
+	// Annotations returns the annotations of the cells, nil if none
+	Annotations() Annotations
+}
+
+// Annotate returns the board carrying the given annotations, its states are the ones of the given board
+func Annotate(board Board, a Annotations) Board {
+	return &annotatedBoard{Board: board, annotations: a}
+}
+
+// AnnotationsOf returns the annotations carried by the board, nil if none
+func AnnotationsOf(board Board) Annotations {
+	if ab, ok := board.(Annotated); ok {
+		return ab.Annotations()
+	}
+	return nil
+}
+
+// annotatedBoard is a board with annotations
+type annotatedBoard struct {
+	Board
+	annotations Annotations
+}
+
+// Annotations returns the annotations of the cells
+func (b *annotatedBoard) Annotations() Annotations {
+	return b.annotations
+}
@@ -0,0 +1,144 @@
+package fsm
+
+import (
+	"bender/grid"
+)
+
+// Board is a read-only view of the states of a machine
+type Board interface {
+	// Width returns the length of the longest row
+	Width() int
+	// Height returns the number of rows
+	Height() int
+	// At returns the state at the given coordinates, zero if out of the board
+	At(x, y int) byte
+}
+
+// WalkableCells returns the number of cells of the board Bender can enter: every cell but the unbreakable walls
+// and the holes of the short rows, the breakable walls count as they may be destroyed
+// it doesn't depend on the shape of the board, the frame and the interior walls are excluded alike
+func WalkableCells(board Board) int {
+	n := 0
+	for y := 0; y < board.Height(); y++ {
+		for x := 0; x < board.Width(); x++ {
+			if c := board.At(x, y); c != 0 && c != '#' {
+				n++
+			}
+		}
+	}
+	return n
+}
+
+// NewBoard returns an immutable board from the given map
+// the board can be shared by any number of machines, a character drawn with several bytes is a single cell, see Tile
+func NewBoard(plan []string) Board {
+	rows := make([][]byte, 0, len(plan))
+	for _, s := range plan {
+		rows = append(rows, decodeRow(s))
+	}
+	return &gridBoard{grid.FromRows(rows, grid.Frame)}
+}
+
+// gridBoard is a board stored in a grid of states
+type gridBoard struct {
+	g *grid.Grid[byte]
+}
+
+// Width returns the length of the longest row
+func (b *gridBoard) Width() int {
+	return b.g.Width()
+}
+
+// Height returns the number of rows
+func (b *gridBoard) Height() int {
+	return b.g.Height()
+}
+
+// At returns the state at the given coordinates, zero if out of the board
+func (b *gridBoard) At(x, y int) byte {
+	return b.g.At(grid.Point{X: x, Y: y})
+}
+
+// layered is a board with some states changed on top of another board
+// the board below is never modified
+type layered struct {
+	base    Board
+	changes map[Pair]byte
+}
+
+// Width returns the length of the longest row
+func (l *layered) Width() int {
+	return l.base.Width()
+}
+
+// Height returns the number of rows
+func (l *layered) Height() int {
+	return l.base.Height()
+}
+
+// At returns the state at the given coordinates, zero if out of the board
+func (l *layered) At(x, y int) byte {
+	if c, exist := l.changes[Pair{X: x, Y: y}]; exist {
+		return c
+	}
+	return l.base.At(x, y)
+}
+
+// Annotations returns the annotations of the board below
+func (l *layered) Annotations() Annotations {
+	return AnnotationsOf(l.base)
+}
+
+// boardRow returns the given row of the board
+func boardRow(b Board, y int) string {
+	row := make([]byte, 0, b.Width())
+	for x := 0; x < b.Width(); x++ {
+		c := b.At(x, y)
+		if c == 0 {
+			// end of a short row
+			break
+		}
+		row = append(row, c)
+	}
+	return string(row)
+}
+
+// Rows returns all the rows of the board, like the rows of its map
+func Rows(b Board) []string {
+	rows := make([]string, 0, b.Height())
+	for y := 0; y < b.Height(); y++ {
+		rows = append(rows, boardRow(b, y))
+	}
+	return rows
+}
+
+// boardView is the live read-only view of the states of a machine
+type boardView struct {
+	f *FSM
+}
+
+// Width returns the length of the longest row
+func (v boardView) Width() int {
+	if v.f.board == nil {
+		return 0
+	}
+	return v.f.board.Width()
+}
+
+// Height returns the number of rows
+func (v boardView) Height() int {
+	if v.f.board == nil {
+		return 0
+	}
+	return v.f.board.Height()
+}
+
+// At returns the state at the given coordinates, zero if out of the board
+func (v boardView) At(x, y int) byte {
+	return v.f.at(Pair{X: x, Y: y})
+}
+
+// Annotations returns the annotations of the board of the machine
+func (v boardView) Annotations() Annotations {
+	return AnnotationsOf(v.f.board)
+}
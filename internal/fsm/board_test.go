@@ -0,0 +1,166 @@
+package fsm
+
+import (
+	"testing"
+)
+
+func TestBoardView(t *testing.T) {
+	plan := []string{
+		"#####",
+		"#@X$#",
+		"####",
+	}
+	var seen Board
+	before := func(e *Event) {
+		seen = e.Board()
+		if e.Dst == 'X' {
+			e.ChangeDst(' ')
+		}
+	}
+	fsm, err := NewFSM(plan, before, func(e *Event) {})
+	if err != nil {
+		t.Fatalf("Failed to create the FSM: %v", err)
+	}
+	board := fsm.Board()
+	if board.Width() != 5 || board.Height() != 3 {
+		t.Fatalf("Wrong board size. Expected 5x3, got %dx%d", board.Width(), board.Height())
+	}
+	if c := board.At(4, 2); c != 0 {
+		t.Fatalf("Cell out of a short row must be zero, got %q", c)
+	}
+	if c := board.At(-1, 0); c != 0 {
+		t.Fatalf("Cell out of the board must be zero, got %q", c)
+	}
+
+	if err := fsm.Event(EAST); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if c := seen.At(2, 1); c != ' ' {
+		t.Fatalf("Callback view doesn't follow the changes. Expected ' ', got %q", c)
+	}
+	if c := board.At(2, 1); c != ' ' {
+		t.Fatalf("Board view doesn't follow the changes. Expected ' ', got %q", c)
+	}
+}
+
+func TestWalkableCells(t *testing.T) {
+	testCases := []struct {
+		name     string
+		plan     []string
+		expected int
+	}{
+		{
+			name:     "empty",
+			plan:     nil,
+			expected: 0,
+		},
+		{
+			name: "rectangle",
+			plan: []string{
+				"#####",
+				"#@  #",
+				"#  $#",
+				"#####",
+			},
+			expected: 6,
+		},
+		{
+			name: "interior walls",
+			plan: []string{
+				"######",
+				"#@ # #",
+				"# ## #",
+				"#   $#",
+				"######",
+			},
+			expected: 9,
+		},
+		{
+			name: "breakable walls",
+			plan: []string{
+				"#####",
+				"#@XX#",
+				"#XX$#",
+				"#####",
+			},
+			expected: 6,
+		},
+		{
+			name: "ragged rows",
+			plan: []string{
+				"####",
+				"#@ ####",
+				"#     #",
+				"#  $##",
+				"####",
+			},
+			expected: 10,
+		},
+		{
+			name: "no frame",
+			plan: []string{
+				"@ S",
+				"  $",
+			},
+			expected: 6,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := WalkableCells(NewBoard(tc.plan)); got != tc.expected {
+				t.Fatalf("Wrong number of walkable cells. Expected %d, got %d", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestAnnotations(t *testing.T) {
+	a := Annotations{}
+	a.Set(Pair{X: 3, Y: 1}, "door", "A")
+	a.Set(Pair{X: 2, Y: 1}, "door", "B")
+	a.Set(Pair{X: 2, Y: 1}, "conveyor", "EAST")
+	a.Set(Pair{X: 1, Y: 2}, "tmp", "1")
+	a.Set(Pair{X: 1, Y: 2}, "tmp", "")
+	if cells := a.Cells(); len(cells) != 2 || cells[0] != (Pair{X: 2, Y: 1}) || cells[1] != (Pair{X: 3, Y: 1}) {
+		t.Fatalf("Wrong annotated cells %v", cells)
+	}
+
+	plan := []string{
+		"######",
+		"#@X $#",
+		"######",
+	}
+	seen := map[Pair]string{}
+	before := func(e *Event) {
+		seen[e.DstPosition()] = e.Annotation("door")
+		if e.Dst == 'X' {
+			e.ChangeDst(' ')
+		}
+	}
+	fsm, err := NewFSMFromBoard(Annotate(NewBoard(plan), a), before, func(e *Event) {})
+	if err != nil {
+		t.Fatalf("Failed to create the FSM: %v", err)
+	}
+	for i := 0; i < 3; i++ {
+		if err := fsm.Event(EAST); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+	expected := map[Pair]string{{X: 2, Y: 1}: "B", {X: 3, Y: 1}: "A", {X: 4, Y: 1}: ""}
+	if len(seen) != len(expected) {
+		t.Fatalf("Wrong annotations seen by the callback. Expected %v, got %v", expected, seen)
+	}
+	for p, v := range expected {
+		if seen[p] != v {
+			t.Fatalf("Wrong annotation of %v. Expected %q, got %q", p, v, seen[p])
+		}
+	}
+	for _, b := range []Board{fsm.Board(), fsm.Snapshot()} {
+		if v := AnnotationsOf(b).Get(Pair{X: 2, Y: 1}, "conveyor"); v != "EAST" {
+			t.Fatalf("Wrong annotation of the board. Expected EAST, got %q", v)
+		}
+	}
+	if AnnotationsOf(NewBoard(plan)) != nil {
+		t.Fatalf("Unexpected annotations of a plain board")
+	}
+}
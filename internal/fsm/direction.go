@@ -0,0 +1,73 @@
+package fsm
+
+import (
+	"fmt"
+)
+
+// Direction is a cardinal direction, the event of the transitions of the machine
+type Direction string
+
+const (
+	// SOUTH direction
+	SOUTH Direction = "SOUTH"
+	// NORTH direction
+	NORTH Direction = "NORTH"
+	// EAST direction
+	EAST Direction = "EAST"
+	// WEST direction
+	WEST Direction = "WEST"
+)
+
+// Directions are the directions in the order of the priorities of Bender
+var Directions = []Direction{SOUTH, EAST, NORTH, WEST}
+
+// move is a transition of the machine
+type move struct {
+	dir      Direction
+	offset   Pair
+	opposite Direction
+}
+
+// moves are the transitions indexed by the first letter of their direction
+// a single comparison resolves the direction without branching on every one
+var moves = func() (m [256]move) {
+	for _, mv := range []move{
+		{SOUTH, Pair{X: 0, Y: 1}, NORTH},
+		{NORTH, Pair{X: 0, Y: -1}, SOUTH},
+		{EAST, Pair{X: 1, Y: 0}, WEST},
+		{WEST, Pair{X: -1, Y: 0}, EAST},
+	} {
+		m[mv.dir[0]] = mv
+	}
+	return m
+}()
+
+// ParseDirection returns the direction of the given name, like SOUTH
+// the error wraps ErrUnknownDirection for any other name, the typos included
+func ParseDirection(s string) (Direction, error) {
+	if d := Direction(s); d.Valid() {
+		return d, nil
+	}
+	return "", fmt.Errorf("%w %q", ErrUnknownDirection, s)
+}
+
+// Valid returns true if the direction is one of the four cardinal directions
+func (d Direction) Valid() bool {
+	return d != "" && moves[d[0]].dir == d
+}
+
+// Opposite returns the direction going back, empty if the direction isn't valid
+func (d Direction) Opposite() Direction {
+	if !d.Valid() {
+		return ""
+	}
+	return moves[d[0]].opposite
+}
+
+// Delta returns the offset of a step in the direction, zero if the direction isn't valid
+func (d Direction) Delta() Pair {
+	if !d.Valid() {
+		return Pair{}
+	}
+	return moves[d[0]].offset
+}
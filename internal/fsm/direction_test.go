@@ -0,0 +1,41 @@
+package fsm
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestDirection(t *testing.T) {
+	testCases := []struct {
+		name     string
+		valid    bool
+		opposite Direction
+		delta    Pair
+	}{
+		{name: "SOUTH", valid: true, opposite: NORTH, delta: Pair{X: 0, Y: 1}},
+		{name: "NORTH", valid: true, opposite: SOUTH, delta: Pair{X: 0, Y: -1}},
+		{name: "EAST", valid: true, opposite: WEST, delta: Pair{X: 1, Y: 0}},
+		{name: "WEST", valid: true, opposite: EAST, delta: Pair{X: -1, Y: 0}},
+		{name: "SOTUH"},
+		{name: "S"},
+		{name: "south"},
+		{name: ""},
+	}
+	for _, tc := range testCases {
+		d, err := ParseDirection(tc.name)
+		if (err == nil) != tc.valid || (err != nil && !errors.Is(err, ErrUnknownDirection)) {
+			t.Fatalf("Wrong error for %q. Expected valid %v, got %v", tc.name, tc.valid, err)
+		}
+		if tc.valid && d != Direction(tc.name) {
+			t.Fatalf("Wrong direction. Expected %s, got %s", tc.name, d)
+		}
+		d = Direction(tc.name)
+		if d.Valid() != tc.valid || d.Opposite() != tc.opposite || d.Delta() != tc.delta {
+			t.Fatalf("Wrong direction %q. Expected valid %v, opposite %q and delta %v, got %v, %q and %v",
+				tc.name, tc.valid, tc.opposite, tc.delta, d.Valid(), d.Opposite(), d.Delta())
+		}
+		if tc.valid && d.Delta().Add(d.Opposite().Delta()) != (Pair{}) {
+			t.Fatalf("Opposite of %s doesn't go back", d)
+		}
+	}
+}
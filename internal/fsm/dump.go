@@ -0,0 +1,23 @@
+package fsm
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DumpState returns a deterministic textual representation of the machine:
+// the board with the changes done by the callbacks, the position and the changes
+func (f *FSM) DumpState() string {
+	sb := &strings.Builder{}
+	fmt.Fprintln(sb, "board:")
+	for _, row := range Rows(f.Board()) {
+		fmt.Fprintf(sb, "  |%s|\n", row)
+	}
+	fmt.Fprintf(sb, "position: %s\n", f.curr)
+	fmt.Fprint(sb, "changes:")
+	for _, c := range f.changes {
+		fmt.Fprintf(sb, " %s", c)
+	}
+	fmt.Fprintln(sb)
+	return sb.String()
+}
@@ -0,0 +1,464 @@
+package fsm
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"bender/grid"
+)
+
+// errors of the maps and of the machines, the returned errors wrap them to be tested with errors.Is
+var (
+	// the map has no start @
+	ErrNoStart = errors.New("no start in the map")
+	// the teleports are not paired
+	ErrBadTeleports = errors.New("teleports badly setup")
+	// the map holds a tile which isn't one of the game
+	ErrInvalidSymbol = errors.New("invalid tile")
+	// a transition leads out of the board
+	ErrOutOfBounds = errors.New("out of the board")
+	// the event of a transition isn't a direction
+	ErrUnknownDirection = errors.New("unknown direction")
+	// no transition is kept to be undone
+	ErrNoUndo = errors.New("no transition to undo")
+)
+
+// Pair is a pair of coordinates
+type Pair = grid.Point
+
+// FSM is a 2D array Finite State Machine.
+// Each item in the array is a state.
+// Transitions between the states are the cardinal directions.
+// Example:
+// [1,1] SOUTH [1,2]
+// [1,1] NORTH [1,0]
+// [1,1] EAST  [2,1]
+// [1,1] WEST  [0,1]
+type FSM struct {
+	board          Board
+	overlay        map[Pair]byte
+	curr           Pair
+	teleports      []Pair
+	changes        []Change
+	steps          int
+	beforeCallback Callback
+	enterCallback  Callback
+	// tiles of the board other than the ones of the game, see NewFSM
+	tiles []byte
+	// callbacks registered per tile
+	handlers handlers
+	// middlewares around the callbacks
+	chain chain
+	// event passed to the callbacks, valid only during the callbacks
+	event Event
+	// channels receiving the events, not kept by the clones
+	subscribers subscribers
+	// positions before the last transitions, kept to undo them
+	undo undoHistory
+}
+
+// Change is a modification of a state done by a callback
+type Change struct {
+	// coordinates of the state
+	At Pair
+	// value of the state before and after the change
+	From, To byte
+	// number of the transition during which the change was done, starting from 1
+	Step int
+}
+
+// String formats the change as (x,y):'from'->'to'@step
+func (c Change) String() string {
+	return fmt.Sprintf("%s:%q->%q@%d", c.At, c.From, c.To, c.Step)
+}
+
+// NewFSM returns an instance of FSM from given map
+// before callback is called when the state is not yet entered
+// enter callback is called when the state is already entered
+// they handle the tiles without the callbacks registered with OnBefore and OnEnter
+// the given tiles are accepted on the map besides the ones of the game, like the new kinds of tiles given callbacks,
+// an error wrapping ErrInvalidSymbol is returned for the other tiles, another one if the teleports are badly setup
+func NewFSM(plan []string, beforeCB, enterCB Callback, tiles ...byte) (*FSM, error) {
+	return NewFSMFromBoard(NewBoard(plan), beforeCB, enterCB, tiles...)
+}
+
+// NewFSMFromBoard returns an instance of FSM from the given board
+// the board is never modified: the changes done by the callbacks are kept by the machine
+// so the same board can be used for many machines
+// the board is checked like the map of NewFSM
+func NewFSMFromBoard(board Board, beforeCB, enterCB Callback, tiles ...byte) (*FSM, error) {
+	if board == nil || board.Width() == 0 || board.Height() == 0 {
+		return nil, fmt.Errorf("empty map")
+	}
+	if err := checkTiles(board, tiles); err != nil {
+		return nil, err
+	}
+	if err := checkTeleports(board); err != nil {
+		return nil, err
+	}
+
+	start, tp, ok := scanBoard(board)
+	if !ok {
+		// Bender would start out of the map
+		return nil, ErrNoStart
+	}
+	return &FSM{
+		board:          board,
+		overlay:        map[Pair]byte{},
+		curr:           start,
+		teleports:      tp,
+		tiles:          append([]byte(nil), tiles...),
+		beforeCallback: beforeCB,
+		enterCallback:  enterCB,
+	}, nil
+}
+
+// scanBoard returns the start position and the teleports of the board, false if it has no start
+func scanBoard(board Board) (start Pair, tp []Pair, ok bool) {
+	tp = []Pair{}
+	for y := 0; y < board.Height(); y++ {
+		for x := 0; x < board.Width(); x++ {
+			if c := board.At(x, y); c == '@' {
+				start, ok = Pair{X: x, Y: y}, true
+			} else if IsTeleport(c) {
+				tp = append(tp, Pair{X: x, Y: y})
+			}
+		}
+	}
+	return start, tp, ok
+}
+
+// Rebase replaces the board below the changes done so far, the position is kept
+// the teleports are looked up on the new board, an error is returned if it holds unknown tiles
+// or if the teleports are badly setup
+func (f *FSM) Rebase(board Board) error {
+	if board == nil || board.Width() == 0 || board.Height() == 0 {
+		return fmt.Errorf("empty map")
+	}
+	if err := checkTiles(board, f.tiles); err != nil {
+		return err
+	}
+	if err := checkTeleports(board); err != nil {
+		return err
+	}
+	_, f.teleports, _ = scanBoard(board)
+	f.board = board
+	return nil
+}
+
+// Rewind undoes the transitions done after the given number of steps
+// the changes done after these steps are dropped and the position is set to the given one
+func (f *FSM) Rewind(steps int, curr Pair) {
+	n := 0
+	for n < len(f.changes) && f.changes[n].Step <= steps {
+		n++
+	}
+	f.changes = f.changes[:n]
+	f.overlay = make(map[Pair]byte, n)
+	for _, c := range f.changes {
+		f.overlay[c.At] = c.To
+	}
+	f.undo.drop(f.steps - steps)
+	f.steps = steps
+	f.curr = curr
+}
+
+// at returns the state at the given coordinates including the changes
+func (f *FSM) at(p Pair) byte {
+	if f.board == nil {
+		return 0
+	}
+	if len(f.overlay) > 0 {
+		if c, exist := f.overlay[p]; exist {
+			return c
+		}
+	}
+	return f.board.At(p.X, p.Y)
+}
+
+// Event changes the state according to the direction given
+// runs the before and enter callbacks passing the given arguments to them
+func (f *FSM) Event(evt Direction, args ...interface{}) error {
+	if f.board == nil {
+		return fmt.Errorf("machine has no board")
+	}
+
+	if !evt.Valid() {
+		return fmt.Errorf("%w %q", ErrUnknownDirection, string(evt))
+	}
+	dst := f.curr.Add(evt.Delta())
+
+	c := f.at(dst)
+	if c == 0 {
+		return &OutOfBoardError{Event: evt, From: f.curr, To: dst}
+	}
+
+	// the event is reused to avoid an allocation per transition
+	e := &f.event
+	*e = Event{
+		fsm:       f,
+		Event:     evt,
+		Dst:       c,
+		dstC:      dst,
+		Src:       f.at(f.curr),
+		srcC:      f.curr,
+		StepIndex: f.steps + 1,
+		Args:      args,
+	}
+	if len(f.chain.middlewares) > 0 || f.subscribed() {
+		// the clock isn't read by the transitions nobody observes
+		e.Time = time.Now()
+	}
+
+	if cb := f.beforeHandler(c); cb != nil {
+		cb(e)
+	}
+	if e.err != nil {
+		return e.err
+	}
+	if e.Cancelled {
+		// don't enter the state
+		f.publish(e)
+		return nil
+	}
+	f.undo.push(f.curr)
+	f.curr = dst
+	f.steps++
+	e.entered = true
+	if cb := f.enterHandler(c); cb != nil {
+		cb(e)
+	}
+	f.publish(e)
+	return e.err
+}
+
+// Steps returns the number of the transitions done so far
+func (f *FSM) Steps() int {
+	return f.steps
+}
+
+// Changes returns the modifications of the states done by the callbacks, in order
+func (f *FSM) Changes() []Change {
+	return append([]Change(nil), f.changes...)
+}
+
+// Position returns the coordinates of the current state of the machine
+func (f *FSM) Position() Pair {
+	return f.curr
+}
+
+// SetState sets the current state of the machine
+func (f *FSM) SetState(p Pair) {
+	f.curr = p
+}
+
+// Board returns the read-only view of the states of the machine
+// the view follows the changes of the states
+func (f *FSM) Board() Board {
+	return boardView{f}
+}
+
+// Snapshot returns the board with the changes done so far
+// the snapshot is not affected by the next changes
+func (f *FSM) Snapshot() Board {
+	if len(f.overlay) == 0 {
+		return f.board
+	}
+	changes := make(map[Pair]byte, len(f.overlay))
+	for p, c := range f.overlay {
+		changes[p] = c
+	}
+	return &layered{base: f.board, changes: changes}
+}
+
+// Clone returns an independent copy of the machine
+// the board is shared as it's never modified, the callbacks are shared too
+func (f *FSM) Clone() *FSM {
+	if f == nil {
+		return nil
+	}
+	c := &FSM{
+		board:          f.board,
+		curr:           f.curr,
+		teleports:      append([]Pair(nil), f.teleports...),
+		changes:        append([]Change(nil), f.changes...),
+		steps:          f.steps,
+		tiles:          f.tiles,
+		beforeCallback: f.beforeCallback,
+		enterCallback:  f.enterCallback,
+		handlers:       f.handlers.clone(),
+		chain:          f.chain.clone(),
+		undo:           f.undo.clone(),
+	}
+	if len(f.overlay) > 0 {
+		c.overlay = make(map[Pair]byte, len(f.overlay))
+		for p, s := range f.overlay {
+			c.overlay[p] = s
+		}
+	}
+	return c
+}
+
+// TeleportDst gives the destination coordinates of the given teleport, the other teleport of the same label
+// an error is returned if the teleports are badly setup
+func (f *FSM) TeleportDst(ps Pair) (Pair, error) {
+	label := f.at(ps)
+	dst, n := ps, 0
+	for _, t := range f.teleports {
+		if f.at(t) != label {
+			continue
+		}
+		n++
+		if t != ps {
+			dst = t
+		}
+	}
+	if n != 2 {
+		return Pair{}, fmt.Errorf("%w: %d teleport(s) %q found", ErrBadTeleports, n, label)
+	}
+	return dst, nil
+}
+
+// OutOfBoardError is returned by Event when the destination is out of the board,
+// possible with the maps without frame or with holes
+type OutOfBoardError struct {
+	// event of the transition
+	Event Direction
+	// position of the machine and destination out of the board
+	From, To Pair
+}
+
+// Error formats the error with the destination
+func (e *OutOfBoardError) Error() string {
+	return fmt.Sprintf("unknown state %v", e.To)
+}
+
+// Unwrap returns ErrOutOfBounds
+func (e *OutOfBoardError) Unwrap() error {
+	return ErrOutOfBounds
+}
+
+// Callback type to handle state actions
+// the event is reused by the machine, it must not be retained after the callback returns
+type Callback func(e *Event)
+
+// Event represents the transition event
+type Event struct {
+	// pointer back to the finite state machine
+	// only accessible through the methods of the event
+	fsm *FSM
+	// name of the event (direction)
+	Event Direction
+	// destination state
+	Dst byte
+	// destination state's coordinates
+	dstC Pair
+	// source state, the state of the machine before the transition, and its coordinates
+	Src  byte
+	srcC Pair
+	// number of the transition of the event, starting from 1, it follows the steps of the machine:
+	// a cancelled event has the number of the next transition, like the event after it,
+	// and the numbers start again from the step restored by Undo or Rewind
+	StepIndex int
+	// time of the event, zero unless the machine has middlewares or subscribers
+	Time time.Time
+	// true if event was cancelled
+	Cancelled bool
+	// arguments for the callbacks
+	Args []interface{}
+	// error which aborted the event
+	err error
+	// true once the state is entered
+	entered bool
+}
+
+// Entered returns true once the state is entered, for the enter callbacks
+func (e *Event) Entered() bool {
+	return e.entered
+}
+
+// Cancel cancels the event.
+// Events cancelled before entering the state will not be entered.
+func (e *Event) Cancel() {
+	e.Cancelled = true
+}
+
+// ChangeDst sets the destination state with the given value
+func (e *Event) ChangeDst(dst byte) {
+	if e.fsm.overlay == nil {
+		e.fsm.overlay = map[Pair]byte{}
+	}
+	e.fsm.changes = append(e.fsm.changes, Change{At: e.dstC, From: e.Dst, To: dst, Step: e.StepIndex})
+	e.fsm.overlay[e.dstC] = dst
+}
+
+// Board returns the read-only view of the states of the machine
+func (e *Event) Board() Board {
+	return e.fsm.Board()
+}
+
+// Position returns the coordinates of the current state of the machine
+func (e *Event) Position() Pair {
+	return e.fsm.curr
+}
+
+// DstPosition returns the coordinates of the destination state of the event
+func (e *Event) DstPosition() Pair {
+	return e.dstC
+}
+
+// SrcPosition returns the coordinates of the source state of the event, where the machine was before it
+func (e *Event) SrcPosition() Pair {
+	return e.srcC
+}
+
+// TeleportDst gives the destination coordinates of the teleport being the destination of the event
+func (e *Event) TeleportDst() (Pair, error) {
+	return e.fsm.TeleportDst(e.dstC)
+}
+
+// Annotation returns the value of the key on the destination cell, empty if none
+func (e *Event) Annotation(key string) string {
+	return AnnotationsOf(e.fsm.board).Get(e.dstC, key)
+}
+
+// Abort stops the event, the error is returned by the machine
+func (e *Event) Abort(err error) {
+	e.err = err
+	e.Cancelled = true
+}
+
+// SetState sets the current state of the machine
+func (e *Event) SetState(p Pair) {
+	e.fsm.SetState(p)
+}
+
+// DstID returns the id of the destination state, its tile and its position
+func (e *Event) DstID() StateID {
+	return StateID{Tile: e.Dst, At: e.dstC}
+}
+
+// StateID identifies a state by its tile and its position, it's comparable so it's a map key without allocation
+type StateID struct {
+	Tile byte
+	At   Pair
+}
+
+// String returns the tile followed by the position, like "B(3,12)", it's parsed back by ParseStateID
+func (id StateID) String() string {
+	return string(id.Tile) + id.At.String()
+}
+
+// ParseStateID returns the state id formatted by String
+func ParseStateID(s string) (StateID, error) {
+	var id StateID
+	if len(s) == 0 {
+		return id, fmt.Errorf("empty state id")
+	}
+	id.Tile = s[0]
+	if n, err := fmt.Sscanf(s[1:], "(%d,%d)", &id.At.X, &id.At.Y); err != nil || n != 2 || id.String() != s {
+		return id, fmt.Errorf("malformed state id %q", s)
+	}
+	return id, nil
+}
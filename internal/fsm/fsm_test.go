@@ -0,0 +1,425 @@
+package fsm
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFSM(t *testing.T) {
+	testArg := []interface{}{
+		"argument",
+	}
+	testCases := []struct {
+		name                 string
+		plan                 []string
+		dirs                 []Direction
+		testCallbacks        testCallback
+		expectedBeforeEvents []Event
+		expectedEnterEvents  []Event
+	}{
+		{
+			name: "nominal",
+			plan: []string{
+				"#####",
+				"#$ X#",
+				"# @B#",
+				"#####",
+			},
+			testCallbacks: newCallbackRecorder(),
+			dirs: []Direction{
+				EAST,
+				NORTH,
+				WEST,
+				WEST,
+			},
+			expectedBeforeEvents: []Event{
+				Event{Event: EAST, Dst: 'B', dstC: Pair{X: 3, Y: 2}, Args: testArg},
+				Event{Event: NORTH, Dst: 'X', dstC: Pair{X: 3, Y: 1}, Args: testArg},
+				Event{Event: WEST, Dst: ' ', dstC: Pair{X: 2, Y: 1}, Args: testArg},
+				Event{Event: WEST, Dst: '$', dstC: Pair{X: 1, Y: 1}, Args: testArg},
+			},
+			expectedEnterEvents: []Event{
+				Event{Event: EAST, Dst: 'B', dstC: Pair{X: 3, Y: 2}, Args: testArg},
+				Event{Event: NORTH, Dst: 'X', dstC: Pair{X: 3, Y: 1}, Args: testArg},
+				Event{Event: WEST, Dst: ' ', dstC: Pair{X: 2, Y: 1}, Args: testArg},
+				Event{Event: WEST, Dst: '$', dstC: Pair{X: 1, Y: 1}, Args: testArg},
+			},
+		},
+		{
+			name: "cancelled",
+			plan: []string{
+				"#####",
+				"#$  #",
+				"#@ X#",
+				"#####",
+			},
+			testCallbacks: newCallbackRecorderCancel(2),
+			dirs: []Direction{
+				EAST,
+				EAST,
+				NORTH,
+				WEST,
+			},
+			expectedBeforeEvents: []Event{
+				Event{Event: EAST, Dst: ' ', dstC: Pair{X: 2, Y: 2}, Args: testArg},
+				Event{Event: EAST, Dst: 'X', dstC: Pair{X: 3, Y: 2}, Args: testArg},
+				Event{Event: NORTH, Dst: ' ', dstC: Pair{X: 2, Y: 1}, Args: testArg},
+				Event{Event: WEST, Dst: '$', dstC: Pair{X: 1, Y: 1}, Args: testArg},
+			},
+			expectedEnterEvents: []Event{
+				Event{Event: EAST, Dst: ' ', dstC: Pair{X: 2, Y: 2}, Args: testArg},
+				Event{Event: NORTH, Dst: ' ', dstC: Pair{X: 2, Y: 1}, Args: testArg},
+				Event{Event: WEST, Dst: '$', dstC: Pair{X: 1, Y: 1}, Args: testArg},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			fsm, err := NewFSM(tc.plan, tc.testCallbacks.before, tc.testCallbacks.enter)
+			if err != nil {
+				t.Fatalf("Test case %q: unexpected error %v", tc.name, err)
+			}
+			for _, d := range tc.dirs {
+				fsm.Event(d, testArg...)
+			}
+
+			for i, act := range tc.testCallbacks.beforeStack() {
+				exp := tc.expectedBeforeEvents[i]
+				if !eventEqual(exp, act, fsm) {
+					t.Errorf("Test case %q: event %v doesn't match expected %v", tc.name, act, exp)
+				}
+			}
+			for i, act := range tc.testCallbacks.enterStack() {
+				exp := tc.expectedEnterEvents[i]
+				if !eventEqual(exp, act, fsm) {
+					t.Errorf("Test case %q: event %v doesn't match expected %v", tc.name, act, exp)
+				}
+			}
+		})
+	}
+}
+
+type testCallback interface {
+	before(*Event)
+	enter(*Event)
+	beforeStack() []Event
+	enterStack() []Event
+}
+
+type callbackRecorder struct {
+	bStack []Event
+	eStack []Event
+}
+
+func newCallbackRecorder() *callbackRecorder {
+	return &callbackRecorder{
+		bStack: []Event{},
+		eStack: []Event{},
+	}
+}
+
+func (c *callbackRecorder) before(e *Event) {
+	c.bStack = append(c.bStack, *e)
+}
+
+func (c *callbackRecorder) enter(e *Event) {
+	c.eStack = append(c.eStack, *e)
+}
+
+func (c *callbackRecorder) beforeStack() []Event {
+	return c.bStack
+}
+
+func (c *callbackRecorder) enterStack() []Event {
+	return c.eStack
+}
+
+type callbackRecorderCancel struct {
+	bStack    []Event
+	eStack    []Event
+	cancelIdx int
+	beforeCnt int
+}
+
+func newCallbackRecorderCancel(idx int) *callbackRecorderCancel {
+	return &callbackRecorderCancel{
+		bStack:    []Event{},
+		eStack:    []Event{},
+		cancelIdx: idx,
+	}
+}
+
+func (c *callbackRecorderCancel) before(e *Event) {
+	c.bStack = append(c.bStack, *e)
+	c.beforeCnt++
+	if c.cancelIdx == c.beforeCnt {
+		e.Cancel()
+	}
+}
+
+func (c *callbackRecorderCancel) enter(e *Event) {
+	c.eStack = append(c.eStack, *e)
+}
+
+func (c *callbackRecorderCancel) beforeStack() []Event {
+	return c.bStack
+}
+
+func (c *callbackRecorderCancel) enterStack() []Event {
+	return c.eStack
+}
+
+func eventEqual(exp, act Event, fsm *FSM) bool {
+	if act.fsm != fsm {
+		return false
+	}
+	if exp.Event != act.Event {
+		return false
+	}
+	if exp.Dst != act.Dst {
+		return false
+	}
+	if exp.dstC != act.dstC {
+		return false
+	}
+	if !reflect.DeepEqual(exp.Args, act.Args) {
+		return false
+	}
+	return true
+}
+
+func TestStateID(t *testing.T) {
+	// the ids of (1,12) and (11,2) collided when the coordinates were concatenated
+	a := StateID{Tile: ' ', At: Pair{X: 1, Y: 12}}
+	b := StateID{Tile: ' ', At: Pair{X: 11, Y: 2}}
+	if a == b || a.String() == b.String() {
+		t.Fatalf("Colliding ids %q and %q", a, b)
+	}
+	for _, id := range []StateID{a, b, {Tile: 'B', At: Pair{X: 3, Y: 2}}, {Tile: '#', At: Pair{X: -1, Y: 0}}} {
+		parsed, err := ParseStateID(id.String())
+		if err != nil {
+			t.Fatalf("Unexpected error for %q: %v", id, err)
+		}
+		if parsed != id {
+			t.Fatalf("Wrong id. Expected %v, got %v", id, parsed)
+		}
+	}
+	for _, s := range []string{"", " ", " 112", "B(3,2", "B(3,2)x", "B( 3,2)"} {
+		if _, err := ParseStateID(s); err == nil {
+			t.Fatalf("Expected an error for %q", s)
+		}
+	}
+}
+
+func TestClone(t *testing.T) {
+	plan := []string{
+		"#####",
+		"#@X #",
+		"#####",
+	}
+	// the walls are destroyed
+	f, err := NewFSM(plan, func(e *Event) {
+		if e.Dst == 'X' {
+			e.ChangeDst(' ')
+		}
+	}, nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	c := f.Clone()
+	if err := c.Event(EAST); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if c.Position() != (Pair{X: 2, Y: 1}) || c.Steps() != 1 || c.Snapshot().At(2, 1) != ' ' {
+		t.Fatalf("Wrong clone after a step: at %v after %d steps", c.Position(), c.Steps())
+	}
+	// the branch doesn't change the original
+	if f.Position() != (Pair{X: 1, Y: 1}) || f.Steps() != 0 || len(f.Changes()) != 0 || f.Snapshot().At(2, 1) != 'X' {
+		t.Fatalf("Original changed by its clone: at %v after %d steps, changes %v", f.Position(), f.Steps(), f.Changes())
+	}
+	if err := f.Event(EAST); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(c.Changes()) != 1 || len(f.Changes()) != 1 {
+		t.Fatalf("Wrong changes. Expected one change each, got %v and %v", c.Changes(), f.Changes())
+	}
+}
+
+func TestHandlers(t *testing.T) {
+	plan := []string{
+		"######",
+		"#@ X #",
+		"######",
+	}
+	var entered []byte
+	// only the walls block the machine by default
+	f, err := NewFSM(plan, func(e *Event) {
+		if e.Dst == '#' {
+			e.Cancel()
+		}
+	}, func(e *Event) {
+		entered = append(entered, e.Dst)
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	f.OnBefore('X', func(e *Event) {
+		e.Cancel()
+	})
+	f.OnEnter(' ', func(e *Event) {
+		entered = append(entered, '.')
+	})
+	c := f.Clone()
+	for _, d := range []Direction{EAST, EAST} {
+		if err := f.Event(d); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+	if f.Position() != (Pair{X: 2, Y: 1}) || string(entered) != "." {
+		t.Fatalf("Wrong machine at %v, entered %q", f.Position(), entered)
+	}
+
+	// the clone keeps the handlers, removed they fall back to the callbacks of the machine
+	entered = nil
+	c.OnBefore('X', nil)
+	c.OnEnter(' ', nil)
+	for _, d := range []Direction{EAST, EAST} {
+		if err := c.Event(d); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+	if c.Position() != (Pair{X: 3, Y: 1}) || string(entered) != " X" {
+		t.Fatalf("Wrong clone at %v, entered %q", c.Position(), entered)
+	}
+	if err := f.Event(EAST); err != nil || f.Position() != (Pair{X: 2, Y: 1}) {
+		t.Fatalf("Original changed by its clone: at %v: %v", f.Position(), err)
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	plan := []string{
+		"######",
+		"#@ X #",
+		"######",
+	}
+	var calls []string
+	// log returns a middleware logging the events around the next callback
+	log := func(name string) Middleware {
+		return func(next Callback) Callback {
+			return func(e *Event) {
+				calls = append(calls, fmt.Sprintf("%s>%q:%v", name, e.Dst, e.Entered()))
+				next(e)
+				calls = append(calls, name+"<")
+			}
+		}
+	}
+	f, err := NewFSM(plan, nil, nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	f.Use(log("a"), log("b"))
+	// the walls are blocked by a middleware
+	f.Use(func(next Callback) Callback {
+		return func(e *Event) {
+			if e.Dst == 'X' {
+				e.Cancel()
+				return
+			}
+			next(e)
+		}
+	})
+	f.OnEnter(' ', func(e *Event) {
+		calls = append(calls, "enter")
+	})
+	for _, d := range []Direction{EAST, EAST} {
+		if err := f.Event(d); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+	expected := "a>' ':false b>' ':false b< a< a>' ':true b>' ':true enter b< a< a>'X':false b>'X':false b< a<"
+	if f.Position() != (Pair{X: 2, Y: 1}) || strings.Join(calls, " ") != expected {
+		t.Fatalf("Wrong calls at %v. Expected %q, got %q", f.Position(), expected, strings.Join(calls, " "))
+	}
+
+	// the middlewares are kept with new callbacks and by the clones
+	calls = nil
+	f.SetCallbacks(nil, func(e *Event) {
+		calls = append(calls, "callback")
+	})
+	c := f.Clone()
+	if err := c.Event(WEST); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected = "a>'@':false b>'@':false b< a< a>'@':true b>'@':true callback b< a<"
+	if strings.Join(calls, " ") != expected {
+		t.Fatalf("Wrong calls of the clone. Expected %q, got %q", expected, strings.Join(calls, " "))
+	}
+	calls = nil
+	f.OnEnter(' ', nil)
+	for _, d := range []Direction{WEST, EAST} {
+		if err := f.Event(d); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+	expected = "a>'@':false b>'@':false b< a< a>'@':true b>'@':true callback b< a< " +
+		"a>' ':false b>' ':false b< a< a>' ':true b>' ':true callback b< a<"
+	if strings.Join(calls, " ") != expected {
+		t.Fatalf("Wrong calls. Expected %q, got %q", expected, strings.Join(calls, " "))
+	}
+}
+
+func TestEventSource(t *testing.T) {
+	plan := []string{
+		"#####",
+		"#@ ##",
+		"#####",
+	}
+	type source struct {
+		src       byte
+		at        Pair
+		stepIndex int
+	}
+	var sources []source
+	timed := false
+	record := func(e *Event) {
+		if e.Time.IsZero() == timed {
+			t.Fatalf("Wrong time of the event %v. Expected a time %v, got %v", e.Event, timed, e.Time)
+		}
+		sources = append(sources, source{src: e.Src, at: e.SrcPosition(), stepIndex: e.StepIndex})
+	}
+	f, err := NewFSM(plan, func(e *Event) {
+		if e.Dst == '#' {
+			e.Cancel()
+			record(e)
+		}
+	}, record)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	for _, d := range []Direction{EAST, EAST, WEST} {
+		if err := f.Event(d); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+	// the events are timed once they're observed
+	f.Use(func(next Callback) Callback { return next })
+	timed = true
+	f.Rewind(0, Pair{X: 1, Y: 1})
+	if err := f.Event(EAST); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected := []source{
+		{src: '@', at: Pair{X: 1, Y: 1}, stepIndex: 1},
+		// the cancelled event has the number of the next transition
+		{src: ' ', at: Pair{X: 2, Y: 1}, stepIndex: 2},
+		{src: ' ', at: Pair{X: 2, Y: 1}, stepIndex: 2},
+		// the rewound steps are numbered again
+		{src: '@', at: Pair{X: 1, Y: 1}, stepIndex: 1},
+	}
+	if !reflect.DeepEqual(sources, expected) {
+		t.Fatalf("Wrong sources. Expected %v, got %v", expected, sources)
+	}
+}
@@ -0,0 +1,78 @@
+package fsm
+
+import (
+	"unicode/utf8"
+)
+
+// variationSelector follows some emoji to draw them in color, it's not a cell
+const variationSelector = '\ufe0f'
+
+// unknownTile is the state of the cells drawn with a character which isn't a tile, it's never valid
+const unknownTile = 0x7f
+
+// glyphTiles are the tiles drawn with the glyphs of the unicode and emoji themes of the renderers
+var glyphTiles = map[rune]byte{
+	'█': '#', '▒': 'X', '☻': '@', '▣': '$',
+	'↓': 'S', '↑': 'N', '→': 'E', '←': 'W',
+	'⇅': 'I', 'β': 'B', '◎': 'T',
+	'🧱': '#', '📦': 'X', '🤖': '@', '🚪': '$',
+	'👇': 'S', '👆': 'N', '👉': 'E', '👈': 'W',
+	'🔃': 'I', '🍺': 'B', '🌀': 'T',
+}
+
+// Tile returns the tile drawn by the given character: the ASCII characters are the tiles themselves,
+// the glyphs of the unicode and emoji themes are their tiles and the box drawing and block characters are walls
+// false if the character isn't a tile
+func Tile(r rune) (byte, bool) {
+	if r < utf8.RuneSelf {
+		c := byte(r)
+		return c, (packedCodes[c] != 0xff && packedCodes[c] != 0) || IsTeleport(c)
+	}
+	if c, exist := glyphTiles[r]; exist {
+		return c, true
+	}
+	if r >= '\u2500' && r <= '\u259f' {
+		// box drawing and block elements
+		return '#', true
+	}
+	return unknownTile, false
+}
+
+// decodeRow returns the states of the row, a cell per character whatever its number of bytes
+// the characters which aren't tiles keep their byte if ASCII, they're unknownTile otherwise
+func decodeRow(s string) []byte {
+	row := make([]byte, 0, len(s))
+	for _, r := range cells(s) {
+		c, _ := Tile(r)
+		row = append(row, c)
+	}
+	return row
+}
+
+// cells returns the characters of the row, a cell per character like decodeRow
+func cells(s string) []rune {
+	row := make([]rune, 0, len(s))
+	for _, r := range s {
+		if r != variationSelector {
+			row = append(row, r)
+		}
+	}
+	return row
+}
+
+// DecodePlan returns the map with its glyphs replaced by their tiles, see Tile
+// the characters which aren't tiles are kept so they're reported by Validate
+func DecodePlan(plan []string) []string {
+	decoded := make([]string, 0, len(plan))
+	for _, s := range plan {
+		row := make([]rune, 0, len(s))
+		for _, r := range cells(s) {
+			if c, ok := Tile(r); ok {
+				r = rune(c)
+			}
+			row = append(row, r)
+		}
+		decoded = append(decoded, string(row))
+	}
+	return decoded
+}
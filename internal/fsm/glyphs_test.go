@@ -0,0 +1,90 @@
+package fsm
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestTile(t *testing.T) {
+	testCases := []struct {
+		r        rune
+		expected byte
+		ok       bool
+	}{
+		{r: '#', expected: '#', ok: true},
+		{r: ' ', expected: ' ', ok: true},
+		{r: 'Z', expected: 'Z', ok: false},
+		{r: '┌', expected: '#', ok: true},
+		{r: '═', expected: '#', ok: true},
+		{r: '█', expected: '#', ok: true},
+		{r: '▒', expected: 'X', ok: true},
+		{r: '☻', expected: '@', ok: true},
+		{r: '🧱', expected: '#', ok: true},
+		{r: '🚪', expected: '$', ok: true},
+		{r: '🌀', expected: 'T', ok: true},
+		{r: '🐱', expected: unknownTile, ok: false},
+	}
+	for _, tc := range testCases {
+		if c, ok := Tile(tc.r); c != tc.expected || ok != tc.ok {
+			t.Fatalf("Wrong tile of %q. Expected %q %v, got %q %v", tc.r, tc.expected, tc.ok, c, ok)
+		}
+	}
+}
+
+func TestUnicodeMap(t *testing.T) {
+	plan := []string{
+		"┌────┐",
+		"│@ 🧱 │",
+		"│ ▒️ $│",
+		"└────┘",
+	}
+	expected := []string{
+		"######",
+		"#@ # #",
+		"# X $#",
+		"######",
+	}
+	if decoded := DecodePlan(plan); !reflect.DeepEqual(decoded, expected) {
+		t.Fatalf("Wrong decoded map. Expected %q, got %q", expected, decoded)
+	}
+	if rows := Rows(NewBoard(plan)); !reflect.DeepEqual(rows, expected) {
+		t.Fatalf("Wrong board. Expected %q, got %q", expected, rows)
+	}
+	packed, err := NewPackedBoard(plan)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if rows := Rows(packed); !reflect.DeepEqual(rows, expected) {
+		t.Fatalf("Wrong packed board. Expected %q, got %q", expected, rows)
+	}
+	if err := Validate(plan); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	var dst byte
+	f, err := NewFSM(plan, func(e *Event) { dst = e.Dst }, func(e *Event) {})
+	if err != nil {
+		t.Fatalf("Failed to create the FSM: %v", err)
+	}
+	if err := f.Event(EAST); err != nil || dst != ' ' || f.Position() != (Pair{X: 2, Y: 1}) {
+		t.Fatalf("Wrong step east. Expected ' ' at (2,1), got %q at %v: %v", dst, f.Position(), err)
+	}
+	if err := f.Event(EAST); err != nil || dst != '#' {
+		t.Fatalf("Wrong step east. Expected '#', got %q: %v", dst, err)
+	}
+
+	bad := []string{
+		"#####",
+		"#@🐱$#",
+		"#####",
+	}
+	err = Validate(bad)
+	var errs ParseErrors
+	if !errors.As(err, &errs) || len(errs) != 1 || errs[0].Col != 3 || errs[0].Line != bad[1] || !errors.Is(err, ErrInvalidSymbol) {
+		t.Fatalf("Wrong error of an unknown character. Expected 2:3: unknown tile '🐱', got %v", err)
+	}
+	if _, err := NewPackedBoard(bad); !errors.Is(err, ErrInvalidSymbol) {
+		t.Fatalf("Wrong error of an unknown character. Expected %v, got %v", ErrInvalidSymbol, err)
+	}
+}
@@ -0,0 +1,81 @@
+package fsm
+
+// handlers are the callbacks registered per tile, they replace the callbacks of the machine for their tile
+// so the callbacks given to NewFSM are the default handlers of the other tiles
+type handlers struct {
+	before, enter map[byte]Callback
+}
+
+// OnBefore registers the before callback of the given tile, nil removes it
+func (f *FSM) OnBefore(tile byte, cb Callback) {
+	f.handlers.before = register(f.handlers.before, tile, cb)
+	f.wrap()
+}
+
+// OnEnter registers the enter callback of the given tile, nil removes it
+// the callback is chosen by the tile before the changes done by the before callback
+func (f *FSM) OnEnter(tile byte, cb Callback) {
+	f.handlers.enter = register(f.handlers.enter, tile, cb)
+	f.wrap()
+}
+
+// Customized returns true if callbacks were registered per tile or middlewares were used,
+// the rules of the machine are then more than the callbacks given to NewFSM
+func (f *FSM) Customized() bool {
+	return len(f.handlers.before) > 0 || len(f.handlers.enter) > 0 || len(f.chain.middlewares) > 0
+}
+
+// register sets the callback of the tile in the given handlers, they are allocated on the first registration
+func register(m map[byte]Callback, tile byte, cb Callback) map[byte]Callback {
+	if cb == nil {
+		delete(m, tile)
+		return m
+	}
+	if m == nil {
+		m = map[byte]Callback{}
+	}
+	m[tile] = cb
+	return m
+}
+
+// beforeHandler returns the before callback of the tile, the one of the machine if none is registered,
+// wrapped with the middlewares
+func (f *FSM) beforeHandler(tile byte) Callback {
+	if len(f.chain.middlewares) > 0 {
+		if cb, ok := f.chain.beforeTiles[tile]; ok {
+			return cb
+		}
+		return f.chain.before
+	}
+	if cb, ok := f.handlers.before[tile]; ok {
+		return cb
+	}
+	return f.beforeCallback
+}
+
+// enterHandler returns the enter callback of the tile, the one of the machine if none is registered,
+// wrapped with the middlewares
+func (f *FSM) enterHandler(tile byte) Callback {
+	if len(f.chain.middlewares) > 0 {
+		if cb, ok := f.chain.enterTiles[tile]; ok {
+			return cb
+		}
+		return f.chain.enter
+	}
+	if cb, ok := f.handlers.enter[tile]; ok {
+		return cb
+	}
+	return f.enterCallback
+}
+
+// clone returns a copy of the handlers, the callbacks are shared
+func (h handlers) clone() handlers {
+	c := handlers{}
+	for tile, cb := range h.before {
+		c.before = register(c.before, tile, cb)
+	}
+	for tile, cb := range h.enter {
+		c.enter = register(c.enter, tile, cb)
+	}
+	return c
+}
@@ -0,0 +1,82 @@
+package fsm
+
+import (
+	"bytes"
+	"errors"
+
+	"bender/internal/mmap"
+)
+
+// MappedBoard is a board read from a map file mapped in memory, its states are the bytes of the file
+// it's meant for generated boards too large to be held as strings, only the offsets of the rows are in memory
+type MappedBoard struct {
+	file  *mmap.File
+	data  []byte
+	rows  []int
+	ends  []int
+	width int
+}
+
+// OpenMappedBoard maps the text map file in memory, one row per line
+// the file must not be modified while the board is used, nor be compressed
+func OpenMappedBoard(name string) (*MappedBoard, error) {
+	f, err := mmap.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	if bytes.HasPrefix(f.Data, []byte{0x1f, 0x8b}) {
+		f.Close()
+		return nil, errors.New(name + ": a compressed map can't be mapped in memory")
+	}
+	b := &MappedBoard{file: f, data: f.Data}
+	for start := 0; start < len(b.data); {
+		end := bytes.IndexByte(b.data[start:], '\n')
+		next := start + end + 1
+		if end < 0 {
+			end, next = len(b.data)-start, len(b.data)
+		}
+		end += start
+		if end > start && b.data[end-1] == '\r' {
+			end--
+		}
+		b.rows = append(b.rows, start)
+		b.ends = append(b.ends, end)
+		if end-start > b.width {
+			b.width = end - start
+		}
+		start = next
+	}
+	// the trailing blank lines aren't rows
+	for n := len(b.rows); n > 0 && len(bytes.TrimSpace(b.data[b.rows[n-1]:b.ends[n-1]])) == 0; n-- {
+		b.rows, b.ends = b.rows[:n-1], b.ends[:n-1]
+	}
+	return b, nil
+}
+
+// Width returns the length of the longest row
+func (b *MappedBoard) Width() int {
+	return b.width
+}
+
+// Height returns the number of rows
+func (b *MappedBoard) Height() int {
+	return len(b.rows)
+}
+
+// At returns the state at the given coordinates, zero if out of the board
+func (b *MappedBoard) At(x, y int) byte {
+	if y < 0 || y >= len(b.rows) || x < 0 {
+		return 0
+	}
+	i := b.rows[y] + x
+	if i >= b.ends[y] {
+		return 0
+	}
+	return b.data[i]
+}
+
+// Close releases the file, the board and the machines using it must not be used afterwards
+func (b *MappedBoard) Close() error {
+	b.data, b.rows, b.ends = nil, nil, nil
+	return b.file.Close()
+}
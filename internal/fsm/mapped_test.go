@@ -0,0 +1,64 @@
+package fsm
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMappedBoard(t *testing.T) {
+	plan := []string{
+		"#########",
+		"#@ SNEWI#",
+		"#BTXT $",
+		"#########",
+	}
+	testCases := []struct {
+		name string
+		data string
+	}{
+		{name: "lf", data: "#########\n#@ SNEWI#\n#BTXT $\n#########\n"},
+		{name: "crlf and blank lines", data: "#########\r\n#@ SNEWI#\r\n#BTXT $\r\n#########\r\n\r\n  \n"},
+		{name: "no final newline", data: "#########\n#@ SNEWI#\n#BTXT $\n#########"},
+	}
+	g := NewBoard(plan)
+	for _, tc := range testCases {
+		name := filepath.Join(t.TempDir(), "map.txt")
+		if err := os.WriteFile(name, []byte(tc.data), 0644); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		b, err := OpenMappedBoard(name)
+		if err != nil {
+			t.Fatalf("Test case %q: unexpected error: %v", tc.name, err)
+		}
+		if b.Width() != g.Width() || b.Height() != g.Height() {
+			t.Fatalf("Test case %q: wrong board size. Expected %dx%d, got %dx%d", tc.name, g.Width(), g.Height(), b.Width(), b.Height())
+		}
+		for y := -1; y <= g.Height(); y++ {
+			for x := -1; x <= g.Width(); x++ {
+				if b.At(x, y) != g.At(x, y) {
+					t.Fatalf("Test case %q: wrong state at %s. Expected %q, got %q", tc.name, Pair{X: x, Y: y}, g.At(x, y), b.At(x, y))
+				}
+			}
+		}
+
+		f, err := NewFSMFromBoard(b, nil, nil)
+		if err != nil {
+			t.Fatalf("Test case %q: unexpected error: %v", tc.name, err)
+		}
+		if f.Position() != (Pair{X: 1, Y: 1}) {
+			t.Fatalf("Test case %q: wrong start. Expected (1,1), got %s", tc.name, f.Position())
+		}
+		if err := b.Close(); err != nil {
+			t.Fatalf("Test case %q: unexpected error: %v", tc.name, err)
+		}
+	}
+
+	name := filepath.Join(t.TempDir(), "map.txt.gz")
+	if err := os.WriteFile(name, []byte{0x1f, 0x8b, 8, 0}, 0644); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, err := OpenMappedBoard(name); err == nil {
+		t.Fatalf("Expected an error for a compressed map")
+	}
+}
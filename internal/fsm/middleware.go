@@ -0,0 +1,72 @@
+package fsm
+
+// Middleware wraps the before and enter callbacks of a machine: it's given the next callback of the chain
+// and returns a callback free to inspect, modify or cancel the event before or after calling it.
+// Event.Entered tells the enter callbacks from the before ones
+type Middleware func(next Callback) Callback
+
+// chain is the middlewares of a machine and the callbacks wrapped by them,
+// they're wrapped once when the callbacks or the middlewares change so the transitions don't allocate
+type chain struct {
+	middlewares []Middleware
+	before      Callback
+	enter       Callback
+	// callbacks registered per tile
+	beforeTiles map[byte]Callback
+	enterTiles  map[byte]Callback
+}
+
+// Use adds middlewares around the callbacks of the machine, the ones registered per tile included
+// the first middleware used is the outermost, it sees the event first
+func (f *FSM) Use(mws ...Middleware) {
+	for _, mw := range mws {
+		if mw != nil {
+			f.chain.middlewares = append(f.chain.middlewares, mw)
+		}
+	}
+	f.wrap()
+}
+
+// wrap wraps the callbacks of the machine with its middlewares
+func (f *FSM) wrap() {
+	c := &f.chain
+	if len(c.middlewares) == 0 {
+		return
+	}
+	c.before = c.wrap(f.beforeCallback)
+	c.enter = c.wrap(f.enterCallback)
+	c.beforeTiles, c.enterTiles = nil, nil
+	for tile, cb := range f.handlers.before {
+		c.beforeTiles = register(c.beforeTiles, tile, c.wrap(cb))
+	}
+	for tile, cb := range f.handlers.enter {
+		c.enterTiles = register(c.enterTiles, tile, c.wrap(cb))
+	}
+}
+
+// wrap returns the callback wrapped with the middlewares, a missing callback is wrapped too
+func (c *chain) wrap(cb Callback) Callback {
+	if cb == nil {
+		cb = func(*Event) {}
+	}
+	for i := len(c.middlewares) - 1; i >= 0; i-- {
+		cb = c.middlewares[i](cb)
+	}
+	return cb
+}
+
+// clone returns a copy of the chain, the wrapped callbacks are shared
+func (c chain) clone() chain {
+	cc := chain{
+		middlewares: append([]Middleware(nil), c.middlewares...),
+		before:      c.before,
+		enter:       c.enter,
+	}
+	for tile, cb := range c.beforeTiles {
+		cc.beforeTiles = register(cc.beforeTiles, tile, cb)
+	}
+	for tile, cb := range c.enterTiles {
+		cc.enterTiles = register(cc.enterTiles, tile, cb)
+	}
+	return cc
+}
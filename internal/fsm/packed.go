@@ -0,0 +1,84 @@
+package fsm
+
+import (
+	"fmt"
+)
+
+// packedTiles are the tiles a packed board can store, the index of a tile is its code
+// the code 0 is the missing state of the short rows
+const packedTiles = "\x00 #X@$SNEWIBT"
+
+// packedCodes maps the tiles to their codes, invalid tiles are mapped to 0xff
+var packedCodes = func() (codes [256]byte) {
+	for i := range codes {
+		codes[i] = 0xff
+	}
+	for i := 0; i < len(packedTiles); i++ {
+		codes[packedTiles[i]] = byte(i)
+	}
+	return codes
+}()
+
+// packed is a board storing the states in 4 bits
+// two cells are packed in a byte: the even cell in the low nibble, the odd one in the high nibble
+type packed struct {
+	cells         []byte
+	width, height int
+}
+
+// NewPackedBoard returns an immutable board using half of the memory of NewBoard
+// the map can contain only the tiles of the game but the labelled teleports 1 to 9,
+// all the other tiles are reported as ParseErrors
+func NewPackedBoard(plan []string) (Board, error) {
+	p := &packed{height: len(plan)}
+	rows := make([][]rune, 0, len(plan))
+	for _, s := range plan {
+		row := cells(s)
+		if len(row) > p.width {
+			p.width = len(row)
+		}
+		rows = append(rows, row)
+	}
+	p.cells = make([]byte, (p.width*p.height+1)/2)
+
+	errs := ParseErrors{}
+	for y, s := range plan {
+		for x, r := range rows[y] {
+			c, ok := Tile(r)
+			if !ok {
+				errs = append(errs, &ParseError{Row: y + 1, Col: x + 1, Line: s, Msg: fmt.Sprintf("unknown tile %q", r), Format: "unknown tile %q", Args: []interface{}{r}, Kind: ErrInvalidSymbol})
+				continue
+			}
+			code := packedCodes[c]
+			if code == 0xff {
+				errs = append(errs, &ParseError{Row: y + 1, Col: x + 1, Line: s, Msg: fmt.Sprintf("labelled teleport %q can't be packed", r), Format: "labelled teleport %q can't be packed", Args: []interface{}{r}, Kind: ErrInvalidSymbol})
+				continue
+			}
+			i := y*p.width + x
+			p.cells[i/2] |= code << (4 * uint(i%2))
+		}
+	}
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	return p, nil
+}
+
+// Width returns the length of the longest row
+func (p *packed) Width() int {
+	return p.width
+}
+
+// Height returns the number of rows
+func (p *packed) Height() int {
+	return p.height
+}
+
+// At returns the state at the given coordinates, zero if out of the board
+func (p *packed) At(x, y int) byte {
+	if x < 0 || x >= p.width || y < 0 || y >= p.height {
+		return 0
+	}
+	i := y*p.width + x
+	return packedTiles[(p.cells[i/2]>>(4*uint(i%2)))&0xf]
+}
@@ -0,0 +1,112 @@
+package fsm
+
+import (
+	"errors"
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+func TestPackedBoard(t *testing.T) {
+	plan := []string{
+		"#########",
+		"#@ SNEWI#",
+		"#BTXT $",
+		"#########",
+	}
+	p, err := NewPackedBoard(plan)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	g := NewBoard(plan)
+	if p.Width() != g.Width() || p.Height() != g.Height() {
+		t.Fatalf("Wrong board size. Expected %dx%d, got %dx%d", g.Width(), g.Height(), p.Width(), p.Height())
+	}
+	for y := -1; y <= g.Height(); y++ {
+		for x := -1; x <= g.Width(); x++ {
+			if p.At(x, y) != g.At(x, y) {
+				t.Fatalf("Wrong state at %s. Expected %q, got %q", Pair{X: x, Y: y}, g.At(x, y), p.At(x, y))
+			}
+		}
+	}
+	if !reflect.DeepEqual(Rows(p), plan) {
+		t.Fatalf("Wrong rows. Expected %q, got %q", plan, Rows(p))
+	}
+
+	fsm, err := NewFSMFromBoard(p, nil, nil)
+	if err != nil {
+		t.Fatalf("Failed to create the FSM: %v", err)
+	}
+	if err := fsm.Event(EAST); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	_, err = NewPackedBoard([]string{"###", "#?@", "##\x00"})
+	var perrs ParseErrors
+	if !errors.As(err, &perrs) || len(perrs) != 2 {
+		t.Fatalf("Expected 2 parse errors, got %v", err)
+	}
+	if perrs[0].Error() != `2:2: unknown tile '?'` {
+		t.Fatalf("Wrong error, got %q", perrs[0].Error())
+	}
+}
+
+// largePlan returns a generated map of the given size
+func largePlan(width, height int) []string {
+	rnd := rand.New(rand.NewSource(42))
+	tiles := "    #X"
+	plan := make([]string, height)
+	for y := range plan {
+		row := make([]byte, width)
+		for x := range row {
+			row[x] = tiles[rnd.Intn(len(tiles))]
+		}
+		plan[y] = string(row)
+	}
+	return plan
+}
+
+// benchmarkScan reads all the states of the board
+func benchmarkScan(b *testing.B, board Board) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		n := 0
+		for y := 0; y < board.Height(); y++ {
+			for x := 0; x < board.Width(); x++ {
+				if board.At(x, y) == '#' {
+					n++
+				}
+			}
+		}
+	}
+}
+
+func BenchmarkBoardScan(b *testing.B) {
+	plan := largePlan(2000, 2000)
+	b.Run("grid", func(b *testing.B) {
+		benchmarkScan(b, NewBoard(plan))
+	})
+	b.Run("packed", func(b *testing.B) {
+		board, err := NewPackedBoard(plan)
+		if err != nil {
+			b.Fatalf("Unexpected error: %v", err)
+		}
+		benchmarkScan(b, board)
+	})
+}
+
+func BenchmarkNewBoard(b *testing.B) {
+	plan := largePlan(2000, 2000)
+	b.Run("grid", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			NewBoard(plan)
+		}
+	})
+	b.Run("packed", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			NewPackedBoard(plan)
+		}
+	})
+}
@@ -0,0 +1,119 @@
+package fsm
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+)
+
+// State is the serializable state of FSM
+// the callbacks are not part of the state
+type State struct {
+	// states including the changes done by the callbacks
+	States    []string `json:"states"`
+	Curr      [2]int   `json:"curr"`
+	Teleports [][2]int `json:"teleports"`
+	// changes done by the callbacks, in order
+	Changes []ChangeState `json:"changes,omitempty"`
+	Steps   int           `json:"steps"`
+}
+
+// ChangeState is the serializable change of a state
+type ChangeState struct {
+	At   [2]int `json:"at"`
+	From string `json:"from"`
+	To   string `json:"to"`
+	Step int    `json:"step"`
+}
+
+// State returns the serializable state of the machine
+func (f *FSM) State() *State {
+	s := &State{
+		States:    Rows(f.Board()),
+		Curr:      [2]int{f.curr.X, f.curr.Y},
+		Teleports: make([][2]int, 0, len(f.teleports)),
+	}
+	for _, t := range f.teleports {
+		s.Teleports = append(s.Teleports, [2]int{t.X, t.Y})
+	}
+	s.Steps = f.steps
+	for _, c := range f.changes {
+		s.Changes = append(s.Changes, ChangeState{At: [2]int{c.At.X, c.At.Y}, From: string(c.From), To: string(c.To), Step: c.Step})
+	}
+	return s
+}
+
+// setState restores the machine from the given state
+func (f *FSM) setState(s *State) {
+	f.board = NewBoard(s.States)
+	f.overlay = map[Pair]byte{}
+	f.curr = Pair{X: s.Curr[0], Y: s.Curr[1]}
+	f.teleports = make([]Pair, 0, len(s.Teleports))
+	for _, t := range s.Teleports {
+		f.teleports = append(f.teleports, Pair{X: t[0], Y: t[1]})
+	}
+	f.changes = nil
+	for _, c := range s.Changes {
+		f.changes = append(f.changes, Change{At: Pair{X: c.At[0], Y: c.At[1]}, From: firstByte(c.From), To: firstByte(c.To), Step: c.Step})
+	}
+	f.steps = s.Steps
+	// the board of the state holds the changes, the transitions before it can't be undone
+	f.undo.drop(f.undo.n)
+}
+
+// firstByte returns the first byte of the string, zero if empty
+func firstByte(s string) byte {
+	if s == "" {
+		return 0
+	}
+	return s[0]
+}
+
+// MarshalJSON encodes the whole state of the machine
+func (f *FSM) MarshalJSON() ([]byte, error) {
+	return json.Marshal(f.State())
+}
+
+// UnmarshalJSON restores the machine from its JSON encoding
+// the callbacks are kept: decode into a machine created by NewFSM
+// or set them with SetCallbacks
+func (f *FSM) UnmarshalJSON(data []byte) error {
+	s := &State{}
+	if err := json.Unmarshal(data, s); err != nil {
+		return err
+	}
+	f.setState(s)
+	return nil
+}
+
+// GobEncode encodes the whole state of the machine
+func (f *FSM) GobEncode() ([]byte, error) {
+	return gobEncode(f.State())
+}
+
+// GobDecode restores the machine from its gob encoding
+// the callbacks are kept like for UnmarshalJSON
+func (f *FSM) GobDecode(data []byte) error {
+	s := &State{}
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(s); err != nil {
+		return err
+	}
+	f.setState(s)
+	return nil
+}
+
+// SetCallbacks sets the before and enter callbacks of the machine, the middlewares are kept
+func (f *FSM) SetCallbacks(beforeCB, enterCB Callback) {
+	f.beforeCallback = beforeCB
+	f.enterCallback = enterCB
+	f.wrap()
+}
+
+// gobEncode encodes the given value with gob
+func gobEncode(v interface{}) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	if err := gob.NewEncoder(buf).Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
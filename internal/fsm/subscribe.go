@@ -0,0 +1,124 @@
+package fsm
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Notification is the detached copy of an event sent to the subscribers, once the callbacks are done
+// it holds values only, not the arguments of the callbacks which may point to the simulator:
+// it can be read from any goroutine while the machine keeps running
+type Notification struct {
+	// direction of the event
+	Event Direction
+	// source and destination states and their coordinates
+	Src, Dst byte
+	From, To Pair
+	// position of the machine once the callbacks are done, after a teleport for instance
+	Position Pair
+	// true if the event was cancelled
+	Cancelled bool
+	// number of the transition of the event, see Event.StepIndex
+	StepIndex int
+	// time of the event
+	Time time.Time
+	// error which aborted the event, nil if none
+	Err error
+}
+
+// subscriptionBuffer is the number of events a subscriber can lag behind before the machine waits for it
+const subscriptionBuffer = 64
+
+// subscription is a channel receiving the events of a machine
+type subscription struct {
+	ch chan Notification
+	// closed by Unsubscribe to release the machine waiting to send
+	done chan struct{}
+	// held while sending, so the channel isn't closed meanwhile
+	mu     sync.Mutex
+	closed bool
+}
+
+// subscribers are the subscriptions of a machine
+type subscribers struct {
+	mu   sync.Mutex
+	subs []*subscription
+	// number of subscriptions, read without the lock on every transition
+	n int32
+}
+
+// Subscribe returns a channel receiving a notification of every event of the machine once its callbacks are done,
+// the cancelled ones included, until Unsubscribe closes it
+// the machine waits for the subscribers lagging too much behind: the channel must be drained or unsubscribed
+func (f *FSM) Subscribe() <-chan Notification {
+	s := &subscription{ch: make(chan Notification, subscriptionBuffer), done: make(chan struct{})}
+	f.subscribers.mu.Lock()
+	defer f.subscribers.mu.Unlock()
+	f.subscribers.subs = append(f.subscribers.subs, s)
+	atomic.AddInt32(&f.subscribers.n, 1)
+	return s.ch
+}
+
+// Unsubscribe stops sending the events to the channel returned by Subscribe and closes it
+// the events already buffered are still received, it can be called from any goroutine
+func (f *FSM) Unsubscribe(ch <-chan Notification) {
+	f.subscribers.mu.Lock()
+	var s *subscription
+	for i, sub := range f.subscribers.subs {
+		if sub.ch == ch {
+			s = sub
+			// a new slice, the machine may be publishing to the old one
+			f.subscribers.subs = append(f.subscribers.subs[:i:i], f.subscribers.subs[i+1:]...)
+			atomic.AddInt32(&f.subscribers.n, -1)
+			break
+		}
+	}
+	f.subscribers.mu.Unlock()
+	if s == nil {
+		return
+	}
+	// release the machine if it's waiting for the subscriber
+	close(s.done)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	close(s.ch)
+}
+
+// subscribed returns true if the machine has subscribers
+func (f *FSM) subscribed() bool {
+	return atomic.LoadInt32(&f.subscribers.n) > 0
+}
+
+// publish sends the notification of the event to the subscribers
+func (f *FSM) publish(e *Event) {
+	if !f.subscribed() {
+		return
+	}
+	f.subscribers.mu.Lock()
+	subs := f.subscribers.subs
+	f.subscribers.mu.Unlock()
+	n := Notification{
+		Event:     e.Event,
+		Src:       e.Src,
+		Dst:       e.Dst,
+		From:      e.srcC,
+		To:        e.dstC,
+		Position:  f.curr,
+		Cancelled: e.Cancelled,
+		StepIndex: e.StepIndex,
+		Time:      e.Time,
+		Err:       e.err,
+	}
+	for _, s := range subs {
+		s.mu.Lock()
+		if !s.closed {
+			select {
+			case s.ch <- n:
+			case <-s.done:
+			}
+		}
+		s.mu.Unlock()
+	}
+}
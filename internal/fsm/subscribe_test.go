@@ -0,0 +1,125 @@
+package fsm
+
+import (
+	"testing"
+)
+
+func TestSubscribe(t *testing.T) {
+	plan := []string{
+		"#####",
+		"#@ X#",
+		"#####",
+	}
+	// X cancels the transitions
+	before := func(e *Event) {
+		if e.Dst == 'X' {
+			e.Cancelled = true
+		}
+	}
+	f, err := NewFSM(plan, before, nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	ch := f.Subscribe()
+	other := f.Subscribe()
+	for _, d := range []Direction{EAST, EAST, WEST} {
+		if err := f.Event(d); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+	f.Unsubscribe(ch)
+	f.Unsubscribe(ch)
+	if err := f.Event(EAST); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := []struct {
+		dir       Direction
+		dst       byte
+		cancelled bool
+	}{
+		{EAST, ' ', false},
+		{EAST, 'X', true},
+		{WEST, '@', false},
+	}
+	var got []Notification
+	for e := range ch {
+		got = append(got, e)
+	}
+	if len(got) != len(expected) {
+		t.Fatalf("Wrong number of events. Expected %d, got %d", len(expected), len(got))
+	}
+	for i, exp := range expected {
+		if got[i].Event != exp.dir || got[i].Dst != exp.dst || got[i].Cancelled != exp.cancelled {
+			t.Fatalf("Wrong event %d. Expected %v %q %v, got %v %q %v", i, exp.dir, exp.dst, exp.cancelled, got[i].Event, got[i].Dst, got[i].Cancelled)
+		}
+	}
+	// the other subscription still receives
+	if n := len(other); n != len(expected)+1 {
+		t.Fatalf("Wrong number of events. Expected %d, got %d", len(expected)+1, n)
+	}
+}
+
+func TestSubscribeRace(t *testing.T) {
+	f, err := NewFSM([]string{"####", "#@ #", "####"}, nil, nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	ch := f.Subscribe()
+	done := make(chan []Pair)
+	go func() {
+		// the notifications are read while the machine moves on, go test -race checks they're detached
+		var positions []Pair
+		for n := range ch {
+			positions = append(positions, n.Position)
+		}
+		done <- positions
+	}()
+	for i := 0; i < subscriptionBuffer*2; i++ {
+		d := EAST
+		if i%2 == 1 {
+			d = WEST
+		}
+		if err := f.Event(d, i); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+	f.Unsubscribe(ch)
+	positions := <-done
+	if len(positions) != subscriptionBuffer*2 || positions[0] != (Pair{X: 2, Y: 1}) || positions[1] != (Pair{X: 1, Y: 1}) {
+		t.Fatalf("Wrong positions %v", positions)
+	}
+}
+
+func TestUnsubscribeReleases(t *testing.T) {
+	f, err := NewFSM([]string{"####", "#@ #", "####"}, nil, nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	ch := f.Subscribe()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		// more events than the buffer, the machine waits for the subscriber until it unsubscribes
+		for i := 0; i < subscriptionBuffer*2; i++ {
+			d := EAST
+			if i%2 == 1 {
+				d = WEST
+			}
+			if err := f.Event(d); err != nil {
+				t.Errorf("Unexpected error: %v", err)
+				return
+			}
+		}
+	}()
+	<-ch
+	f.Unsubscribe(ch)
+	<-done
+	n := 0
+	for range ch {
+		n++
+	}
+	if n >= subscriptionBuffer*2 {
+		t.Fatalf("Wrong number of events. Expected less than %d, got %d", subscriptionBuffer*2, n)
+	}
+}
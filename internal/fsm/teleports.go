@@ -0,0 +1,53 @@
+package fsm
+
+// IsTeleport returns true if the given tile is a teleport: T or a labelled teleport 1 to 9,
+// the teleports are paired by their tile so a map has up to ten pairs
+func IsTeleport(c byte) bool {
+	return c == 'T' || (c >= '1' && c <= '9')
+}
+
+// teleportsByLabel returns the teleports of the board by tile and the tiles in the order of their first teleport
+func teleportsByLabel(board Board) (map[byte][]Pair, []byte) {
+	found := map[byte][]Pair{}
+	labels := []byte{}
+	for y := 0; y < board.Height(); y++ {
+		for x := 0; x < board.Width(); x++ {
+			c := board.At(x, y)
+			if !IsTeleport(c) {
+				continue
+			}
+			if _, exist := found[c]; !exist {
+				labels = append(labels, c)
+			}
+			found[c] = append(found[c], Pair{X: x, Y: y})
+		}
+	}
+	return found, labels
+}
+
+// TeleportPairs returns the pairs of teleports of the board in the order of their first teleport,
+// the teleports which aren't paired are left out
+func TeleportPairs(board Board) [][2]Pair {
+	found, labels := teleportsByLabel(board)
+	pairs := [][2]Pair{}
+	for _, l := range labels {
+		if len(found[l]) == 2 {
+			pairs = append(pairs, [2]Pair{found[l][0], found[l][1]})
+		}
+	}
+	return pairs
+}
+
+// PairedTeleport returns the teleport of the board paired with the one at the given position,
+// the position itself and false if there is none
+func PairedTeleport(board Board, at Pair) (Pair, bool) {
+	for _, p := range TeleportPairs(board) {
+		switch at {
+		case p[0]:
+			return p[1], true
+		case p[1]:
+			return p[0], true
+		}
+	}
+	return at, false
+}
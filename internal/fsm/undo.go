@@ -0,0 +1,66 @@
+package fsm
+
+// undoHistory is a ring of the positions before the last transitions
+type undoHistory struct {
+	from []Pair
+	// index of the oldest position and number of positions
+	start, n int
+}
+
+// SetUndoLimit keeps the given number of the last transitions to undo them, zero disables the history
+// the history is cleared, it's disabled by default
+func (f *FSM) SetUndoLimit(n int) {
+	if n < 0 {
+		n = 0
+	}
+	f.undo = undoHistory{from: make([]Pair, n)}
+}
+
+// Undoable returns the number of transitions which can be undone
+func (f *FSM) Undoable() int {
+	return f.undo.n
+}
+
+// Undo steps back before the last transition: the position, the number of steps and the changes done
+// by the callbacks during the transition, like the destroyed walls, are restored
+// the state kept by the callbacks isn't, ErrNoUndo is returned once the history is exhausted
+func (f *FSM) Undo() error {
+	if f.undo.n == 0 {
+		return ErrNoUndo
+	}
+	f.Rewind(f.steps-1, f.undo.last())
+	return nil
+}
+
+// push records the position before a transition, the oldest one is forgotten once the history is full
+func (h *undoHistory) push(p Pair) {
+	if len(h.from) == 0 {
+		return
+	}
+	if h.n < len(h.from) {
+		h.from[(h.start+h.n)%len(h.from)] = p
+		h.n++
+		return
+	}
+	h.from[h.start] = p
+	h.start = (h.start + 1) % len(h.from)
+}
+
+// last returns the position before the last transition, the history must not be empty
+func (h *undoHistory) last() Pair {
+	return h.from[(h.start+h.n-1)%len(h.from)]
+}
+
+// drop forgets the positions before the given number of last transitions, all of them if it's negative
+func (h *undoHistory) drop(n int) {
+	if n < 0 || n > h.n {
+		n = h.n
+	}
+	h.n -= n
+}
+
+// clone returns an independent copy of the history
+func (h undoHistory) clone() undoHistory {
+	h.from = append([]Pair(nil), h.from...)
+	return h
+}
@@ -0,0 +1,100 @@
+package fsm
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestUndo(t *testing.T) {
+	plan := []string{
+		"######",
+		"#@X T#",
+		"#T   #",
+		"######",
+	}
+	// the walls are destroyed and the teleports followed
+	f, err := NewFSM(plan, func(e *Event) {
+		if e.Dst == 'X' {
+			e.ChangeDst(' ')
+		}
+	}, func(e *Event) {
+		if e.Dst == 'T' {
+			dst, err := e.TeleportDst()
+			if err != nil {
+				e.Abort(err)
+				return
+			}
+			e.SetState(dst)
+		}
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := f.Undo(); !errors.Is(err, ErrNoUndo) {
+		t.Fatalf("Expected %v without history, got %v", ErrNoUndo, err)
+	}
+	f.SetUndoLimit(2)
+	for _, d := range []Direction{EAST, EAST, EAST} {
+		if err := f.Event(d); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+	if f.Position() != (Pair{X: 1, Y: 2}) || f.Undoable() != 2 {
+		t.Fatalf("Wrong machine at %v with %d undoable transitions", f.Position(), f.Undoable())
+	}
+
+	testCases := []struct {
+		at    Pair
+		steps int
+		tile  byte
+	}{
+		// back before the teleport
+		{at: Pair{X: 3, Y: 1}, steps: 2, tile: ' '},
+		// the wall destroyed by the first step is still destroyed
+		{at: Pair{X: 2, Y: 1}, steps: 1, tile: ' '},
+	}
+	for i, tc := range testCases {
+		if err := f.Undo(); err != nil {
+			t.Fatalf("Undo #%d: unexpected error: %v", i, err)
+		}
+		if f.Position() != tc.at || f.Steps() != tc.steps || f.Board().At(2, 1) != tc.tile {
+			t.Fatalf("Undo #%d: wrong machine. Expected %v after %d steps, got %v after %d steps", i, tc.at, tc.steps, f.Position(), f.Steps())
+		}
+	}
+	// the history holds the last two transitions
+	if err := f.Undo(); !errors.Is(err, ErrNoUndo) {
+		t.Fatalf("Expected %v once the history is exhausted, got %v", ErrNoUndo, err)
+	}
+
+	// the destroyed wall is restored
+	f.SetUndoLimit(1)
+	if err := f.Event(WEST); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := f.Undo(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if f.Position() != (Pair{X: 2, Y: 1}) || len(f.Changes()) != 1 {
+		t.Fatalf("Wrong machine at %v with changes %v", f.Position(), f.Changes())
+	}
+	f2, _ := NewFSM(plan, func(e *Event) {
+		if e.Dst == 'X' {
+			e.ChangeDst(' ')
+		}
+	}, nil)
+	f2.SetUndoLimit(1)
+	if err := f2.Event(EAST); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	c := f2.Clone()
+	if err := f2.Undo(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if f2.Position() != (Pair{X: 1, Y: 1}) || f2.Steps() != 0 || f2.Board().At(2, 1) != 'X' {
+		t.Fatalf("Wrong machine after the undo at %v after %d steps, tile %q", f2.Position(), f2.Steps(), f2.Board().At(2, 1))
+	}
+	// the clone keeps its own history
+	if c.Undoable() != 1 || c.Board().At(2, 1) != ' ' {
+		t.Fatalf("Wrong clone with %d undoable transitions", c.Undoable())
+	}
+}
@@ -0,0 +1,204 @@
+package fsm
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ParseError is an error found in a map at a given position
+type ParseError struct {
+	// name of the file the map was loaded from, empty if not loaded from disk
+	File string
+	// row and column of the offending cell, starting from 1
+	Row, Col int
+	// offending line of the map
+	Line string
+	// description of the error
+	Msg string
+	// format and arguments of the description, to translate it
+	Format string
+	Args   []interface{}
+	// sentinel error of the kind of the error, like ErrBadTeleports, nil if none
+	Kind error
+}
+
+// newParseError returns an error for the cell at the given coordinates of the board
+func newParseError(board Board, p Pair, format string, args ...interface{}) *ParseError {
+	return &ParseError{
+		Row:    p.Y + 1,
+		Col:    p.X + 1,
+		Line:   boardRow(board, p.Y),
+		Msg:    fmt.Sprintf(format, args...),
+		Format: format,
+		Args:   args,
+	}
+}
+
+// Error formats the error as file:row:col: message, or file: message for the errors of the whole map
+func (e *ParseError) Error() string {
+	return e.Localize(nil)
+}
+
+// Localize formats the error like Error with the description formatted by the given function,
+// like the Sprintf of a catalog of translations, the description is in English if nil
+func (e *ParseError) Localize(sprintf func(format string, args ...interface{}) string) string {
+	msg := e.Msg
+	if sprintf != nil && e.Format != "" {
+		msg = sprintf(e.Format, e.Args...)
+	}
+	if e.Row < 1 {
+		// error of the whole map
+		if e.File != "" {
+			return fmt.Sprintf("%s: %s", e.File, msg)
+		}
+		return msg
+	}
+	if e.File != "" {
+		return fmt.Sprintf("%s:%d:%d: %s", e.File, e.Row, e.Col, msg)
+	}
+	return fmt.Sprintf("%d:%d: %s", e.Row, e.Col, msg)
+}
+
+// Unwrap returns the sentinel error of the kind of the error
+func (e *ParseError) Unwrap() error {
+	return e.Kind
+}
+
+// Excerpt returns the offending line with a caret under the offending cell
+func (e *ParseError) Excerpt() string {
+	if e.Col < 1 {
+		return e.Line
+	}
+	return e.Line + "\n" + strings.Repeat(" ", e.Col-1) + "^"
+}
+
+// ParseErrors is the list of all the errors found in a map
+type ParseErrors []*ParseError
+
+// Error formats the errors one per line
+func (e ParseErrors) Error() string {
+	msgs := make([]string, 0, len(e))
+	for _, pe := range e {
+		msgs = append(msgs, pe.Error())
+	}
+	return strings.Join(msgs, "\n")
+}
+
+// Is returns true if any of the errors is the target
+func (e ParseErrors) Is(target error) bool {
+	for _, pe := range e {
+		if errors.Is(pe, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate checks that the map is a well formed puzzle: a single start @, at least a booth $,
+// no teleport or exactly two of each label, rectangular rows closed by a frame of walls and only the tiles of the game
+// all the errors are returned as ParseErrors, the ones of the whole map have no position
+func Validate(plan []string) error {
+	if len(plan) == 0 {
+		return errors.New("empty map")
+	}
+	board := NewBoard(plan)
+	width := len(cells(plan[0]))
+	errs := ParseErrors{}
+	starts, booths := []Pair{}, 0
+	for y, s := range plan {
+		row := cells(s)
+		if len(row) != width {
+			col := len(row)
+			if col > width {
+				col = width
+			}
+			errs = append(errs, newParseError(board, Pair{X: col, Y: y}, "row of %d tile(s), expected %d like the first row", len(row), width))
+		}
+		for x, r := range row {
+			c, ok := Tile(r)
+			if !ok {
+				pe := newParseError(board, Pair{X: x, Y: y}, "unknown tile %q", r)
+				pe.Kind = ErrInvalidSymbol
+				// the row of the board has no character for it
+				pe.Line = s
+				errs = append(errs, pe)
+				continue
+			}
+			if (y == 0 || y == len(plan)-1 || x == 0 || x == width-1) && c != '#' {
+				errs = append(errs, newParseError(board, Pair{X: x, Y: y}, "tile %q in the frame, expected a wall", c))
+			}
+			switch c {
+			case '@':
+				starts = append(starts, Pair{X: x, Y: y})
+			case '$':
+				booths++
+			}
+		}
+	}
+	if len(starts) == 0 {
+		errs = append(errs, &ParseError{Msg: "no start @ in the map", Format: "no start @ in the map", Kind: ErrNoStart})
+	}
+	if len(starts) > 1 {
+		for _, p := range starts {
+			errs = append(errs, newParseError(board, p, "start @ appears %d time(s), expected exactly 1", len(starts)))
+		}
+	}
+	if booths == 0 {
+		errs = append(errs, &ParseError{Msg: "no booth $ in the map", Format: "no booth $ in the map"})
+	}
+	var terrs ParseErrors
+	if errors.As(checkTeleports(board), &terrs) {
+		errs = append(errs, terrs...)
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// checkTiles verifies that the board holds only the tiles of the game and the given ones, the holes of the short rows aside
+func checkTiles(board Board, extra []byte) error {
+	var allowed [256]bool
+	for _, c := range extra {
+		allowed[c] = true
+	}
+	errs := ParseErrors{}
+	for y := 0; y < board.Height(); y++ {
+		for x := 0; x < board.Width(); x++ {
+			c := board.At(x, y)
+			if _, ok := Tile(rune(c)); ok || c == 0 || allowed[c] {
+				continue
+			}
+			pe := newParseError(board, Pair{X: x, Y: y}, "unknown tile %q", rune(c))
+			pe.Kind = ErrInvalidSymbol
+			errs = append(errs, pe)
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// checkTeleports verifies that every teleport label appears exactly twice on the board
+// every teleport of a bad pair is reported
+func checkTeleports(board Board) error {
+	found, labels := teleportsByLabel(board)
+
+	errs := ParseErrors{}
+	for _, l := range labels {
+		if len(found[l]) == 2 {
+			continue
+		}
+		for _, p := range found[l] {
+			pe := newParseError(board, p, "teleport %q appears %d time(s), expected exactly 2", l, len(found[l]))
+			pe.Kind = ErrBadTeleports
+			errs = append(errs, pe)
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
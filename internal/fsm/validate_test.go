@@ -0,0 +1,281 @@
+package fsm
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestTeleportPairs(t *testing.T) {
+	plan := []string{
+		"######",
+		"#@T1$#",
+		"#T 21#",
+		"#  23#",
+		"######",
+	}
+	board := NewBoard(plan)
+	pairs := TeleportPairs(board)
+	expected := [][2]Pair{
+		{{X: 2, Y: 1}, {X: 1, Y: 2}},
+		{{X: 3, Y: 1}, {X: 4, Y: 2}},
+		{{X: 3, Y: 2}, {X: 3, Y: 3}},
+	}
+	if fmt.Sprint(pairs) != fmt.Sprint(expected) {
+		t.Fatalf("Wrong pairs. Expected %v, got %v", expected, pairs)
+	}
+	if p, ok := PairedTeleport(board, Pair{X: 4, Y: 2}); !ok || p != (Pair{X: 3, Y: 1}) {
+		t.Fatalf("Wrong paired teleport. Expected %v, got %v", Pair{X: 3, Y: 1}, p)
+	}
+	// the lone teleport has no pair
+	if p, ok := PairedTeleport(board, Pair{X: 4, Y: 3}); ok {
+		t.Fatalf("Unexpected pair %v of a lone teleport", p)
+	}
+	if _, err := NewPackedBoard(plan); !errors.Is(err, ErrInvalidSymbol) {
+		t.Fatalf("Expected %v for the packed labelled teleports, got %v", ErrInvalidSymbol, err)
+	}
+}
+
+func TestCheckTeleports(t *testing.T) {
+	testCases := []struct {
+		name     string
+		plan     []string
+		expected string
+	}{
+		{
+			name: "no teleport",
+			plan: []string{
+				"#####",
+				"#@ $#",
+				"#####",
+			},
+		},
+		{
+			name: "pair",
+			plan: []string{
+				"#####",
+				"#@T$#",
+				"#T  #",
+				"#####",
+			},
+		},
+		{
+			name: "single",
+			plan: []string{
+				"#####",
+				"#@ $#",
+				"#  T#",
+				"#####",
+			},
+			expected: "3:4: teleport 'T' appears 1 time(s), expected exactly 2",
+		},
+		{
+			name: "triple",
+			plan: []string{
+				"#####",
+				"#@T$#",
+				"#T T#",
+				"#####",
+			},
+			expected: "2:3: teleport 'T' appears 3 time(s), expected exactly 2\n" +
+				"3:2: teleport 'T' appears 3 time(s), expected exactly 2\n" +
+				"3:4: teleport 'T' appears 3 time(s), expected exactly 2",
+		},
+		{
+			name: "labelled pairs",
+			plan: []string{
+				"######",
+				"#@T1$#",
+				"#T 21#",
+				"#  2 #",
+				"######",
+			},
+		},
+		{
+			name: "single labelled",
+			plan: []string{
+				"######",
+				"#@T1$#",
+				"#T 21#",
+				"#    #",
+				"######",
+			},
+			expected: "3:4: teleport '2' appears 1 time(s), expected exactly 2",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := checkTeleports(NewBoard(tc.plan))
+			if tc.expected == "" {
+				if err != nil {
+					t.Fatalf("Test case %q: unexpected error %v", tc.name, err)
+				}
+				return
+			}
+			if err == nil || err.Error() != tc.expected {
+				t.Fatalf("Test case %q: expected error %q, got %v", tc.name, tc.expected, err)
+			}
+			if _, err := NewFSM(tc.plan, nil, nil); err == nil {
+				t.Fatalf("Test case %q: FSM created from a bad plan", tc.name)
+			}
+		})
+	}
+}
+
+func TestParseError(t *testing.T) {
+	plan := []string{
+		"#####",
+		"#@ $#",
+		"#  T#",
+		"#####",
+	}
+	err := newParseError(NewBoard(plan), Pair{X: 3, Y: 2}, "teleport %q has no pair", 'T')
+	if err.Error() != "3:4: teleport 'T' has no pair" {
+		t.Fatalf("Wrong error message: %q", err.Error())
+	}
+	err.File = "map.txt"
+	if err.Error() != "map.txt:3:4: teleport 'T' has no pair" {
+		t.Fatalf("Wrong error message with file: %q", err.Error())
+	}
+	if err.Excerpt() != "#  T#\n   ^" {
+		t.Fatalf("Wrong excerpt:\n%s", err.Excerpt())
+	}
+	upper := func(format string, args ...interface{}) string {
+		return strings.ToUpper(fmt.Sprintf(format, args...))
+	}
+	if msg := err.Localize(upper); msg != "map.txt:3:4: TELEPORT 'T' HAS NO PAIR" {
+		t.Fatalf("Wrong localized error message: %q", msg)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	testCases := []struct {
+		name     string
+		plan     []string
+		expected string
+	}{
+		{
+			name: "valid",
+			plan: []string{
+				"######",
+				"#@T $#",
+				"#T XI#",
+				"######",
+			},
+		},
+		{
+			name:     "empty",
+			expected: "empty map",
+		},
+		{
+			name: "not rectangular",
+			plan: []string{
+				"#####",
+				"#@ $##",
+				"####",
+			},
+			expected: "2:6: row of 6 tile(s), expected 5 like the first row\n" +
+				"3:5: row of 4 tile(s), expected 5 like the first row",
+		},
+		{
+			name: "open frame",
+			plan: []string{
+				"#####",
+				"#@  $",
+				"## ##",
+			},
+			expected: "2:5: tile '$' in the frame, expected a wall\n" +
+				"3:3: tile ' ' in the frame, expected a wall",
+		},
+		{
+			name: "unknown tile",
+			plan: []string{
+				"#####",
+				"#@?$#",
+				"#####",
+			},
+			expected: "2:3: unknown tile '?'",
+		},
+		{
+			name: "two starts without booth",
+			plan: []string{
+				"#####",
+				"#@ @#",
+				"#####",
+			},
+			expected: "2:2: start @ appears 2 time(s), expected exactly 1\n" +
+				"2:4: start @ appears 2 time(s), expected exactly 1\n" +
+				"no booth $ in the map",
+		},
+		{
+			name: "no start and single teleport",
+			plan: []string{
+				"#####",
+				"# T$#",
+				"#####",
+			},
+			expected: "no start @ in the map\n" +
+				"2:3: teleport 'T' appears 1 time(s), expected exactly 2",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := Validate(tc.plan)
+			if tc.expected == "" {
+				if err != nil {
+					t.Fatalf("Unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != tc.expected {
+				t.Fatalf("Wrong error. Expected %q, got %v", tc.expected, err)
+			}
+		})
+	}
+}
+
+func TestSentinelErrors(t *testing.T) {
+	noop := func(e *Event) {}
+	_, err := NewFSM([]string{"####", "# $#", "####"}, noop, noop)
+	if !errors.Is(err, ErrNoStart) {
+		t.Fatalf("Expected ErrNoStart, got %v", err)
+	}
+	_, err = NewFSM([]string{"#####", "#@T$#", "#####"}, noop, noop)
+	if !errors.Is(err, ErrBadTeleports) {
+		t.Fatalf("Expected ErrBadTeleports, got %v", err)
+	}
+	_, err = NewPackedBoard([]string{"####", "#@?#", "####"})
+	if !errors.Is(err, ErrInvalidSymbol) {
+		t.Fatalf("Expected ErrInvalidSymbol, got %v", err)
+	}
+	if err := Validate([]string{"#####", "#@?$#", "#####"}); !errors.Is(err, ErrInvalidSymbol) || errors.Is(err, ErrNoStart) {
+		t.Fatalf("Expected ErrInvalidSymbol only, got %v", err)
+	}
+	// the machines reject the unknown tiles like Validate, unless they're given as new kinds of tiles
+	_, err = NewFSM([]string{"#####", "#@Z$#", "#####"}, noop, noop)
+	if !errors.Is(err, ErrInvalidSymbol) || err.Error() != "2:3: unknown tile 'Z'" {
+		t.Fatalf("Expected ErrInvalidSymbol at 2:3, got %v", err)
+	}
+	if _, err := NewFSM([]string{"#####", "#@Z$#", "#####"}, noop, noop, 'Z'); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, err := NewFSM([]string{"####", "#@🐱#", "####"}, noop, noop); !errors.Is(err, ErrInvalidSymbol) {
+		t.Fatalf("Expected ErrInvalidSymbol, got %v", err)
+	}
+
+	f, err := NewFSM([]string{"@ "}, noop, noop)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	err = f.Event(NORTH)
+	var oob *OutOfBoardError
+	if !errors.Is(err, ErrOutOfBounds) || !errors.As(err, &oob) {
+		t.Fatalf("Expected ErrOutOfBounds, got %v", err)
+	}
+	if _, err := f.TeleportDst(Pair{}); !errors.Is(err, ErrBadTeleports) {
+		t.Fatalf("Expected ErrBadTeleports, got %v", err)
+	}
+}
@@ -0,0 +1,132 @@
+// Package history keeps the metadata of the local runs in a directory, to browse, replay and compare them later
+package history
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Run is the metadata of a simulation
+// the simulations are deterministic, a run is simulated again from its map and its step limit
+type Run struct {
+	// number of the run in the history, starting from 1
+	ID   int       `json:"id"`
+	Time time.Time `json:"time"`
+	// simulated map
+	Plan []string `json:"plan"`
+	// step limit of the simulation, 0 if none
+	MaxSteps int `json:"max_steps,omitempty"`
+	// how the simulation ended and its number of steps
+	Outcome string `json:"outcome"`
+	Steps   int    `json:"steps"`
+	// replay recorded with the run, if any
+	Replay string `json:"replay,omitempty"`
+}
+
+// Store is a history of runs, a JSON file per run named after its id
+type Store struct {
+	dir string
+}
+
+// Open returns the history stored in the directory, the directory is created if needed
+func Open(dir string) (*Store, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	return &Store{dir: dir}, nil
+}
+
+// file returns the file of the run
+func (s *Store) file(id int) string {
+	return filepath.Join(s.dir, strconv.Itoa(id)+".json")
+}
+
+// Add stores the run with the next id, which is set in the run
+// concurrent runs get distinct ids as the files are created exclusively
+func (s *Store) Add(r *Run) error {
+	ids, err := s.ids()
+	if err != nil {
+		return err
+	}
+	id := 1
+	if len(ids) > 0 {
+		id = ids[len(ids)-1] + 1
+	}
+	for {
+		f, err := os.OpenFile(s.file(id), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+		if errors.Is(err, os.ErrExist) {
+			id++
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		r.ID = id
+		data, err := json.Marshal(r)
+		if err == nil {
+			_, err = f.Write(append(data, '\n'))
+		}
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+		return err
+	}
+}
+
+// Get returns the run of the given id
+func (s *Store) Get(id int) (Run, error) {
+	data, err := os.ReadFile(s.file(id))
+	if errors.Is(err, os.ErrNotExist) {
+		return Run{}, fmt.Errorf("no run %d in the history", id)
+	}
+	if err != nil {
+		return Run{}, err
+	}
+	var r Run
+	if err := json.Unmarshal(data, &r); err != nil {
+		return Run{}, fmt.Errorf("malformed run %d: %v", id, err)
+	}
+	return r, nil
+}
+
+// List returns the runs of the history by id
+func (s *Store) List() ([]Run, error) {
+	ids, err := s.ids()
+	if err != nil {
+		return nil, err
+	}
+	runs := make([]Run, 0, len(ids))
+	for _, id := range ids {
+		r, err := s.Get(id)
+		if err != nil {
+			return nil, err
+		}
+		runs = append(runs, r)
+	}
+	return runs, nil
+}
+
+// ids returns the sorted ids of the stored runs, the other files are ignored
+func (s *Store) ids() ([]int, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, err
+	}
+	ids := []int{}
+	for _, e := range entries {
+		id, err := strconv.Atoi(strings.TrimSuffix(e.Name(), ".json"))
+		if err != nil || e.IsDir() || !strings.HasSuffix(e.Name(), ".json") || id < 1 {
+			continue
+		}
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	return ids, nil
+}
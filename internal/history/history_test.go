@@ -0,0 +1,79 @@
+package history
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestStore(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "runs")
+	s, err := Open(dir)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	runs := []Run{
+		{Time: now, Plan: []string{"####", "#@$#", "####"}, Outcome: "reached", Steps: 1},
+		{Time: now, Plan: []string{"###", "#@#", "###"}, MaxSteps: 10, Outcome: "died", Replay: "run.bdr"},
+	}
+	for i := range runs {
+		if err := s.Add(&runs[i]); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if runs[i].ID != i+1 {
+			t.Fatalf("Wrong id. Expected %d, got %d", i+1, runs[i].ID)
+		}
+	}
+	// the other files are ignored
+	if err := os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("notes"), 0644); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	listed, err := s.List()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(listed, runs) {
+		t.Fatalf("Wrong runs. Expected %+v, got %+v", runs, listed)
+	}
+	if r, err := s.Get(2); err != nil || !reflect.DeepEqual(r, runs[1]) {
+		t.Fatalf("Wrong run 2 %+v: %v", r, err)
+	}
+	if _, err := s.Get(3); err == nil {
+		t.Fatalf("Expected an error for an unknown run")
+	}
+}
+
+func TestStoreConcurrent(t *testing.T) {
+	s, err := Open(t.TempDir())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	const n = 10
+	wg := sync.WaitGroup{}
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := s.Add(&Run{Outcome: "reached"}); err != nil {
+				t.Errorf("Unexpected error: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+	runs, err := s.List()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	for i, r := range runs {
+		if r.ID != i+1 {
+			t.Fatalf("Wrong ids %+v", runs)
+		}
+	}
+	if len(runs) != n {
+		t.Fatalf("Wrong number of runs. Expected %d, got %d", n, len(runs))
+	}
+}
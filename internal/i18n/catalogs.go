@@ -0,0 +1,39 @@
+package i18n
+
+// builtin are the catalogs shipped with bender, by locale
+var builtin = map[string]Catalog{
+	"fr": french,
+}
+
+// french is the French catalog
+var french = Catalog{
+	// narration of the steps
+	"Bender didn't move yet.": "Bender n'a pas encore bougé.",
+	"An obstacle blocked the way, Bender took %s, the first free direction of its priorities %s.": "Un obstacle bloquait le chemin, Bender a pris %s, la première direction libre de ses priorités %s.",
+	"Bender followed the path modifier to the %s.":                                                "Bender a suivi le modificateur de chemin vers %s.",
+	"Nothing blocked the way, Bender kept going %s.":                                              "Rien ne bloquait le chemin, Bender a continué vers %s.",
+	"In breaker mode, it destroyed the breakable wall.":                                           "En mode casseur, il a détruit le mur cassable.",
+	"It drank a beer and toggled its breaker mode.":                                               "Il a bu une bière et basculé son mode casseur.",
+	"It crossed an inverter, its priorities are inverted at the next obstacle.":                   "Il a traversé un inverseur, ses priorités sont inversées au prochain obstacle.",
+	"It was teleported to the other teleport.":                                                    "Il a été téléporté vers l'autre téléporteur.",
+	"It reached the suicide booth.":                                                               "Il a atteint la cabine de suicide.",
+
+	// quiz
+	"Predict the direction of every step of Bender, q to quit.": "Prédisez la direction de chaque pas de Bender, q pour quitter.",
+	"Step %d, direction? ":                           "Pas %d, direction ? ",
+	"Unknown direction %q, answer %s, %s, %s or %s.": "Direction %q inconnue, répondez %s, %s, %s ou %s.",
+	"Right! %s":            "Juste ! %s",
+	"Wrong, it's %s. %s":   "Faux, c'est %s. %s",
+	"Simulation ended: %s": "Fin de la simulation : %s",
+	"Score: %d/%d":         "Score : %d/%d",
+
+	// diagnostics
+	"Failed with error: %v":                              "Échec avec l'erreur : %v",
+	"teleport %q appears %d time(s), expected exactly 2": "le téléporteur %q apparaît %d fois, exactement 2 attendus",
+	"unknown tile %q":                                    "case %q inconnue",
+	"row of %d tile(s), expected %d like the first row":  "ligne de %d case(s), %d attendues comme la première ligne",
+	"tile %q in the frame, expected a wall":              "case %q dans le cadre, un mur attendu",
+	"start @ appears %d time(s), expected exactly 1":     "le départ @ apparaît %d fois, exactement 1 attendu",
+	"no start @ in the map":                              "pas de départ @ dans la carte",
+	"no booth $ in the map":                              "pas de cabine $ dans la carte",
+}
@@ -0,0 +1,118 @@
+// Package i18n translates the messages printed to the users: the narration of the steps and the diagnostics
+//
+// The messages are identified by their English format, as given to fmt, so English needs no catalog
+// and a message missing from a catalog is printed in English.
+package i18n
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Catalog maps the English formats of the messages to their translation
+type Catalog map[string]string
+
+// Sprintf formats the translation of the given English format, the format itself if it's not translated
+// the nil catalog is the English one
+func (c Catalog) Sprintf(format string, args ...interface{}) string {
+	if t, exist := c[format]; exist {
+		format = t
+	}
+	return fmt.Sprintf(format, args...)
+}
+
+// check verifies that every translation has the verbs of its English format, in the same order
+func (c Catalog) check() error {
+	for format, t := range c {
+		if v, tv := verbs(format), verbs(t); v != tv {
+			return fmt.Errorf("translation %q of %q has the verbs %q, expected %q", t, format, tv, v)
+		}
+	}
+	return nil
+}
+
+// verbs returns the verbs of the format, like "%q%d"
+func verbs(format string) string {
+	sb := &strings.Builder{}
+	for i := 0; i < len(format); i++ {
+		if format[i] != '%' {
+			continue
+		}
+		j := i + 1
+		for j < len(format) && strings.IndexByte("+-# 0123456789.", format[j]) >= 0 {
+			j++
+		}
+		if j < len(format) && format[j] != '%' {
+			sb.WriteString(format[i : j+1])
+		}
+		i = j
+	}
+	return sb.String()
+}
+
+// ErrNoCatalog is returned by the loaders for the locales without catalog
+var ErrNoCatalog = errors.New("no catalog")
+
+// Loader returns the catalog of the given locale, or ErrNoCatalog
+type Loader func(locale string) (Catalog, error)
+
+// Builtin loads the catalogs shipped with bender
+func Builtin(locale string) (Catalog, error) {
+	if c, exist := builtin[locale]; exist {
+		return c, nil
+	}
+	return nil, ErrNoCatalog
+}
+
+// Dir returns the loader of the catalogs stored in the given directory as <locale>.json,
+// a JSON object mapping the English formats to their translation
+func Dir(dir string) Loader {
+	return func(locale string) (Catalog, error) {
+		data, err := os.ReadFile(filepath.Join(dir, locale+".json"))
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, ErrNoCatalog
+		}
+		if err != nil {
+			return nil, err
+		}
+		c := Catalog{}
+		if err := json.Unmarshal(data, &c); err != nil {
+			return nil, fmt.Errorf("catalog of %s: %v", locale, err)
+		}
+		if err := c.check(); err != nil {
+			return nil, fmt.Errorf("catalog of %s: %v", locale, err)
+		}
+		return c, nil
+	}
+}
+
+// Load returns the catalog of the given locale from the first loader which has one
+// the locale is like fr, fr_FR or fr_FR.UTF-8, the catalog of the language is used if the region has none
+// English (en, C, POSIX or empty) needs no catalog and is returned as nil
+func Load(locale string, loaders ...Loader) (Catalog, error) {
+	if i := strings.IndexAny(locale, ".@"); i >= 0 {
+		locale = locale[:i]
+	}
+	candidates := []string{locale}
+	if i := strings.IndexAny(locale, "_-"); i >= 0 {
+		candidates = append(candidates, locale[:i])
+	}
+	for _, l := range candidates {
+		switch l {
+		case "", "en", "C", "POSIX":
+			return nil, nil
+		}
+		for _, load := range loaders {
+			c, err := load(l)
+			if errors.Is(err, ErrNoCatalog) {
+				continue
+			}
+			return c, err
+		}
+	}
+	return nil, fmt.Errorf("no catalog for the locale %q", locale)
+}
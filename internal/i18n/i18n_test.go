@@ -0,0 +1,91 @@
+package i18n
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSprintf(t *testing.T) {
+	c := Catalog{"Score: %d/%d": "Punkte: %d/%d"}
+	testCases := []struct {
+		c        Catalog
+		format   string
+		expected string
+	}{
+		{c: c, format: "Score: %d/%d", expected: "Punkte: 2/3"},
+		{c: c, format: "Total: %d/%d", expected: "Total: 2/3"},
+		{c: nil, format: "Score: %d/%d", expected: "Score: 2/3"},
+	}
+	for _, tc := range testCases {
+		if s := tc.c.Sprintf(tc.format, 2, 3); s != tc.expected {
+			t.Fatalf("Wrong message. Expected %q, got %q", tc.expected, s)
+		}
+	}
+}
+
+func TestBuiltin(t *testing.T) {
+	for locale, c := range builtin {
+		if err := c.check(); err != nil {
+			t.Fatalf("Wrong catalog %s: %v", locale, err)
+		}
+	}
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "de.json"), []byte(`{"Score: %d/%d": "Punkte: %d/%d"}`), 0o644); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "it.json"), []byte(`{"Score: %d/%d": "Punti: %d"}`), 0o644); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	loaders := []Loader{Builtin, Dir(dir)}
+
+	testCases := []struct {
+		locale   string
+		expected string
+		err      bool
+	}{
+		{locale: "", expected: "Score: 1/2"},
+		{locale: "en_US.UTF-8", expected: "Score: 1/2"},
+		{locale: "C", expected: "Score: 1/2"},
+		{locale: "fr", expected: "Score : 1/2"},
+		{locale: "fr_CA.UTF-8", expected: "Score : 1/2"},
+		{locale: "de_DE", expected: "Punkte: 1/2"},
+		{locale: "it", err: true},
+		{locale: "ja", err: true},
+	}
+	for _, tc := range testCases {
+		c, err := Load(tc.locale, loaders...)
+		if (err != nil) != tc.err {
+			t.Fatalf("Wrong error for %q: %v", tc.locale, err)
+		}
+		if err != nil {
+			continue
+		}
+		if s := c.Sprintf("Score: %d/%d", 1, 2); s != tc.expected {
+			t.Fatalf("Wrong message for %q. Expected %q, got %q", tc.locale, tc.expected, s)
+		}
+	}
+	if _, err := Dir(dir)("es"); !errors.Is(err, ErrNoCatalog) {
+		t.Fatalf("Expected no catalog, got %v", err)
+	}
+}
+
+func TestVerbs(t *testing.T) {
+	testCases := []struct {
+		format   string
+		expected string
+	}{
+		{format: "no verb", expected: ""},
+		{format: "100%% of %q at %5.2f", expected: "%q%5.2f"},
+		{format: "trailing %", expected: ""},
+	}
+	for _, tc := range testCases {
+		if v := verbs(tc.format); v != tc.expected {
+			t.Fatalf("Wrong verbs of %q. Expected %q, got %q", tc.format, tc.expected, v)
+		}
+	}
+}
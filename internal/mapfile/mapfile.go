@@ -0,0 +1,148 @@
+package mapfile
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"bender/internal/compress"
+	"bender/internal/fsm"
+	"bender/schema"
+)
+
+// MapSchema is the JSON Schema of the JSON map format
+var MapSchema = schema.Map
+
+// mapSchema is the compiled MapSchema
+var mapSchema = mustCompileSchema(MapSchema)
+
+// mustCompileSchema compiles the given schema, panics if it's malformed
+func mustCompileSchema(data []byte) *jsonSchema {
+	s, err := compileSchema(data)
+	if err != nil {
+		panic(fmt.Sprintf("bad schema: %v", err))
+	}
+	return s
+}
+
+// Map is a map in the JSON format
+type Map struct {
+	// name of the map
+	Name string `json:"name,omitempty"`
+	// rows of the map
+	Plan []string `json:"plan"`
+	// expected path, a single LOOP if a loop is expected
+	Expected []string `json:"expected,omitempty"`
+	// annotations of the cells, sorted by row then by column
+	Annotations []Annotation `json:"annotations,omitempty"`
+}
+
+// Annotation is the key/value pairs of a cell
+type Annotation struct {
+	X      int               `json:"x"`
+	Y      int               `json:"y"`
+	Values map[string]string `json:"values"`
+}
+
+// Board returns the board of the map carrying its annotations
+func (m *Map) Board() fsm.Board {
+	board := fsm.NewBoard(m.Plan)
+	if len(m.Annotations) == 0 {
+		return board
+	}
+	a := fsm.Annotations{}
+	for _, an := range m.Annotations {
+		for k, v := range an.Values {
+			a.Set(fsm.Pair{X: an.X, Y: an.Y}, k, v)
+		}
+	}
+	return fsm.Annotate(board, a)
+}
+
+// Annotate replaces the annotations of the map by the given ones
+func (m *Map) Annotate(a fsm.Annotations) {
+	m.Annotations = nil
+	for _, p := range a.Cells() {
+		values := make(map[string]string, len(a[p]))
+		for k, v := range a[p] {
+			values[k] = v
+		}
+		m.Annotations = append(m.Annotations, Annotation{X: p.X, Y: p.Y, Values: values})
+	}
+}
+
+// LoadJSONMap reads the map in the JSON format from r, possibly gzip compressed
+// the map is validated against MapSchema, the violations are returned as SchemaErrors
+func LoadJSONMap(r io.Reader) (*Map, error) {
+	zr, err := compress.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	data, err := io.ReadAll(zr)
+	if err != nil {
+		return nil, err
+	}
+
+	var raw interface{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("malformed JSON map: %v", err)
+	}
+	if errs := mapSchema.validate(raw); len(errs) > 0 {
+		return nil, errs
+	}
+
+	m := &Map{}
+	if err := json.Unmarshal(data, m); err != nil {
+		return nil, err
+	}
+	m.Plan = fsm.DecodePlan(m.Plan)
+	for i, an := range m.Annotations {
+		if an.Y < 0 || an.Y >= len(m.Plan) || an.X < 0 || an.X >= len(m.Plan[an.Y]) {
+			return nil, fmt.Errorf("annotation %d out of the map at (%d,%d)", i, an.X, an.Y)
+		}
+	}
+	return m, nil
+}
+
+// ReadPlan returns the map read from r until its end, like ParsePlan
+func ReadPlan(r io.Reader) ([]string, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	return ParsePlan(data)
+}
+
+// ParsePlan returns the map held by the data, possibly gzip compressed
+// data starting with { is a JSON map, otherwise its lines are the rows of the map
+// the glyphs drawing the tiles, like the box drawing walls, are replaced by their tiles, see fsm.Tile
+func ParsePlan(data []byte) ([]string, error) {
+	m, err := ParseMap(data)
+	if err != nil {
+		return nil, err
+	}
+	return m.Plan, nil
+}
+
+// ParseMap returns the map held by the data like ParsePlan, with the name, the expected path
+// and the annotations of the JSON maps
+func ParseMap(data []byte) (*Map, error) {
+	data, err := compress.Decompress(data)
+	if err != nil {
+		return nil, err
+	}
+	if t := bytes.TrimSpace(data); len(t) > 0 && t[0] == '{' {
+		return LoadJSONMap(bytes.NewReader(data))
+	}
+	plan := strings.Split(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n")
+	for len(plan) > 0 && strings.TrimSpace(plan[len(plan)-1]) == "" {
+		plan = plan[:len(plan)-1]
+	}
+	if len(plan) == 0 {
+		return nil, errors.New("empty map")
+	}
+	return &Map{Plan: fsm.DecodePlan(plan)}, nil
+}
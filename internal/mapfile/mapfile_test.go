@@ -0,0 +1,168 @@
+package mapfile
+
+import (
+	"errors"
+	"math/rand"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"bender/internal/compress"
+	"bender/internal/fsm"
+)
+
+func TestLoadJSONMap(t *testing.T) {
+	expected := &Map{
+		Name: "simple",
+		Plan: []string{
+			"#####",
+			"#@  #",
+			"#  $#",
+			"#####",
+		},
+		Expected: []string{"SOUTH", "EAST", "EAST"},
+	}
+	for _, file := range []string{"testdata/simple.json", "testdata/simple.json.gz"} {
+		f, err := os.Open(file)
+		if err != nil {
+			t.Fatalf("Failed to open the map: %v", err)
+		}
+		defer f.Close()
+
+		m, err := LoadJSONMap(f)
+		if err != nil {
+			t.Fatalf("Unexpected error for %s: %v", file, err)
+		}
+		if !reflect.DeepEqual(m, expected) {
+			t.Fatalf("Wrong map in %s. Expected %v, got %v", file, expected, m)
+		}
+	}
+}
+
+func TestLoadJSONMapInvalid(t *testing.T) {
+	f, err := os.Open("testdata/invalid.json")
+	if err != nil {
+		t.Fatalf("Failed to open the map: %v", err)
+	}
+	defer f.Close()
+
+	_, err = LoadJSONMap(f)
+	var serrs SchemaErrors
+	if !errors.As(err, &serrs) {
+		t.Fatalf("Expected schema errors, got %v", err)
+	}
+	expected := []SchemaError{
+		{Path: "/", Msg: `unknown property "author"`},
+		{Path: "/expected/0", Msg: "SOTUH is not one of [SOUTH NORTH EAST WEST LOOP]"},
+		{Path: "/name", Msg: "expected string, got integer"},
+		{Path: "/plan/1", Msg: `"#@ ?#" doesn't match ^[ #X@$SNEWIBT]+$`},
+		{Path: "/plan/2", Msg: "expected at least 1 character(s), got 0"},
+		{Path: "/plan/2", Msg: `"" doesn't match ^[ #X@$SNEWIBT]+$`},
+	}
+	if len(serrs) != len(expected) {
+		t.Fatalf("Wrong number of errors. Expected %d, got %d:\n%v", len(expected), len(serrs), serrs)
+	}
+	for i, se := range serrs {
+		if se.Error() != expected[i].Error() {
+			t.Errorf("Wrong error #%d. Expected %q, got %q", i, expected[i].Error(), se.Error())
+		}
+	}
+
+	_, err = LoadJSONMap(strings.NewReader("{"))
+	if err == nil || errors.As(err, &serrs) {
+		t.Fatalf("Expected JSON syntax error, got %v", err)
+	}
+}
+
+func TestLoadJSONMapAdversarial(t *testing.T) {
+	rnd := rand.New(rand.NewSource(42))
+	inputs := []string{"", "null", "[]", `{"plan":null}`, `{"plan":[1]}`, `{"plan":[[]]}`, `{"plan":{}}`, `{"expected":[null]}`}
+	for i := 0; i < 1000; i++ {
+		buf := make([]byte, rnd.Intn(32))
+		rnd.Read(buf)
+		inputs = append(inputs, string(buf))
+	}
+	for _, in := range inputs {
+		func() {
+			defer func() {
+				if r := recover(); r != nil {
+					t.Fatalf("LoadJSONMap(%q) panicked: %v", in, r)
+				}
+			}()
+			LoadJSONMap(strings.NewReader(in))
+		}()
+	}
+}
+
+func TestParsePlan(t *testing.T) {
+	gz, err := compress.Compress("map.txt.gz", []byte("###\n#@$\n###\n"))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	testCases := []struct {
+		name     string
+		data     string
+		expected []string
+		err      bool
+	}{
+		{name: "text", data: "###\r\n#@$\r\n###\r\n\r\n", expected: []string{"###", "#@$", "###"}},
+		{name: "json", data: ` {"plan": ["###", "#@$", "###"]}`, expected: []string{"###", "#@$", "###"}},
+		{name: "gzip", data: string(gz), expected: []string{"###", "#@$", "###"}},
+		{name: "unicode", data: "┌─┐\n│@$\n└─┘\n", expected: []string{"###", "#@$", "###"}},
+		{name: "invalid json", data: `{"plan": []}`, err: true},
+		{name: "empty", data: "\n \n", err: true},
+	}
+	for _, tc := range testCases {
+		plan, err := ParsePlan([]byte(tc.data))
+		if (err != nil) != tc.err {
+			t.Fatalf("Test case %q: unexpected error %v", tc.name, err)
+		}
+		if !reflect.DeepEqual(plan, tc.expected) {
+			t.Fatalf("Test case %q: wrong map. Expected %q, got %q", tc.name, tc.expected, plan)
+		}
+		if plan, err = ReadPlan(strings.NewReader(tc.data)); (err != nil) != tc.err || !reflect.DeepEqual(plan, tc.expected) {
+			t.Fatalf("Test case %q: wrong map read. Expected %q, got %q: %v", tc.name, tc.expected, plan, err)
+		}
+	}
+}
+
+func TestMapAnnotations(t *testing.T) {
+	data := []byte(`{"plan": ["#####", "#@ $#", "#####"], "annotations": [{"x": 3, "y": 1, "values": {"door": "A"}}, {"x": 2, "y": 1, "values": {"door": "B", "conveyor": "EAST"}}]}`)
+	m, err := ParseMap(data)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	a := fsm.AnnotationsOf(m.Board())
+	if a.Get(fsm.Pair{X: 3, Y: 1}, "door") != "A" || a.Get(fsm.Pair{X: 2, Y: 1}, "conveyor") != "EAST" {
+		t.Fatalf("Wrong annotations of the board %v", a)
+	}
+
+	// the annotations are written back sorted by cell
+	m.Annotate(a)
+	expected := []Annotation{
+		{X: 2, Y: 1, Values: map[string]string{"door": "B", "conveyor": "EAST"}},
+		{X: 3, Y: 1, Values: map[string]string{"door": "A"}},
+	}
+	if !reflect.DeepEqual(m.Annotations, expected) {
+		t.Fatalf("Wrong annotations. Expected %v, got %v", expected, m.Annotations)
+	}
+
+	m, err = ParseMap([]byte("#####\n#@ $#\n#####\n"))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if fsm.AnnotationsOf(m.Board()) != nil {
+		t.Fatalf("Unexpected annotations of a text map")
+	}
+
+	for _, bad := range []string{
+		`{"plan": ["#@$#"], "annotations": [{"x": 4, "y": 0, "values": {}}]}`,
+		`{"plan": ["#@$#"], "annotations": [{"x": 1, "y": 0, "values": {"door": 1}}]}`,
+		`{"plan": ["#@$#"], "annotations": [{"x": 1, "values": {}}]}`,
+	} {
+		if _, err := ParseMap([]byte(bad)); err == nil {
+			t.Fatalf("Expected an error for %s", bad)
+		}
+	}
+}
@@ -0,0 +1,179 @@
+package mapfile
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// jsonSchema is a JSON Schema
+// only the keywords used by the schemas of this repository are supported
+type jsonSchema struct {
+	Type                 string                 `json:"type"`
+	Required             []string               `json:"required"`
+	Properties           map[string]*jsonSchema `json:"properties"`
+	AdditionalProperties *bool                  `json:"additionalProperties"`
+	Items                *jsonSchema            `json:"items"`
+	MinItems             *int                   `json:"minItems"`
+	MinLength            *int                   `json:"minLength"`
+	Pattern              string                 `json:"pattern"`
+	Enum                 []interface{}          `json:"enum"`
+
+	pattern *regexp.Regexp
+}
+
+// SchemaError is a violation of a JSON Schema
+type SchemaError struct {
+	// JSON pointer to the offending value
+	Path string
+	// description of the violation
+	Msg string
+}
+
+// Error formats the error as path: message
+func (e *SchemaError) Error() string {
+	path := e.Path
+	if path == "" {
+		path = "/"
+	}
+	return fmt.Sprintf("%s: %s", path, e.Msg)
+}
+
+// SchemaErrors is the list of all the violations of a JSON Schema
+type SchemaErrors []*SchemaError
+
+// Error formats the errors one per line
+func (e SchemaErrors) Error() string {
+	msgs := make([]string, 0, len(e))
+	for _, se := range e {
+		msgs = append(msgs, se.Error())
+	}
+	return strings.Join(msgs, "\n")
+}
+
+// compileSchema parses the given JSON Schema
+func compileSchema(data []byte) (*jsonSchema, error) {
+	s := &jsonSchema{}
+	if err := json.Unmarshal(data, s); err != nil {
+		return nil, err
+	}
+	if err := s.compile(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// compile prepares the patterns of the schema and its subschemas
+func (s *jsonSchema) compile() error {
+	if s.Pattern != "" {
+		re, err := regexp.Compile(s.Pattern)
+		if err != nil {
+			return err
+		}
+		s.pattern = re
+	}
+	for _, p := range s.Properties {
+		if err := p.compile(); err != nil {
+			return err
+		}
+	}
+	if s.Items != nil {
+		return s.Items.compile()
+	}
+	return nil
+}
+
+// validate checks the given decoded JSON value against the schema
+// all the violations are returned
+func (s *jsonSchema) validate(v interface{}) SchemaErrors {
+	errs := SchemaErrors{}
+	s.check("", v, &errs)
+	return errs
+}
+
+// check appends the violations of the value at the given path
+func (s *jsonSchema) check(path string, v interface{}, errs *SchemaErrors) {
+	fail := func(format string, args ...interface{}) {
+		*errs = append(*errs, &SchemaError{Path: path, Msg: fmt.Sprintf(format, args...)})
+	}
+
+	if t := jsonType(v); s.Type != "" && t != s.Type && !(s.Type == "number" && t == "integer") {
+		fail("expected %s, got %s", s.Type, t)
+		return
+	}
+
+	if len(s.Enum) > 0 {
+		found := false
+		for _, e := range s.Enum {
+			if e == v {
+				found = true
+				break
+			}
+		}
+		if !found {
+			fail("%v is not one of %v", v, s.Enum)
+		}
+	}
+
+	switch val := v.(type) {
+	case map[string]interface{}:
+		for _, r := range s.Required {
+			if _, exist := val[r]; !exist {
+				fail("missing required property %q", r)
+			}
+		}
+		keys := make([]string, 0, len(val))
+		for k := range val {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			if p, exist := s.Properties[k]; exist {
+				p.check(path+"/"+k, val[k], errs)
+			} else if s.AdditionalProperties != nil && !*s.AdditionalProperties {
+				fail("unknown property %q", k)
+			}
+		}
+	case []interface{}:
+		if s.MinItems != nil && len(val) < *s.MinItems {
+			fail("expected at least %d item(s), got %d", *s.MinItems, len(val))
+		}
+		if s.Items != nil {
+			for i, item := range val {
+				s.Items.check(fmt.Sprintf("%s/%d", path, i), item, errs)
+			}
+		}
+	case string:
+		if s.MinLength != nil && utf8.RuneCountInString(val) < *s.MinLength {
+			fail("expected at least %d character(s), got %d", *s.MinLength, utf8.RuneCountInString(val))
+		}
+		if s.pattern != nil && !s.pattern.MatchString(val) {
+			fail("%q doesn't match %s", val, s.Pattern)
+		}
+	}
+}
+
+// jsonType returns the JSON Schema type of the given decoded JSON value
+func jsonType(v interface{}) string {
+	switch val := v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		if val == float64(int64(val)) {
+			return "integer"
+		}
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	}
+	return "unknown"
+}
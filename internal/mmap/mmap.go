@@ -0,0 +1,50 @@
+// Package mmap maps files in memory read-only, so large files are read without copying them
+package mmap
+
+import "os"
+
+// File is a file mapped in memory
+type File struct {
+	// content of the file
+	Data []byte
+	// unmap releases the mapping, nil if the file was read instead
+	unmap func() error
+}
+
+// Open maps the file in memory, it's read instead on the platforms without mmap or if mmap fails
+func Open(name string) (*File, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	fi, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if fi.Size() > 0 {
+		if data, unmap, err := mmap(f, fi.Size()); err == nil {
+			return &File{Data: data, unmap: unmap}, nil
+		}
+	}
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	return &File{Data: data}, nil
+}
+
+// Mapped returns true if the file is mapped rather than read
+func (f *File) Mapped() bool {
+	return f.unmap != nil
+}
+
+// Close releases the mapping, the data must not be used afterwards
+func (f *File) Close() error {
+	unmap := f.unmap
+	f.Data, f.unmap = nil, nil
+	if unmap != nil {
+		return unmap()
+	}
+	return nil
+}
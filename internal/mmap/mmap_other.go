@@ -0,0 +1,13 @@
+//go:build !linux && !darwin && !freebsd && !netbsd && !openbsd && !dragonfly
+
+package mmap
+
+import (
+	"errors"
+	"os"
+)
+
+// mmap isn't supported, the file is read instead
+func mmap(f *os.File, size int64) ([]byte, func() error, error) {
+	return nil, nil, errors.New("mmap not supported")
+}
@@ -0,0 +1,42 @@
+package mmap
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestOpen(t *testing.T) {
+	dir := t.TempDir()
+	testCases := []struct {
+		name   string
+		data   string
+		mapped bool
+	}{
+		{name: "data", data: "#####\n#@ $#\n#####\n", mapped: runtime.GOOS != "windows"},
+		// an empty file can't be mapped
+		{name: "empty", data: ""},
+	}
+
+	for _, tc := range testCases {
+		file := filepath.Join(dir, tc.name)
+		if err := os.WriteFile(file, []byte(tc.data), 0644); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		f, err := Open(file)
+		if err != nil {
+			t.Fatalf("Unexpected error for %q: %v", tc.name, err)
+		}
+		if string(f.Data) != tc.data || f.Mapped() != tc.mapped {
+			t.Fatalf("Wrong file %q. Expected %q mapped %v, got %q mapped %v", tc.name, tc.data, tc.mapped, f.Data, f.Mapped())
+		}
+		if err := f.Close(); err != nil || f.Data != nil {
+			t.Fatalf("Wrong close of %q: %v", tc.name, err)
+		}
+	}
+
+	if _, err := Open(filepath.Join(dir, "missing")); err == nil {
+		t.Fatalf("Missing file was opened")
+	}
+}
@@ -0,0 +1,308 @@
+package pb
+
+import (
+	"bender/internal/bender"
+	"bender/internal/fsm"
+)
+
+// Map is a map to simulate
+type Map struct {
+	Name string
+	Rows []string
+}
+
+// Marshal encodes the map
+func (m *Map) Marshal() []byte {
+	b := appendString(nil, 1, m.Name)
+	return appendRepeated(b, 2, m.Rows)
+}
+
+// Unmarshal decodes the map
+func (m *Map) Unmarshal(data []byte) error {
+	*m = Map{}
+	d := &decoder{data: data}
+	for {
+		field, wire, ok, err := d.next()
+		if err != nil || !ok {
+			return err
+		}
+		switch field {
+		case 1:
+			m.Name, err = d.string(wire)
+		case 2:
+			var row string
+			row, err = d.string(wire)
+			m.Rows = append(m.Rows, row)
+		default:
+			err = d.skip(wire)
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// Options bound a simulation, zero means no limit
+type Options struct {
+	MaxSteps int64
+}
+
+// Marshal encodes the options
+func (o *Options) Marshal() []byte {
+	return appendInt(nil, 1, o.MaxSteps)
+}
+
+// Unmarshal decodes the options
+func (o *Options) Unmarshal(data []byte) error {
+	*o = Options{}
+	d := &decoder{data: data}
+	for {
+		field, wire, ok, err := d.next()
+		if err != nil || !ok {
+			return err
+		}
+		switch field {
+		case 1:
+			o.MaxSteps, err = d.int(wire)
+		default:
+			err = d.skip(wire)
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// SimulateRequest asks for the simulation of a map
+type SimulateRequest struct {
+	Map     Map
+	Options Options
+}
+
+// Marshal encodes the request
+func (r *SimulateRequest) Marshal() []byte {
+	b := appendMessage(nil, 1, r.Map.Marshal())
+	return appendMessage(b, 2, r.Options.Marshal())
+}
+
+// Unmarshal decodes the request
+func (r *SimulateRequest) Unmarshal(data []byte) error {
+	*r = SimulateRequest{}
+	d := &decoder{data: data}
+	for {
+		field, wire, ok, err := d.next()
+		if err != nil || !ok {
+			return err
+		}
+		var msg []byte
+		switch field {
+		case 1:
+			if msg, err = d.message(wire); err == nil {
+				err = r.Map.Unmarshal(msg)
+			}
+		case 2:
+			if msg, err = d.message(wire); err == nil {
+				err = r.Options.Unmarshal(msg)
+			}
+		default:
+			err = d.skip(wire)
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// Destruction is a breakable wall destroyed by Bender
+type Destruction struct {
+	X, Y, Step int64
+}
+
+// Marshal encodes the destruction
+func (ds *Destruction) Marshal() []byte {
+	b := appendInt(nil, 1, ds.X)
+	b = appendInt(b, 2, ds.Y)
+	return appendInt(b, 3, ds.Step)
+}
+
+// Unmarshal decodes the destruction
+func (ds *Destruction) Unmarshal(data []byte) error {
+	*ds = Destruction{}
+	d := &decoder{data: data}
+	for {
+		field, wire, ok, err := d.next()
+		if err != nil || !ok {
+			return err
+		}
+		switch field {
+		case 1:
+			ds.X, err = d.int(wire)
+		case 2:
+			ds.Y, err = d.int(wire)
+		case 3:
+			ds.Step, err = d.int(wire)
+		default:
+			err = d.skip(wire)
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// Escape is the step which would have taken Bender out of the board
+type Escape struct {
+	Step      int64
+	Direction string
+	X, Y      int64
+	ToX, ToY  int64
+}
+
+// Marshal encodes the escape
+func (e *Escape) Marshal() []byte {
+	b := appendInt(nil, 1, e.Step)
+	b = appendString(b, 2, e.Direction)
+	b = appendInt(b, 3, e.X)
+	b = appendInt(b, 4, e.Y)
+	b = appendInt(b, 5, e.ToX)
+	return appendInt(b, 6, e.ToY)
+}
+
+// Unmarshal decodes the escape
+func (e *Escape) Unmarshal(data []byte) error {
+	*e = Escape{}
+	d := &decoder{data: data}
+	for {
+		field, wire, ok, err := d.next()
+		if err != nil || !ok {
+			return err
+		}
+		switch field {
+		case 1:
+			e.Step, err = d.int(wire)
+		case 2:
+			e.Direction, err = d.string(wire)
+		case 3:
+			e.X, err = d.int(wire)
+		case 4:
+			e.Y, err = d.int(wire)
+		case 5:
+			e.ToX, err = d.int(wire)
+		case 6:
+			e.ToY, err = d.int(wire)
+		default:
+			err = d.skip(wire)
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// Result is the result of a simulation
+// the values of the outcome enum are the ones of bender.Outcome
+type Result struct {
+	Outcome   bender.Outcome
+	Path      []string
+	Destroyed []Destruction
+	Exceeded  string
+	// nil unless the outcome is bender.Escaped
+	Escape *Escape
+	Steps  int64
+	X, Y   int64
+}
+
+// NewResult returns the message of the result
+func NewResult(res bender.Result) *Result {
+	r := &Result{Outcome: res.Outcome, Path: res.Path, Exceeded: res.Exceeded, Steps: int64(res.Steps), X: int64(res.Position.X), Y: int64(res.Position.Y)}
+	for _, d := range res.Destroyed {
+		r.Destroyed = append(r.Destroyed, Destruction{X: int64(d.At.X), Y: int64(d.At.Y), Step: int64(d.Step)})
+	}
+	if e := res.Escape; e != nil {
+		r.Escape = &Escape{Step: int64(e.Step), Direction: string(e.Direction), X: int64(e.From.X), Y: int64(e.From.Y), ToX: int64(e.To.X), ToY: int64(e.To.Y)}
+	}
+	return r
+}
+
+// Result returns the result of the message
+func (r *Result) Result() bender.Result {
+	res := bender.Result{
+		Outcome:   r.Outcome,
+		Path:      append([]string{}, r.Path...),
+		Steps:     int(r.Steps),
+		Position:  fsm.Pair{X: int(r.X), Y: int(r.Y)},
+		Destroyed: []bender.Destruction{},
+		Exceeded:  r.Exceeded,
+	}
+	for _, d := range r.Destroyed {
+		res.Destroyed = append(res.Destroyed, bender.Destruction{At: fsm.Pair{X: int(d.X), Y: int(d.Y)}, Step: int(d.Step)})
+	}
+	if e := r.Escape; e != nil {
+		res.Escape = &bender.Escape{Step: int(e.Step), Direction: fsm.Direction(e.Direction), From: fsm.Pair{X: int(e.X), Y: int(e.Y)}, To: fsm.Pair{X: int(e.ToX), Y: int(e.ToY)}}
+	}
+	return res
+}
+
+// Marshal encodes the result
+func (r *Result) Marshal() []byte {
+	b := appendInt(nil, 1, int64(r.Outcome))
+	b = appendRepeated(b, 2, r.Path)
+	for i := range r.Destroyed {
+		b = appendMessage(b, 3, r.Destroyed[i].Marshal())
+	}
+	b = appendString(b, 4, r.Exceeded)
+	if r.Escape != nil {
+		b = appendMessage(b, 5, r.Escape.Marshal())
+	}
+	b = appendInt(b, 6, r.Steps)
+	b = appendInt(b, 7, r.X)
+	return appendInt(b, 8, r.Y)
+}
+
+// Unmarshal decodes the result
+func (r *Result) Unmarshal(data []byte) error {
+	*r = Result{}
+	d := &decoder{data: data}
+	for {
+		field, wire, ok, err := d.next()
+		if err != nil || !ok {
+			return err
+		}
+		switch field {
+		case 1:
+			var o int64
+			o, err = d.int(wire)
+			r.Outcome = bender.Outcome(o)
+		case 2:
+			var dir string
+			dir, err = d.string(wire)
+			r.Path = append(r.Path, dir)
+		case 3:
+			var msg []byte
+			if msg, err = d.message(wire); err == nil {
+				ds := Destruction{}
+				err = ds.Unmarshal(msg)
+				r.Destroyed = append(r.Destroyed, ds)
+			}
+		case 4:
+			r.Exceeded, err = d.string(wire)
+		case 5:
+			var msg []byte
+			if msg, err = d.message(wire); err == nil {
+				r.Escape = &Escape{}
+				err = r.Escape.Unmarshal(msg)
+			}
+		case 6:
+			r.Steps, err = d.int(wire)
+		case 7:
+			r.X, err = d.int(wire)
+		case 8:
+			r.Y, err = d.int(wire)
+		default:
+			err = d.skip(wire)
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package pb
+
+import (
+	"reflect"
+	"testing"
+
+	"bender/internal/bender"
+	"bender/internal/fsm"
+)
+
+func TestMessagesRoundTrip(t *testing.T) {
+	req := &SimulateRequest{
+		Map:     Map{Name: "simple", Rows: []string{"####", "#@$#", "", "####"}},
+		Options: Options{MaxSteps: 1000},
+	}
+	actualReq := &SimulateRequest{}
+	if err := actualReq.Unmarshal(req.Marshal()); err != nil || !reflect.DeepEqual(actualReq, req) {
+		t.Fatalf("Wrong request. Expected %+v, got %+v: %v", req, actualReq, err)
+	}
+
+	res := bender.Result{
+		Outcome:   bender.BudgetExceeded,
+		Path:      []string{"SOUTH", "SOUTH"},
+		Steps:     2,
+		Position:  fsm.Pair{X: 1, Y: 3},
+		Destroyed: []bender.Destruction{{At: fsm.Pair{X: 1, Y: 2}, Step: 1}},
+		Exceeded:  bender.ResourceSteps,
+	}
+	actualRes := &Result{}
+	if err := actualRes.Unmarshal(NewResult(res).Marshal()); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(actualRes.Result(), res) {
+		t.Fatalf("Wrong result. Expected %+v, got %+v", res, actualRes.Result())
+	}
+
+	res = bender.Result{
+		Outcome:   bender.Escaped,
+		Path:      []string{"NORTH"},
+		Steps:     1,
+		Position:  fsm.Pair{X: 1, Y: 0},
+		Destroyed: []bender.Destruction{},
+		Escape:    &bender.Escape{Step: 2, Direction: fsm.NORTH, From: fsm.Pair{X: 1, Y: 0}, To: fsm.Pair{X: 1, Y: -1}},
+	}
+	if err := actualRes.Unmarshal(NewResult(res).Marshal()); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(actualRes.Result(), res) {
+		t.Fatalf("Wrong escaped result. Expected %+v, got %+v", res, actualRes.Result())
+	}
+}
+
+func TestUnknownFields(t *testing.T) {
+	// a newer version of the map with fields 6 (varint), 7 (string), 8 (fixed64) and 9 (fixed32)
+	data := (&Map{Name: "m", Rows: []string{"#@$#"}}).Marshal()
+	data = appendInt(data, 6, 42)
+	data = appendString(data, 7, "new")
+	data = append(appendTag(data, 8, wire64), 1, 2, 3, 4, 5, 6, 7, 8)
+	data = append(appendTag(data, 9, wire32), 1, 2, 3, 4)
+	actual := &Map{}
+	if err := actual.Unmarshal(data); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if expected := (&Map{Name: "m", Rows: []string{"#@$#"}}); !reflect.DeepEqual(actual, expected) {
+		t.Fatalf("Wrong map. Expected %+v, got %+v", expected, actual)
+	}
+}
@@ -0,0 +1,167 @@
+// Package pb encodes the messages of schema/bender.proto in the Protocol Buffers wire format
+// the messages are written by hand as the standard library has no Protocol Buffers support,
+// they're compatible with the code generated from the definition in other languages
+package pb
+
+import (
+	"errors"
+	"fmt"
+)
+
+// wire types of the fields
+const (
+	wireVarint = 0
+	wire64     = 1
+	wireBytes  = 2
+	wire32     = 5
+)
+
+// errTruncated is returned for a message ending in the middle of a field
+var errTruncated = errors.New("truncated protobuf message")
+
+// appendVarint appends the value as a varint
+func appendVarint(b []byte, v uint64) []byte {
+	for v >= 0x80 {
+		b = append(b, byte(v)|0x80)
+		v >>= 7
+	}
+	return append(b, byte(v))
+}
+
+// appendTag appends the key of a field
+func appendTag(b []byte, field int, wire int) []byte {
+	return appendVarint(b, uint64(field)<<3|uint64(wire))
+}
+
+// appendInt appends an int64 field, omitted if zero
+func appendInt(b []byte, field int, v int64) []byte {
+	if v == 0 {
+		return b
+	}
+	return appendVarint(appendTag(b, field, wireVarint), uint64(v))
+}
+
+// appendString appends a string field, omitted if empty
+func appendString(b []byte, field int, s string) []byte {
+	if s == "" {
+		return b
+	}
+	b = appendVarint(appendTag(b, field, wireBytes), uint64(len(s)))
+	return append(b, s...)
+}
+
+// appendRepeated appends the strings of a repeated field, the empty ones included
+func appendRepeated(b []byte, field int, ss []string) []byte {
+	for _, s := range ss {
+		b = appendVarint(appendTag(b, field, wireBytes), uint64(len(s)))
+		b = append(b, s...)
+	}
+	return b
+}
+
+// appendMessage appends an embedded message field
+func appendMessage(b []byte, field int, msg []byte) []byte {
+	b = appendVarint(appendTag(b, field, wireBytes), uint64(len(msg)))
+	return append(b, msg...)
+}
+
+// decoder reads the fields of a message
+type decoder struct {
+	data []byte
+}
+
+// next returns the number and the wire type of the next field, false at the end of the message
+func (d *decoder) next() (int, int, bool, error) {
+	if len(d.data) == 0 {
+		return 0, 0, false, nil
+	}
+	key, err := d.varint()
+	if err != nil {
+		return 0, 0, false, err
+	}
+	field, wire := int(key>>3), int(key&7)
+	if field <= 0 {
+		return 0, 0, false, fmt.Errorf("invalid protobuf field number %d", field)
+	}
+	return field, wire, true, nil
+}
+
+// varint reads a varint
+func (d *decoder) varint() (uint64, error) {
+	var v uint64
+	for i := 0; i < 10; i++ {
+		if i >= len(d.data) {
+			return 0, errTruncated
+		}
+		c := d.data[i]
+		v |= uint64(c&0x7f) << (7 * i)
+		if c < 0x80 {
+			d.data = d.data[i+1:]
+			return v, nil
+		}
+	}
+	return 0, errors.New("protobuf varint overflow")
+}
+
+// bytes reads a length-delimited value
+func (d *decoder) bytes() ([]byte, error) {
+	n, err := d.varint()
+	if err != nil {
+		return nil, err
+	}
+	if n > uint64(len(d.data)) {
+		return nil, errTruncated
+	}
+	b := d.data[:n]
+	d.data = d.data[n:]
+	return b, nil
+}
+
+// int reads an int64 field of the given wire type
+func (d *decoder) int(wire int) (int64, error) {
+	if wire != wireVarint {
+		return 0, fmt.Errorf("wrong protobuf wire type %d for an integer", wire)
+	}
+	v, err := d.varint()
+	return int64(v), err
+}
+
+// string reads a string field of the given wire type
+func (d *decoder) string(wire int) (string, error) {
+	if wire != wireBytes {
+		return "", fmt.Errorf("wrong protobuf wire type %d for a string", wire)
+	}
+	b, err := d.bytes()
+	return string(b), err
+}
+
+// message reads an embedded message field of the given wire type
+func (d *decoder) message(wire int) ([]byte, error) {
+	if wire != wireBytes {
+		return nil, fmt.Errorf("wrong protobuf wire type %d for a message", wire)
+	}
+	return d.bytes()
+}
+
+// skip skips the value of an unknown field of the given wire type
+func (d *decoder) skip(wire int) error {
+	var err error
+	switch wire {
+	case wireVarint:
+		_, err = d.varint()
+	case wireBytes:
+		_, err = d.bytes()
+	case wire64, wire32:
+		n := 8
+		if wire == wire32 {
+			n = 4
+		}
+		if len(d.data) < n {
+			return errTruncated
+		}
+		d.data = d.data[n:]
+	default:
+		err = fmt.Errorf("unsupported protobuf wire type %d", wire)
+	}
+	return err
+}
@@ -0,0 +1,57 @@
+package pb
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestWireEncoding(t *testing.T) {
+	// examples of the Protocol Buffers encoding guide
+	testCases := []struct {
+		name     string
+		actual   []byte
+		expected []byte
+	}{
+		{name: "varint", actual: appendInt(nil, 1, 150), expected: []byte{0x08, 0x96, 0x01}},
+		{name: "string", actual: appendString(nil, 2, "testing"), expected: []byte{0x12, 0x07, 't', 'e', 's', 't', 'i', 'n', 'g'}},
+		{name: "message", actual: appendMessage(nil, 3, appendInt(nil, 1, 150)), expected: []byte{0x1a, 0x03, 0x08, 0x96, 0x01}},
+		{name: "negative", actual: appendInt(nil, 1, -1), expected: []byte{0x08, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x01}},
+		{name: "zero omitted", actual: appendInt(appendString(nil, 2, ""), 1, 0), expected: nil},
+		{name: "empty repeated kept", actual: appendRepeated(nil, 2, []string{""}), expected: []byte{0x12, 0x00}},
+	}
+
+	for _, tc := range testCases {
+		if !bytes.Equal(tc.actual, tc.expected) {
+			t.Fatalf("Wrong encoding of %s. Expected % x, got % x", tc.name, tc.expected, tc.actual)
+		}
+	}
+
+	d := &decoder{data: []byte{0x08, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x01}}
+	if _, wire, _, err := d.next(); err != nil || wire != wireVarint {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if v, err := d.int(wireVarint); err != nil || v != -1 {
+		t.Fatalf("Wrong negative value. Expected -1, got %d: %v", v, err)
+	}
+}
+
+func TestWireDecodingErrors(t *testing.T) {
+	testCases := []struct {
+		name string
+		data []byte
+	}{
+		{name: "truncated varint", data: []byte{0x08, 0x96}},
+		{name: "truncated string", data: []byte{0x12, 0x07, 't'}},
+		{name: "varint overflow", data: []byte{0x08, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x01}},
+		{name: "field zero", data: []byte{0x00, 0x01}},
+		{name: "wrong wire type", data: []byte{0x0a, 0x00}},
+		{name: "truncated fixed", data: []byte{0x19, 0x00}},
+		{name: "group", data: []byte{0x1b}},
+	}
+
+	for _, tc := range testCases {
+		if err := (&Escape{}).Unmarshal(tc.data); err == nil {
+			t.Fatalf("Expected an error for %s", tc.name)
+		}
+	}
+}
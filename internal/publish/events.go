@@ -0,0 +1,96 @@
+package publish
+
+import (
+	"encoding/json"
+
+	"bender/internal/bender"
+	"bender/internal/fsm"
+)
+
+// Publisher sends messages to the subjects of a broker
+type Publisher interface {
+	Publish(subject string, data []byte) error
+}
+
+// StepMessage is published for every step of Bender
+type StepMessage struct {
+	// number of the run, to tell apart the simulations of a stream
+	Run int `json:"run"`
+	// number of the step, starting from 1
+	Step int `json:"step"`
+	// direction of the step
+	Direction string `json:"direction"`
+	// position and tile entered by Bender
+	X    int    `json:"x"`
+	Y    int    `json:"y"`
+	Tile string `json:"tile"`
+	// rule which chose the direction and rule applied by the tile, if any
+	Rule   string `json:"rule"`
+	Effect string `json:"effect,omitempty"`
+}
+
+// ResultMessage is published at the end of every run
+type ResultMessage struct {
+	// number of the run, to tell apart the simulations of a stream
+	Run int `json:"run"`
+	// how the simulation ended
+	Outcome string `json:"outcome"`
+	// path followed by Bender
+	Path []string `json:"path"`
+	// error of the run, the other fields are empty if set
+	Error string `json:"error,omitempty"`
+}
+
+// Events publishes the steps on the subject <subject>.steps, the results on <subject>.results
+// and the named events of the runs on <subject>.events.<name>
+type Events struct {
+	pub     Publisher
+	subject string
+}
+
+// NewEvents returns the events published with the given publisher under the given subject
+func NewEvents(pub Publisher, subject string) *Events {
+	return &Events{pub: pub, subject: subject}
+}
+
+// Step publishes the step of the given run done by the given entered event of the simulator,
+// followed by its named events
+func (ev *Events) Step(run, step int, e *fsm.Event, b *bender.BenderSimulator) error {
+	p := e.DstPosition()
+	choice, effect := b.StepRules()
+	err := ev.publish("steps", StepMessage{
+		Run:       run,
+		Step:      step,
+		Direction: string(e.Event),
+		X:         p.X,
+		Y:         p.Y,
+		Tile:      string(e.Dst),
+		Rule:      string(choice),
+		Effect:    string(effect),
+	})
+	if err != nil {
+		return err
+	}
+	return ev.hooks(run, step, e, b)
+}
+
+// Result publishes the result of the given run, or its error
+func (ev *Events) Result(run int, res bender.Result, err error) error {
+	m := ResultMessage{Run: run, Path: []string{}}
+	if err != nil {
+		m.Error = err.Error()
+	} else {
+		m.Outcome = res.Outcome.String()
+		m.Path = append(m.Path, res.Path...)
+	}
+	return ev.publish("results", m)
+}
+
+// publish sends the message encoded as JSON to the given subject under the subject of the events
+func (ev *Events) publish(subject string, m interface{}) error {
+	data, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	return ev.pub.Publish(ev.subject+"."+subject, data)
+}
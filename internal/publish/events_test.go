@@ -0,0 +1,59 @@
+package publish
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"bender/internal/bender"
+	"bender/internal/fsm"
+)
+
+// recorder records the published messages
+type recorder []string
+
+func (r *recorder) Publish(subject string, data []byte) error {
+	*r = append(*r, subject+" "+string(data))
+	return nil
+}
+
+func TestEvents(t *testing.T) {
+	plan := []string{
+		"#####",
+		"#@ $#",
+		"#####",
+	}
+	rec := &recorder{}
+	ev := NewEvents(rec, "bender")
+
+	step := 0
+	b := bender.NewBenderSimulator()
+	f, err := fsm.NewFSM(plan, bender.BeforeCallback, func(e *fsm.Event) {
+		bender.EnterCallback(e)
+		step++
+		if err := ev.Step(1, step, e, b); err != nil {
+			e.Abort(err)
+		}
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	res, err := bender.Resume(f, b)
+	if err := ev.Result(1, res, err); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := ev.Result(2, bender.Result{}, fmt.Errorf("invalid map")); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := &recorder{
+		`bender.steps {"run":1,"step":1,"direction":"EAST","x":2,"y":1,"tile":" ","rule":"priority fallback"}`,
+		`bender.steps {"run":1,"step":2,"direction":"EAST","x":3,"y":1,"tile":"$","rule":"forward","effect":"booth"}`,
+		`bender.events.reached {"run":1,"event":"reached","step":2,"direction":"EAST","x":3,"y":1}`,
+		`bender.results {"run":1,"outcome":"reached","path":["EAST","EAST"]}`,
+		`bender.results {"run":2,"outcome":"","path":[],"error":"invalid map"}`,
+	}
+	if !reflect.DeepEqual(rec, expected) {
+		t.Fatalf("Wrong messages. Expected %q, got %q", *expected, *rec)
+	}
+}
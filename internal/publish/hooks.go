@@ -0,0 +1,95 @@
+package publish
+
+import (
+	"bender/internal/bender"
+	"bender/internal/fsm"
+)
+
+// names of the events of a run published on <subject>.events.<name>,
+// so the front-ends trigger their effects without parsing the steps
+const (
+	// Bender hits an obstacle, the position is the obstacle
+	HookBoom = "boom"
+	// Bender is teleported, the position is the entered teleport and to the other one
+	HookTeleport = "teleport"
+	// Bender drinks a beer and the breaker mode is on
+	HookBreakerOn = "breaker_on"
+	// Bender drinks a beer and the breaker mode is off
+	HookBreakerOff = "breaker_off"
+	// Bender crosses an inverter
+	HookInverted = "inverted"
+	// Bender reaches the suicide booth
+	HookReached = "reached"
+	// Bender enters an endless cycle
+	HookLoop = "loop"
+)
+
+// Position is a position on the board
+type Position struct {
+	X int `json:"x"`
+	Y int `json:"y"`
+}
+
+// HookMessage is published for the named events of a run, its schema is schema/events.schema.json
+type HookMessage struct {
+	// number of the run, to tell apart the simulations of a stream
+	Run int `json:"run"`
+	// name of the event
+	Event string `json:"event"`
+	// number of the steps done when the event happened
+	Step int `json:"step"`
+	// direction of Bender
+	Direction string `json:"direction"`
+	// position of the event
+	X int `json:"x"`
+	Y int `json:"y"`
+	// destination of a teleport
+	To *Position `json:"to,omitempty"`
+}
+
+// Before publishes the boom of the given run if the given before event of the simulator was cancelled by an obstacle,
+// steps is the number of the steps done so far
+func (ev *Events) Before(run, steps int, e *fsm.Event) error {
+	if !e.Cancelled || (e.Dst != '#' && e.Dst != 'X') {
+		return nil
+	}
+	p := e.DstPosition()
+	return ev.hook(HookMessage{Run: run, Event: HookBoom, Step: steps, Direction: string(e.Event), X: p.X, Y: p.Y})
+}
+
+// hooks publishes the named events of the given step done by the given entered event of the simulator
+func (ev *Events) hooks(run, step int, e *fsm.Event, b *bender.BenderSimulator) error {
+	p := e.DstPosition()
+	m := HookMessage{Run: run, Step: step, Direction: string(e.Event), X: p.X, Y: p.Y}
+	_, effect := b.StepRules()
+	switch effect {
+	case bender.RuleTeleport:
+		m.Event = HookTeleport
+		to := e.Position()
+		m.To = &Position{X: to.X, Y: to.Y}
+	case bender.RuleBreakerToggle:
+		m.Event = HookBreakerOff
+		if b.Breaker() {
+			m.Event = HookBreakerOn
+		}
+	case bender.RuleInversion:
+		m.Event = HookInverted
+	case bender.RuleBooth:
+		m.Event = HookReached
+	}
+	if m.Event != "" {
+		if err := ev.hook(m); err != nil {
+			return err
+		}
+	}
+	if b.Loop() {
+		m.Event, m.To = HookLoop, nil
+		return ev.hook(m)
+	}
+	return nil
+}
+
+// hook publishes the given named event on <subject>.events.<name>
+func (ev *Events) hook(m HookMessage) error {
+	return ev.publish("events."+m.Event, m)
+}
@@ -0,0 +1,106 @@
+package publish
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"bender/internal/bender"
+	"bender/internal/fsm"
+)
+
+func TestHooks(t *testing.T) {
+	testCases := []struct {
+		name     string
+		plan     []string
+		expected []HookMessage
+	}{
+		{
+			name: "boom",
+			plan: []string{"#####", "#@ $#", "#####"},
+			expected: []HookMessage{
+				{Run: 1, Event: HookBoom, Step: 0, Direction: "SOUTH", X: 1, Y: 2},
+				{Run: 1, Event: HookReached, Step: 2, Direction: "EAST", X: 3, Y: 1},
+			},
+		},
+		{
+			name: "breaker and inverter",
+			plan: []string{"###", "#@#", "#B#", "#I#", "#B#", "#$#", "###"},
+			expected: []HookMessage{
+				{Run: 1, Event: HookBreakerOn, Step: 1, Direction: "SOUTH", X: 1, Y: 2},
+				{Run: 1, Event: HookInverted, Step: 2, Direction: "SOUTH", X: 1, Y: 3},
+				{Run: 1, Event: HookBreakerOff, Step: 3, Direction: "SOUTH", X: 1, Y: 4},
+				{Run: 1, Event: HookReached, Step: 4, Direction: "SOUTH", X: 1, Y: 5},
+			},
+		},
+		{
+			name: "teleport",
+			plan: []string{"#####", "#@#T#", "#T#$#", "#####"},
+			expected: []HookMessage{
+				{Run: 1, Event: HookTeleport, Step: 1, Direction: "SOUTH", X: 1, Y: 2, To: &Position{X: 3, Y: 1}},
+				{Run: 1, Event: HookReached, Step: 2, Direction: "SOUTH", X: 3, Y: 2},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		got := runHooks(t, tc.plan)
+		if !reflect.DeepEqual(got, tc.expected) {
+			t.Fatalf("Test case %q: wrong events. Expected %+v, got %+v", tc.name, tc.expected, got)
+		}
+	}
+
+	// Bender turns around the room forever
+	got := runHooks(t, []string{"####", "#@ #", "#  #", "####"})
+	if len(got) == 0 || got[len(got)-1].Event != HookLoop {
+		t.Fatalf("Expected a loop event at the end, got %+v", got)
+	}
+	for _, m := range got[:len(got)-1] {
+		if m.Event != HookBoom {
+			t.Fatalf("Expected only booms before the loop, got %+v", got)
+		}
+	}
+}
+
+// runHooks simulates the plan publishing its events and returns the named events
+func runHooks(t *testing.T, plan []string) []HookMessage {
+	rec := &recorder{}
+	ev := NewEvents(rec, "bender")
+	b := bender.NewBenderSimulator()
+	var f *fsm.FSM
+	f, err := fsm.NewFSM(plan, func(e *fsm.Event) {
+		bender.BeforeCallback(e)
+		if err := ev.Before(1, f.Steps(), e); err != nil {
+			e.Abort(err)
+		}
+	}, func(e *fsm.Event) {
+		bender.EnterCallback(e)
+		if err := ev.Step(1, f.Steps(), e, b); err != nil {
+			e.Abort(err)
+		}
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, err := bender.Resume(f, b); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	hooks := []HookMessage{}
+	for _, msg := range *rec {
+		parts := strings.SplitN(msg, " ", 2)
+		if !strings.HasPrefix(parts[0], "bender.events.") {
+			continue
+		}
+		m := HookMessage{}
+		if err := json.Unmarshal([]byte(parts[1]), &m); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if parts[0] != "bender.events."+m.Event {
+			t.Fatalf("Wrong subject %q of the event %q", parts[0], m.Event)
+		}
+		hooks = append(hooks, m)
+	}
+	return hooks
+}
@@ -0,0 +1,240 @@
+// Package publish connects the simulations to message brokers: it emits their events and results and receives jobs
+package publish
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// natsTimeout is the time given to the NATS server to answer
+const natsTimeout = 5 * time.Second
+
+// NATS publishes and receives messages with a NATS server using its text protocol
+// it's safe for concurrent use
+type NATS struct {
+	conn net.Conn
+	// mu guards the writer, the pending flushes, the subscriptions and the error
+	mu sync.Mutex
+	w  *bufio.Writer
+	// channels closed by the PONG answering the pending PINGs, in order
+	pongs []chan struct{}
+	// channels receiving the messages of the subscriptions, by subscription id
+	subs    map[string]chan []byte
+	nextSid int
+	// number of the messages dropped because their receiver lagged
+	dropped int
+	// error sent by the server or of the connection
+	err error
+}
+
+// DialNATS connects to the NATS server at the given address, like nats://localhost:4222 or localhost:4222
+func DialNATS(addr string) (*NATS, error) {
+	addr = strings.TrimPrefix(addr, "nats://")
+	conn, err := net.DialTimeout("tcp", addr, natsTimeout)
+	if err != nil {
+		return nil, err
+	}
+	n := &NATS{conn: conn, w: bufio.NewWriter(conn), subs: map[string]chan []byte{}}
+	r := bufio.NewReader(conn)
+
+	// the server greets with its information
+	conn.SetReadDeadline(time.Now().Add(natsTimeout))
+	line, err := r.ReadString('\n')
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if !strings.HasPrefix(line, "INFO ") {
+		conn.Close()
+		return nil, fmt.Errorf("unexpected NATS greeting %q", strings.TrimSpace(line))
+	}
+	conn.SetReadDeadline(time.Time{})
+	go n.read(r)
+
+	n.mu.Lock()
+	fmt.Fprint(n.w, "CONNECT {\"verbose\":false,\"pedantic\":false,\"name\":\"bender\"}\r\n")
+	n.mu.Unlock()
+	if err := n.Flush(); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return n, nil
+}
+
+// Publish sends the given data to the given subject
+// the message is buffered, Flush waits for the server to process it
+func (n *NATS) Publish(subject string, data []byte) error {
+	if subject == "" || strings.ContainsAny(subject, " \t\r\n") {
+		return fmt.Errorf("invalid NATS subject %q", subject)
+	}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.err != nil {
+		return n.err
+	}
+	fmt.Fprintf(n.w, "PUB %s %d\r\n", subject, len(data))
+	n.w.Write(data)
+	if _, err := n.w.WriteString("\r\n"); err != nil {
+		n.err = err
+	}
+	return n.err
+}
+
+// Flush sends the buffered messages and waits for the server to process them
+func (n *NATS) Flush() error {
+	pong := make(chan struct{})
+	n.mu.Lock()
+	if n.err != nil {
+		n.mu.Unlock()
+		return n.err
+	}
+	n.pongs = append(n.pongs, pong)
+	n.w.WriteString("PING\r\n")
+	if err := n.w.Flush(); err != nil {
+		n.err = err
+	}
+	err := n.err
+	n.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	select {
+	case <-pong:
+	case <-time.After(natsTimeout):
+		return fmt.Errorf("NATS server didn't answer in %v", natsTimeout)
+	}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.err
+}
+
+// Subscribe receives the messages sent to the given subject
+// the messages are shared among the subscribers of the same queue group if not empty
+// NATS delivers every message at most once, the messages are dropped if the receiver lags too much, see Dropped
+// the channel is closed with the connection
+func (n *NATS) Subscribe(subject, queue string) (<-chan []byte, error) {
+	if subject == "" || strings.ContainsAny(subject, " \t\r\n") || strings.ContainsAny(queue, " \t\r\n") {
+		return nil, fmt.Errorf("invalid NATS subject %q or queue group %q", subject, queue)
+	}
+	n.mu.Lock()
+	if n.err != nil {
+		n.mu.Unlock()
+		return nil, n.err
+	}
+	n.nextSid++
+	sid := fmt.Sprint(n.nextSid)
+	msgs := make(chan []byte, 64)
+	n.subs[sid] = msgs
+	if queue != "" {
+		fmt.Fprintf(n.w, "SUB %s %s %s\r\n", subject, queue, sid)
+	} else {
+		fmt.Fprintf(n.w, "SUB %s %s\r\n", subject, sid)
+	}
+	n.mu.Unlock()
+	if err := n.Flush(); err != nil {
+		return nil, err
+	}
+	return msgs, nil
+}
+
+// Dropped returns the number of the messages of the subscriptions dropped because their receiver lagged
+func (n *NATS) Dropped() int {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.dropped
+}
+
+// Close flushes the buffered messages and closes the connection
+func (n *NATS) Close() error {
+	err := n.Flush()
+	if cerr := n.conn.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// read handles the messages of the server until the connection is closed
+func (n *NATS) read(r *bufio.Reader) {
+	defer func() {
+		n.mu.Lock()
+		defer n.mu.Unlock()
+		for sid, msgs := range n.subs {
+			close(msgs)
+			delete(n.subs, sid)
+		}
+	}()
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			n.fail(err)
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+		switch {
+		case strings.HasPrefix(line, "MSG "):
+			// MSG <subject> <sid> [reply-to] <size>
+			fields := strings.Fields(line)
+			size, err := strconv.Atoi(fields[len(fields)-1])
+			if len(fields) < 4 || err != nil || size < 0 {
+				n.fail(fmt.Errorf("malformed NATS message %q", line))
+				return
+			}
+			data := make([]byte, size+2)
+			if _, err := io.ReadFull(r, data); err != nil {
+				n.fail(err)
+				return
+			}
+			n.mu.Lock()
+			msgs := n.subs[fields[2]]
+			n.mu.Unlock()
+			if msgs != nil {
+				// waiting for the receiver would stop the answers to the PINGs of the server
+				select {
+				case msgs <- data[:size]:
+				default:
+					n.mu.Lock()
+					n.dropped++
+					n.mu.Unlock()
+				}
+			}
+		case line == "PING":
+			n.mu.Lock()
+			n.w.WriteString("PONG\r\n")
+			n.w.Flush()
+			n.mu.Unlock()
+		case line == "PONG":
+			n.mu.Lock()
+			if len(n.pongs) > 0 {
+				close(n.pongs[0])
+				n.pongs = n.pongs[1:]
+			}
+			n.mu.Unlock()
+		case strings.HasPrefix(line, "-ERR"):
+			n.fail(fmt.Errorf("NATS error: %s", strings.Trim(strings.TrimSpace(line[len("-ERR"):]), "'")))
+		}
+	}
+}
+
+// fail records the first error and releases the pending flushes
+func (n *NATS) fail(err error) {
+	if errors.Is(err, net.ErrClosed) {
+		err = fmt.Errorf("NATS connection closed")
+	}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.err == nil {
+		n.err = err
+	}
+	for _, p := range n.pongs {
+		close(p)
+	}
+	n.pongs = nil
+}
@@ -0,0 +1,198 @@
+package publish
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// fakeNATS is a NATS server recording the published messages
+type fakeNATS struct {
+	l net.Listener
+	// messages received, formatted as subject:payload
+	msgs chan string
+}
+
+// newFakeNATS starts a server answering the given error to every publication if not empty
+// the given messages are delivered to every subscription
+func newFakeNATS(t *testing.T, pubErr string, deliver ...string) *fakeNATS {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	t.Cleanup(func() { l.Close() })
+	s := &fakeNATS{l: l, msgs: make(chan string, 100)}
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		fmt.Fprint(conn, "INFO {\"server_id\":\"fake\"}\r\n")
+		// the server pings the client first
+		fmt.Fprint(conn, "PING\r\n")
+		r := bufio.NewReader(conn)
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			line = strings.TrimRight(line, "\r\n")
+			switch {
+			case strings.HasPrefix(line, "SUB "):
+				fields := strings.Fields(line)
+				s.msgs <- strings.Join(fields[:len(fields)-1], " ")
+				for _, m := range deliver {
+					fmt.Fprintf(conn, "MSG %s %s %d\r\n%s\r\n", fields[1], fields[len(fields)-1], len(m), m)
+				}
+			case line == "PING":
+				fmt.Fprint(conn, "PONG\r\n")
+			case strings.HasPrefix(line, "PUB "):
+				var subject string
+				var size int
+				fmt.Sscanf(line, "PUB %s %d", &subject, &size)
+				data := make([]byte, size+2)
+				if _, err := io.ReadFull(r, data); err != nil {
+					return
+				}
+				if pubErr != "" {
+					fmt.Fprintf(conn, "-ERR '%s'\r\n", pubErr)
+					continue
+				}
+				s.msgs <- subject + ":" + string(data[:size])
+			}
+		}
+	}()
+	return s
+}
+
+func TestNATSPublish(t *testing.T) {
+	s := newFakeNATS(t, "")
+	n, err := DialNATS("nats://" + s.l.Addr().String())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected := []string{"bender.results:{}", "bender.steps:a\r\nb", "bender.steps:"}
+	for _, m := range expected {
+		i := strings.Index(m, ":")
+		if err := n.Publish(m[:i], []byte(m[i+1:])); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+	if err := n.Publish("bad subject", nil); err == nil {
+		t.Fatalf("Expected an error for a subject with a space")
+	}
+	if err := n.Close(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	actual := []string{}
+	for range expected {
+		actual = append(actual, <-s.msgs)
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Fatalf("Wrong messages. Expected %q, got %q", expected, actual)
+	}
+}
+
+func TestNATSServerError(t *testing.T) {
+	s := newFakeNATS(t, "Permissions Violation for Publish")
+	n, err := DialNATS(s.l.Addr().String())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer n.Close()
+	if err := n.Publish("bender.results", []byte("{}")); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	err = n.Flush()
+	expected := "NATS error: Permissions Violation for Publish"
+	if err == nil || err.Error() != expected {
+		t.Fatalf("Wrong error. Expected %q, got %v", expected, err)
+	}
+	if err := n.Publish("bender.results", []byte("{}")); err == nil {
+		t.Fatalf("Expected the error to be kept")
+	}
+}
+
+func TestDialNATSNotNATS(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer l.Close()
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		fmt.Fprint(conn, "HTTP/1.1 400 Bad Request\r\n")
+	}()
+	if _, err := DialNATS(l.Addr().String()); err == nil {
+		t.Fatalf("Expected an error for a server which isn't NATS")
+	}
+}
+
+func TestNATSSubscribe(t *testing.T) {
+	expected := []string{"#@$#", "", "a\r\nb"}
+	s := newFakeNATS(t, "", expected...)
+	n, err := DialNATS(s.l.Addr().String())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	msgs, err := n.Subscribe("maps", "workers")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if sub := <-s.msgs; sub != "SUB maps workers" {
+		t.Fatalf("Wrong subscription. Expected %q, got %q", "SUB maps workers", sub)
+	}
+	actual := []string{}
+	for range expected {
+		actual = append(actual, string(<-msgs))
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Fatalf("Wrong messages. Expected %q, got %q", expected, actual)
+	}
+	if _, err := n.Subscribe("maps", "bad queue"); err == nil {
+		t.Fatalf("Expected an error for a queue group with a space")
+	}
+
+	n.Close()
+	if _, open := <-msgs; open {
+		t.Fatalf("Expected the messages to be closed with the connection")
+	}
+}
+
+func TestNATSSlowReceiver(t *testing.T) {
+	deliver := make([]string, 100)
+	for i := range deliver {
+		deliver[i] = fmt.Sprint(i)
+	}
+	s := newFakeNATS(t, "", deliver...)
+	n, err := DialNATS(s.l.Addr().String())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer n.Close()
+	// the messages aren't received while the server waits for the PONG
+	msgs, err := n.Subscribe("maps", "")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := n.Flush(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if expected := len(deliver) - cap(msgs); n.Dropped() != expected {
+		t.Fatalf("Wrong dropped messages. Expected %v, got %v", expected, n.Dropped())
+	}
+	for i := 0; i < cap(msgs); i++ {
+		if m := string(<-msgs); m != deliver[i] {
+			t.Fatalf("Wrong message. Expected %q, got %q", deliver[i], m)
+		}
+	}
+}
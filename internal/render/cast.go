@@ -0,0 +1,147 @@
+package render
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"bender/internal/fsm"
+)
+
+// castDelay is the default time between two frames of a cast
+const castDelay = 100 * time.Millisecond
+
+// castClear moves the cursor home and clears the screen before every frame
+const castClear = "\x1b[H\x1b[2J"
+
+// castHeader is the header of an asciinema cast (format version 2)
+type castHeader struct {
+	Version int    `json:"version"`
+	Width   int    `json:"width"`
+	Height  int    `json:"height"`
+	Title   string `json:"title,omitempty"`
+}
+
+// CastRenderer writes the terminal animation as an asciinema cast (https://asciinema.org),
+// a header line followed by a line per frame, to be played by asciinema or embedded in web pages
+// the frames are timed at a fixed delay so the casts of a run are reproducible
+type CastRenderer struct {
+	w      *bufio.Writer
+	labels Labels
+	theme  *Theme
+	delay  time.Duration
+	// time of the next frame
+	at    time.Duration
+	frame bytes.Buffer
+	err   error
+}
+
+// NewCastRenderer returns a renderer writing the cast to w, the frames are delay apart
+// the directions are printed with the given labels
+func NewCastRenderer(w io.Writer, labels Labels, delay time.Duration) *CastRenderer {
+	if delay <= 0 {
+		delay = castDelay
+	}
+	return &CastRenderer{
+		w:      bufio.NewWriter(w),
+		labels: labels,
+		theme:  ClassicTheme,
+		delay:  delay,
+	}
+}
+
+// SetTheme sets the theme of the tiles, classic if nil
+func (c *CastRenderer) SetTheme(theme *Theme) {
+	if theme == nil {
+		theme = ClassicTheme
+	}
+	c.theme = theme
+}
+
+// RenderBoard writes the header sized for the plan and the first frame with the plan
+func (c *CastRenderer) RenderBoard(plan []string) error {
+	width, cell := 0, 1
+	for _, g := range c.theme.Glyphs {
+		if w := displayWidth(g); w > cell {
+			cell = w
+		}
+	}
+	for _, s := range plan {
+		if len(s)*cell > width {
+			width = len(s) * cell
+		}
+	}
+	// the frames have the direction and the destroyed wall above the board
+	h := castHeader{Version: 2, Width: width, Height: len(plan) + 2}
+	data, err := json.Marshal(h)
+	if err != nil {
+		return err
+	}
+	c.w.Write(data)
+	c.w.WriteByte('\n')
+
+	fw := bufio.NewWriter(&c.frame)
+	fw.WriteString(castClear + "Plan:\r\n")
+	for _, s := range plan {
+		for i := 0; i < len(s); i++ {
+			fw.WriteString(c.theme.glyph(s[i]))
+		}
+		fw.WriteString("\r\n")
+	}
+	fw.Flush()
+	return c.output()
+}
+
+// RenderStep writes the frame of the board with Bender at its current position
+func (c *CastRenderer) RenderStep(e *fsm.Event) error {
+	fw := bufio.NewWriter(&c.frame)
+	fw.WriteString(castClear)
+	writeFrame(fw, e, c.labels, c.theme, "\r\n")
+	fw.Flush()
+	return c.output()
+}
+
+// RenderPath writes the path after the last frame
+func (c *CastRenderer) RenderPath(path []string) error {
+	fmt.Fprint(&c.frame, c.labels.Path(path), "\r\n")
+	return c.output()
+}
+
+// output writes the frame as an output event at its time, the next frame is delayed
+// every event is flushed so the cast can be played while it's written
+func (c *CastRenderer) output() error {
+	if c.err != nil {
+		return c.err
+	}
+	data, err := json.Marshal([]interface{}{c.at.Seconds(), "o", c.frame.String()})
+	c.frame.Reset()
+	if err != nil {
+		c.err = err
+		return err
+	}
+	c.at += c.delay
+	c.w.Write(data)
+	c.w.WriteByte('\n')
+	c.err = c.w.Flush()
+	return c.err
+}
+
+// displayWidth returns the number of terminal columns of the glyph:
+// the emoji take two columns, the other characters one
+func displayWidth(g string) int {
+	w := 0
+	for _, r := range g {
+		switch {
+		case r == '\ufe0f' || r == '\u200d':
+			// variation selector and joiner
+		case r >= 0x1f000:
+			w += 2
+		default:
+			w++
+		}
+	}
+	return w
+}
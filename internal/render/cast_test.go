@@ -0,0 +1,76 @@
+package render
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// castEvent is an output event of a cast
+type castEvent struct {
+	at    float64
+	frame string
+}
+
+func TestCastRenderer(t *testing.T) {
+	plan := []string{
+		"####",
+		"#@ #",
+		"# $#",
+		"####",
+	}
+	buf := &bytes.Buffer{}
+	renderRun(t, plan, NewCastRenderer(buf, LetterLabels, 250*time.Millisecond))
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if expected := `{"version":2,"width":4,"height":6}`; lines[0] != expected {
+		t.Fatalf("Wrong header. Expected %s, got %s", expected, lines[0])
+	}
+	got := []castEvent{}
+	for _, line := range lines[1:] {
+		var ev []interface{}
+		if err := json.Unmarshal([]byte(line), &ev); err != nil {
+			t.Fatalf("Malformed event %s: %v", line, err)
+		}
+		if len(ev) != 3 || ev[1] != "o" {
+			t.Fatalf("Wrong event %s", line)
+		}
+		got = append(got, castEvent{at: ev[0].(float64), frame: ev[2].(string)})
+	}
+	expected := []castEvent{
+		{at: 0, frame: castClear + "Plan:\r\n####\r\n#@ #\r\n# $#\r\n####\r\n"},
+		{at: 0.25, frame: castClear + "S\r\n####\r\n#  #\r\n#@$#\r\n####\r\n"},
+		{at: 0.5, frame: castClear + "E\r\n####\r\n#  #\r\n# @#\r\n####\r\n"},
+		{at: 0.75, frame: "[S E]\r\n"},
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Fatalf("Wrong events. Expected %+v, got %+v", expected, got)
+	}
+}
+
+func TestCastWidth(t *testing.T) {
+	testCases := []struct {
+		theme    *Theme
+		expected string
+	}{
+		{theme: nil, expected: `{"version":2,"width":5,"height":4}`},
+		{theme: UnicodeTheme, expected: `{"version":2,"width":5,"height":4}`},
+		{theme: EmojiTheme, expected: `{"version":2,"width":10,"height":4}`},
+	}
+	for _, tc := range testCases {
+		buf := &bytes.Buffer{}
+		r, err := NewRenderer("cast", buf, nil, tc.theme)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if err := r.RenderBoard([]string{"#####", "#@ $#"}); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if header, _, _ := strings.Cut(buf.String(), "\n"); header != tc.expected {
+			t.Fatalf("Wrong header. Expected %s, got %s", tc.expected, header)
+		}
+	}
+}
@@ -0,0 +1,150 @@
+package render
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+
+	"bender/internal/fsm"
+)
+
+// Move is a step of a recorded run
+type Move struct {
+	// direction of the step
+	Direction fsm.Direction
+	// position of Bender after the step
+	At fsm.Pair
+	// true if the step destroyed a breakable wall
+	Destroyed bool
+}
+
+// Divergence returns the index of the first move which differs between the runs,
+// the length of the shortest run if one is a prefix of the other, or -1 if they're identical
+func Divergence(a, b []Move) int {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if a[i] != b[i] {
+			return i
+		}
+	}
+	if len(a) != len(b) {
+		return min(len(a), len(b))
+	}
+	return -1
+}
+
+// min returns the smallest of the integers
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// Compare plays the two runs of the plan side by side in the terminal, a frame per step:
+// the steps from the divergence are flagged with a '*' and the rows where the boards differ with a '<'
+// the directions are printed with the given labels and the tiles with the given theme, classic if nil
+func Compare(w io.Writer, plan []string, a, b []Move, labels Labels, theme *Theme) error {
+	if theme == nil {
+		theme = ClassicTheme
+	}
+	board := fsm.NewBoard(plan)
+	start := fsm.Pair{X: -1, Y: -1}
+	for y, row := range plan {
+		if x := strings.IndexByte(row, '@'); x >= 0 {
+			start = fsm.Pair{X: x, Y: y}
+			break
+		}
+	}
+	cell := 1
+	for _, g := range theme.Glyphs {
+		if gw := displayWidth(g); gw > cell {
+			cell = gw
+		}
+	}
+	div := Divergence(a, b)
+
+	bw := bufio.NewWriter(w)
+	frames := len(a)
+	if len(b) > frames {
+		frames = len(b)
+	}
+	for n := 0; n <= frames; n++ {
+		mark := " "
+		if div >= 0 && n > div {
+			mark = "*"
+		}
+		fmt.Fprintf(bw, "%sstep %d: %s | %s\n", mark, n, moveLabel(a, n, labels), moveLabel(b, n, labels))
+		rowsA := compareRows(board, start, a, n, theme, cell)
+		rowsB := compareRows(board, start, b, n, theme, cell)
+		for y := range rowsA {
+			diff := ""
+			if rowsA[y] != rowsB[y] {
+				diff = " <"
+			}
+			fmt.Fprintf(bw, "%s | %s%s\n", rowsA[y], rowsB[y], diff)
+		}
+	}
+	switch {
+	case div < 0:
+		fmt.Fprintf(bw, "identical runs of %d steps\n", len(a))
+	default:
+		fmt.Fprintf(bw, "the runs of %d and %d steps diverge at step %d\n", len(a), len(b), div+1)
+	}
+	return bw.Flush()
+}
+
+// moveLabel returns the label of the direction of the n-th step of the run, starting from 1
+func moveLabel(run []Move, n int, labels Labels) string {
+	switch {
+	case n == 0:
+		return "start"
+	case n > len(run):
+		return "ended"
+	}
+	return labels.Label(string(run[n-1].Direction))
+}
+
+// compareRows returns the rows of the board after the n first moves of the run, or all of them if the run is shorter,
+// every row is padded to the width of the board
+func compareRows(board fsm.Board, start fsm.Pair, run []Move, n int, theme *Theme, cell int) []string {
+	if n > len(run) {
+		n = len(run)
+	}
+	destroyed := map[fsm.Pair]bool{}
+	for _, m := range run[:n] {
+		if m.Destroyed {
+			destroyed[m.At] = true
+		}
+	}
+	pos := start
+	if n > 0 {
+		pos = run[n-1].At
+	}
+	rows := make([]string, 0, board.Height())
+	for y := 0; y < board.Height(); y++ {
+		sb := &strings.Builder{}
+		width := 0
+		for x := 0; x < board.Width(); x++ {
+			c := board.At(x, y)
+			p := fsm.Pair{X: x, Y: y}
+			switch {
+			case c == 0:
+				// end of a short row
+				continue
+			case p == pos:
+				c = '@'
+			case c == '@' || destroyed[p]:
+				c = ' '
+			}
+			g := theme.glyph(c)
+			sb.WriteString(g)
+			width += displayWidth(g)
+		}
+		if pad := board.Width()*cell - width; pad > 0 {
+			sb.WriteString(strings.Repeat(" ", pad))
+		}
+		rows = append(rows, sb.String())
+	}
+	return rows
+}
@@ -0,0 +1,85 @@
+package render
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"bender/internal/fsm"
+)
+
+func TestDivergence(t *testing.T) {
+	south := Move{Direction: fsm.SOUTH, At: fsm.Pair{X: 1, Y: 2}}
+	east := Move{Direction: fsm.EAST, At: fsm.Pair{X: 2, Y: 1}}
+	testCases := []struct {
+		a, b     []Move
+		expected int
+	}{
+		{a: nil, b: nil, expected: -1},
+		{a: []Move{south, east}, b: []Move{south, east}, expected: -1},
+		{a: []Move{south, east}, b: []Move{south, south}, expected: 1},
+		{a: []Move{south}, b: []Move{south, east}, expected: 1},
+		{a: []Move{east}, b: []Move{south}, expected: 0},
+		{a: []Move{south}, b: []Move{{Direction: fsm.SOUTH, At: fsm.Pair{X: 1, Y: 2}, Destroyed: true}}, expected: 0},
+	}
+	for _, tc := range testCases {
+		if d := Divergence(tc.a, tc.b); d != tc.expected {
+			t.Fatalf("Wrong divergence of %v and %v. Expected %d, got %d", tc.a, tc.b, tc.expected, d)
+		}
+	}
+}
+
+func TestCompare(t *testing.T) {
+	plan := []string{
+		"####",
+		"#@ #",
+		"#$ #",
+		"####",
+	}
+	a := []Move{{Direction: fsm.SOUTH, At: fsm.Pair{X: 1, Y: 2}}}
+	b := []Move{
+		{Direction: fsm.EAST, At: fsm.Pair{X: 2, Y: 1}},
+		{Direction: fsm.SOUTH, At: fsm.Pair{X: 2, Y: 2}},
+		{Direction: fsm.WEST, At: fsm.Pair{X: 1, Y: 2}},
+	}
+	buf := &bytes.Buffer{}
+	if err := Compare(buf, plan, a, b, LetterLabels, nil); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected := strings.Join([]string{
+		" step 0: start | start",
+		"#### | ####",
+		"#@ # | #@ #",
+		"#$ # | #$ #",
+		"#### | ####",
+		"*step 1: S | E",
+		"#### | ####",
+		"#  # | # @# <",
+		"#@ # | #$ # <",
+		"#### | ####",
+		"*step 2: ended | S",
+		"#### | ####",
+		"#  # | #  #",
+		"#@ # | #$@# <",
+		"#### | ####",
+		"*step 3: ended | W",
+		"#### | ####",
+		"#  # | #  #",
+		"#@ # | #@ #",
+		"#### | ####",
+		"the runs of 1 and 3 steps diverge at step 1",
+		"",
+	}, "\n")
+	if buf.String() != expected {
+		t.Fatalf("Wrong comparison. Expected:\n%s\ngot:\n%s", expected, buf.String())
+	}
+
+	// the rows are aligned whatever the width of the glyphs
+	buf.Reset()
+	if err := Compare(buf, []string{"####", "#@$", "####"}, a, a, nil, EmojiTheme); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !strings.Contains(buf.String(), "🧱🤖🚪   | 🧱🤖🚪  \n") || !strings.HasSuffix(buf.String(), "identical runs of 1 steps\n") {
+		t.Fatalf("Wrong comparison with emoji:\n%s", buf.String())
+	}
+}
@@ -0,0 +1,101 @@
+package render
+
+import (
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"image/color"
+	"io"
+
+	"bender/internal/fsm"
+)
+
+// heatColor is the color of the overlay of the heatmaps
+var heatColor = color.RGBA{0xff, 0x00, 0x00, 0xff}
+
+// Mark is a colored cell overlaid on the board
+type Mark struct {
+	Color color.RGBA
+	// between 0 (transparent) and 1 (opaque)
+	Opacity float64
+	// tooltip of the cell
+	Title string
+}
+
+// Overlay draws the board of the plan as an SVG image with the marks overlaid on their cells,
+// the tiles are colored with the given theme, classic if nil
+func Overlay(w io.Writer, plan []string, marks map[fsm.Pair]Mark, theme *Theme) error {
+	if theme == nil {
+		theme = ClassicTheme
+	}
+	width := 0
+	for _, row := range plan {
+		if len(row) > width {
+			width = len(row)
+		}
+	}
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "<svg xmlns=\"http://www.w3.org/2000/svg\" width=\"%d\" height=\"%d\">\n", width*cellSize, len(plan)*cellSize)
+	for y, row := range plan {
+		for x := range row {
+			fmt.Fprintf(bw, "<rect x=\"%d\" y=\"%d\" width=\"%d\" height=\"%d\" fill=\"%s\"/>\n", x*cellSize, y*cellSize, cellSize, cellSize, hexColor(theme.color(row[x])))
+		}
+	}
+	for y, row := range plan {
+		for x := range row {
+			m, marked := marks[fsm.Pair{X: x, Y: y}]
+			if !marked {
+				continue
+			}
+			fmt.Fprintf(bw, "<rect class=\"mark\" x=\"%d\" y=\"%d\" width=\"%d\" height=\"%d\" fill=\"%s\" fill-opacity=\"%.2f\"><title>",
+				x*cellSize, y*cellSize, cellSize, cellSize, hexColor(m.Color), m.Opacity)
+			xml.EscapeText(bw, []byte(m.Title))
+			fmt.Fprintln(bw, "</title></rect>")
+		}
+	}
+	fmt.Fprintln(bw, "</svg>")
+	return bw.Flush()
+}
+
+// Heatmap draws the board of the plan as an SVG image with the heat of the cells overlaid,
+// the heat is between 0 and 1 and the hottest cells are the most opaque, the cells without heat are left as is
+// every hot cell has its heat as tooltip, the tiles are colored with the given theme, classic if nil
+func Heatmap(w io.Writer, plan []string, heat map[fsm.Pair]float64, theme *Theme) error {
+	marks := make(map[fsm.Pair]Mark, len(heat))
+	for at, h := range heat {
+		// the coldest cells stay visible
+		marks[at] = Mark{Color: heatColor, Opacity: 0.2 + 0.7*h, Title: fmt.Sprintf("%s %.2f", at, h)}
+	}
+	return Overlay(w, plan, marks, theme)
+}
+
+// Thumbnail draws the board of the plan as a small SVG image, its longest side is the given size in pixels,
+// a cell per unit of its view box so it scales with the page, the tiles are colored with the given theme, classic if nil
+func Thumbnail(w io.Writer, plan []string, size int, theme *Theme) error {
+	if theme == nil {
+		theme = ClassicTheme
+	}
+	width := 0
+	for _, row := range plan {
+		if len(row) > width {
+			width = len(row)
+		}
+	}
+	longest := width
+	if len(plan) > longest {
+		longest = len(plan)
+	}
+	if longest == 0 {
+		longest = 1
+	}
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "<svg xmlns=\"http://www.w3.org/2000/svg\" viewBox=\"0 0 %d %d\" width=\"%d\" height=\"%d\" shape-rendering=\"crispEdges\">\n",
+		width, len(plan), width*size/longest, len(plan)*size/longest)
+	for y, row := range plan {
+		for x := range row {
+			fmt.Fprintf(bw, "<rect x=\"%d\" y=\"%d\" width=\"1\" height=\"1\" fill=\"%s\"/>\n", x, y, hexColor(theme.color(row[x])))
+		}
+	}
+	fmt.Fprintln(bw, "</svg>")
+	return bw.Flush()
+}
@@ -0,0 +1,52 @@
+package render
+
+import (
+	"bytes"
+	"encoding/xml"
+	"strings"
+	"testing"
+
+	"bender/internal/fsm"
+)
+
+func TestHeatmap(t *testing.T) {
+	plan := []string{"#####", "#@#$#", "#   #", "#####"}
+	buf := &bytes.Buffer{}
+	heat := map[fsm.Pair]float64{{X: 2, Y: 1}: 1, {X: 2, Y: 3}: 0.5}
+	if err := Heatmap(buf, plan, heat, nil); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := xml.Unmarshal(buf.Bytes(), new(struct{})); err != nil {
+		t.Fatalf("Invalid SVG: %v", err)
+	}
+	for _, expected := range []string{
+		`<svg xmlns="http://www.w3.org/2000/svg" width="80" height="64">`,
+		`x="32" y="16" width="16" height="16" fill="#ff0000" fill-opacity="0.90"><title>(2,1) 1.00</title>`,
+		`x="32" y="48" width="16" height="16" fill="#ff0000" fill-opacity="0.55"><title>(2,3) 0.50</title>`,
+	} {
+		if !strings.Contains(buf.String(), expected) {
+			t.Fatalf("Wrong heatmap. Expected %q in:\n%s", expected, buf.String())
+		}
+	}
+	if n := strings.Count(buf.String(), `class="mark"`); n != len(heat) {
+		t.Fatalf("Wrong number of hot cells. Expected %d, got %d", len(heat), n)
+	}
+}
+
+func TestThumbnail(t *testing.T) {
+	plan := []string{"#####", "#@ $#", "#####"}
+	buf := &bytes.Buffer{}
+	if err := Thumbnail(buf, plan, 100, nil); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := xml.Unmarshal(buf.Bytes(), new(struct{})); err != nil {
+		t.Fatalf("Invalid SVG: %v", err)
+	}
+	expected := `<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 5 3" width="100" height="60" shape-rendering="crispEdges">`
+	if !strings.HasPrefix(buf.String(), expected) {
+		t.Fatalf("Wrong thumbnail. Expected %q in:\n%s", expected, buf.String())
+	}
+	if n := strings.Count(buf.String(), "<rect "); n != 15 {
+		t.Fatalf("Wrong number of cells. Expected 15, got %d", n)
+	}
+}
@@ -0,0 +1,205 @@
+package render
+
+import (
+	"html/template"
+	"io"
+
+	"bender/internal/fsm"
+)
+
+// htmlStep is a step of the player of the HTML page
+type htmlStep struct {
+	// direction of the step, labelled
+	Direction string `json:"d"`
+	// position of Bender after the step
+	X int `json:"x"`
+	Y int `json:"y"`
+	// true if the step destroyed a breakable wall
+	Destroyed bool `json:"destroyed,omitempty"`
+}
+
+// htmlData is the run embedded in the HTML page
+type htmlData struct {
+	Plan   []string          `json:"plan"`
+	Steps  []htmlStep        `json:"steps"`
+	Path   []string          `json:"path"`
+	Glyphs map[string]string `json:"glyphs"`
+	Colors map[string]string `json:"colors"`
+	Trail  string            `json:"trail"`
+}
+
+// HTMLRenderer writes a self-contained HTML page playing the simulation with a scrubber,
+// to share a run with people who won't install anything
+// the page is written once the path is rendered
+type HTMLRenderer struct {
+	w      io.Writer
+	labels Labels
+	theme  *Theme
+	plan   []string
+	steps  []htmlStep
+}
+
+// NewHTMLRenderer returns a renderer writing the HTML page to w
+// the directions are printed with the given labels
+func NewHTMLRenderer(w io.Writer, labels Labels) *HTMLRenderer {
+	return &HTMLRenderer{
+		w:      w,
+		labels: labels,
+		theme:  ClassicTheme,
+	}
+}
+
+// SetTheme sets the theme of the tiles, classic if nil
+func (h *HTMLRenderer) SetTheme(theme *Theme) {
+	if theme == nil {
+		theme = ClassicTheme
+	}
+	h.theme = theme
+}
+
+// RenderBoard records the plan
+func (h *HTMLRenderer) RenderBoard(plan []string) error {
+	h.plan = plan
+	return nil
+}
+
+// RenderStep records the step
+func (h *HTMLRenderer) RenderStep(e *fsm.Event) error {
+	h.AddStep(Move{Direction: e.Event, At: e.Position(), Destroyed: e.Dst == 'X'})
+	return nil
+}
+
+// AddStep records a step of a run which isn't simulated, like a replay
+func (h *HTMLRenderer) AddStep(m Move) {
+	h.steps = append(h.steps, htmlStep{Direction: h.labels.Label(string(m.Direction)), X: m.At.X, Y: m.At.Y, Destroyed: m.Destroyed})
+}
+
+// RenderPath writes the page
+func (h *HTMLRenderer) RenderPath(path []string) error {
+	d := htmlData{
+		Plan:   h.plan,
+		Steps:  h.steps,
+		Path:   h.labels.Path(path),
+		Glyphs: map[string]string{},
+		Colors: map[string]string{},
+		Trail:  hexColor(h.theme.Trail),
+	}
+	if d.Plan == nil {
+		d.Plan = []string{}
+	}
+	if d.Steps == nil {
+		d.Steps = []htmlStep{}
+	}
+	for i := 0; i < len(themeTiles); i++ {
+		c := themeTiles[i]
+		d.Glyphs[string(c)] = h.theme.glyph(c)
+		d.Colors[string(c)] = hexColor(h.theme.color(c))
+	}
+	// the labelled teleports keep their label as glyph
+	for c := byte('1'); c <= '9'; c++ {
+		d.Colors[string(c)] = hexColor(h.theme.color(c))
+	}
+	return htmlPage.Execute(h.w, d)
+}
+
+// htmlPage is the page of the HTML renderer, the run is embedded as JSON by html/template
+var htmlPage = template.Must(template.New("player").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Bender</title>
+<style>
+body { font-family: sans-serif; margin: 1em; }
+#controls { display: flex; align-items: center; gap: 0.5em; margin: 0.5em 0; }
+#scrubber { flex: 1; max-width: 40em; }
+#path { font-family: monospace; word-break: break-all; }
+</style>
+</head>
+<body>
+<canvas id="board"></canvas>
+<div id="controls">
+<button id="play">Play</button>
+<input id="scrubber" type="range" min="0" value="0">
+<span id="status"></span>
+</div>
+<div id="path"></div>
+<script>
+const run = {{.}};
+const cell = 24;
+const canvas = document.getElementById("board");
+const ctx = canvas.getContext("2d");
+const scrubber = document.getElementById("scrubber");
+const status = document.getElementById("status");
+const play = document.getElementById("play");
+const width = Math.max(0, ...run.plan.map(row => row.length));
+canvas.width = width * cell;
+canvas.height = run.plan.length * cell;
+scrubber.max = run.steps.length;
+document.getElementById("path").textContent = run.path.join(" ");
+
+let start = {x: -1, y: -1};
+run.plan.forEach((row, y) => { const x = row.indexOf("@"); if (x >= 0 && start.x < 0) start = {x, y}; });
+
+// textColor returns a readable color over the given background
+function textColor(bg) {
+  const n = parseInt(bg.slice(1), 16);
+  const l = 0.299 * (n >> 16) + 0.587 * ((n >> 8) & 0xff) + 0.114 * (n & 0xff);
+  return l > 128 ? "#000000" : "#ffffff";
+}
+
+// drawTile draws the tile at x, y
+function drawTile(tile, x, y) {
+  const bg = run.colors[tile] || "#ffffff";
+  ctx.fillStyle = bg;
+  ctx.fillRect(x * cell, y * cell, cell, cell);
+  const glyph = run.glyphs[tile] || tile;
+  if (tile !== " " && glyph.trim() !== "") {
+    ctx.fillStyle = textColor(bg);
+    ctx.fillText(glyph, x * cell + cell / 2, y * cell + cell / 2);
+  }
+}
+
+// draw draws the board after the given number of steps
+function draw(n) {
+  const rows = run.plan.map(row => row.split(""));
+  rows[start.y] && (rows[start.y][start.x] = " ");
+  for (let i = 0; i < n; i++) {
+    const s = run.steps[i];
+    if (s.destroyed) rows[s.y][s.x] = " ";
+  }
+  ctx.font = (cell - 6) + "px monospace";
+  ctx.textAlign = "center";
+  ctx.textBaseline = "middle";
+  rows.forEach((row, y) => row.forEach((tile, x) => drawTile(tile, x, y)));
+  ctx.fillStyle = run.trail;
+  for (let i = 0; i < n; i++) {
+    const s = run.steps[i];
+    ctx.fillRect(s.x * cell + cell / 4, s.y * cell + cell / 4, cell / 2, cell / 2);
+  }
+  const at = n > 0 ? run.steps[n - 1] : start;
+  if (at.x >= 0) drawTile("@", at.x, at.y);
+  status.textContent = "Step " + n + "/" + run.steps.length + (n > 0 ? " " + run.steps[n - 1].d : "");
+}
+
+let timer = null;
+function stop() {
+  clearInterval(timer);
+  timer = null;
+  play.textContent = "Play";
+}
+play.onclick = () => {
+  if (timer) return stop();
+  if (+scrubber.value >= run.steps.length) scrubber.value = 0;
+  play.textContent = "Pause";
+  timer = setInterval(() => {
+    if (+scrubber.value >= run.steps.length) return stop();
+    scrubber.value = +scrubber.value + 1;
+    draw(+scrubber.value);
+  }, 200);
+};
+scrubber.oninput = () => { stop(); draw(+scrubber.value); };
+draw(0);
+</script>
+</body>
+</html>
+`))
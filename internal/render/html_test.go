@@ -0,0 +1,72 @@
+package render
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"bender/internal/fsm"
+)
+
+// htmlRun returns the run embedded in the HTML page
+func htmlRun(t *testing.T, page string) htmlData {
+	_, rest, found := strings.Cut(page, "const run = ")
+	if !found {
+		t.Fatalf("No run in the page:\n%s", page)
+	}
+	data, _, _ := strings.Cut(rest, ";\n")
+	d := htmlData{}
+	if err := json.Unmarshal([]byte(data), &d); err != nil {
+		t.Fatalf("Malformed run %s: %v", data, err)
+	}
+	return d
+}
+
+func TestHTMLRenderer(t *testing.T) {
+	plan := []string{
+		"#####",
+		"#@  #",
+		"#BX #",
+		"#$  #",
+		"#####",
+	}
+	buf := &bytes.Buffer{}
+	r, err := NewRenderer("html", buf, Labels{"SOUTH": "</script>"}, RoguelikeTheme)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	renderRun(t, plan, r)
+	page := buf.String()
+	if !strings.HasPrefix(page, "<!DOCTYPE html>") || strings.Count(page, "</script>") != 1 {
+		t.Fatalf("Malformed page:\n%s", page)
+	}
+
+	d := htmlRun(t, page)
+	expected := []htmlStep{
+		{Direction: "</script>", X: 1, Y: 2},
+		{Direction: "</script>", X: 1, Y: 3},
+	}
+	if !reflect.DeepEqual(d.Plan, plan) || !reflect.DeepEqual(d.Steps, expected) {
+		t.Fatalf("Wrong run. Expected %v %+v, got %v %+v", plan, expected, d.Plan, d.Steps)
+	}
+	if !reflect.DeepEqual(d.Path, []string{"</script>", "</script>"}) {
+		t.Fatalf("Wrong path %q", d.Path)
+	}
+	if d.Glyphs[" "] != "." || d.Colors["#"] != "#555555" || d.Trail != "#ffd700" {
+		t.Fatalf("Wrong theme: %+v %+v %s", d.Glyphs, d.Colors, d.Trail)
+	}
+
+	// a recorded step
+	buf.Reset()
+	h := NewHTMLRenderer(buf, nil)
+	h.RenderBoard(plan)
+	h.AddStep(Move{Direction: "EAST", At: fsm.Pair{X: 2, Y: 2}, Destroyed: true})
+	if err := h.RenderPath([]string{"EAST"}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if d := htmlRun(t, buf.String()); !reflect.DeepEqual(d.Steps, []htmlStep{{Direction: "EAST", X: 2, Y: 2, Destroyed: true}}) {
+		t.Fatalf("Wrong recorded steps %+v", d.Steps)
+	}
+}
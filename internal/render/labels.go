@@ -0,0 +1,75 @@
+package render
+
+import (
+	"fmt"
+	"strings"
+
+	"bender/internal/bender"
+	"bender/internal/fsm"
+)
+
+// Labels maps the directions (and the LOOP indicator) to the tokens used in the output
+// the directions without a label are printed as they are
+type Labels map[string]string
+
+var (
+	// LetterLabels prints the directions as single letters
+	LetterLabels = Labels{
+		string(fsm.SOUTH): "S",
+		string(fsm.NORTH): "N",
+		string(fsm.EAST):  "E",
+		string(fsm.WEST):  "W",
+	}
+	// ArrowLabels prints the directions as arrows
+	ArrowLabels = Labels{
+		string(fsm.SOUTH): "↓",
+		string(fsm.NORTH): "↑",
+		string(fsm.EAST):  "→",
+		string(fsm.WEST):  "←",
+		bender.LOOP:       "∞",
+	}
+)
+
+// ParseLabels returns the labels from the given configuration:
+// either a preset (words, letters, arrows)
+// or a comma separated list of remappings like "SOUTH=sud,NORTH=nord"
+func ParseLabels(conf string) (Labels, error) {
+	switch conf {
+	case "", "words":
+		return Labels{}, nil
+	case "letters":
+		return LetterLabels, nil
+	case "arrows":
+		return ArrowLabels, nil
+	}
+
+	l := Labels{}
+	for _, kv := range strings.Split(conf, ",") {
+		parts := strings.SplitN(kv, "=", 2)
+		if len(parts) != 2 || parts[1] == "" {
+			return nil, fmt.Errorf("bad label %q, expected DIRECTION=label", kv)
+		}
+		if _, err := fsm.ParseDirection(parts[0]); err != nil && parts[0] != bender.LOOP {
+			return nil, err
+		}
+		l[parts[0]] = parts[1]
+	}
+	return l, nil
+}
+
+// Label returns the token for the given direction
+func (l Labels) Label(dir string) string {
+	if s, exist := l[dir]; exist {
+		return s
+	}
+	return dir
+}
+
+// Path returns the given path with the directions replaced by their tokens
+func (l Labels) Path(path []string) []string {
+	res := make([]string, 0, len(path))
+	for _, d := range path {
+		res = append(res, l.Label(d))
+	}
+	return res
+}
@@ -0,0 +1,67 @@
+package render
+
+import (
+	"reflect"
+	"testing"
+
+	"bender/internal/bender"
+)
+
+func TestParseLabels(t *testing.T) {
+	testCases := []struct {
+		name     string
+		conf     string
+		expected []string
+		err      bool
+	}{
+		{
+			name:     "default",
+			conf:     "",
+			expected: []string{"SOUTH", "EAST", bender.LOOP},
+		},
+		{
+			name:     "letters",
+			conf:     "letters",
+			expected: []string{"S", "E", bender.LOOP},
+		},
+		{
+			name:     "arrows",
+			conf:     "arrows",
+			expected: []string{"↓", "→", "∞"},
+		},
+		{
+			name:     "custom",
+			conf:     "SOUTH=sud,LOOP=boucle",
+			expected: []string{"sud", "EAST", "boucle"},
+		},
+		{
+			name: "unknown direction",
+			conf: "SOTUH=sud",
+			err:  true,
+		},
+		{
+			name: "no label",
+			conf: "SOUTH=",
+			err:  true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			l, err := ParseLabels(tc.conf)
+			if tc.err {
+				if err == nil {
+					t.Fatalf("Test case %q: expected error", tc.name)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Test case %q: unexpected error %v", tc.name, err)
+			}
+			act := l.Path([]string{"SOUTH", "EAST", bender.LOOP})
+			if !reflect.DeepEqual(act, tc.expected) {
+				t.Fatalf("Test case %q: expected %v, got %v", tc.name, tc.expected, act)
+			}
+		})
+	}
+}
@@ -0,0 +1,295 @@
+package render
+
+import (
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"strings"
+
+	"bender/internal/fsm"
+)
+
+// cellSize is the size in pixels of a cell for the image renderers
+const cellSize = 16
+
+// Renderer visualizes a simulation
+type Renderer interface {
+	// RenderBoard draws the initial board
+	RenderBoard(plan []string) error
+	// RenderStep draws a transition which was entered
+	RenderStep(e *fsm.Event) error
+	// RenderPath draws the final path
+	RenderPath(path []string) error
+}
+
+// NewRenderer returns the renderer of the given kind writing to w
+// the known kinds are: terminal, png, svg, cast, html and none
+// the directions are printed with the given labels and the tiles with the given theme, classic if nil
+func NewRenderer(kind string, w io.Writer, labels Labels, theme *Theme) (Renderer, error) {
+	switch kind {
+	case "terminal":
+		r := NewTerminalRenderer(w, false, labels)
+		r.SetTheme(theme)
+		return r, nil
+	case "png":
+		r := NewPNGRenderer(w)
+		r.SetTheme(theme)
+		return r, nil
+	case "svg":
+		r := NewSVGRenderer(w, labels)
+		r.SetTheme(theme)
+		return r, nil
+	case "cast":
+		r := NewCastRenderer(w, labels, castDelay)
+		r.SetTheme(theme)
+		return r, nil
+	case "html":
+		r := NewHTMLRenderer(w, labels)
+		r.SetTheme(theme)
+		return r, nil
+	case "none":
+		return NopRenderer{}, nil
+	}
+	return nil, fmt.Errorf("unknown renderer %q", kind)
+}
+
+// NopRenderer doesn't render anything
+type NopRenderer struct{}
+
+// RenderBoard does nothing
+func (NopRenderer) RenderBoard(plan []string) error { return nil }
+
+// RenderStep does nothing
+func (NopRenderer) RenderStep(e *fsm.Event) error { return nil }
+
+// RenderPath does nothing
+func (NopRenderer) RenderPath(path []string) error { return nil }
+
+// TerminalRenderer prints the simulation as text
+type TerminalRenderer struct {
+	w      io.Writer
+	steps  bool
+	labels Labels
+	theme  *Theme
+}
+
+// NewTerminalRenderer returns a renderer printing to the given writer
+// every entered state is printed as a board if steps is true
+func NewTerminalRenderer(w io.Writer, steps bool, labels Labels) *TerminalRenderer {
+	return &TerminalRenderer{
+		w:      w,
+		steps:  steps,
+		labels: labels,
+		theme:  ClassicTheme,
+	}
+}
+
+// SetTheme sets the theme of the tiles, classic if nil
+func (t *TerminalRenderer) SetTheme(theme *Theme) {
+	if theme == nil {
+		theme = ClassicTheme
+	}
+	t.theme = theme
+}
+
+// RenderBoard prints the plan
+func (t *TerminalRenderer) RenderBoard(plan []string) error {
+	bw := bufio.NewWriter(t.w)
+	fmt.Fprintln(bw, "Plan:")
+	for _, s := range plan {
+		for i := 0; i < len(s); i++ {
+			bw.WriteString(t.theme.glyph(s[i]))
+		}
+		bw.WriteByte('\n')
+	}
+	return bw.Flush()
+}
+
+// RenderStep prints the board with Bender at its current position
+func (t *TerminalRenderer) RenderStep(e *fsm.Event) error {
+	if !t.steps {
+		return nil
+	}
+	bw := bufio.NewWriter(t.w)
+	writeFrame(bw, e, t.labels, t.theme, "\n")
+	return bw.Flush()
+}
+
+// writeFrame writes the direction of the step, the destroyed wall if any
+// and the board with Bender at its current position, the lines end with eol
+func writeFrame(bw *bufio.Writer, e *fsm.Event, labels Labels, theme *Theme, eol string) {
+	bw.WriteString(labels.Label(string(e.Event)) + eol)
+	if e.Dst == 'X' {
+		// entered a breakable wall: it's destroyed
+		fmt.Fprintf(bw, "destroyed %s%s", e.Position(), eol)
+	}
+	board, pos := e.Board(), e.Position()
+	for y := 0; y < board.Height(); y++ {
+		for x := 0; x < board.Width(); x++ {
+			c := board.At(x, y)
+			switch {
+			case c == 0:
+				// end of a short row
+				continue
+			case x == pos.X && y == pos.Y:
+				c = '@'
+			case c == '@':
+				// Bender is not at the start anymore
+				c = ' '
+			}
+			bw.WriteString(theme.glyph(c))
+		}
+		bw.WriteString(eol)
+	}
+}
+
+// RenderPath prints the path
+func (t *TerminalRenderer) RenderPath(path []string) error {
+	_, err := fmt.Fprintln(t.w, t.labels.Path(path))
+	return err
+}
+
+// trail records the board, the visited cells and the destroyed walls for the image renderers
+type trail struct {
+	plan      []string
+	visited   []fsm.Pair
+	destroyed []fsm.Pair
+	theme     *Theme
+}
+
+// SetTheme sets the colors of the tiles, classic if nil
+func (t *trail) SetTheme(theme *Theme) {
+	t.theme = theme
+}
+
+// colors returns the theme of the image
+func (t *trail) colors() *Theme {
+	if t.theme == nil {
+		return ClassicTheme
+	}
+	return t.theme
+}
+
+func (t *trail) RenderBoard(plan []string) error {
+	t.plan = plan
+	return nil
+}
+
+func (t *trail) RenderStep(e *fsm.Event) error {
+	t.visited = append(t.visited, e.Position())
+	if e.Dst == 'X' {
+		t.destroyed = append(t.destroyed, e.Position())
+	}
+	return nil
+}
+
+// width returns the width of the widest row of the plan
+func (t *trail) width() int {
+	w := 0
+	for _, s := range t.plan {
+		if len(s) > w {
+			w = len(s)
+		}
+	}
+	return w
+}
+
+// PNGRenderer draws the board and the visited cells as a PNG image
+// the image is written once the path is rendered
+type PNGRenderer struct {
+	trail
+	w io.Writer
+}
+
+// NewPNGRenderer returns a renderer writing the PNG image to w
+func NewPNGRenderer(w io.Writer) *PNGRenderer {
+	return &PNGRenderer{
+		w: w,
+	}
+}
+
+// RenderPath writes the image
+func (p *PNGRenderer) RenderPath(path []string) error {
+	th := p.colors()
+	img := image.NewRGBA(image.Rect(0, 0, p.width()*cellSize, len(p.plan)*cellSize))
+	for y, s := range p.plan {
+		for x := range s {
+			fillRect(img, x*cellSize, y*cellSize, cellSize, th.color(s[x]))
+		}
+	}
+	for _, d := range p.destroyed {
+		// rubble: the floor framed by the color of the wall
+		fillRect(img, d.X*cellSize, d.Y*cellSize, cellSize, th.color('X'))
+		fillRect(img, d.X*cellSize+2, d.Y*cellSize+2, cellSize-4, th.color(' '))
+	}
+	for _, v := range p.visited {
+		fillRect(img, v.X*cellSize+cellSize/4, v.Y*cellSize+cellSize/4, cellSize/2, th.Trail)
+	}
+	return png.Encode(p.w, img)
+}
+
+// fillRect fills the square of the given size starting at x, y
+func fillRect(img *image.RGBA, x, y, size int, c color.RGBA) {
+	for i := x; i < x+size; i++ {
+		for j := y; j < y+size; j++ {
+			img.SetRGBA(i, j, c)
+		}
+	}
+}
+
+// SVGRenderer draws the board and the path as an SVG image
+// the image is written once the path is rendered
+type SVGRenderer struct {
+	trail
+	w      io.Writer
+	labels Labels
+}
+
+// NewSVGRenderer returns a renderer writing the SVG image to w
+// the path is given as the title of the image using the given labels
+func NewSVGRenderer(w io.Writer, labels Labels) *SVGRenderer {
+	return &SVGRenderer{
+		w:      w,
+		labels: labels,
+	}
+}
+
+// RenderPath writes the image
+func (s *SVGRenderer) RenderPath(path []string) error {
+	th := s.colors()
+	bw := bufio.NewWriter(s.w)
+	fmt.Fprintf(bw, "<svg xmlns=\"http://www.w3.org/2000/svg\" width=\"%d\" height=\"%d\">\n", s.width()*cellSize, len(s.plan)*cellSize)
+	fmt.Fprint(bw, "<title>")
+	xml.EscapeText(bw, []byte(strings.Join(s.labels.Path(path), " ")))
+	fmt.Fprintln(bw, "</title>")
+	for y, row := range s.plan {
+		for x := range row {
+			fmt.Fprintf(bw, "<rect x=\"%d\" y=\"%d\" width=\"%d\" height=\"%d\" fill=\"%s\"/>\n", x*cellSize, y*cellSize, cellSize, cellSize, hexColor(th.color(row[x])))
+		}
+	}
+	for _, d := range s.destroyed {
+		// rubble: the floor framed by the color of the wall
+		fmt.Fprintf(bw, "<rect class=\"destroyed\" x=\"%d\" y=\"%d\" width=\"%d\" height=\"%d\" fill=\"%s\" stroke=\"%s\" stroke-width=\"2\"/>\n", d.X*cellSize+1, d.Y*cellSize+1, cellSize-2, cellSize-2, hexColor(th.color(' ')), hexColor(th.color('X')))
+	}
+	if len(s.visited) > 0 {
+		fmt.Fprint(bw, "<polyline fill=\"none\" stroke=\""+hexColor(th.Trail)+"\" stroke-width=\"2\" points=\"")
+		for i, v := range s.visited {
+			if i > 0 {
+				fmt.Fprint(bw, " ")
+			}
+			fmt.Fprintf(bw, "%d,%d", v.X*cellSize+cellSize/2, v.Y*cellSize+cellSize/2)
+		}
+		fmt.Fprintln(bw, "\"/>")
+	}
+	fmt.Fprintln(bw, "</svg>")
+	return bw.Flush()
+}
+
+// hexColor formats the color for SVG
+func hexColor(c color.RGBA) string {
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
@@ -0,0 +1,136 @@
+package render
+
+import (
+	"bytes"
+	"image/png"
+	"strings"
+	"testing"
+
+	"bender/internal/bender"
+	"bender/internal/fsm"
+)
+
+// renderRun simulates the given plan rendering it with the given renderer
+func renderRun(t *testing.T, plan []string, r Renderer) {
+	if err := r.RenderBoard(plan); err != nil {
+		t.Fatalf("Failed to render the board: %v", err)
+	}
+	enter := func(e *fsm.Event) {
+		bender.EnterCallback(e)
+		if err := r.RenderStep(e); err != nil {
+			t.Fatalf("Failed to render the step: %v", err)
+		}
+	}
+	m, err := fsm.NewFSM(plan, bender.BeforeCallback, enter)
+	if err != nil {
+		t.Fatalf("Failed to create the FSM: %v", err)
+	}
+	b := bender.NewBenderSimulator()
+	for !b.Over() {
+		if err := m.Event(b.Direction(), b); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+	if err := r.RenderPath(bender.NewResult(m, b).ClassicPath()); err != nil {
+		t.Fatalf("Failed to render the path: %v", err)
+	}
+}
+
+func TestRenderers(t *testing.T) {
+	plan := []string{
+		"#####",
+		"#@  #",
+		"#  $#",
+		"#####",
+	}
+
+	// terminal
+	buf := &bytes.Buffer{}
+	renderRun(t, plan, NewTerminalRenderer(buf, true, nil))
+	expected := strings.Join([]string{
+		"Plan:",
+		"#####",
+		"#@  #",
+		"#  $#",
+		"#####",
+		"SOUTH",
+		"#####",
+		"#   #",
+		"#@ $#",
+		"#####",
+		"EAST",
+		"#####",
+		"#   #",
+		"# @$#",
+		"#####",
+		"EAST",
+		"#####",
+		"#   #",
+		"#  @#",
+		"#####",
+		"[SOUTH EAST EAST]",
+		"",
+	}, "\n")
+	if buf.String() != expected {
+		t.Fatalf("Wrong terminal render. Expected:\n%s\ngot:\n%s", expected, buf.String())
+	}
+
+	// png
+	buf = &bytes.Buffer{}
+	renderRun(t, plan, NewPNGRenderer(buf))
+	img, err := png.Decode(buf)
+	if err != nil {
+		t.Fatalf("Failed to decode the PNG render: %v", err)
+	}
+	if size := img.Bounds().Size(); size.X != 5*cellSize || size.Y != 4*cellSize {
+		t.Fatalf("Wrong PNG size. Expected %dx%d, got %dx%d", 5*cellSize, 4*cellSize, size.X, size.Y)
+	}
+
+	// svg
+	buf = &bytes.Buffer{}
+	renderRun(t, plan, NewSVGRenderer(buf, LetterLabels))
+	svg := buf.String()
+	if !strings.HasPrefix(svg, "<svg") || !strings.HasSuffix(svg, "</svg>\n") {
+		t.Fatalf("Malformed SVG render: %s", svg)
+	}
+	if n := strings.Count(svg, "<rect"); n != 20 {
+		t.Fatalf("Wrong number of SVG cells. Expected %d, got %d", 20, n)
+	}
+	if !strings.Contains(svg, "<title>S E E</title>") {
+		t.Fatalf("Wrong SVG title: %s", svg)
+	}
+	if !strings.Contains(svg, "points=\"24,40 40,40 56,40\"") {
+		t.Fatalf("Wrong SVG path: %s", svg)
+	}
+
+	// none
+	renderRun(t, plan, NopRenderer{})
+
+	if _, err := NewRenderer("gif", buf, nil, nil); err == nil {
+		t.Fatalf("Unknown renderer was accepted")
+	}
+}
+
+func TestRenderDestroyed(t *testing.T) {
+	// goes through the breaker and two breakable walls
+	plan := []string{
+		"#######",
+		"# @   #",
+		"# B   #",
+		"# X   #",
+		"# I   #",
+		"#$X   #",
+		"#######",
+	}
+	buf := &bytes.Buffer{}
+	renderRun(t, plan, NewTerminalRenderer(buf, true, nil))
+	if !strings.Contains(buf.String(), "destroyed (2,3)\n") || !strings.Contains(buf.String(), "destroyed (2,5)\n") {
+		t.Fatalf("Destroyed walls are not printed:\n%s", buf.String())
+	}
+
+	buf = &bytes.Buffer{}
+	renderRun(t, plan, NewSVGRenderer(buf, nil))
+	if n := strings.Count(buf.String(), `class="destroyed"`); n != 2 {
+		t.Fatalf("Wrong number of destroyed walls. Expected %d, got %d", 2, n)
+	}
+}
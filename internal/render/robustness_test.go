@@ -0,0 +1,65 @@
+package render
+
+import (
+	"fmt"
+	"io/ioutil"
+	"math/rand"
+	"testing"
+)
+
+// noPanic fails the test if the given function panics
+func noPanic(t *testing.T, name string, fn func()) {
+	t.Helper()
+	defer func() {
+		if r := recover(); r != nil {
+			t.Fatalf("%s panicked: %v", name, r)
+		}
+	}()
+	fn()
+}
+
+// randomPlan returns a map of random size with random tiles, rows may be ragged or empty
+func randomPlan(rnd *rand.Rand) []string {
+	tiles := "  ##X@$SNEWIBT?\x00\xff"
+	plan := make([]string, rnd.Intn(7))
+	for i := range plan {
+		row := make([]byte, rnd.Intn(9))
+		for j := range row {
+			row[j] = tiles[rnd.Intn(len(tiles))]
+		}
+		plan[i] = string(row)
+	}
+	return plan
+}
+
+func TestRenderAdversarialMaps(t *testing.T) {
+	plans := [][]string{nil, {}, {""}, {"@"}, {"###", "#@", "#"}}
+	rnd := rand.New(rand.NewSource(42))
+	for i := 0; i < 500; i++ {
+		plans = append(plans, randomPlan(rnd))
+	}
+
+	for _, plan := range plans {
+		noPanic(t, fmt.Sprintf("rendering %q", plan), func() {
+			for _, r := range []Renderer{NewTerminalRenderer(ioutil.Discard, true, nil), NewPNGRenderer(ioutil.Discard), NewSVGRenderer(ioutil.Discard, nil), NewCastRenderer(ioutil.Discard, nil, 0), NewHTMLRenderer(ioutil.Discard, nil)} {
+				r.RenderBoard(plan)
+				r.RenderPath(nil)
+			}
+		})
+	}
+}
+
+func TestParseLabelsAdversarial(t *testing.T) {
+	rnd := rand.New(rand.NewSource(42))
+	inputs := []string{"", "=", ",", "SOUTH=,=", "words,letters"}
+	for i := 0; i < 1000; i++ {
+		buf := make([]byte, rnd.Intn(32))
+		rnd.Read(buf)
+		inputs = append(inputs, string(buf))
+	}
+	for _, in := range inputs {
+		noPanic(t, fmt.Sprintf("ParseLabels(%q)", in), func() {
+			ParseLabels(in)
+		})
+	}
+}
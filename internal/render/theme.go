@@ -0,0 +1,247 @@
+package render
+
+import (
+	"encoding/json"
+	"fmt"
+	"image/color"
+	"os"
+	"strconv"
+	"strings"
+
+	"bender/internal/fsm"
+)
+
+// themeTiles are the tiles a theme can restyle
+const themeTiles = " #X@$SNEWIBT"
+
+// Theme is the look of the tiles, shared by the terminal and the image renderers
+type Theme struct {
+	// name of the theme
+	Name string
+	// glyphs of the tiles in the terminal, Bender is drawn with the glyph of '@'
+	// the tiles without glyph are printed as they are
+	Glyphs map[byte]string
+	// colors of the tiles in the images, the tiles without color are white
+	Colors map[byte]color.RGBA
+	// color of the cells visited by Bender in the images
+	Trail color.RGBA
+}
+
+// classicColors are the colors of the tiles of the classic theme
+var classicColors = map[byte]color.RGBA{
+	'#': {0x33, 0x33, 0x33, 0xff},
+	'X': {0x8b, 0x45, 0x13, 0xff},
+	'@': {0x90, 0xee, 0x90, 0xff},
+	'$': {0xff, 0xd7, 0x00, 0xff},
+	'S': {0x87, 0xce, 0xeb, 0xff},
+	'N': {0x87, 0xce, 0xeb, 0xff},
+	'E': {0x87, 0xce, 0xeb, 0xff},
+	'W': {0x87, 0xce, 0xeb, 0xff},
+	'I': {0xff, 0xa5, 0x00, 0xff},
+	'B': {0xdc, 0x14, 0x3c, 0xff},
+	'T': {0x93, 0x70, 0xdb, 0xff},
+}
+
+var (
+	// ClassicTheme draws the tiles of the puzzle in ASCII
+	ClassicTheme = &Theme{
+		Name:   "classic",
+		Colors: classicColors,
+		Trail:  color.RGBA{0x2e, 0x8b, 0x57, 0xff},
+	}
+	// UnicodeTheme draws the tiles with Unicode blocks and arrows, the images are in shades of grey
+	UnicodeTheme = &Theme{
+		Name: "unicode",
+		Glyphs: map[byte]string{
+			'#': "█", 'X': "▒", '@': "☻", '$': "▣",
+			'S': "↓", 'N': "↑", 'E': "→", 'W': "←",
+			'I': "⇅", 'B': "β", 'T': "◎",
+		},
+		Colors: map[byte]color.RGBA{
+			' ': {0xf5, 0xf5, 0xf5, 0xff},
+			'#': {0x1a, 0x1a, 0x1a, 0xff},
+			'X': {0x80, 0x80, 0x80, 0xff},
+			'@': {0xd0, 0xd0, 0xd0, 0xff},
+			'$': {0x40, 0x40, 0x40, 0xff},
+			'S': {0xb0, 0xb0, 0xb0, 0xff},
+			'N': {0xb0, 0xb0, 0xb0, 0xff},
+			'E': {0xb0, 0xb0, 0xb0, 0xff},
+			'W': {0xb0, 0xb0, 0xb0, 0xff},
+			'I': {0x99, 0x99, 0x99, 0xff},
+			'B': {0x66, 0x66, 0x66, 0xff},
+			'T': {0xc0, 0xc0, 0xc0, 0xff},
+		},
+		Trail: color.RGBA{0x00, 0x00, 0x00, 0xff},
+	}
+	// EmojiTheme draws the tiles with emoji, every glyph is two columns wide, the images are bright
+	EmojiTheme = &Theme{
+		Name: "emoji",
+		Glyphs: map[byte]string{
+			' ': "  ", '#': "🧱", 'X': "📦", '@': "🤖", '$': "🚪",
+			'S': "👇", 'N': "👆", 'E': "👉", 'W': "👈",
+			'I': "🔃", 'B': "🍺", 'T': "🌀",
+		},
+		Colors: map[byte]color.RGBA{
+			' ': {0xff, 0xfa, 0xe6, 0xff},
+			'#': {0xb2, 0x22, 0x22, 0xff},
+			'X': {0xde, 0xb8, 0x87, 0xff},
+			'@': {0x7f, 0xff, 0xd4, 0xff},
+			'$': {0xff, 0x14, 0x93, 0xff},
+			'S': {0xff, 0xd7, 0x00, 0xff},
+			'N': {0xff, 0xd7, 0x00, 0xff},
+			'E': {0xff, 0xd7, 0x00, 0xff},
+			'W': {0xff, 0xd7, 0x00, 0xff},
+			'I': {0x00, 0xbf, 0xff, 0xff},
+			'B': {0xff, 0x8c, 0x00, 0xff},
+			'T': {0x8a, 0x2b, 0xe2, 0xff},
+		},
+		Trail: color.RGBA{0x1e, 0x90, 0xff, 0xff},
+	}
+	// RoguelikeTheme draws the tiles like a dungeon: floor dots, doors, potions, traps and stairs, the images are dark
+	RoguelikeTheme = &Theme{
+		Name: "roguelike",
+		Glyphs: map[byte]string{
+			' ': ".", 'X': "+", '$': ">", 'I': "?", 'B': "!", 'T': "^",
+		},
+		Colors: map[byte]color.RGBA{
+			' ': {0x10, 0x10, 0x10, 0xff},
+			'#': {0x55, 0x55, 0x55, 0xff},
+			'X': {0x8b, 0x5a, 0x2b, 0xff},
+			'@': {0x10, 0x10, 0x10, 0xff},
+			'$': {0xff, 0xff, 0xff, 0xff},
+			'S': {0x00, 0x64, 0x00, 0xff},
+			'N': {0x00, 0x64, 0x00, 0xff},
+			'E': {0x00, 0x64, 0x00, 0xff},
+			'W': {0x00, 0x64, 0x00, 0xff},
+			'I': {0x00, 0x00, 0x8b, 0xff},
+			'B': {0x8b, 0x00, 0x00, 0xff},
+			'T': {0x80, 0x00, 0x80, 0xff},
+		},
+		Trail: color.RGBA{0xff, 0xd7, 0x00, 0xff},
+	}
+)
+
+// themes are the built-in themes by name
+var themes = map[string]*Theme{
+	ClassicTheme.Name:   ClassicTheme,
+	UnicodeTheme.Name:   UnicodeTheme,
+	EmojiTheme.Name:     EmojiTheme,
+	RoguelikeTheme.Name: RoguelikeTheme,
+}
+
+// ParseTheme returns the theme of the given configuration:
+// either a built-in theme (classic, unicode, emoji, roguelike) or a theme file ending with .json
+func ParseTheme(conf string) (*Theme, error) {
+	if conf == "" {
+		return ClassicTheme, nil
+	}
+	if th, exist := themes[conf]; exist {
+		return th, nil
+	}
+	if strings.HasSuffix(conf, ".json") {
+		return LoadTheme(conf)
+	}
+	return nil, fmt.Errorf("unknown theme %q, expected classic, unicode, emoji, roguelike or a .json file", conf)
+}
+
+// themeFile is a theme stored as JSON
+// the tiles are the keys of the glyphs and the colors, the colors are like #rrggbb
+type themeFile struct {
+	Name string `json:"name"`
+	// built-in theme restyled by the file, classic by default
+	Base   string            `json:"base"`
+	Glyphs map[string]string `json:"glyphs"`
+	Colors map[string]string `json:"colors"`
+	Trail  string            `json:"trail"`
+}
+
+// LoadTheme returns the theme stored in the given JSON file, like
+// {"name": "mine", "base": "unicode", "glyphs": {"#": "▓"}, "colors": {"#": "#202020"}, "trail": "#ff0000"}
+func LoadTheme(name string) (*Theme, error) {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	tf := themeFile{}
+	if err := json.Unmarshal(data, &tf); err != nil {
+		return nil, fmt.Errorf("malformed theme %s: %v", name, err)
+	}
+	base := ClassicTheme
+	if tf.Base != "" {
+		if base = themes[tf.Base]; base == nil {
+			return nil, fmt.Errorf("theme %s: unknown base theme %q", name, tf.Base)
+		}
+	}
+	th := &Theme{Name: tf.Name, Glyphs: map[byte]string{}, Colors: map[byte]color.RGBA{}, Trail: base.Trail}
+	if th.Name == "" {
+		th.Name = name
+	}
+	for c, g := range base.Glyphs {
+		th.Glyphs[c] = g
+	}
+	for c, rgb := range base.Colors {
+		th.Colors[c] = rgb
+	}
+	for tile, g := range tf.Glyphs {
+		c, err := themeTile(tile)
+		if err != nil {
+			return nil, fmt.Errorf("theme %s: %v", name, err)
+		}
+		th.Glyphs[c] = g
+	}
+	for tile, s := range tf.Colors {
+		c, err := themeTile(tile)
+		if err != nil {
+			return nil, fmt.Errorf("theme %s: %v", name, err)
+		}
+		if th.Colors[c], err = parseColor(s); err != nil {
+			return nil, fmt.Errorf("theme %s: %v", name, err)
+		}
+	}
+	if tf.Trail != "" {
+		if th.Trail, err = parseColor(tf.Trail); err != nil {
+			return nil, fmt.Errorf("theme %s: %v", name, err)
+		}
+	}
+	return th, nil
+}
+
+// themeTile returns the tile of the key of a theme file
+func themeTile(key string) (byte, error) {
+	if len(key) != 1 || !strings.Contains(themeTiles, key) {
+		return 0, fmt.Errorf("unknown tile %q, expected one of %q", key, themeTiles)
+	}
+	return key[0], nil
+}
+
+// parseColor parses a color like #rrggbb
+func parseColor(s string) (color.RGBA, error) {
+	if len(s) != 7 || s[0] != '#' {
+		return color.RGBA{}, fmt.Errorf("bad color %q, expected #rrggbb", s)
+	}
+	rgb, err := strconv.ParseUint(s[1:], 16, 32)
+	if err != nil {
+		return color.RGBA{}, fmt.Errorf("bad color %q, expected #rrggbb", s)
+	}
+	return color.RGBA{uint8(rgb >> 16), uint8(rgb >> 8), uint8(rgb), 0xff}, nil
+}
+
+// glyph returns the glyph of the given tile in the terminal
+func (th *Theme) glyph(c byte) string {
+	if g, exist := th.Glyphs[c]; exist {
+		return g
+	}
+	return string(c)
+}
+
+// color returns the color of the given tile in the images
+func (th *Theme) color(c byte) color.RGBA {
+	if rgb, exist := th.Colors[c]; exist {
+		return rgb
+	}
+	if rgb, exist := th.Colors['T']; exist && fsm.IsTeleport(c) {
+		// the labelled teleports look like T
+		return rgb
+	}
+	return color.RGBA{0xff, 0xff, 0xff, 0xff}
+}
@@ -0,0 +1,130 @@
+package render
+
+import (
+	"bytes"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"bender/internal/fsm"
+)
+
+func TestThemes(t *testing.T) {
+	plan := []string{
+		"#####",
+		"#@ $#",
+		"#####",
+	}
+
+	testCases := []struct {
+		theme    string
+		expected string
+	}{
+		{theme: "classic", expected: "#####\n#@ $#\n#####\n"},
+		{theme: "unicode", expected: "█████\n█☻ ▣█\n█████\n"},
+		{theme: "emoji", expected: "🧱🧱🧱🧱🧱\n🧱🤖  🚪🧱\n🧱🧱🧱🧱🧱\n"},
+		{theme: "roguelike", expected: "#####\n#@.>#\n#####\n"},
+	}
+	for _, tc := range testCases {
+		th, err := ParseTheme(tc.theme)
+		if err != nil {
+			t.Fatalf("Unexpected error for %s: %v", tc.theme, err)
+		}
+		buf := &bytes.Buffer{}
+		r, err := NewRenderer("terminal", buf, nil, th)
+		if err != nil {
+			t.Fatalf("Unexpected error for %s: %v", tc.theme, err)
+		}
+		renderRun(t, plan, r)
+		expected := "Plan:\n" + tc.expected + "[EAST EAST]\n"
+		if buf.String() != expected {
+			t.Fatalf("Wrong %s render. Expected:\n%s\ngot:\n%s", tc.theme, expected, buf.String())
+		}
+
+		// the images take the colors of the theme
+		buf.Reset()
+		if r, err = NewRenderer("png", buf, nil, th); err != nil {
+			t.Fatalf("Unexpected error for %s: %v", tc.theme, err)
+		}
+		renderRun(t, plan, r)
+		img, err := png.Decode(buf)
+		if err != nil {
+			t.Fatalf("Failed to decode the %s PNG render: %v", tc.theme, err)
+		}
+		if c := color.RGBAModel.Convert(img.At(0, 0)); c != th.color('#') {
+			t.Fatalf("Wrong %s color of the wall. Expected %v, got %v", tc.theme, th.color('#'), c)
+		}
+
+		buf.Reset()
+		if r, err = NewRenderer("svg", buf, nil, th); err != nil {
+			t.Fatalf("Unexpected error for %s: %v", tc.theme, err)
+		}
+		renderRun(t, plan, r)
+		if !strings.Contains(buf.String(), `stroke="`+hexColor(th.Trail)+`"`) {
+			t.Fatalf("Wrong %s color of the trail: %s", tc.theme, buf.String())
+		}
+	}
+
+	if _, err := ParseTheme("neon"); err == nil {
+		t.Fatalf("Unknown theme was accepted")
+	}
+}
+
+func TestLoadTheme(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		file := filepath.Join(dir, name)
+		if err := os.WriteFile(file, []byte(content), 0o644); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		return file
+	}
+
+	file := write("mine.json", `{"name": "mine", "base": "unicode", "glyphs": {"#": "▓", " ": "·"}, "colors": {"#": "#202020"}, "trail": "#ff0000"}`)
+	th, err := ParseTheme(file)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if th.Name != "mine" || th.glyph('#') != "▓" || th.glyph(' ') != "·" || th.glyph('$') != "▣" || th.glyph('?') != "?" {
+		t.Fatalf("Wrong glyphs of the theme: %+v", th)
+	}
+	if th.color('#') != (color.RGBA{0x20, 0x20, 0x20, 0xff}) || th.color('$') != UnicodeTheme.color('$') || th.Trail != (color.RGBA{0xff, 0, 0, 0xff}) {
+		t.Fatalf("Wrong colors of the theme: %+v", th)
+	}
+	if UnicodeTheme.Glyphs['#'] != "█" {
+		t.Fatalf("The base theme was modified")
+	}
+
+	for _, content := range []string{
+		`{"glyphs": {"##": "x"}}`,
+		`{"glyphs": {"?": "x"}}`,
+		`{"colors": {"#": "red"}}`,
+		`{"colors": {"#": "#12345g"}}`,
+		`{"trail": "#fff"}`,
+		`{"base": "neon"}`,
+		`{"glyphs": []}`,
+	} {
+		if _, err := LoadTheme(write("bad.json", content)); err == nil {
+			t.Fatalf("Bad theme %s was accepted", content)
+		}
+	}
+	if _, err := LoadTheme(filepath.Join(dir, "missing.json")); err == nil {
+		t.Fatalf("Missing theme was accepted")
+	}
+}
+
+func TestThemeGlyphsParsed(t *testing.T) {
+	for _, th := range []*Theme{UnicodeTheme, EmojiTheme} {
+		for tile, glyph := range th.Glyphs {
+			if tile == ' ' {
+				continue
+			}
+			if plan := fsm.DecodePlan([]string{glyph}); plan[0] != string(tile) {
+				t.Fatalf("Wrong tile of the glyph %q of the %s theme. Expected %q, got %q", glyph, th.Name, tile, plan[0])
+			}
+		}
+	}
+}
@@ -0,0 +1,325 @@
+// Package replay records the steps of a simulation in a flat binary file, the .bdr replay
+//
+// The replay is laid out to be mapped in memory and scanned without decoding it:
+//
+//	header  16 bytes: magic "BDR\x01", width and height of the board (uint32), reserved
+//	board   width*height bytes, row by row, the short rows padded with zeros, then padded to 4 bytes
+//	steps   12 bytes each: x and y (uint32), direction and tile (bytes), flags (byte), rules (byte)
+//	footer  16 bytes: number of steps (uint64), outcome (byte), reserved, magic "BDRE"
+//
+// The rules of a step are the rule which chose the direction in the low nibble and the rule applied by the tile
+// in the high nibble, numbered from 1 in the order of the rules, 0 is none. The integers are little-endian. The step i is at a fixed offset so it's read in constant time.
+package replay
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"bender/internal/bender"
+	"bender/internal/compress"
+	"bender/internal/fsm"
+	"bender/internal/mmap"
+)
+
+const (
+	headerSize = 16
+	recordSize = 12
+	footerSize = 16
+	// flagBreaker is set on the steps made in breaker mode
+	flagBreaker = 1
+)
+
+var (
+	headerMagic = []byte("BDR\x01")
+	footerMagic = []byte("BDRE")
+	// rules are the rules of the steps, numbered from 1
+	rules = []bender.Rule{
+		bender.RuleForward,
+		bender.RulePriorityFallback,
+		bender.RulePathModifier,
+		bender.RuleBreakerDestruction,
+		bender.RuleBreakerToggle,
+		bender.RuleInversion,
+		bender.RuleTeleport,
+		bender.RuleBooth,
+	}
+)
+
+// Ext is the extension of the replays
+const Ext = ".bdr"
+
+// Step is a step of a replay
+type Step struct {
+	// number of the step, starting from 1
+	Number int
+	// direction of the step
+	Direction fsm.Direction
+	// position and tile entered by Bender
+	At   fsm.Pair
+	Tile byte
+	// true if Bender is in breaker mode after the step
+	Breaker bool
+	// rule which chose the direction and rule applied by the tile, if any
+	Rule   bender.Rule
+	Effect bender.Rule
+}
+
+// Writer records the steps of a simulation
+type Writer struct {
+	w      *bufio.Writer
+	closer []io.Closer
+	steps  uint64
+	record [recordSize]byte
+	err    error
+}
+
+// Create records a replay of the given map in the file, gzip compressed if its name has the .gz extension
+// the compressed replays can't be mapped in memory, they're decompressed when opened
+func Create(name string, plan []string) (*Writer, error) {
+	f, err := os.Create(name)
+	if err != nil {
+		return nil, err
+	}
+	var w io.Writer = f
+	closers := []io.Closer{f}
+	if strings.HasSuffix(name, compress.Ext) {
+		zw := gzip.NewWriter(f)
+		w = zw
+		closers = []io.Closer{zw, f}
+	}
+	rw, err := NewWriter(w, plan)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	rw.closer = closers
+	return rw, nil
+}
+
+// NewWriter records a replay of the given map to w
+func NewWriter(w io.Writer, plan []string) (*Writer, error) {
+	width := 0
+	for _, row := range plan {
+		if len(row) > width {
+			width = len(row)
+		}
+	}
+	rw := &Writer{w: bufio.NewWriter(w)}
+	header := make([]byte, headerSize)
+	copy(header, headerMagic)
+	binary.LittleEndian.PutUint32(header[4:], uint32(width))
+	binary.LittleEndian.PutUint32(header[8:], uint32(len(plan)))
+	rw.w.Write(header)
+	row := make([]byte, width)
+	for _, r := range plan {
+		n := copy(row, r)
+		for i := n; i < width; i++ {
+			row[i] = 0
+		}
+		rw.w.Write(row)
+	}
+	rw.w.Write(make([]byte, padding(width*len(plan))))
+	if err := rw.w.Flush(); err != nil {
+		return nil, err
+	}
+	return rw, nil
+}
+
+// Record records the step done by the given entered event of the simulator
+func (w *Writer) Record(e *fsm.Event, b *bender.BenderSimulator) error {
+	choice, effect := b.StepRules()
+	return w.Append(Step{Direction: e.Event, At: e.DstPosition(), Tile: e.Dst, Breaker: b.Breaker(), Rule: choice, Effect: effect})
+}
+
+// Append records the step read from another replay, its number is ignored
+func (w *Writer) Append(s Step) error {
+	if w.err != nil {
+		return w.err
+	}
+	binary.LittleEndian.PutUint32(w.record[0:], uint32(s.At.X))
+	binary.LittleEndian.PutUint32(w.record[4:], uint32(s.At.Y))
+	w.record[8] = s.Direction[0]
+	w.record[9] = s.Tile
+	w.record[10] = 0
+	if s.Breaker {
+		w.record[10] = flagBreaker
+	}
+	w.record[11] = ruleCode(s.Rule) | ruleCode(s.Effect)<<4
+	if _, err := w.w.Write(w.record[:]); err != nil {
+		w.err = err
+		return err
+	}
+	w.steps++
+	return nil
+}
+
+// Close records the outcome of the simulation and closes the file
+func (w *Writer) Close(outcome bender.Outcome) error {
+	footer := make([]byte, footerSize)
+	binary.LittleEndian.PutUint64(footer, w.steps)
+	footer[8] = byte(outcome)
+	copy(footer[12:], footerMagic)
+	err := w.err
+	if err == nil {
+		w.w.Write(footer)
+		err = w.w.Flush()
+	}
+	for _, c := range w.closer {
+		if cerr := c.Close(); err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
+
+// Replay is a recorded simulation, its steps are decoded on access
+type Replay struct {
+	file          *mmap.File
+	width, height int
+	board         []byte
+	steps         []byte
+	outcome       bender.Outcome
+}
+
+// Open opens the replay, it's mapped in memory unless it's compressed or in the text format
+func Open(name string) (*Replay, error) {
+	f, err := mmap.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	data := f.Data
+	if !bytes.HasPrefix(data, headerMagic) {
+		if data, err = compress.Decompress(data); err != nil {
+			f.Close()
+			return nil, err
+		}
+	}
+	if bytes.HasPrefix(data, textMagic) {
+		// the text replay is converted, the file isn't needed afterwards
+		r, err := ParseText(data)
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", name, err)
+		}
+		return r, nil
+	}
+	r, err := Parse(data)
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("%s: %v", name, err)
+	}
+	r.file = f
+	return r, nil
+}
+
+// Parse returns the replay held by the data, the data must not be modified while the replay is used
+func Parse(data []byte) (*Replay, error) {
+	if len(data) < headerSize+footerSize || !bytes.HasPrefix(data, headerMagic) {
+		return nil, errors.New("not a replay")
+	}
+	if !bytes.HasSuffix(data, footerMagic) {
+		return nil, errors.New("truncated replay")
+	}
+	r := &Replay{
+		width:  int(binary.LittleEndian.Uint32(data[4:])),
+		height: int(binary.LittleEndian.Uint32(data[8:])),
+	}
+	cells := uint64(r.width) * uint64(r.height)
+	footer := data[len(data)-footerSize:]
+	n := binary.LittleEndian.Uint64(footer)
+	r.outcome = bender.Outcome(footer[8])
+	body := uint64(len(data) - headerSize - footerSize)
+	if cells > body || n > (body-cells)/recordSize || headerSize+cells+uint64(padding(int(cells)))+n*recordSize+footerSize != uint64(len(data)) {
+		return nil, errors.New("inconsistent replay sizes")
+	}
+	r.board = data[headerSize : headerSize+cells]
+	start := headerSize + cells + uint64(padding(int(cells)))
+	r.steps = data[start : start+n*recordSize]
+	return r, nil
+}
+
+// Plan returns the map of the replay
+func (r *Replay) Plan() []string {
+	plan := make([]string, r.height)
+	for y := range plan {
+		plan[y] = strings.TrimRight(string(r.board[y*r.width:(y+1)*r.width]), "\x00")
+	}
+	return plan
+}
+
+// Outcome returns how the simulation ended
+func (r *Replay) Outcome() bender.Outcome {
+	return r.outcome
+}
+
+// Len returns the number of steps
+func (r *Replay) Len() int {
+	return len(r.steps) / recordSize
+}
+
+// Step returns the step of the given index, starting from 0, only this step is decoded
+func (r *Replay) Step(i int) Step {
+	rec := r.steps[i*recordSize : (i+1)*recordSize]
+	return Step{
+		Number:    i + 1,
+		Direction: directionOf(rec[8]),
+		At:        fsm.Pair{X: int(binary.LittleEndian.Uint32(rec[0:])), Y: int(binary.LittleEndian.Uint32(rec[4:]))},
+		Tile:      rec[9],
+		Breaker:   rec[10]&flagBreaker != 0,
+		Rule:      ruleOf(rec[11] & 0xf),
+		Effect:    ruleOf(rec[11] >> 4),
+	}
+}
+
+// Close releases the file of the replay, the replay must not be used afterwards
+func (r *Replay) Close() error {
+	if r.file == nil {
+		return nil
+	}
+	return r.file.Close()
+}
+
+// directionOf returns the direction recorded as its first letter
+func directionOf(c byte) fsm.Direction {
+	switch c {
+	case 'S':
+		return fsm.SOUTH
+	case 'N':
+		return fsm.NORTH
+	case 'E':
+		return fsm.EAST
+	case 'W':
+		return fsm.WEST
+	}
+	return fsm.Direction([]byte{c})
+}
+
+// ruleCode returns the number of the rule, 0 if none
+func ruleCode(r bender.Rule) byte {
+	for i, rule := range rules {
+		if r == rule {
+			return byte(i + 1)
+		}
+	}
+	return 0
+}
+
+// ruleOf returns the rule of the given number, empty if none
+func ruleOf(code byte) bender.Rule {
+	if code == 0 || int(code) > len(rules) {
+		return ""
+	}
+	return rules[code-1]
+}
+
+// padding returns the number of bytes aligning the given size on 4 bytes
+func padding(size int) int {
+	return (4 - size%4) % 4
+}
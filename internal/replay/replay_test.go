@@ -0,0 +1,106 @@
+package replay
+
+import (
+	"bytes"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"bender/internal/bender"
+	"bender/internal/fsm"
+)
+
+// record records the simulation of the map to the file
+func record(t *testing.T, name string, plan []string) bender.Result {
+	w, err := Create(name, plan)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	m, err := fsm.NewFSM(plan, nil, nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	b := bender.NewBenderSimulator()
+	m.SetCallbacks(bender.BeforeCallback, func(e *fsm.Event) {
+		bender.EnterCallback(e)
+		if err := w.Record(e, b); err != nil {
+			e.Abort(err)
+		}
+	})
+	res, err := bender.Resume(m, b)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := w.Close(res.Outcome); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	return res
+}
+
+func TestReplay(t *testing.T) {
+	plan := []string{
+		"#####",
+		"#@  #",
+		"# B$#",
+		"###",
+	}
+	expected := []Step{
+		{Number: 1, Direction: fsm.SOUTH, At: fsm.Pair{X: 1, Y: 2}, Tile: ' ', Rule: bender.RuleForward},
+		{Number: 2, Direction: fsm.EAST, At: fsm.Pair{X: 2, Y: 2}, Tile: 'B', Breaker: true, Rule: bender.RulePriorityFallback, Effect: bender.RuleBreakerToggle},
+		{Number: 3, Direction: fsm.EAST, At: fsm.Pair{X: 3, Y: 2}, Tile: '$', Breaker: true, Rule: bender.RuleForward, Effect: bender.RuleBooth},
+	}
+	for _, name := range []string{"run.bdr", "run.bdr.gz"} {
+		file := filepath.Join(t.TempDir(), name)
+		res := record(t, file, plan)
+		r, err := Open(file)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", name, err)
+		}
+		if !reflect.DeepEqual(r.Plan(), plan) {
+			t.Fatalf("%s: wrong plan. Expected %q, got %q", name, plan, r.Plan())
+		}
+		if r.Outcome() != res.Outcome {
+			t.Fatalf("%s: wrong outcome. Expected %v, got %v", name, res.Outcome, r.Outcome())
+		}
+		if r.Len() != len(expected) {
+			t.Fatalf("%s: wrong number of steps. Expected %d, got %d", name, len(expected), r.Len())
+		}
+		for i := len(expected) - 1; i >= 0; i-- {
+			if s := r.Step(i); s != expected[i] {
+				t.Fatalf("%s: wrong step %d. Expected %+v, got %+v", name, i, expected[i], s)
+			}
+		}
+		if err := r.Close(); err != nil {
+			t.Fatalf("%s: unexpected error: %v", name, err)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	buf := &bytes.Buffer{}
+	w, err := NewWriter(buf, []string{"###", "#@$", "###"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := w.Close(bender.Reached); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	data := buf.Bytes()
+	testCases := []struct {
+		name string
+		data []byte
+	}{
+		{name: "empty", data: nil},
+		{name: "not a replay", data: bytes.Repeat([]byte{'#'}, 64)},
+		{name: "truncated", data: data[:len(data)-1]},
+		{name: "missing cells", data: append(append([]byte{}, data[:headerSize+4]...), data[len(data)-footerSize:]...)},
+	}
+	for _, tc := range testCases {
+		if _, err := Parse(tc.data); err == nil {
+			t.Fatalf("Test case %q: expected an error", tc.name)
+		}
+	}
+	if r, err := Parse(data); err != nil || r.Len() != 0 || r.Outcome() != bender.Reached {
+		t.Fatalf("Wrong empty replay, got %+v, %v", r, err)
+	}
+}
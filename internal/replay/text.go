@@ -0,0 +1,185 @@
+package replay
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+
+	"bender/internal/bender"
+)
+
+// TextExt is the extension of the text replays
+const TextExt = ".bdt"
+
+// textMagic is the first line of the text replays
+var textMagic = []byte("bender replay 1\n")
+
+// noField is the field of a step without breaker mode or rule
+const noField = "-"
+
+// WriteText writes the replay in the text format, made to be diffed in version control:
+//
+//	bender replay 1
+//	board <width> <height>
+//	|<row>|          height lines, the pipes keep the trailing spaces
+//	outcome <outcome>
+//	steps <number of steps>
+//	<number> <direction> <x> <y> <tile> <breaker> <rule> <effect>
+//
+// A step per line with its fields in this order: the tile is quoted like "B", breaker is breaker or -,
+// the spaces of the rules are dashes like priority-fallback and - is no rule.
+// The format is canonical: a replay has a single text, so the same run always gives the same file.
+func WriteText(w io.Writer, r *Replay) error {
+	bw := bufio.NewWriter(w)
+	bw.Write(textMagic)
+	fmt.Fprintf(bw, "board %d %d\n", r.width, r.height)
+	for _, row := range r.Plan() {
+		fmt.Fprintf(bw, "|%s|\n", row)
+	}
+	fmt.Fprintf(bw, "outcome %v\n", r.Outcome())
+	fmt.Fprintf(bw, "steps %d\n", r.Len())
+	for i := 0; i < r.Len(); i++ {
+		bw.WriteString(formatStep(r.Step(i)))
+		bw.WriteByte('\n')
+	}
+	return bw.Flush()
+}
+
+// ParseText returns the replay held by the data in the text format, it's converted to the binary format in memory
+// only the canonical text is accepted, the errors give the line
+func ParseText(data []byte) (*Replay, error) {
+	if !bytes.HasPrefix(data, textMagic) {
+		return nil, fmt.Errorf("not a text replay")
+	}
+	lines := strings.Split(strings.TrimSuffix(string(data[len(textMagic):]), "\n"), "\n")
+	// the lines are numbered from the magic
+	n := 1
+	next := func() (string, bool) {
+		if len(lines) == 0 {
+			return "", false
+		}
+		line := lines[0]
+		lines = lines[1:]
+		n++
+		return line, true
+	}
+
+	var width, height int
+	if line, _ := next(); !scanLine(line, "board %d %d", &width, &height) || width < 0 || height < 0 {
+		return nil, fmt.Errorf("line %d: expected board <width> <height>, got %q", n, line)
+	}
+	plan := make([]string, height)
+	for y := range plan {
+		line, ok := next()
+		if !ok || len(line) < 2 || line[0] != '|' || line[len(line)-1] != '|' || len(line)-2 > width {
+			return nil, fmt.Errorf("line %d: expected a row of at most %d tiles between pipes, got %q", n, width, line)
+		}
+		plan[y] = line[1 : len(line)-1]
+	}
+	longest := 0
+	for _, row := range plan {
+		if len(row) > longest {
+			longest = len(row)
+		}
+	}
+	if longest != width {
+		return nil, fmt.Errorf("line %d: expected a row of %d tiles, the longest has %d", n, width, longest)
+	}
+
+	line, _ := next()
+	outcome, ok := parseOutcome(strings.TrimPrefix(line, "outcome "))
+	if !ok || !strings.HasPrefix(line, "outcome ") {
+		return nil, fmt.Errorf("line %d: expected outcome <outcome>, got %q", n, line)
+	}
+	var steps int
+	if line, _ := next(); !scanLine(line, "steps %d", &steps) || steps < 0 {
+		return nil, fmt.Errorf("line %d: expected steps <number of steps>, got %q", n, line)
+	}
+	if len(lines) != steps {
+		return nil, fmt.Errorf("line %d: expected %d steps, got %d", n, steps, len(lines))
+	}
+
+	buf := &bytes.Buffer{}
+	w, err := NewWriter(buf, plan)
+	if err != nil {
+		return nil, err
+	}
+	for i := 1; i <= steps; i++ {
+		line, _ := next()
+		s, err := parseStep(line)
+		if err != nil || s.Number != i {
+			return nil, fmt.Errorf("line %d: expected the step %d, got %q", n, i, line)
+		}
+		w.Append(s)
+	}
+	if err := w.Close(outcome); err != nil {
+		return nil, err
+	}
+	return Parse(buf.Bytes())
+}
+
+// formatStep returns the line of the step
+func formatStep(s Step) string {
+	breaker := noField
+	if s.Breaker {
+		breaker = "breaker"
+	}
+	return fmt.Sprintf("%d %s %d %d %q %s %s %s", s.Number, s.Direction, s.At.X, s.At.Y, string(s.Tile), breaker, formatRule(s.Rule), formatRule(s.Effect))
+}
+
+// parseStep returns the step of the line, it must be canonical
+func parseStep(line string) (Step, error) {
+	var s Step
+	var tile, breaker, rule, effect string
+	if _, err := fmt.Sscanf(line, "%d %s %d %d %q %s %s %s", &s.Number, &s.Direction, &s.At.X, &s.At.Y, &tile, &breaker, &rule, &effect); err != nil {
+		return s, err
+	}
+	if len(tile) != 1 {
+		return s, fmt.Errorf("invalid tile %q", tile)
+	}
+	s.Tile = tile[0]
+	s.Breaker = breaker == "breaker"
+	s.Rule, s.Effect = parseRule(rule), parseRule(effect)
+	if directionOf(s.Direction[0]) != s.Direction || formatStep(s) != line {
+		return s, fmt.Errorf("not canonical")
+	}
+	return s, nil
+}
+
+// formatRule returns the rule with its spaces as dashes, - if none
+func formatRule(r bender.Rule) string {
+	if r == "" {
+		return noField
+	}
+	return strings.ReplaceAll(string(r), " ", "-")
+}
+
+// parseRule returns the rule formatted by formatRule, the unknown rules are none
+// and fail the canonical check of the step
+func parseRule(s string) bender.Rule {
+	return ruleOf(ruleCode(bender.Rule(strings.ReplaceAll(s, "-", " "))))
+}
+
+// parseOutcome returns the outcome of the given name
+func parseOutcome(s string) (bender.Outcome, bool) {
+	for o := bender.Interrupted; o <= bender.Escaped; o++ {
+		if o.String() == s {
+			return o, true
+		}
+	}
+	return 0, false
+}
+
+// scanLine scans the integers of the line with the format, the whole line must be canonical
+func scanLine(line, format string, args ...interface{}) bool {
+	if _, err := fmt.Sscanf(line, format, args...); err != nil {
+		return false
+	}
+	values := make([]interface{}, len(args))
+	for i, a := range args {
+		values[i] = *a.(*int)
+	}
+	return fmt.Sprintf(format, values...) == line
+}
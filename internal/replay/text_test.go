@@ -0,0 +1,90 @@
+package replay
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// text is the text replay of the map of TestReplay
+const text = `bender replay 1
+board 5 4
+|#####|
+|#@  #|
+|# B$#|
+|###|
+outcome reached
+steps 3
+1 SOUTH 1 2 " " - forward -
+2 EAST 2 2 "B" breaker priority-fallback breaker-toggle
+3 EAST 3 2 "$" breaker forward booth
+`
+
+func TestText(t *testing.T) {
+	dir := t.TempDir()
+	plan := []string{"#####", "#@  #", "# B$#", "###"}
+	bin := filepath.Join(dir, "run.bdr")
+	record(t, bin, plan)
+	r, err := Open(bin)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer r.Close()
+	buf := &bytes.Buffer{}
+	if err := WriteText(buf, r); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if buf.String() != text {
+		t.Fatalf("Wrong text. Expected:\n%s\ngot:\n%s", text, buf.String())
+	}
+
+	// the text replays are opened like the binary ones
+	name := filepath.Join(dir, "run"+TextExt)
+	if err := os.WriteFile(name, []byte(text), 0644); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	tr, err := Open(name)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer tr.Close()
+	if tr.Outcome() != r.Outcome() || tr.Len() != r.Len() || strings.Join(tr.Plan(), "\n") != strings.Join(r.Plan(), "\n") {
+		t.Fatalf("Wrong replay. Expected %v %d %q, got %v %d %q", r.Outcome(), r.Len(), r.Plan(), tr.Outcome(), tr.Len(), tr.Plan())
+	}
+	for i := 0; i < r.Len(); i++ {
+		if tr.Step(i) != r.Step(i) {
+			t.Fatalf("Wrong step %d. Expected %+v, got %+v", i, r.Step(i), tr.Step(i))
+		}
+	}
+}
+
+func TestParseTextErrors(t *testing.T) {
+	testCases := []struct {
+		name     string
+		old, new string
+		expected string
+	}{
+		{name: "not a replay", old: "bender replay 1", new: "bender replay 2", expected: "not a text replay"},
+		{name: "board", old: "board 5 4", new: "board 5", expected: "line 2: expected board <width> <height>"},
+		{name: "padded board", old: "board 5 4", new: "board  5 4", expected: "line 2: expected board <width> <height>"},
+		{name: "row without pipes", old: "|###|", new: "###", expected: "line 6: expected a row of at most 5 tiles between pipes"},
+		{name: "narrower board", old: "board 5 4", new: "board 6 4", expected: "line 6: expected a row of 6 tiles, the longest has 5"},
+		{name: "outcome", old: "outcome reached", new: "outcome won", expected: "line 7: expected outcome <outcome>"},
+		{name: "missing step", old: "steps 3", new: "steps 4", expected: "line 8: expected 4 steps, got 3"},
+		{name: "step number", old: "2 EAST", new: "4 EAST", expected: "line 10: expected the step 2"},
+		{name: "direction", old: "2 EAST", new: "2 E", expected: "line 10: expected the step 2"},
+		{name: "unquoted tile", old: `"B"`, new: "B", expected: "line 10: expected the step 2"},
+		{name: "unknown rule", old: "breaker-toggle", new: "toggle", expected: "line 10: expected the step 2"},
+		{name: "extra field", old: "forward booth", new: "forward booth x", expected: "line 11: expected the step 3"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := ParseText([]byte(strings.Replace(text, tc.old, tc.new, 1)))
+			if err == nil || !strings.HasPrefix(err.Error(), tc.expected) {
+				t.Fatalf("Wrong error. Expected %q, got %v", tc.expected, err)
+			}
+		})
+	}
+}
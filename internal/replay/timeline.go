@@ -0,0 +1,122 @@
+package replay
+
+import (
+	"fmt"
+
+	"bender/internal/bender"
+	"bender/internal/fsm"
+)
+
+// keyframeInterval is the default number of steps between two keyframes of a timeline
+const keyframeInterval = 256
+
+// Frame is the state of a recorded run after a number of steps
+type Frame struct {
+	// number of steps made, 0 before the first one
+	Step int
+	// board without Bender, the destroyed walls are floors
+	Board []string
+	// position of Bender
+	At fsm.Pair
+	// true if Bender is in breaker mode
+	Breaker bool
+	// true if Bender went through an odd number of inverters
+	Inverted bool
+}
+
+// keyframe is the full state of the run at a step, the frames are replayed from the latest one
+type keyframe struct {
+	board    []byte
+	at       fsm.Pair
+	breaker  bool
+	inverted bool
+}
+
+// Timeline materializes the frames of a recorded run at any step, it's the backend of the scrubbers:
+// a keyframe is saved every interval steps and the steps after the keyframe are replayed,
+// so a frame costs a copy of the board and at most interval steps
+type Timeline struct {
+	r             *Replay
+	width, height int
+	interval      int
+	teleports     []fsm.Pair
+	keyframes     []keyframe
+}
+
+// NewTimeline returns the timeline of the replay with a keyframe every interval steps, 256 if interval isn't positive
+// the replay is scanned once to build the keyframes
+func NewTimeline(r *Replay, interval int) *Timeline {
+	if interval <= 0 {
+		interval = keyframeInterval
+	}
+	t := &Timeline{r: r, width: r.width, height: r.height, interval: interval}
+	k := keyframe{board: append([]byte{}, r.board...), at: fsm.Pair{X: -1, Y: -1}}
+	for i, c := range k.board {
+		if c == '@' {
+			k.at = fsm.Pair{X: i % t.width, Y: i / t.width}
+			k.board[i] = ' '
+		} else if fsm.IsTeleport(c) {
+			t.teleports = append(t.teleports, fsm.Pair{X: i % t.width, Y: i / t.width})
+		}
+	}
+	t.keyframes = append(t.keyframes, k.clone())
+	for i := 0; i < r.Len(); i++ {
+		t.apply(&k, r.Step(i))
+		if (i+1)%interval == 0 {
+			t.keyframes = append(t.keyframes, k.clone())
+		}
+	}
+	return t
+}
+
+// clone returns a copy of the keyframe with its own board
+func (k keyframe) clone() keyframe {
+	k.board = append([]byte{}, k.board...)
+	return k
+}
+
+// Len returns the number of steps of the run, the frames go from 0 to Len
+func (t *Timeline) Len() int {
+	return t.r.Len()
+}
+
+// Frame returns the state of the run after the given number of steps
+func (t *Timeline) Frame(step int) (Frame, error) {
+	if step < 0 || step > t.r.Len() {
+		return Frame{}, fmt.Errorf("step %d out of the run of %d steps", step, t.r.Len())
+	}
+	k := t.keyframes[step/t.interval].clone()
+	for i := step / t.interval * t.interval; i < step; i++ {
+		t.apply(&k, t.r.Step(i))
+	}
+	f := Frame{Step: step, Board: make([]string, t.height), At: k.at, Breaker: k.breaker, Inverted: k.inverted}
+	for y := range f.Board {
+		row := k.board[y*t.width : (y+1)*t.width]
+		n := len(row)
+		for n > 0 && row[n-1] == 0 {
+			n--
+		}
+		f.Board[y] = string(row[:n])
+	}
+	return f, nil
+}
+
+// apply updates the state with the step
+func (t *Timeline) apply(k *keyframe, s Step) {
+	k.at = s.At
+	k.breaker = s.Breaker
+	switch s.Effect {
+	case bender.RuleBreakerDestruction:
+		k.board[s.At.Y*t.width+s.At.X] = ' '
+	case bender.RuleInversion:
+		k.inverted = !k.inverted
+	case bender.RuleTeleport:
+		// Bender leaves from the other teleport of the same label
+		label := k.board[s.At.Y*t.width+s.At.X]
+		for _, p := range t.teleports {
+			if p != s.At && k.board[p.Y*t.width+p.X] == label {
+				k.at = p
+			}
+		}
+	}
+}
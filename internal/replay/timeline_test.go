@@ -0,0 +1,83 @@
+package replay
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"bender/internal/fsm"
+)
+
+func TestTimeline(t *testing.T) {
+	plan := []string{
+		"#####",
+		"#@  #",
+		"#B  #",
+		"#X  #",
+		"#I  #",
+		"#$  #",
+		"#####",
+	}
+	file := filepath.Join(t.TempDir(), "run.bdr")
+	record(t, file, plan)
+	r, err := Open(file)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer r.Close()
+
+	board := []string{"#####", "#   #", "#B  #", "#X  #", "#I  #", "#$  #", "#####"}
+	destroyed := []string{"#####", "#   #", "#B  #", "#   #", "#I  #", "#$  #", "#####"}
+	expected := []Frame{
+		{Step: 0, Board: board, At: fsm.Pair{X: 1, Y: 1}},
+		{Step: 1, Board: board, At: fsm.Pair{X: 1, Y: 2}, Breaker: true},
+		{Step: 2, Board: destroyed, At: fsm.Pair{X: 1, Y: 3}, Breaker: true},
+		{Step: 3, Board: destroyed, At: fsm.Pair{X: 1, Y: 4}, Breaker: true, Inverted: true},
+		{Step: 4, Board: destroyed, At: fsm.Pair{X: 1, Y: 5}, Breaker: true, Inverted: true},
+	}
+	// the frames don't depend on the keyframes
+	for _, interval := range []int{0, 1, 2, 3} {
+		tl := NewTimeline(r, interval)
+		if tl.Len() != len(expected)-1 {
+			t.Fatalf("Wrong length. Expected %d, got %d", len(expected)-1, tl.Len())
+		}
+		for _, e := range expected {
+			f, err := tl.Frame(e.Step)
+			if err != nil {
+				t.Fatalf("Interval %d, step %d: unexpected error: %v", interval, e.Step, err)
+			}
+			if !reflect.DeepEqual(f, e) {
+				t.Fatalf("Interval %d: wrong frame. Expected %+v, got %+v", interval, e, f)
+			}
+		}
+		for _, step := range []int{-1, len(expected)} {
+			if _, err := tl.Frame(step); err == nil {
+				t.Fatalf("Expected an error for the step %d", step)
+			}
+		}
+	}
+}
+
+func TestTimelineTeleport(t *testing.T) {
+	plan := []string{
+		"######",
+		"#@  T#",
+		"#T   #",
+		"#   $#",
+		"######",
+	}
+	file := filepath.Join(t.TempDir(), "run.bdr")
+	record(t, file, plan)
+	r, err := Open(file)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer r.Close()
+	f, err := NewTimeline(r, 0).Frame(1)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if expected := (fsm.Pair{X: 4, Y: 1}); f.At != expected {
+		t.Fatalf("Wrong position after the teleport. Expected %v, got %v", expected, f.At)
+	}
+}
@@ -0,0 +1,278 @@
+package replay
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+
+	"bender/internal/bender"
+	"bender/internal/fsm"
+)
+
+// traceVersion is the version of the trace format
+const traceVersion = 1
+
+// A trace records every event of a simulation as JSON lines, the blocked ones included, to compare engine versions:
+//
+//	{"trace":1,"plan":[...],"maxSteps":0}                    header, the map and the step limit of the run
+//	{"event":1,"direction":"SOUTH","tile":" ","x":1,"y":2,...} an event per line, in order
+//	{"outcome":"reached","steps":5}                          end of the run
+//
+// Unlike the replays, a trace holds the cancelled events, it's meant for debugging rather than for the players.
+
+// TraceEvent is an event of a trace
+type TraceEvent struct {
+	// number of the event, starting from 1
+	Event int `json:"event"`
+	// direction of the event
+	Direction fsm.Direction `json:"direction"`
+	// destination tile once the callbacks are done and its coordinates
+	Tile string `json:"tile"`
+	X    int    `json:"x"`
+	Y    int    `json:"y"`
+	// true if an obstacle blocked Bender
+	Cancelled bool `json:"cancelled,omitempty"`
+	// flags of Bender after the event
+	Breaker  bool `json:"breaker,omitempty"`
+	Inverted bool `json:"inverted,omitempty"`
+	// steps made after the event
+	Steps int `json:"steps"`
+}
+
+// traceHeader is the first line of a trace
+type traceHeader struct {
+	Version  int      `json:"trace"`
+	Plan     []string `json:"plan"`
+	MaxSteps int      `json:"maxSteps,omitempty"`
+}
+
+// traceLine is a line of a trace after the header, an event or the end of the run
+type traceLine struct {
+	TraceEvent
+	Outcome string `json:"outcome"`
+}
+
+// traceEnd is the last line of a trace
+type traceEnd struct {
+	Outcome string `json:"outcome"`
+	Steps   int    `json:"steps"`
+}
+
+// TraceWriter records the events of a simulation
+type TraceWriter struct {
+	w      *bufio.Writer
+	enc    *json.Encoder
+	closer io.Closer
+	events int
+}
+
+// CreateTrace records a trace of the given map simulated with the step limit in the file
+func CreateTrace(name string, plan []string, maxSteps int) (*TraceWriter, error) {
+	f, err := os.Create(name)
+	if err != nil {
+		return nil, err
+	}
+	tw, err := NewTraceWriter(f, plan, maxSteps)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	tw.closer = f
+	return tw, nil
+}
+
+// NewTraceWriter records a trace of the given map simulated with the step limit to w
+func NewTraceWriter(w io.Writer, plan []string, maxSteps int) (*TraceWriter, error) {
+	bw := bufio.NewWriter(w)
+	tw := &TraceWriter{w: bw, enc: json.NewEncoder(bw)}
+	if err := tw.enc.Encode(traceHeader{Version: traceVersion, Plan: plan, MaxSteps: maxSteps}); err != nil {
+		return nil, err
+	}
+	return tw, tw.w.Flush()
+}
+
+// Record records the event of the simulator once its callbacks are done, cancelled or entered,
+// with the number of steps made by the machine
+func (t *TraceWriter) Record(e *fsm.Event, b *bender.BenderSimulator, steps int) error {
+	t.events++
+	return t.enc.Encode(traceEvent(t.events, e, b, steps))
+}
+
+// Middleware records the events of the simulator once the callbacks of the machine are done,
+// the cancelled events and the entered ones
+func (t *TraceWriter) Middleware(m *fsm.FSM, b *bender.BenderSimulator) fsm.Middleware {
+	return traceMiddleware(func(e *fsm.Event) error {
+		return t.Record(e, b, m.Steps())
+	})
+}
+
+// traceMiddleware returns the middleware passing the traced events to record,
+// the event is aborted if it can't be recorded
+func traceMiddleware(record func(e *fsm.Event) error) fsm.Middleware {
+	return func(next fsm.Callback) fsm.Callback {
+		return func(e *fsm.Event) {
+			next(e)
+			if !e.Entered() && !e.Cancelled {
+				return
+			}
+			if err := record(e); err != nil {
+				e.Abort(err)
+			}
+		}
+	}
+}
+
+// Close writes the end of the run and closes the file of the trace
+func (t *TraceWriter) Close(res bender.Result) error {
+	err := t.enc.Encode(traceEnd{Outcome: res.Outcome.String(), Steps: res.Steps})
+	if ferr := t.w.Flush(); err == nil {
+		err = ferr
+	}
+	if t.closer != nil {
+		if cerr := t.closer.Close(); err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
+
+// traceEvent returns the traced event number n
+func traceEvent(n int, e *fsm.Event, b *bender.BenderSimulator, steps int) TraceEvent {
+	at := e.DstPosition()
+	return TraceEvent{
+		Event:     n,
+		Direction: e.Event,
+		Tile:      string(e.Dst),
+		X:         at.X,
+		Y:         at.Y,
+		Cancelled: e.Cancelled,
+		Breaker:   b.Breaker(),
+		Inverted:  b.Inverted(),
+		Steps:     steps,
+	}
+}
+
+// Trace is a recorded simulation
+type Trace struct {
+	Plan     []string
+	MaxSteps int
+	Events   []TraceEvent
+	Outcome  bender.Outcome
+	Steps    int
+}
+
+// OpenTrace reads the trace of the file
+func OpenTrace(name string) (*Trace, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ReadTrace(f)
+}
+
+// ReadTrace reads a trace, the errors give the line
+func ReadTrace(r io.Reader) (*Trace, error) {
+	s := bufio.NewScanner(r)
+	// the header holds the whole map
+	s.Buffer(nil, 1<<30)
+	if !s.Scan() {
+		if err := s.Err(); err != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("empty trace")
+	}
+	var h traceHeader
+	if err := json.Unmarshal(s.Bytes(), &h); err != nil || h.Version != traceVersion {
+		return nil, fmt.Errorf("line 1: not a trace of version %d", traceVersion)
+	}
+	t := &Trace{Plan: h.Plan, MaxSteps: h.MaxSteps}
+	n := 1
+	for s.Scan() {
+		n++
+		var l traceLine
+		if err := json.Unmarshal(s.Bytes(), &l); err != nil {
+			return nil, fmt.Errorf("line %d: %v", n, err)
+		}
+		if l.Outcome != "" {
+			o, ok := parseOutcome(l.Outcome)
+			if !ok {
+				return nil, fmt.Errorf("line %d: unknown outcome %q", n, l.Outcome)
+			}
+			t.Outcome, t.Steps = o, l.Steps
+			if s.Scan() {
+				return nil, fmt.Errorf("line %d: expected the end of the trace", n+1)
+			}
+			return t, s.Err()
+		}
+		if l.Event != len(t.Events)+1 {
+			return nil, fmt.Errorf("line %d: expected the event %d, got %d", n, len(t.Events)+1, l.Event)
+		}
+		t.Events = append(t.Events, l.TraceEvent)
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+	return nil, fmt.Errorf("line %d: missing end of the trace", n)
+}
+
+// Divergence is the first difference between a trace and its simulation by the current engine
+type Divergence struct {
+	// number of the diverging event, after the last one if the numbers of events differ
+	Event int
+	// traced and simulated events, nil if missing
+	Traced, Simulated *TraceEvent
+}
+
+// Error returns the diverging events
+func (d *Divergence) Error() string {
+	return fmt.Sprintf("event %d: traced %s, simulated %s", d.Event, formatTraceEvent(d.Traced), formatTraceEvent(d.Simulated))
+}
+
+// formatTraceEvent returns the event as its JSON line, none if missing
+func formatTraceEvent(e *TraceEvent) string {
+	if e == nil {
+		return "none"
+	}
+	data, _ := json.Marshal(e)
+	return string(data)
+}
+
+// Verify simulates the map of the trace again with the given options and compares the events and the outcome
+// it returns the result of the simulation and a Divergence error at the first difference
+func (t *Trace) Verify(opts ...bender.Option) (bender.Result, error) {
+	var events []TraceEvent
+	b := bender.NewBenderSimulator()
+	f, err := fsm.NewFSM(t.Plan, bender.BeforeCallback, bender.EnterCallback)
+	if err != nil {
+		return bender.Result{}, err
+	}
+	f.Use(traceMiddleware(func(e *fsm.Event) error {
+		events = append(events, traceEvent(len(events)+1, e, b, f.Steps()))
+		return nil
+	}))
+	opts = append([]bender.Option{bender.WithMaxSteps(t.MaxSteps)}, opts...)
+	res, err := bender.Resume(f, b, opts...)
+	if err != nil {
+		return res, err
+	}
+	for i := 0; i < len(t.Events) || i < len(events); i++ {
+		d := &Divergence{Event: i + 1}
+		if i < len(t.Events) {
+			d.Traced = &t.Events[i]
+		}
+		if i < len(events) {
+			d.Simulated = &events[i]
+		}
+		if d.Traced == nil || d.Simulated == nil || !reflect.DeepEqual(*d.Traced, *d.Simulated) {
+			return res, d
+		}
+	}
+	if res.Outcome != t.Outcome || res.Steps != t.Steps {
+		return res, fmt.Errorf("traced %v after %d steps, simulated %v after %d steps", t.Outcome, t.Steps, res.Outcome, res.Steps)
+	}
+	return res, nil
+}
@@ -0,0 +1,132 @@
+package replay
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"bender/internal/bender"
+	"bender/internal/fsm"
+)
+
+// trace returns the trace of the simulation of the map
+func trace(t *testing.T, plan []string, maxSteps int) []byte {
+	buf := &bytes.Buffer{}
+	w, err := NewTraceWriter(buf, plan, maxSteps)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	m, err := fsm.NewFSM(plan, bender.BeforeCallback, bender.EnterCallback)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	b := bender.NewBenderSimulator()
+	m.Use(w.Middleware(m, b))
+	res, err := bender.Resume(m, b, bender.WithMaxSteps(maxSteps))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := w.Close(res); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestTrace(t *testing.T) {
+	plan := []string{"#####", "#@  #", "# B$#", "#####"}
+	data := trace(t, plan, 0)
+	expected := `{"trace":1,"plan":["#####","#@  #","# B$#","#####"]}
+{"event":1,"direction":"SOUTH","tile":" ","x":1,"y":2,"steps":1}
+{"event":2,"direction":"SOUTH","tile":"#","x":1,"y":3,"cancelled":true,"steps":1}
+{"event":3,"direction":"EAST","tile":"B","x":2,"y":2,"breaker":true,"steps":2}
+{"event":4,"direction":"EAST","tile":"$","x":3,"y":2,"breaker":true,"steps":3}
+{"outcome":"reached","steps":3}
+`
+	if string(data) != expected {
+		t.Fatalf("Wrong trace. Expected:\n%s\ngot:\n%s", expected, data)
+	}
+
+	tr, err := ReadTrace(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(tr.Events) != 4 || tr.Outcome != bender.Reached || tr.Steps != 3 {
+		t.Fatalf("Wrong trace %+v", tr)
+	}
+	res, err := tr.Verify()
+	if err != nil || res.Outcome != bender.Reached {
+		t.Fatalf("Wrong verification. Expected %v, got %v: %v", bender.Reached, res.Outcome, err)
+	}
+
+	// the step limit of the run is kept
+	tr, err = ReadTrace(bytes.NewReader(trace(t, []string{"#####", "#@  #", "#####"}, 2)))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if res, err := tr.Verify(); err != nil || res.Outcome != bender.StepLimitExceeded {
+		t.Fatalf("Wrong verification. Expected %v, got %v: %v", bender.StepLimitExceeded, res.Outcome, err)
+	}
+}
+
+func TestTraceDivergence(t *testing.T) {
+	plan := []string{"#####", "#@  #", "# B$#", "#####"}
+	data := string(trace(t, plan, 0))
+	testCases := []struct {
+		name  string
+		data  string
+		event int
+	}{
+		{
+			name:  "other flags",
+			data:  strings.Replace(data, `"x":2,"y":2,"breaker":true`, `"x":2,"y":2`, 1),
+			event: 3,
+		},
+		{
+			name:  "missing event",
+			data:  strings.Replace(data, "{\"event\":4,\"direction\":\"EAST\",\"tile\":\"$\",\"x\":3,\"y\":2,\"breaker\":true,\"steps\":3}\n", "", 1),
+			event: 4,
+		},
+	}
+	for _, tc := range testCases {
+		tr, err := ReadTrace(strings.NewReader(tc.data))
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tc.name, err)
+		}
+		_, err = tr.Verify()
+		var d *Divergence
+		if !errors.As(err, &d) || d.Event != tc.event {
+			t.Fatalf("%s: wrong divergence. Expected the event %d, got %v", tc.name, tc.event, err)
+		}
+	}
+
+	tr, err := ReadTrace(strings.NewReader(strings.Replace(data, `"outcome":"reached"`, `"outcome":"loop"`, 1)))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, err := tr.Verify(); err == nil {
+		t.Fatalf("Expected an error for another outcome")
+	}
+}
+
+func TestReadTraceErrors(t *testing.T) {
+	header := `{"trace":1,"plan":["###","#@#","###"]}` + "\n"
+	testCases := []struct {
+		name string
+		data string
+		err  string
+	}{
+		{name: "empty", data: "", err: "empty trace"},
+		{name: "other version", data: `{"trace":2,"plan":[]}` + "\n", err: "line 1"},
+		{name: "event out of order", data: header + `{"event":2,"direction":"SOUTH"}` + "\n", err: "line 2: expected the event 1"},
+		{name: "no end", data: header + `{"event":1,"direction":"SOUTH"}` + "\n", err: "line 2: missing end"},
+		{name: "unknown outcome", data: header + `{"outcome":"won","steps":1}` + "\n", err: "line 2: unknown outcome"},
+		{name: "after the end", data: header + `{"outcome":"loop","steps":0}` + "\n{}\n", err: "line 3"},
+	}
+	for _, tc := range testCases {
+		_, err := ReadTrace(strings.NewReader(tc.data))
+		if err == nil || !strings.Contains(err.Error(), tc.err) {
+			t.Fatalf("%s: wrong error. Expected %q, got %v", tc.name, tc.err, err)
+		}
+	}
+}
@@ -0,0 +1,179 @@
+package server
+
+import (
+	"errors"
+
+	"bender/internal/bender"
+	"bender/internal/fsm"
+	"bender/internal/pb"
+)
+
+// SimulateRequest is the body of a simulation request
+type SimulateRequest struct {
+	// map to simulate
+	Plan []string `json:"plan"`
+	// maximum number of steps, zero means no limit
+	MaxSteps int `json:"maxSteps,omitempty"`
+}
+
+// protoRequest is a request which can be decoded from a Protocol Buffers message
+type protoRequest interface {
+	unmarshalProto(data []byte) error
+}
+
+// unmarshalProto decodes the request from a pb.SimulateRequest message
+func (r *SimulateRequest) unmarshalProto(data []byte) error {
+	m := &pb.SimulateRequest{}
+	if err := m.Unmarshal(data); err != nil {
+		return err
+	}
+	r.Plan, r.MaxSteps = m.Map.Rows, int(m.Options.MaxSteps)
+	return nil
+}
+
+// SimulateResponse is the body of the response to a simulation request
+type SimulateResponse struct {
+	// how the simulation ended
+	Outcome string `json:"outcome"`
+	// resource whose budget was exceeded if the outcome is "budget exceeded": cpu time, memory or steps
+	Exceeded string `json:"exceeded,omitempty"`
+	// path followed by Bender
+	Path []string `json:"path"`
+	// number of steps made by Bender and his position at the end
+	Steps int `json:"steps"`
+	X     int `json:"x"`
+	Y     int `json:"y"`
+	// breakable walls destroyed by Bender, in order
+	Destroyed []DestroyedWall `json:"destroyed"`
+	// step out of the board if the outcome is "escaped board"
+	Escape *EscapeStep `json:"escape,omitempty"`
+}
+
+// DestroyedWall is a breakable wall destroyed by Bender
+type DestroyedWall struct {
+	X    int `json:"x"`
+	Y    int `json:"y"`
+	Step int `json:"step"`
+}
+
+// EscapeStep is the step which would have taken Bender out of the board
+type EscapeStep struct {
+	// number of the step, starting from 1
+	Step int `json:"step"`
+	// direction of the step
+	Direction string `json:"direction"`
+	// position of Bender
+	X int `json:"x"`
+	Y int `json:"y"`
+	// destination out of the board
+	ToX int `json:"toX"`
+	ToY int `json:"toY"`
+}
+
+// StepEvent is the data of the step events of a simulation streamed as Server-Sent Events
+type StepEvent struct {
+	// number of the step, starting from 1
+	Step int `json:"step"`
+	// direction of the step
+	Direction string `json:"direction"`
+	// position and tile entered by Bender
+	X    int    `json:"x"`
+	Y    int    `json:"y"`
+	Tile string `json:"tile"`
+	// rule which chose the direction and rule applied by the tile, if any
+	Rule   string `json:"rule"`
+	Effect string `json:"effect,omitempty"`
+}
+
+// ValidateRequest is the body of a validation request
+type ValidateRequest struct {
+	// map to validate
+	Plan []string `json:"plan"`
+}
+
+// ValidateResponse is the body of the response to a validation request
+type ValidateResponse struct {
+	// true if the map can be simulated
+	Valid bool `json:"valid"`
+	// errors found in the map
+	Errors []MapError `json:"errors"`
+}
+
+// MapError is an error found in a map
+type MapError struct {
+	// row and column of the offending cell, starting from 1, zero if the error is not about a cell
+	Row int `json:"row,omitempty"`
+	Col int `json:"col,omitempty"`
+	// description of the error
+	Message string `json:"message"`
+}
+
+// codes of the errors
+const (
+	// CodeMethodNotAllowed the endpoint doesn't support the method of the request
+	CodeMethodNotAllowed = "method_not_allowed"
+	// CodeMalformedRequest the body of the request isn't the expected JSON or Protocol Buffers message
+	CodeMalformedRequest = "malformed_request"
+	// CodeUnsupportedMediaType the endpoint doesn't support the content type of the request
+	CodeUnsupportedMediaType = "unsupported_media_type"
+	// CodeInvalidMap the map can't be simulated, the details tell why
+	CodeInvalidMap = "invalid_map"
+	// CodeMapTooLarge the map has more rows or columns than allowed
+	CodeMapTooLarge = "map_too_large"
+	// CodeStepsOverLimit the requested number of steps is over the limit
+	CodeStepsOverLimit = "steps_over_limit"
+	// CodeRateLimited the client sent too many requests, it should retry after the delay of the Retry-After header
+	CodeRateLimited = "rate_limited"
+	// CodeLimitExceeded the streamed simulation reached the step limit of the server
+	CodeLimitExceeded = "limit_exceeded"
+	// CodeBudgetExceeded the streamed simulation used more resources than the budget of the server
+	CodeBudgetExceeded = "budget_exceeded"
+	// CodeSimulationFailed the streamed simulation stopped on an error which isn't about the map
+	CodeSimulationFailed = "simulation_failed"
+)
+
+// ErrorResponse is the body of the responses to the failed requests
+type ErrorResponse struct {
+	// code of the error, one of the Code constants
+	Code string `json:"code"`
+	// description of the error
+	Error string `json:"error"`
+	// errors found in the map, if any
+	Details []MapError `json:"details,omitempty"`
+}
+
+// newSimulateResponse converts the result of a simulation
+func newSimulateResponse(res bender.Result) SimulateResponse {
+	r := SimulateResponse{
+		Outcome:   res.Outcome.String(),
+		Exceeded:  res.Exceeded,
+		Path:      res.Path,
+		Steps:     res.Steps,
+		X:         res.Position.X,
+		Y:         res.Position.Y,
+		Destroyed: []DestroyedWall{},
+	}
+	if r.Path == nil {
+		r.Path = []string{}
+	}
+	for _, d := range res.Destroyed {
+		r.Destroyed = append(r.Destroyed, DestroyedWall{X: d.At.X, Y: d.At.Y, Step: d.Step})
+	}
+	if e := res.Escape; e != nil {
+		r.Escape = &EscapeStep{Step: e.Step, Direction: string(e.Direction), X: e.From.X, Y: e.From.Y, ToX: e.To.X, ToY: e.To.Y}
+	}
+	return r
+}
+
+// mapErrors converts the error of a map
+func mapErrors(err error) []MapError {
+	var perrs fsm.ParseErrors
+	if !errors.As(err, &perrs) {
+		return []MapError{{Message: err.Error()}}
+	}
+	errs := make([]MapError, 0, len(perrs))
+	for _, pe := range perrs {
+		errs = append(errs, MapError{Row: pe.Row, Col: pe.Col, Message: pe.Msg})
+	}
+	return errs
+}
@@ -0,0 +1,73 @@
+package server
+
+import (
+	"sync"
+	"time"
+)
+
+// maxIdleClients is the number of clients above which the idle ones are forgotten
+const maxIdleClients = 10000
+
+// bucket is the token bucket of a client
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// rateLimiter limits the rate of the requests of every client with a token bucket
+type rateLimiter struct {
+	rate  float64
+	burst float64
+	now   func() time.Time
+	// mu guards the buckets
+	mu      sync.Mutex
+	clients map[string]*bucket
+}
+
+// newRateLimiter returns a limiter allowing the given rate of requests per second with bursts of the given size
+func newRateLimiter(rate float64, burst int) *rateLimiter {
+	if burst < 1 {
+		burst = 1
+	}
+	return &rateLimiter{rate: rate, burst: float64(burst), now: time.Now, clients: map[string]*bucket{}}
+}
+
+// allow takes a token of the client, or returns the time to wait for the next one
+func (l *rateLimiter) allow(client string) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := l.now()
+	b, exist := l.clients[client]
+	if !exist {
+		if len(l.clients) >= maxIdleClients {
+			l.forgetIdle(now)
+		}
+		b = &bucket{tokens: l.burst, last: now}
+		l.clients[client] = b
+	}
+	l.refill(b, now)
+	if b.tokens < 1 {
+		return false, time.Duration((1 - b.tokens) / l.rate * float64(time.Second))
+	}
+	b.tokens--
+	return true, 0
+}
+
+// refill adds the tokens earned since the last request
+func (l *rateLimiter) refill(b *bucket, now time.Time) {
+	b.tokens += now.Sub(b.last).Seconds() * l.rate
+	if b.tokens > l.burst {
+		b.tokens = l.burst
+	}
+	b.last = now
+}
+
+// forgetIdle forgets the clients whose bucket is full again, they're as good as new
+func (l *rateLimiter) forgetIdle(now time.Time) {
+	for c, b := range l.clients {
+		l.refill(b, now)
+		if b.tokens >= l.burst {
+			delete(l.clients, c)
+		}
+	}
+}
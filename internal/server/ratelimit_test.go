@@ -0,0 +1,56 @@
+package server
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestRateLimiter(t *testing.T) {
+	now := time.Unix(0, 0)
+	l := newRateLimiter(2, 3)
+	l.now = func() time.Time { return now }
+
+	testCases := []struct {
+		elapsed time.Duration
+		allowed bool
+		wait    time.Duration
+	}{
+		// the burst is allowed at once
+		{allowed: true},
+		{allowed: true},
+		{allowed: true},
+		{allowed: false, wait: 500 * time.Millisecond},
+		// a token every half second
+		{elapsed: 250 * time.Millisecond, allowed: false, wait: 250 * time.Millisecond},
+		{elapsed: 250 * time.Millisecond, allowed: true},
+		{allowed: false, wait: 500 * time.Millisecond},
+		// the bucket doesn't hold more than the burst
+		{elapsed: time.Hour, allowed: true},
+		{allowed: true},
+		{allowed: true},
+		{allowed: false, wait: 500 * time.Millisecond},
+	}
+
+	for i, tc := range testCases {
+		now = now.Add(tc.elapsed)
+		allowed, wait := l.allow("client")
+		if allowed != tc.allowed || wait != tc.wait {
+			t.Fatalf("Wrong answer to request %d. Expected %v after %v, got %v after %v", i, tc.allowed, tc.wait, allowed, wait)
+		}
+	}
+}
+
+func TestRateLimiterForgetIdle(t *testing.T) {
+	now := time.Unix(0, 0)
+	l := newRateLimiter(1, 1)
+	l.now = func() time.Time { return now }
+	for i := 0; i < maxIdleClients; i++ {
+		l.allow(fmt.Sprint(i))
+	}
+	now = now.Add(time.Second)
+	l.allow("new")
+	if len(l.clients) != 1 {
+		t.Fatalf("Wrong number of clients. Expected the idle ones to be forgotten, got %d", len(l.clients))
+	}
+}
@@ -0,0 +1,242 @@
+// Package server serves the simulations over HTTP, on TCP or on a Unix domain socket
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"mime"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"bender/internal/bender"
+	"bender/internal/fsm"
+	"bender/internal/pb"
+	"bender/schema"
+)
+
+const (
+	// maxBodySize is the maximum size of a request body
+	maxBodySize = 16 << 20
+	// protobufType is the content type of the Protocol Buffers messages
+	protobufType = "application/x-protobuf"
+)
+
+// Limits protects the API from huge or too many requests, the zero values disable the limits
+type Limits struct {
+	// maximum number of rows and columns of a map
+	MaxRows, MaxCols int
+	// maximum number of steps of a simulation, it's also the default
+	MaxSteps int
+	// resources allowed to every simulation, the simulations exceeding it end with the budget exceeded outcome
+	Budget bender.Budget
+	// requests allowed per second and per client, with bursts of the given size
+	Rate  float64
+	Burst int
+}
+
+// handler handles the requests of the JSON API
+type handler struct {
+	limits  Limits
+	limiter *rateLimiter
+}
+
+// NewHandler returns the handler of the JSON API enforcing the given limits:
+// POST /v1/simulate simulates a map, GET or POST /v1/simulate/events streams its steps as Server-Sent Events,
+// POST /v1/validate validates a map, GET /v1/openapi.json describes the API
+func NewHandler(limits Limits) http.Handler {
+	h := &handler{limits: limits}
+	if limits.Rate > 0 {
+		h.limiter = newRateLimiter(limits.Rate, limits.Burst)
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/simulate", h.simulate)
+	mux.HandleFunc("/v1/simulate/events", h.events)
+	mux.HandleFunc("/v1/validate", h.validate)
+	mux.HandleFunc("/v1/openapi.json", openAPI)
+	return mux
+}
+
+// ListenUnix listens on the Unix domain socket at the given path
+// a stale socket file is removed and the socket is only accessible by its owner
+func ListenUnix(path string) (net.Listener, error) {
+	if fi, err := os.Stat(path); err == nil && fi.Mode()&os.ModeSocket != 0 {
+		if err := os.Remove(path); err != nil {
+			return nil, err
+		}
+	}
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Chmod(path, 0600); err != nil {
+		l.Close()
+		return nil, err
+	}
+	return l, nil
+}
+
+// simulate handles the simulation requests
+func (h *handler) simulate(w http.ResponseWriter, r *http.Request) {
+	req := SimulateRequest{}
+	if !h.decode(w, r, &req) || !h.checkPlan(w, req.Plan) {
+		return
+	}
+	steps, ok := h.checkSteps(w, req.MaxSteps)
+	if !ok {
+		return
+	}
+	res, err := bender.Run(req.Plan, bender.WithMaxSteps(steps), bender.WithBudget(h.limits.Budget), bender.WithContext(r.Context()))
+	if err != nil {
+		writeJSON(w, http.StatusUnprocessableEntity, ErrorResponse{Code: CodeInvalidMap, Error: "invalid map", Details: mapErrors(err)})
+		return
+	}
+	if isProtobuf(r) {
+		w.Header().Set("Content-Type", protobufType)
+		w.WriteHeader(http.StatusOK)
+		w.Write(pb.NewResult(res).Marshal())
+		return
+	}
+	writeJSON(w, http.StatusOK, newSimulateResponse(res))
+}
+
+// validate handles the validation requests
+func (h *handler) validate(w http.ResponseWriter, r *http.Request) {
+	req := ValidateRequest{}
+	if !h.decode(w, r, &req) || !h.checkPlan(w, req.Plan) {
+		return
+	}
+	resp := ValidateResponse{Valid: true, Errors: []MapError{}}
+	if _, err := fsm.NewFSM(req.Plan, nil, nil); err != nil {
+		resp.Valid = false
+		resp.Errors = mapErrors(err)
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// openAPI serves the OpenAPI document of the API
+func openAPI(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		methodNotAllowed(w, r, http.MethodGet)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(schema.OpenAPI)
+}
+
+// decode reads the JSON or Protocol Buffers body of a POST request from a client within its rate
+// the error response is written if the request is refused
+func (h *handler) decode(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	if r.Method != http.MethodPost {
+		methodNotAllowed(w, r, http.MethodPost)
+		return false
+	}
+	if !h.allow(w, r) {
+		return false
+	}
+	body := http.MaxBytesReader(w, r.Body, maxBodySize)
+	if isProtobuf(r) {
+		p, ok := v.(protoRequest)
+		if !ok {
+			writeJSON(w, http.StatusUnsupportedMediaType, ErrorResponse{Code: CodeUnsupportedMediaType, Error: "Protocol Buffers not supported by " + r.URL.Path})
+			return false
+		}
+		data, err := io.ReadAll(body)
+		if err == nil {
+			err = p.unmarshalProto(data)
+		}
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, ErrorResponse{Code: CodeMalformedRequest, Error: fmt.Sprintf("malformed request: %v", err)})
+			return false
+		}
+		return true
+	}
+	dec := json.NewDecoder(body)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{Code: CodeMalformedRequest, Error: fmt.Sprintf("malformed request: %v", err)})
+		return false
+	}
+	return true
+}
+
+// allow checks that the client of the request is within its rate
+// the error response is written if it isn't
+func (h *handler) allow(w http.ResponseWriter, r *http.Request) bool {
+	if h.limiter == nil {
+		return true
+	}
+	if ok, wait := h.limiter.allow(clientOf(r)); !ok {
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+		writeJSON(w, http.StatusTooManyRequests, ErrorResponse{Code: CodeRateLimited, Error: "too many requests"})
+		return false
+	}
+	return true
+}
+
+// checkSteps returns the maximum number of steps of a simulation requesting the given number
+// the error response is written if it's over the limit
+func (h *handler) checkSteps(w http.ResponseWriter, steps int) (int, bool) {
+	if max := h.limits.MaxSteps; max > 0 {
+		if steps > max {
+			writeJSON(w, http.StatusBadRequest, ErrorResponse{Code: CodeStepsOverLimit, Error: fmt.Sprintf("maxSteps %d over the limit of %d", steps, max)})
+			return 0, false
+		}
+		if steps <= 0 {
+			steps = max
+		}
+	}
+	return steps, true
+}
+
+// checkPlan verifies that the map fits the limits, the columns are the cells whatever their glyphs
+// the error response is written if it doesn't
+func (h *handler) checkPlan(w http.ResponseWriter, plan []string) bool {
+	cols := 0
+	for _, row := range fsm.DecodePlan(plan) {
+		if n := utf8.RuneCountInString(row); n > cols {
+			cols = n
+		}
+	}
+	if (h.limits.MaxRows > 0 && len(plan) > h.limits.MaxRows) || (h.limits.MaxCols > 0 && cols > h.limits.MaxCols) {
+		writeJSON(w, http.StatusRequestEntityTooLarge, ErrorResponse{
+			Code:  CodeMapTooLarge,
+			Error: fmt.Sprintf("map of %dx%d over the limit of %dx%d", len(plan), cols, h.limits.MaxRows, h.limits.MaxCols),
+		})
+		return false
+	}
+	return true
+}
+
+// isProtobuf returns true if the body of the request is a Protocol Buffers message
+func isProtobuf(r *http.Request) bool {
+	t, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	return err == nil && t == protobufType
+}
+
+// clientOf returns the client of the request: its IP address,
+// all the clients of a Unix domain socket are the same
+func clientOf(r *http.Request) string {
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		return host
+	}
+	return r.RemoteAddr
+}
+
+// methodNotAllowed writes the response to a request whose method isn't one of the allowed ones
+func methodNotAllowed(w http.ResponseWriter, r *http.Request, allowed ...string) {
+	w.Header().Set("Allow", strings.Join(allowed, ", "))
+	writeJSON(w, http.StatusMethodNotAllowed, ErrorResponse{Code: CodeMethodNotAllowed, Error: fmt.Sprintf("method %s not allowed", r.Method)})
+}
+
+// writeJSON writes the response with the given status
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,393 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"bender/internal/bender"
+	"bender/internal/pb"
+)
+
+func TestSimulate(t *testing.T) {
+	testCases := []struct {
+		name     string
+		method   string
+		body     string
+		status   int
+		expected interface{}
+	}{
+		{
+			name:   "reached",
+			method: http.MethodPost,
+			body:   `{"plan": ["#####", "#@ $#", "#####"]}`,
+			status: http.StatusOK,
+			expected: &SimulateResponse{
+				Outcome:   "reached",
+				Path:      []string{"EAST", "EAST"},
+				Steps:     2,
+				X:         3,
+				Y:         1,
+				Destroyed: []DestroyedWall{},
+			},
+		},
+		{
+			name:   "step limit",
+			method: http.MethodPost,
+			body:   `{"plan": ["#####", "#@ $#", "#####"], "maxSteps": 1}`,
+			status: http.StatusOK,
+			expected: &SimulateResponse{
+				Outcome:   "step limit exceeded",
+				Path:      []string{"EAST"},
+				Steps:     1,
+				X:         2,
+				Y:         1,
+				Destroyed: []DestroyedWall{},
+			},
+		},
+		{
+			name:   "escaped",
+			method: http.MethodPost,
+			body:   `{"plan": ["####", "#@  ", "####"]}`,
+			status: http.StatusOK,
+			expected: &SimulateResponse{
+				Outcome:   "escaped board",
+				Path:      []string{"EAST", "EAST"},
+				Steps:     2,
+				X:         3,
+				Y:         1,
+				Destroyed: []DestroyedWall{},
+				Escape:    &EscapeStep{Step: 3, Direction: "EAST", X: 3, Y: 1, ToX: 4, ToY: 1},
+			},
+		},
+		{
+			name:   "invalid map",
+			method: http.MethodPost,
+			body:   `{"plan": ["#####", "#  $#", "#####"]}`,
+			status: http.StatusUnprocessableEntity,
+			expected: &ErrorResponse{
+				Code:    CodeInvalidMap,
+				Error:   "invalid map",
+				Details: []MapError{{Message: "no start in the map"}},
+			},
+		},
+		{
+			name:     "malformed",
+			method:   http.MethodPost,
+			body:     `{"map": []}`,
+			status:   http.StatusBadRequest,
+			expected: &ErrorResponse{Code: CodeMalformedRequest, Error: `malformed request: json: unknown field "map"`},
+		},
+		{
+			name:     "wrong method",
+			method:   http.MethodGet,
+			status:   http.StatusMethodNotAllowed,
+			expected: &ErrorResponse{Code: CodeMethodNotAllowed, Error: "method GET not allowed"},
+		},
+	}
+
+	for _, tc := range testCases {
+		req := httptest.NewRequest(tc.method, "/v1/simulate", strings.NewReader(tc.body))
+		rec := httptest.NewRecorder()
+		NewHandler(Limits{}).ServeHTTP(rec, req)
+		if rec.Code != tc.status {
+			t.Fatalf("Wrong status for %q. Expected %d, got %d: %s", tc.name, tc.status, rec.Code, rec.Body)
+		}
+		actual := reflect.New(reflect.TypeOf(tc.expected).Elem()).Interface()
+		if err := json.Unmarshal(rec.Body.Bytes(), actual); err != nil {
+			t.Fatalf("Unexpected error for %q: %v", tc.name, err)
+		}
+		if !reflect.DeepEqual(actual, tc.expected) {
+			t.Fatalf("Wrong response for %q. Expected %+v, got %+v", tc.name, tc.expected, actual)
+		}
+	}
+}
+
+func TestLimits(t *testing.T) {
+	limits := Limits{MaxRows: 3, MaxCols: 5, MaxSteps: 1}
+	testCases := []struct {
+		name   string
+		path   string
+		body   string
+		status int
+		code   string
+	}{
+		{
+			name:   "within limits",
+			path:   "/v1/simulate",
+			body:   `{"plan": ["####", "#@$#", "####"]}`,
+			status: http.StatusOK,
+		},
+		{
+			name:   "too many rows",
+			path:   "/v1/simulate",
+			body:   `{"plan": ["###", "#@#", "#$#", "###"]}`,
+			status: http.StatusRequestEntityTooLarge,
+			code:   CodeMapTooLarge,
+		},
+		{
+			name:   "too many columns",
+			path:   "/v1/validate",
+			body:   `{"plan": ["######", "#@  $#", "######"]}`,
+			status: http.StatusRequestEntityTooLarge,
+			code:   CodeMapTooLarge,
+		},
+		{
+			name:   "unicode within limits",
+			path:   "/v1/validate",
+			body:   `{"plan": ["╔═══╗", "║🤖️ 🚪║", "╚═══╝"]}`,
+			status: http.StatusOK,
+		},
+		{
+			name:   "unicode too many columns",
+			path:   "/v1/validate",
+			body:   `{"plan": ["╔════╗", "║☻  ▣║", "╚════╝"]}`,
+			status: http.StatusRequestEntityTooLarge,
+			code:   CodeMapTooLarge,
+		},
+		{
+			name:   "too many steps",
+			path:   "/v1/simulate",
+			body:   `{"plan": ["####", "#@$#", "####"], "maxSteps": 2}`,
+			status: http.StatusBadRequest,
+			code:   CodeStepsOverLimit,
+		},
+	}
+
+	h := NewHandler(limits)
+	for _, tc := range testCases {
+		req := httptest.NewRequest(http.MethodPost, tc.path, strings.NewReader(tc.body))
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		if rec.Code != tc.status {
+			t.Fatalf("Wrong status for %q. Expected %d, got %d: %s", tc.name, tc.status, rec.Code, rec.Body)
+		}
+		if tc.code == "" {
+			continue
+		}
+		actual := ErrorResponse{}
+		if err := json.Unmarshal(rec.Body.Bytes(), &actual); err != nil {
+			t.Fatalf("Unexpected error for %q: %v", tc.name, err)
+		}
+		if actual.Code != tc.code {
+			t.Fatalf("Wrong code for %q. Expected %q, got %q", tc.name, tc.code, actual.Code)
+		}
+	}
+
+	// the step limit is the default
+	req := httptest.NewRequest(http.MethodPost, "/v1/simulate", strings.NewReader(`{"plan": ["#####", "#@ $#", "#####"]}`))
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if !strings.Contains(rec.Body.String(), `"outcome":"step limit exceeded"`) {
+		t.Fatalf("Wrong response. Expected the step limit to be exceeded, got %s", rec.Body)
+	}
+}
+
+func TestBudget(t *testing.T) {
+	h := NewHandler(Limits{MaxSteps: 10, Budget: bender.Budget{Steps: 1}})
+	req := httptest.NewRequest(http.MethodPost, "/v1/simulate", strings.NewReader(`{"plan": ["#####", "#@ $#", "#####"]}`))
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	actual := SimulateResponse{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &actual); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected := SimulateResponse{Outcome: "budget exceeded", Exceeded: "steps", Path: []string{"EAST"}, Steps: 1, X: 2, Y: 1, Destroyed: []DestroyedWall{}}
+	if rec.Code != http.StatusOK || !reflect.DeepEqual(actual, expected) {
+		t.Fatalf("Wrong response. Expected %+v, got %d %+v", expected, rec.Code, actual)
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+	h := NewHandler(Limits{Rate: 0.001, Burst: 2})
+	for i, expected := range []int{http.StatusOK, http.StatusOK, http.StatusTooManyRequests} {
+		req := httptest.NewRequest(http.MethodPost, "/v1/validate", strings.NewReader(`{"plan": ["####", "#@$#", "####"]}`))
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		if rec.Code != expected {
+			t.Fatalf("Wrong status of request %d. Expected %d, got %d", i, expected, rec.Code)
+		}
+		if expected == http.StatusTooManyRequests && rec.Header().Get("Retry-After") == "" {
+			t.Fatalf("Expected a Retry-After header")
+		}
+	}
+	// another client has its own rate
+	req := httptest.NewRequest(http.MethodPost, "/v1/validate", strings.NewReader(`{"plan": ["####", "#@$#", "####"]}`))
+	req.RemoteAddr = "192.0.2.2:1234"
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Wrong status of another client. Expected %d, got %d", http.StatusOK, rec.Code)
+	}
+}
+
+func TestSimulateProtobuf(t *testing.T) {
+	body := (&pb.SimulateRequest{Map: pb.Map{Rows: []string{"#####", "#@ $#", "#####"}}, Options: pb.Options{MaxSteps: 1}}).Marshal()
+	req := httptest.NewRequest(http.MethodPost, "/v1/simulate", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/x-protobuf")
+	rec := httptest.NewRecorder()
+	NewHandler(Limits{}).ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != "application/x-protobuf" {
+		t.Fatalf("Wrong response. Expected %d with Protocol Buffers, got %d with %q: %s", http.StatusOK, rec.Code, rec.Header().Get("Content-Type"), rec.Body)
+	}
+	actual := &pb.Result{}
+	if err := actual.Unmarshal(rec.Body.Bytes()); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected := &pb.Result{Outcome: bender.StepLimitExceeded, Path: []string{"EAST"}, Steps: 1, X: 2, Y: 1}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Fatalf("Wrong result. Expected %+v, got %+v", expected, actual)
+	}
+
+	testCases := []struct {
+		path   string
+		body   []byte
+		status int
+	}{
+		{path: "/v1/simulate", body: []byte{0x0a, 0x05}, status: http.StatusBadRequest},
+		{path: "/v1/validate", body: body, status: http.StatusUnsupportedMediaType},
+	}
+	for _, tc := range testCases {
+		req := httptest.NewRequest(http.MethodPost, tc.path, bytes.NewReader(tc.body))
+		req.Header.Set("Content-Type", "application/x-protobuf")
+		rec := httptest.NewRecorder()
+		NewHandler(Limits{}).ServeHTTP(rec, req)
+		if rec.Code != tc.status {
+			t.Fatalf("Wrong status for %s. Expected %d, got %d", tc.path, tc.status, rec.Code)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	testCases := []struct {
+		name     string
+		body     string
+		expected ValidateResponse
+	}{
+		{
+			name:     "valid",
+			body:     `{"plan": ["#####", "#@ $#", "#####"]}`,
+			expected: ValidateResponse{Valid: true, Errors: []MapError{}},
+		},
+		{
+			name: "lone teleport",
+			body: `{"plan": ["#####", "#@T$#", "#####"]}`,
+			expected: ValidateResponse{
+				Errors: []MapError{{Row: 2, Col: 3, Message: `teleport 'T' appears 1 time(s), expected exactly 2`}},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		req := httptest.NewRequest(http.MethodPost, "/v1/validate", strings.NewReader(tc.body))
+		rec := httptest.NewRecorder()
+		NewHandler(Limits{}).ServeHTTP(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Wrong status for %q. Expected %d, got %d: %s", tc.name, http.StatusOK, rec.Code, rec.Body)
+		}
+		actual := ValidateResponse{}
+		if err := json.Unmarshal(rec.Body.Bytes(), &actual); err != nil {
+			t.Fatalf("Unexpected error for %q: %v", tc.name, err)
+		}
+		if !reflect.DeepEqual(actual, tc.expected) {
+			t.Fatalf("Wrong response for %q. Expected %+v, got %+v", tc.name, tc.expected, actual)
+		}
+	}
+}
+
+func TestUnixSocket(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bender.sock")
+	// a stale socket is replaced
+	for i := 0; i < 2; i++ {
+		l, err := ListenUnix(path)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if i == 0 {
+			l.(*net.UnixListener).SetUnlinkOnClose(false)
+			l.Close()
+			continue
+		}
+		defer l.Close()
+		go http.Serve(l, NewHandler(Limits{}))
+	}
+
+	fi, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if fi.Mode().Perm() != 0600 {
+		t.Fatalf("Wrong socket permissions. Expected %v, got %v", os.FileMode(0600), fi.Mode().Perm())
+	}
+
+	client := &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, "unix", path)
+		},
+	}}
+	resp, err := client.Post("http://bender/v1/simulate", "application/json", strings.NewReader(`{"plan": ["####", "#@$#", "####"]}`))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer resp.Body.Close()
+	actual := SimulateResponse{}
+	if err := json.NewDecoder(resp.Body).Decode(&actual); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if actual.Outcome != "reached" || !reflect.DeepEqual(actual.Path, []string{"EAST"}) {
+		t.Fatalf("Wrong response. Expected reached in one step, got %+v", actual)
+	}
+}
+
+func TestListenUnixNotSocket(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "file")
+	if err := os.WriteFile(path, []byte("data"), 0644); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, err := ListenUnix(path); err == nil {
+		t.Fatalf("Expected an error, the regular file must not be removed")
+	}
+}
+
+func TestOpenAPI(t *testing.T) {
+	rec := httptest.NewRecorder()
+	NewHandler(Limits{}).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/openapi.json", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Wrong status. Expected %d, got %d", http.StatusOK, rec.Code)
+	}
+	doc := struct {
+		Paths      map[string]interface{} `json:"paths"`
+		Components struct {
+			Schemas struct {
+				ErrorResponse struct {
+					Properties struct {
+						Code struct {
+							Enum []string `json:"enum"`
+						} `json:"code"`
+					} `json:"properties"`
+				} `json:"ErrorResponse"`
+			} `json:"schemas"`
+		} `json:"components"`
+	}{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &doc); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	for _, path := range []string{"/v1/simulate", "/v1/simulate/events", "/v1/validate", "/healthz", "/readyz"} {
+		if _, exist := doc.Paths[path]; !exist {
+			t.Fatalf("Path %s missing from the document", path)
+		}
+	}
+	codes := []string{CodeMethodNotAllowed, CodeMalformedRequest, CodeUnsupportedMediaType, CodeInvalidMap, CodeMapTooLarge, CodeStepsOverLimit, CodeRateLimited,
+		CodeLimitExceeded, CodeBudgetExceeded, CodeSimulationFailed}
+	if !reflect.DeepEqual(doc.Components.Schemas.ErrorResponse.Properties.Code.Enum, codes) {
+		t.Fatalf("Wrong error codes. Expected %q, got %q", codes, doc.Components.Schemas.ErrorResponse.Properties.Code.Enum)
+	}
+}
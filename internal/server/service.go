@@ -0,0 +1,163 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Listener is a listener of a service with its transport security
+type Listener struct {
+	net.Listener
+	// certificate and key files of TLS, the connections are in clear text if empty
+	CertFile, KeyFile string
+}
+
+// Service serves the JSON API along health and readiness endpoints and logs the requests
+// GET /healthz answers as long as the process runs, GET /readyz only while the requests are accepted
+type Service struct {
+	api http.Handler
+	// logMu guards the access log
+	logMu     sync.Mutex
+	accessLog io.Writer
+	// 1 while the requests are accepted
+	ready int32
+}
+
+// Config is the configuration of a service
+type Config struct {
+	// writer of the access log, the requests are logged as JSON lines, nil disables the log
+	AccessLog io.Writer
+	// limits of the API
+	Limits Limits
+}
+
+// NewService returns a service with the given configuration
+func NewService(conf Config) *Service {
+	return &Service{api: NewHandler(conf.Limits), accessLog: conf.AccessLog}
+}
+
+// ServeHTTP handles the request and logs it
+func (s *Service) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	rec := &recorder{ResponseWriter: w, status: http.StatusOK}
+	switch r.URL.Path {
+	case "/healthz":
+		writeJSON(rec, http.StatusOK, statusResponse{Status: "ok"})
+	case "/readyz":
+		if atomic.LoadInt32(&s.ready) == 1 {
+			writeJSON(rec, http.StatusOK, statusResponse{Status: "ready"})
+		} else {
+			writeJSON(rec, http.StatusServiceUnavailable, statusResponse{Status: "not ready"})
+		}
+	default:
+		s.api.ServeHTTP(rec, r)
+	}
+	s.log(accessEntry{
+		Time:     start.UTC().Format(time.RFC3339Nano),
+		Remote:   r.RemoteAddr,
+		Method:   r.Method,
+		Path:     r.URL.Path,
+		Status:   rec.status,
+		Bytes:    rec.bytes,
+		Duration: time.Since(start).Seconds() * 1000,
+	})
+}
+
+// Serve serves the requests on the given listeners until the context is done or one of them fails
+// the service is then shut down gracefully: it stops being ready, stops accepting connections
+// and waits for the running requests at most for the given duration
+func (s *Service) Serve(ctx context.Context, listeners []Listener, shutdownTimeout time.Duration) error {
+	if len(listeners) == 0 {
+		return errors.New("nothing to listen on")
+	}
+	srv := &http.Server{Handler: s, ReadHeaderTimeout: 10 * time.Second}
+	errs := make(chan error, len(listeners))
+	atomic.StoreInt32(&s.ready, 1)
+	for _, l := range listeners {
+		go func(l Listener) {
+			if l.CertFile != "" || l.KeyFile != "" {
+				errs <- srv.ServeTLS(l, l.CertFile, l.KeyFile)
+			} else {
+				errs <- srv.Serve(l)
+			}
+		}(l)
+	}
+
+	var err error
+	select {
+	case <-ctx.Done():
+	case err = <-errs:
+	}
+	atomic.StoreInt32(&s.ready, 0)
+	sctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if serr := srv.Shutdown(sctx); err == nil {
+		err = serr
+	}
+	return err
+}
+
+// statusResponse is the body of the responses of the health and readiness endpoints
+type statusResponse struct {
+	Status string `json:"status"`
+}
+
+// accessEntry is a line of the access log
+type accessEntry struct {
+	Time   string `json:"time"`
+	Remote string `json:"remote"`
+	Method string `json:"method"`
+	Path   string `json:"path"`
+	Status int    `json:"status"`
+	Bytes  int    `json:"bytes"`
+	// duration of the request in milliseconds
+	Duration float64 `json:"durationMs"`
+}
+
+// log writes the entry to the access log
+func (s *Service) log(e accessEntry) {
+	if s.accessLog == nil {
+		return
+	}
+	data, err := json.Marshal(e)
+	if err != nil {
+		return
+	}
+	s.logMu.Lock()
+	defer s.logMu.Unlock()
+	s.accessLog.Write(append(data, '\n'))
+}
+
+// recorder records the status and the size of a response
+type recorder struct {
+	http.ResponseWriter
+	status int
+	bytes  int
+}
+
+// WriteHeader records the status
+func (r *recorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// Write records the size of the body
+func (r *recorder) Write(b []byte) (int, error) {
+	n, err := r.ResponseWriter.Write(b)
+	r.bytes += n
+	return n, err
+}
+
+// Flush sends the buffered data to the client, for the streamed responses
+func (r *recorder) Flush() {
+	if f, ok := r.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
@@ -0,0 +1,166 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestServiceEndpoints(t *testing.T) {
+	log := &bytes.Buffer{}
+	s := NewService(Config{AccessLog: log})
+	testCases := []struct {
+		method string
+		path   string
+		ready  bool
+		status int
+	}{
+		{method: http.MethodGet, path: "/healthz", status: http.StatusOK},
+		{method: http.MethodGet, path: "/readyz", status: http.StatusServiceUnavailable},
+		{method: http.MethodGet, path: "/readyz", ready: true, status: http.StatusOK},
+		{method: http.MethodGet, path: "/v1/simulate", status: http.StatusMethodNotAllowed},
+		{method: http.MethodGet, path: "/unknown", status: http.StatusNotFound},
+	}
+
+	for _, tc := range testCases {
+		s.ready = 0
+		if tc.ready {
+			s.ready = 1
+		}
+		req := httptest.NewRequest(tc.method, tc.path, nil)
+		rec := httptest.NewRecorder()
+		s.ServeHTTP(rec, req)
+		if rec.Code != tc.status {
+			t.Fatalf("Wrong status for %s %s. Expected %d, got %d", tc.method, tc.path, tc.status, rec.Code)
+		}
+	}
+
+	lines := strings.Split(strings.TrimSpace(log.String()), "\n")
+	if len(lines) != len(testCases) {
+		t.Fatalf("Wrong number of access log lines. Expected %d, got %d", len(testCases), len(lines))
+	}
+	for i, l := range lines {
+		e := accessEntry{}
+		if err := json.Unmarshal([]byte(l), &e); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		tc := testCases[i]
+		if e.Method != tc.method || e.Path != tc.path || e.Status != tc.status || e.Bytes == 0 || e.Time == "" {
+			t.Fatalf("Wrong access log line for %s %s: %s", tc.method, tc.path, l)
+		}
+	}
+}
+
+func TestServiceShutdown(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	s := NewService(Config{})
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- s.Serve(ctx, []Listener{{Listener: l}}, time.Second)
+	}()
+
+	url := "http://" + l.Addr().String()
+	resp, err := http.Post(url+"/v1/simulate", "application/json", strings.NewReader(`{"plan": ["####", "#@$#", "####"]}`))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Wrong status. Expected %d, got %d", http.StatusOK, resp.StatusCode)
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if s.ready != 0 {
+		t.Fatalf("Expected the service not to be ready after shutdown")
+	}
+	if _, err := http.Get(url + "/healthz"); err == nil {
+		t.Fatalf("Expected the listener to be closed after shutdown")
+	}
+}
+
+func TestServiceTLSError(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	err = NewService(Config{}).Serve(context.Background(), []Listener{{Listener: l, CertFile: "missing.crt", KeyFile: "missing.key"}}, time.Second)
+	if err == nil {
+		t.Fatalf("Expected an error for the missing certificate")
+	}
+	if err := NewService(Config{}).Serve(context.Background(), nil, time.Second); err == nil {
+		t.Fatalf("Expected an error without listeners")
+	}
+}
+
+// writeCert writes a self-signed certificate for 127.0.0.1 and its key to the given directory
+func writeCert(t *testing.T, dir string) (certFile, keyFile string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	certFile, keyFile = filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	if err := os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0600); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	return certFile, keyFile
+}
+
+func TestServiceTLS(t *testing.T) {
+	certFile, keyFile := writeCert(t, t.TempDir())
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go NewService(Config{}).Serve(ctx, []Listener{{Listener: l, CertFile: certFile, KeyFile: keyFile}}, time.Second)
+
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}}}
+	resp, err := client.Get("https://" + l.Addr().String() + "/healthz")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || resp.TLS == nil {
+		t.Fatalf("Wrong response. Expected %d over TLS, got %d", http.StatusOK, resp.StatusCode)
+	}
+}
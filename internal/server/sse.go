@@ -0,0 +1,145 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"bender/internal/bender"
+	"bender/internal/fsm"
+)
+
+// sseFlushInterval is the maximum delay of the events buffered before being sent to the client
+const sseFlushInterval = 100 * time.Millisecond
+
+// events handles the simulation requests streamed as Server-Sent Events:
+// a step event per step then a result event with the SimulateResponse, or an error event with an ErrorResponse
+// if the simulation fails or is stopped by the step limit or the budget of the server, nothing if the client is gone
+// the map is the body of a POST request like for /v1/simulate, or the rows separated by newlines of the plan
+// query parameter of a GET request, for the EventSource of the browsers
+func (h *handler) events(w http.ResponseWriter, r *http.Request) {
+	req := SimulateRequest{}
+	switch r.Method {
+	case http.MethodGet:
+		if !h.allow(w, r) || !queryRequest(w, r, &req) {
+			return
+		}
+	case http.MethodPost:
+		if !h.decode(w, r, &req) {
+			return
+		}
+	default:
+		methodNotAllowed(w, r, http.MethodGet, http.MethodPost)
+		return
+	}
+	if !h.checkPlan(w, req.Plan) {
+		return
+	}
+	steps, ok := h.checkSteps(w, req.MaxSteps)
+	if !ok {
+		return
+	}
+	flusher, _ := w.(http.Flusher)
+	sse := &sseWriter{w: w, flusher: flusher}
+	m, err := fsm.NewFSM(req.Plan, nil, nil)
+	if err != nil {
+		writeJSON(w, http.StatusUnprocessableEntity, ErrorResponse{Code: CodeInvalidMap, Error: "invalid map", Details: mapErrors(err)})
+		return
+	}
+	b := bender.NewBenderSimulator()
+	m.SetCallbacks(bender.BeforeCallback, func(e *fsm.Event) {
+		bender.EnterCallback(e)
+		p := e.DstPosition()
+		choice, effect := b.StepRules()
+		step := StepEvent{Step: m.Steps(), Direction: string(e.Event), X: p.X, Y: p.Y, Tile: string(e.Dst), Rule: string(choice), Effect: string(effect)}
+		if err := sse.send("step", step); err != nil {
+			// the client is gone
+			e.Abort(err)
+		}
+	})
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	res, err := bender.Resume(m, b, bender.WithMaxSteps(steps), bender.WithBudget(h.limits.Budget), bender.WithContext(r.Context()))
+	if r.Context().Err() != nil {
+		// the client is gone
+		return
+	}
+	switch {
+	case err != nil && isMapError(err):
+		sse.send("error", ErrorResponse{Code: CodeInvalidMap, Error: "invalid map", Details: mapErrors(err)})
+	case err != nil:
+		sse.send("error", ErrorResponse{Code: CodeSimulationFailed, Error: fmt.Sprintf("simulation failed: %v", err)})
+	case res.Outcome == bender.BudgetExceeded:
+		sse.send("error", ErrorResponse{Code: CodeBudgetExceeded, Error: fmt.Sprintf("budget of %s exceeded after %d steps", res.Exceeded, res.Steps)})
+	case res.Outcome == bender.StepLimitExceeded && req.MaxSteps <= 0:
+		// the client asked no limit, the one of the server stopped the simulation
+		sse.send("error", ErrorResponse{Code: CodeLimitExceeded, Error: fmt.Sprintf("step limit of %d reached", steps)})
+	default:
+		sse.send("result", newSimulateResponse(res))
+	}
+	sse.flush()
+}
+
+// isMapError returns true if the error is about the map: a parse or validation error of fsm
+func isMapError(err error) bool {
+	var perrs fsm.ParseErrors
+	var pe *fsm.ParseError
+	return errors.As(err, &perrs) || errors.As(err, &pe) ||
+		errors.Is(err, fsm.ErrNoStart) || errors.Is(err, fsm.ErrBadTeleports) || errors.Is(err, fsm.ErrInvalidSymbol)
+}
+
+// queryRequest reads the simulation request from the query parameters plan and maxSteps
+// the error response is written if they're malformed
+func queryRequest(w http.ResponseWriter, r *http.Request, req *SimulateRequest) bool {
+	q := r.URL.Query()
+	if q.Get("plan") == "" {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{Code: CodeMalformedRequest, Error: "malformed request: no plan parameter"})
+		return false
+	}
+	req.Plan = strings.Split(strings.ReplaceAll(q.Get("plan"), "\r\n", "\n"), "\n")
+	if s := q.Get("maxSteps"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, ErrorResponse{Code: CodeMalformedRequest, Error: fmt.Sprintf("malformed request: maxSteps %q", s)})
+			return false
+		}
+		req.MaxSteps = n
+	}
+	return true
+}
+
+// sseWriter writes Server-Sent Events, flushed at most every sseFlushInterval
+type sseWriter struct {
+	w         http.ResponseWriter
+	flusher   http.Flusher
+	lastFlush time.Time
+}
+
+// send writes the event of the given type with the data encoded as JSON
+func (s *sseWriter) send(event string, data interface{}) error {
+	b, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(s.w, "event: %s\ndata: %s\n\n", event, b); err != nil {
+		return err
+	}
+	if now := time.Now(); now.Sub(s.lastFlush) >= sseFlushInterval {
+		s.flush()
+		s.lastFlush = now
+	}
+	return nil
+}
+
+// flush sends the buffered events to the client
+func (s *sseWriter) flush() {
+	if s.flusher != nil {
+		s.flusher.Flush()
+	}
+}
@@ -0,0 +1,139 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"bender/internal/bender"
+)
+
+func TestEvents(t *testing.T) {
+	steps := "event: step\ndata: {\"step\":1,\"direction\":\"EAST\",\"x\":2,\"y\":1,\"tile\":\" \",\"rule\":\"priority fallback\"}\n\n" +
+		"event: step\ndata: {\"step\":2,\"direction\":\"EAST\",\"x\":3,\"y\":1,\"tile\":\"$\",\"rule\":\"forward\",\"effect\":\"booth\"}\n\n"
+	testCases := []struct {
+		name     string
+		method   string
+		target   string
+		body     string
+		status   int
+		expected string
+	}{
+		{
+			name:     "post",
+			method:   http.MethodPost,
+			body:     `{"plan": ["#####", "#@ $#", "#####"]}`,
+			status:   http.StatusOK,
+			expected: steps + "event: result\ndata: {\"outcome\":\"reached\",\"path\":[\"EAST\",\"EAST\"],\"steps\":2,\"x\":3,\"y\":1,\"destroyed\":[]}\n\n",
+		},
+		{
+			name:     "get",
+			method:   http.MethodGet,
+			target:   "?" + url.Values{"plan": {"#####\n#@ $#\n#####"}, "maxSteps": {"1"}}.Encode(),
+			status:   http.StatusOK,
+			expected: steps[:strings.Index(steps, "\n\n")+2] + "event: result\ndata: {\"outcome\":\"step limit exceeded\",\"path\":[\"EAST\"],\"steps\":1,\"x\":2,\"y\":1,\"destroyed\":[]}\n\n",
+		},
+		{
+			name:     "invalid map",
+			method:   http.MethodPost,
+			body:     `{"plan": ["###", "#T@", "###"]}`,
+			status:   http.StatusUnprocessableEntity,
+			expected: `{"code":"invalid_map"`,
+		},
+		{
+			name:     "no plan",
+			method:   http.MethodGet,
+			status:   http.StatusBadRequest,
+			expected: `{"code":"malformed_request","error":"malformed request: no plan parameter"}`,
+		},
+		{
+			name:     "wrong method",
+			method:   http.MethodPut,
+			status:   http.StatusMethodNotAllowed,
+			expected: `{"code":"method_not_allowed","error":"method PUT not allowed"}`,
+		},
+	}
+
+	for _, tc := range testCases {
+		req := httptest.NewRequest(tc.method, "/v1/simulate/events"+tc.target, strings.NewReader(tc.body))
+		rec := httptest.NewRecorder()
+		NewHandler(Limits{}).ServeHTTP(rec, req)
+		if rec.Code != tc.status {
+			t.Fatalf("Test case %q: wrong status. Expected %d, got %d: %s", tc.name, tc.status, rec.Code, rec.Body)
+		}
+		if !strings.HasPrefix(rec.Body.String(), tc.expected) {
+			t.Fatalf("Test case %q: wrong body. Expected it to start with:\n%s\ngot:\n%s", tc.name, tc.expected, rec.Body)
+		}
+		if tc.status == http.StatusOK && rec.Header().Get("Content-Type") != "text/event-stream" {
+			t.Fatalf("Test case %q: wrong content type %q", tc.name, rec.Header().Get("Content-Type"))
+		}
+	}
+}
+
+func TestEventsStopped(t *testing.T) {
+	plan := `{"plan": ["######", "#@  $#", "######"]}`
+	testCases := []struct {
+		name     string
+		limits   Limits
+		expected string
+	}{
+		{
+			name:     "step limit",
+			limits:   Limits{MaxSteps: 1},
+			expected: "event: error\ndata: {\"code\":\"limit_exceeded\",\"error\":\"step limit of 1 reached\"}\n\n",
+		},
+		{
+			name:     "budget",
+			limits:   Limits{Budget: bender.Budget{Steps: 2}},
+			expected: "event: error\ndata: {\"code\":\"budget_exceeded\",\"error\":\"budget of steps exceeded after 2 steps\"}\n\n",
+		},
+	}
+	for _, tc := range testCases {
+		req := httptest.NewRequest(http.MethodPost, "/v1/simulate/events", strings.NewReader(plan))
+		rec := httptest.NewRecorder()
+		NewHandler(tc.limits).ServeHTTP(rec, req)
+		if !strings.HasSuffix(rec.Body.String(), tc.expected) {
+			t.Fatalf("Test case %q: wrong body. Expected it to end with:\n%s\ngot:\n%s", tc.name, tc.expected, rec.Body)
+		}
+	}
+}
+
+func TestEventsClientGone(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	req := httptest.NewRequest(http.MethodPost, "/v1/simulate/events", strings.NewReader(`{"plan": ["#####", "#@ $#", "#####"]}`)).WithContext(ctx)
+	// the client leaves after the first step
+	w := &goneWriter{ResponseRecorder: httptest.NewRecorder(), gone: cancel}
+	NewHandler(Limits{}).ServeHTTP(w, req)
+	if body := w.Body.String(); strings.Contains(body, "event: error") || strings.Contains(body, "event: result") {
+		t.Fatalf("Wrong body. Expected no event once the client is gone, got:\n%s", body)
+	}
+}
+
+// goneWriter is a response writer cancelling the request once the first event is written,
+// the next writes fail but are recorded to see what the handler tried to send
+type goneWriter struct {
+	*httptest.ResponseRecorder
+	gone func()
+}
+
+func (w *goneWriter) Write(b []byte) (int, error) {
+	if w.Body.Len() > 0 {
+		w.gone()
+		w.ResponseRecorder.Write(b)
+		return 0, errors.New("client gone")
+	}
+	return w.ResponseRecorder.Write(b)
+}
+
+func TestEventsFlushed(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/v1/simulate/events", strings.NewReader(`{"plan": ["####", "#@$#", "####"]}`))
+	rec := httptest.NewRecorder()
+	NewService(Config{}).ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK || !rec.Flushed {
+		t.Fatalf("Wrong events through the service, got %d (flushed %t): %s", rec.Code, rec.Flushed, rec.Body)
+	}
+}
@@ -0,0 +1,166 @@
+package worker
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// tuneInterval is the interval between two adjustments of the number of concurrent jobs
+	tuneInterval = time.Second
+	// cpuHigh is the CPU usage of the machine above which the jobs are reduced
+	cpuHigh = 0.9
+	// cpuLow is the CPU usage of the machine under which the jobs are increased when some are waiting
+	cpuLow = 0.75
+	// memoryHigh is the memory usage of the machine above which the jobs are halved
+	memoryHigh = 0.9
+)
+
+// loadSampler returns the fractions of the CPU and of the memory of the machine in use,
+// the CPU usage is the one since the previous sample
+type loadSampler func() (cpu, memory float64, err error)
+
+// concurrency bounds the number of jobs simulated at once, the bound is tuned to the load of the machine
+type concurrency struct {
+	mu      sync.Mutex
+	cond    *sync.Cond
+	limit   int
+	max     int
+	running int
+	waiting int
+}
+
+// newConcurrency returns a bound starting at a single job and growing up to max
+func newConcurrency(max int) *concurrency {
+	c := &concurrency{limit: 1, max: max}
+	c.cond = sync.NewCond(&c.mu)
+	return c
+}
+
+// acquire waits for a free slot
+func (c *concurrency) acquire() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.waiting++
+	for c.running >= c.limit {
+		c.cond.Wait()
+	}
+	c.waiting--
+	c.running++
+}
+
+// release frees a slot
+func (c *concurrency) release() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.running--
+	c.cond.Signal()
+}
+
+// Limit returns the current number of jobs allowed at once
+func (c *concurrency) Limit() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.limit
+}
+
+// tune adjusts the bound to the load of the machine:
+// halved under memory pressure, decreased when the CPUs are saturated
+// and increased when jobs are waiting for idle CPUs
+func (c *concurrency) tune(cpu, memory float64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	switch {
+	case memory >= memoryHigh:
+		c.limit /= 2
+	case cpu >= cpuHigh:
+		c.limit--
+	case cpu < cpuLow && c.waiting > 0:
+		c.limit++
+	}
+	if c.limit < 1 {
+		c.limit = 1
+	}
+	if c.limit > c.max {
+		c.limit = c.max
+	}
+	c.cond.Broadcast()
+}
+
+// run tunes the bound with the samples of the load until the context is done
+// the bound grows up to its maximum if the load can't be sampled
+func (c *concurrency) run(ctx context.Context, interval time.Duration, sample loadSampler) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+		cpu, memory, err := sample()
+		if err != nil {
+			cpu, memory = 0, 0
+		}
+		c.tune(cpu, memory)
+	}
+}
+
+// cpuTimes are the cumulated busy and total times of the CPUs
+type cpuTimes struct {
+	busy, total uint64
+}
+
+// parseCPUStat parses the times of all the CPUs from the first line of /proc/stat
+func parseCPUStat(data []byte) (cpuTimes, error) {
+	line, _, _ := bytes.Cut(data, []byte("\n"))
+	fields := strings.Fields(string(line))
+	if len(fields) < 5 || fields[0] != "cpu" {
+		return cpuTimes{}, errors.New("no cpu line in the statistics")
+	}
+	var t cpuTimes
+	for i, f := range fields[1:] {
+		v, err := strconv.ParseUint(f, 10, 64)
+		if err != nil {
+			return cpuTimes{}, err
+		}
+		t.total += v
+		// idle and iowait
+		if i != 3 && i != 4 {
+			t.busy += v
+		}
+	}
+	return t, nil
+}
+
+// parseMemInfo returns the fraction of the memory in use from /proc/meminfo
+func parseMemInfo(data []byte) (float64, error) {
+	var total, available uint64
+	s := bufio.NewScanner(bytes.NewReader(data))
+	for s.Scan() {
+		fields := strings.Fields(s.Text())
+		if len(fields) < 2 {
+			continue
+		}
+		v, err := strconv.ParseUint(fields[1], 10, 64)
+		if err != nil {
+			continue
+		}
+		switch fields[0] {
+		case "MemTotal:":
+			total = v
+		case "MemAvailable:":
+			available = v
+		}
+	}
+	if total == 0 || available > total {
+		return 0, errors.New("no memory in the statistics")
+	}
+	return 1 - float64(available)/float64(total), nil
+}
@@ -0,0 +1,80 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestConcurrencyTune(t *testing.T) {
+	testCases := []struct {
+		name     string
+		limit    int
+		waiting  int
+		cpu      float64
+		memory   float64
+		expected int
+	}{
+		{name: "idle cpus and waiting jobs", limit: 2, waiting: 1, cpu: 0.5, expected: 3},
+		{name: "idle cpus without waiting jobs", limit: 2, cpu: 0.5, expected: 2},
+		{name: "at the maximum", limit: 4, waiting: 1, cpu: 0.1, expected: 4},
+		{name: "busy cpus", limit: 3, waiting: 1, cpu: 0.8, expected: 3},
+		{name: "saturated cpus", limit: 3, waiting: 1, cpu: 0.95, expected: 2},
+		{name: "saturated cpus at the minimum", limit: 1, cpu: 1, expected: 1},
+		{name: "memory pressure", limit: 4, waiting: 1, cpu: 0.1, memory: 0.95, expected: 2},
+	}
+	for _, tc := range testCases {
+		c := newConcurrency(4)
+		c.limit, c.waiting = tc.limit, tc.waiting
+		c.tune(tc.cpu, tc.memory)
+		if c.Limit() != tc.expected {
+			t.Fatalf("Test case %q: wrong limit. Expected %d, got %d", tc.name, tc.expected, c.Limit())
+		}
+	}
+}
+
+func TestParseLoad(t *testing.T) {
+	stat := "cpu  100 0 50 800 50 0 0 0 0 0\ncpu0 100 0 50 800 50 0 0 0 0 0\n"
+	times, err := parseCPUStat([]byte(stat))
+	if err != nil || times != (cpuTimes{busy: 150, total: 1000}) {
+		t.Fatalf("Wrong CPU times, got %+v, %v", times, err)
+	}
+	if _, err := parseCPUStat([]byte("intr 1 2 3\n")); err == nil {
+		t.Fatalf("Expected an error without the cpu line")
+	}
+	meminfo := "MemTotal:       16000 kB\nMemFree:         1000 kB\nMemAvailable:    4000 kB\n"
+	if memory, err := parseMemInfo([]byte(meminfo)); err != nil || memory != 0.75 {
+		t.Fatalf("Wrong memory usage. Expected 0.75, got %v, %v", memory, err)
+	}
+	if _, err := parseMemInfo([]byte("MemFree: 1000 kB\n")); err == nil {
+		t.Fatalf("Expected an error without the total memory")
+	}
+}
+
+func TestRunDirConcurrent(t *testing.T) {
+	in, out := t.TempDir(), filepath.Join(t.TempDir(), "results")
+	for i := 0; i < 20; i++ {
+		if err := os.WriteFile(filepath.Join(in, fmt.Sprintf("map%02d.txt", i)), []byte("####\n#@$#\n####\n"), 0644); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+	for _, d := range []string{filepath.Join(in, processingDir), filepath.Join(in, doneDir), filepath.Join(in, failedDir), out} {
+		if err := os.MkdirAll(d, 0755); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+	w := New(Config{Out: out, MaxWorkers: 4})
+	w.slots = newConcurrency(4)
+	w.slots.limit = 4
+	if found, err := w.scanDir(context.Background(), in, map[string]int{}); err != nil || found != 20 {
+		t.Fatalf("Wrong scan. Expected 20 jobs, got %d: %v", found, err)
+	}
+	if n := len(listDir(t, filepath.Join(in, doneDir))); n != 20 {
+		t.Fatalf("Wrong number of simulated jobs. Expected 20, got %d", n)
+	}
+	if n := len(listDir(t, out)); n != 20 {
+		t.Fatalf("Wrong number of results. Expected 20, got %d", n)
+	}
+}
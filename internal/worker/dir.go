@@ -0,0 +1,159 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"bender/internal/compress"
+)
+
+const (
+	// processingDir is the subdirectory of the input directory holding the jobs being simulated
+	processingDir = ".processing"
+	// doneDir is the subdirectory of the input directory holding the simulated jobs
+	doneDir = "done"
+	// failedDir is the subdirectory of the input directory holding the poison jobs and the jobs out of retries
+	failedDir = "failed"
+)
+
+// RunDir simulates the jobs of the input directory, one file per job, until the context is done
+// a job is claimed by moving it to the .processing subdirectory, so several workers can share a directory,
+// then it's moved to the done or failed subdirectory, the failed ones along a .error file
+// the directory is scanned again after the given poll interval, zero returns once the directory is empty,
+// only the jobs of the shard of the worker are simulated, up to MaxWorkers at once depending on the load of the machine
+func (w *Worker) RunDir(ctx context.Context, in string, poll time.Duration) error {
+	for _, d := range []string{filepath.Join(in, processingDir), filepath.Join(in, doneDir), filepath.Join(in, failedDir), w.conf.Out} {
+		if err := os.MkdirAll(d, 0755); err != nil {
+			return err
+		}
+	}
+
+	if w.conf.MaxWorkers > 1 {
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		w.slots = newConcurrency(w.conf.MaxWorkers)
+		defer func() { w.slots = nil }()
+		go w.slots.run(ctx, tuneInterval, newLoadSampler())
+	}
+
+	attempts := map[string]int{}
+	for {
+		found, err := w.scanDir(ctx, in, attempts)
+		if err != nil {
+			return err
+		}
+		if poll == 0 && found == 0 {
+			return nil
+		}
+		if found == 0 {
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-time.After(poll):
+			}
+		}
+	}
+}
+
+// scanDir simulates the jobs found in the input directory and returns their number
+// the jobs run concurrently within the bound of the worker, if any
+func (w *Worker) scanDir(ctx context.Context, in string, attempts map[string]int) (int, error) {
+	entries, err := os.ReadDir(in)
+	if err != nil {
+		return 0, err
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name() < entries[j].Name() })
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		firstErr error
+	)
+	failed := func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return firstErr != nil
+	}
+	found := 0
+	for _, e := range entries {
+		if ctx.Err() != nil || failed() {
+			break
+		}
+		file := e.Name()
+		if !e.Type().IsRegular() || strings.HasPrefix(file, ".") || !w.conf.Shard.Owns(file) {
+			continue
+		}
+		claimed := filepath.Join(in, processingDir, file)
+		if err := os.Rename(filepath.Join(in, file), claimed); err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				// claimed by another worker
+				continue
+			}
+			mu.Lock()
+			firstErr = err
+			mu.Unlock()
+			break
+		}
+		found++
+
+		if w.slots == nil {
+			if err := w.runClaimed(in, file, attempts, &mu); err != nil {
+				return found, err
+			}
+			continue
+		}
+		w.slots.acquire()
+		wg.Add(1)
+		go func(file string) {
+			defer wg.Done()
+			defer w.slots.release()
+			if err := w.runClaimed(in, file, attempts, &mu); err != nil {
+				mu.Lock()
+				if firstErr == nil {
+					firstErr = err
+				}
+				mu.Unlock()
+			}
+		}(file)
+	}
+	wg.Wait()
+	return found, firstErr
+}
+
+// runClaimed simulates the claimed job and moves it according to its result
+// the attempts are guarded by the given mutex
+func (w *Worker) runClaimed(in, file string, attempts map[string]int, mu *sync.Mutex) error {
+	claimed := filepath.Join(in, processingDir, file)
+	data, err := os.ReadFile(claimed)
+	if err == nil {
+		name := compress.TrimExt(file)
+		err = w.Process(Job{Name: strings.TrimSuffix(name, filepath.Ext(name)), Data: data})
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	var perr *PoisonError
+	switch {
+	case err == nil:
+		delete(attempts, file)
+		return os.Rename(claimed, filepath.Join(in, doneDir, file))
+	case errors.As(err, &perr) || attempts[file] >= w.conf.Retries:
+		delete(attempts, file)
+		return fail(in, file, err)
+	default:
+		attempts[file]++
+		return os.Rename(claimed, filepath.Join(in, file))
+	}
+}
+
+// fail moves the claimed job to the failed subdirectory along a file with its error
+func fail(in, file string, jobErr error) error {
+	if err := writeFile(filepath.Join(in, failedDir, file+".error"), []byte(jobErr.Error()+"\n")); err != nil {
+		return err
+	}
+	return os.Rename(filepath.Join(in, processingDir, file), filepath.Join(in, failedDir, file))
+}
@@ -0,0 +1,93 @@
+package worker
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+
+	"bender/internal/compress"
+)
+
+// listDir returns the sorted names of the files of the directory
+func listDir(t *testing.T, dir string) []string {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	names := []string{}
+	for _, e := range entries {
+		if !e.IsDir() {
+			names = append(names, e.Name())
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestRunDir(t *testing.T) {
+	in, out := t.TempDir(), filepath.Join(t.TempDir(), "results")
+	gz, err := compress.Compress("d.txt.gz", []byte("####\n#@$#\n####\n"))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	jobs := map[string]string{
+		"a.txt":    "####\n#@$#\n####\n",
+		"d.txt.gz": string(gz),
+		"b.json":   `{"plan": ["####", "#@$#", "####"]}`,
+		"c.txt":    "###\n#T@\n###\n",
+		".hidden":  "####\n#@$#\n####\n",
+	}
+	for name, data := range jobs {
+		if err := os.WriteFile(filepath.Join(in, name), []byte(data), 0644); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+
+	if err := New(Config{Out: out}).RunDir(context.Background(), in, 0); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected := map[string][]string{
+		in:                               {".hidden"},
+		filepath.Join(in, processingDir): {},
+		filepath.Join(in, doneDir):       {"a.txt", "b.json", "d.txt.gz"},
+		filepath.Join(in, failedDir):     {"c.txt", "c.txt.error"},
+		out:                              {"a.json", "b.json", "c.json", "d.json"},
+	}
+	for dir, files := range expected {
+		if actual := listDir(t, dir); !reflect.DeepEqual(actual, files) {
+			t.Fatalf("Wrong files in %s. Expected %q, got %q", dir, files, actual)
+		}
+	}
+}
+
+func TestRunDirRetries(t *testing.T) {
+	in := t.TempDir()
+	for _, d := range []string{processingDir, doneDir, failedDir} {
+		if err := os.Mkdir(filepath.Join(in, d), 0755); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(in, "a.txt"), []byte("####\n#@$#\n####\n"), 0644); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// the artifacts can't be written to a missing directory
+	w := New(Config{Out: filepath.Join(in, "missing"), Retries: 2})
+	attempts := map[string]int{}
+	for i := 0; i < 3; i++ {
+		found, err := w.scanDir(context.Background(), in, attempts)
+		if err != nil || found != 1 {
+			t.Fatalf("Wrong scan %d. Expected 1 job, got %d: %v", i, found, err)
+		}
+		failed := listDir(t, filepath.Join(in, failedDir))
+		if (i == 2) != reflect.DeepEqual(failed, []string{"a.txt", "a.txt.error"}) {
+			t.Fatalf("Wrong failed jobs after scan %d: %q", i, failed)
+		}
+	}
+	if found, err := w.scanDir(context.Background(), in, attempts); err != nil || found != 0 {
+		t.Fatalf("Wrong last scan. Expected no job, got %d: %v", found, err)
+	}
+}
@@ -0,0 +1,97 @@
+package worker
+
+import (
+	"bytes"
+	"html/template"
+	"io"
+
+	"bender/internal/render"
+)
+
+// thumbnailSize is the longest side of the thumbnails of the boards in the HTML report, in pixels
+const thumbnailSize = 96
+
+// htmlRow is a job in the table of the HTML report
+type htmlRow struct {
+	Artifact
+	Thumbnail template.HTML
+}
+
+// htmlReport is the data of the HTML report
+type htmlReport struct {
+	*Report
+	Rows []htmlRow
+}
+
+// htmlTemplate is the static page of the HTML report, the columns are sorted by clicking their header
+var htmlTemplate = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>bender report</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; }
+th, td { border-bottom: 1px solid #ddd; padding: 0.4em 0.8em; text-align: left; vertical-align: middle; }
+th { cursor: pointer; user-select: none; }
+td.error { color: #b22222; }
+</style>
+</head>
+<body>
+<h1>{{.Jobs}} jobs</h1>
+<p>{{range $outcome, $n := .Outcomes}}{{$outcome}}: {{$n}}, {{end}}errors: {{.Errors}}</p>
+<table id="jobs">
+<thead>
+<tr><th>map</th><th>board</th><th>outcome</th><th data-numeric>steps</th><th data-numeric>efficiency</th><th>replay</th></tr>
+</thead>
+<tbody>
+{{range .Rows}}<tr>
+<td>{{.Name}}</td>
+<td>{{.Thumbnail}}</td>
+{{if .Error}}<td class="error">{{.Error}}</td>{{else}}<td>{{.Outcome}}</td>{{end}}
+<td data-value="{{.Steps}}">{{.Steps}}</td>
+<td data-value="{{printf "%.4f" .Efficiency}}">{{printf "%.2f" .Efficiency}}</td>
+<td>{{if .Replay}}<a href="{{.Replay}}">{{.Replay}}</a>{{end}}</td>
+</tr>
+{{end}}</tbody>
+</table>
+<script>
+document.querySelectorAll("#jobs th").forEach((th, col) => {
+	let asc = true;
+	th.addEventListener("click", () => {
+		const tbody = document.querySelector("#jobs tbody");
+		const value = (tr) => {
+			const td = tr.children[col];
+			return th.hasAttribute("data-numeric") ? parseFloat(td.dataset.value) : td.textContent;
+		};
+		const rows = Array.from(tbody.rows).sort((a, b) => {
+			const va = value(a), vb = value(b);
+			return (va < vb ? -1 : va > vb ? 1 : 0) * (asc ? 1 : -1);
+		});
+		asc = !asc;
+		rows.forEach((tr) => tbody.appendChild(tr));
+	});
+});
+</script>
+</body>
+</html>
+`))
+
+// WriteHTML writes the report as a static HTML page: a table of the jobs sortable by column
+// with the thumbnails of their boards, drawn with the given theme, and the links to their replays
+func WriteHTML(w io.Writer, rep *Report, theme *render.Theme) error {
+	data := htmlReport{Report: rep, Rows: make([]htmlRow, 0, len(rep.Results))}
+	for _, a := range rep.Results {
+		row := htmlRow{Artifact: a}
+		if len(a.Plan) > 0 {
+			buf := &bytes.Buffer{}
+			if err := render.Thumbnail(buf, a.Plan, thumbnailSize, theme); err != nil {
+				return err
+			}
+			// the thumbnail is drawn from the tiles of the map only
+			row.Thumbnail = template.HTML(buf.String())
+		}
+		data.Rows = append(data.Rows, row)
+	}
+	return htmlTemplate.Execute(w, data)
+}
@@ -0,0 +1,41 @@
+package worker
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestWriteHTML(t *testing.T) {
+	rep := &Report{
+		Jobs:     3,
+		Outcomes: map[string]int{"reached": 1, "loop": 1},
+		Errors:   1,
+		Results: []Artifact{
+			{Name: "a", Outcome: "reached", Steps: 4, Visited: 4, Plan: []string{"####", "#@$#", "####"}, Replay: "results/a.bdr"},
+			{Name: "b", Outcome: "loop", Steps: 40, Visited: 10, Plan: []string{"####", "#@E#", "####"}},
+			{Name: "c<script>", Error: "empty map"},
+		},
+	}
+	buf := &bytes.Buffer{}
+	if err := WriteHTML(buf, rep, nil); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	page := buf.String()
+	for _, expected := range []string{
+		"<h1>3 jobs</h1>",
+		"<p>loop: 1, reached: 1, errors: 1</p>",
+		`<td data-value="4">4</td>` + "\n" + `<td data-value="1.0000">1.00</td>` + "\n" + `<td><a href="results/a.bdr">results/a.bdr</a></td>`,
+		`<td data-value="0.2500">0.25</td>`,
+		`<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 4 3"`,
+		`<td>c&lt;script&gt;</td>`,
+		`<td class="error">empty map</td>`,
+	} {
+		if !strings.Contains(page, expected) {
+			t.Fatalf("Wrong report. Expected %q in:\n%s", expected, page)
+		}
+	}
+	if n := strings.Count(page, "<svg "); n != 2 {
+		t.Fatalf("Wrong number of thumbnails. Expected 2, got %d", n)
+	}
+}
@@ -0,0 +1,34 @@
+package worker
+
+import "os"
+
+// newLoadSampler returns the sampler of the load of the machine read from /proc
+func newLoadSampler() loadSampler {
+	var prev cpuTimes
+	if data, err := os.ReadFile("/proc/stat"); err == nil {
+		prev, _ = parseCPUStat(data)
+	}
+	return func() (float64, float64, error) {
+		data, err := os.ReadFile("/proc/stat")
+		if err != nil {
+			return 0, 0, err
+		}
+		t, err := parseCPUStat(data)
+		if err != nil {
+			return 0, 0, err
+		}
+		if data, err = os.ReadFile("/proc/meminfo"); err != nil {
+			return 0, 0, err
+		}
+		memory, err := parseMemInfo(data)
+		if err != nil {
+			return 0, 0, err
+		}
+		cpu := 0.0
+		if t.total > prev.total {
+			cpu = float64(t.busy-prev.busy) / float64(t.total-prev.total)
+		}
+		prev = t
+		return cpu, memory, nil
+	}
+}
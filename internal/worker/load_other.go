@@ -0,0 +1,13 @@
+//go:build !linux
+
+package worker
+
+import "errors"
+
+// newLoadSampler returns a sampler failing as the load of the machine isn't available,
+// the number of concurrent jobs grows up to its maximum
+func newLoadSampler() loadSampler {
+	return func() (float64, float64, error) {
+		return 0, 0, errors.New("load of the machine not available")
+	}
+}
@@ -0,0 +1,76 @@
+package worker
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Report combines the artifacts of several workers, like the shards of a corpus
+type Report struct {
+	// number of jobs
+	Jobs int `json:"jobs"`
+	// number of jobs per outcome
+	Outcomes map[string]int `json:"outcomes"`
+	// number of jobs which failed
+	Errors int `json:"errors"`
+	// artifacts of the jobs sorted by name
+	Results []Artifact `json:"results"`
+}
+
+// Efficiency returns the ratio of the distinct cells entered by Bender to his steps,
+// 1 if he never entered a cell twice, 0 if he didn't move
+func (a Artifact) Efficiency() float64 {
+	if a.Steps == 0 {
+		return 0
+	}
+	return float64(a.Visited) / float64(a.Steps)
+}
+
+// Merge reads the artifacts of the given output directories and combines them in a report
+// the replays of the artifacts are joined to their directory
+// a job found in several directories is an error as the shards must not overlap
+func Merge(dirs []string) (*Report, error) {
+	rep := &Report{Outcomes: map[string]int{}, Results: []Artifact{}}
+	seen := map[string]string{}
+	for _, dir := range dirs {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range entries {
+			file := e.Name()
+			if !e.Type().IsRegular() || strings.HasPrefix(file, ".") || filepath.Ext(file) != ".json" {
+				continue
+			}
+			data, err := os.ReadFile(filepath.Join(dir, file))
+			if err != nil {
+				return nil, err
+			}
+			a := Artifact{}
+			if err := json.Unmarshal(data, &a); err != nil || a.Name == "" {
+				// not an artifact, like a report of an earlier merge
+				continue
+			}
+			if other, exist := seen[a.Name]; exist {
+				return nil, fmt.Errorf("job %s found in both %s and %s", a.Name, other, dir)
+			}
+			seen[a.Name] = dir
+			if a.Replay != "" {
+				a.Replay = filepath.Join(dir, a.Replay)
+			}
+			rep.Results = append(rep.Results, a)
+			if a.Error != "" {
+				rep.Errors++
+			} else {
+				rep.Outcomes[a.Outcome]++
+			}
+		}
+	}
+	sort.Slice(rep.Results, func(i, j int) bool { return rep.Results[i].Name < rep.Results[j].Name })
+	rep.Jobs = len(rep.Results)
+	return rep, nil
+}
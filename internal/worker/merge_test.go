@@ -0,0 +1,52 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestMergeShards(t *testing.T) {
+	in := t.TempDir()
+	for i := 0; i < 10; i++ {
+		data := "####\n#@$#\n####\n"
+		if i == 7 {
+			data = "###\n#T@\n###\n"
+		}
+		if err := os.WriteFile(filepath.Join(in, fmt.Sprintf("map%d.txt", i)), []byte(data), 0644); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+	outs := []string{}
+	for i := 1; i <= 3; i++ {
+		out := filepath.Join(t.TempDir(), "results")
+		w := New(Config{Out: out, Shard: Shard{Index: i, Count: 3}})
+		if err := w.RunDir(context.Background(), in, 0); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if n := len(listDir(t, out)); n == 0 || n == 10 {
+			t.Fatalf("Wrong number of jobs of the shard %d, got %d", i, n)
+		}
+		outs = append(outs, out)
+	}
+
+	rep, err := Merge(outs)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if rep.Jobs != 10 || rep.Errors != 1 || !reflect.DeepEqual(rep.Outcomes, map[string]int{"reached": 9}) {
+		t.Fatalf("Wrong report, got %+v", rep)
+	}
+	for i, a := range rep.Results {
+		if expected := fmt.Sprintf("map%d", i); a.Name != expected {
+			t.Fatalf("Wrong result %d. Expected %s, got %s", i, expected, a.Name)
+		}
+	}
+
+	if _, err := Merge([]string{outs[0], outs[0]}); err == nil {
+		t.Fatalf("Expected an error for overlapping shards")
+	}
+}
@@ -0,0 +1,40 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// RunQueue simulates the jobs received from the queue until the context is done or the queue is closed
+// the jobs are named <prefix>-<number of the job>, a job failing for another reason than its map
+// is retried immediately as a queue doesn't give it back, then it gets an artifact with its error
+func (w *Worker) RunQueue(ctx context.Context, jobs <-chan []byte, prefix string) error {
+	for n := 1; ; n++ {
+		var data []byte
+		select {
+		case <-ctx.Done():
+			return nil
+		case d, open := <-jobs:
+			if !open {
+				return errors.New("job queue closed")
+			}
+			data = d
+		}
+
+		j := Job{Name: fmt.Sprintf("%s-%d", prefix, n), Data: data}
+		var perr *PoisonError
+		for attempt := 0; ; attempt++ {
+			err := w.Process(j)
+			if err == nil || errors.As(err, &perr) {
+				break
+			}
+			if attempt >= w.conf.Retries {
+				if err := w.writeArtifact(Artifact{Name: j.Name, Error: err.Error()}); err != nil {
+					return err
+				}
+				break
+			}
+		}
+	}
+}
@@ -0,0 +1,35 @@
+package worker
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestRunQueue(t *testing.T) {
+	out := t.TempDir()
+	jobs := make(chan []byte, 2)
+	jobs <- []byte("####\n#@$#\n####\n")
+	jobs <- []byte("###\n#T@\n###\n")
+	close(jobs)
+
+	err := New(Config{Out: out}).RunQueue(context.Background(), jobs, "maps")
+	if err == nil || err.Error() != "job queue closed" {
+		t.Fatalf("Wrong error. Expected the queue to be closed, got %v", err)
+	}
+	expected := []Artifact{
+		{Name: "maps-1", Outcome: "reached", Path: []string{"EAST"}, Steps: 1, Visited: 1, Plan: []string{"####", "#@$#", "####"}},
+		{Name: "maps-2", Error: "2:2: teleport 'T' appears 1 time(s), expected exactly 2"},
+	}
+	for _, e := range expected {
+		if actual := readArtifact(t, out, e.Name); !reflect.DeepEqual(actual, e) {
+			t.Fatalf("Wrong artifact. Expected %+v, got %+v", e, actual)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := New(Config{Out: out}).RunQueue(ctx, make(chan []byte), "maps"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,46 @@
+package worker
+
+import (
+	"fmt"
+	"hash/fnv"
+	"strconv"
+	"strings"
+)
+
+// Shard is the part of a corpus simulated by a worker, the zero value is the whole corpus
+// the jobs are spread by a hash of their file name, so every process computes the same shards
+type Shard struct {
+	// number of the shard, from 1 to Count
+	Index int
+	// number of shards
+	Count int
+}
+
+// ParseShard parses a shard written as i/n, like 2/5
+func ParseShard(s string) (Shard, error) {
+	i, n, found := strings.Cut(s, "/")
+	index, ierr := strconv.Atoi(i)
+	count, nerr := strconv.Atoi(n)
+	if !found || ierr != nil || nerr != nil {
+		return Shard{}, fmt.Errorf("invalid shard %q, expected i/n like 1/4", s)
+	}
+	if count < 1 || index < 1 || index > count {
+		return Shard{}, fmt.Errorf("invalid shard %q, expected 1 <= i <= n", s)
+	}
+	return Shard{Index: index, Count: count}, nil
+}
+
+// String returns the shard as i/n
+func (s Shard) String() string {
+	return fmt.Sprintf("%d/%d", s.Index, s.Count)
+}
+
+// Owns returns true if the job file of the given name belongs to the shard
+func (s Shard) Owns(file string) bool {
+	if s.Count <= 1 {
+		return true
+	}
+	h := fnv.New32a()
+	h.Write([]byte(file))
+	return int(h.Sum32()%uint32(s.Count)) == s.Index-1
+}
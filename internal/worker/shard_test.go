@@ -0,0 +1,57 @@
+package worker
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestParseShard(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected Shard
+		err      bool
+	}{
+		{input: "1/1", expected: Shard{Index: 1, Count: 1}},
+		{input: "3/4", expected: Shard{Index: 3, Count: 4}},
+		{input: "0/4", err: true},
+		{input: "5/4", err: true},
+		{input: "1/0", err: true},
+		{input: "1", err: true},
+		{input: "a/b", err: true},
+	}
+	for _, tc := range testCases {
+		s, err := ParseShard(tc.input)
+		if (err != nil) != tc.err {
+			t.Fatalf("Test case %q: unexpected error %v", tc.input, err)
+		}
+		if s != tc.expected {
+			t.Fatalf("Test case %q: wrong shard. Expected %v, got %v", tc.input, tc.expected, s)
+		}
+	}
+}
+
+func TestShardOwns(t *testing.T) {
+	shards := []Shard{{Index: 1, Count: 3}, {Index: 2, Count: 3}, {Index: 3, Count: 3}}
+	counts := make([]int, len(shards))
+	for i := 0; i < 300; i++ {
+		file := fmt.Sprintf("map-%d.txt", i)
+		owners := 0
+		for n, s := range shards {
+			if s.Owns(file) {
+				owners++
+				counts[n]++
+			}
+		}
+		if owners != 1 {
+			t.Fatalf("Wrong number of shards owning %s. Expected 1, got %d", file, owners)
+		}
+		if !(Shard{}).Owns(file) {
+			t.Fatalf("The whole corpus doesn't own %s", file)
+		}
+	}
+	for n, c := range counts {
+		if c < 50 {
+			t.Fatalf("Unbalanced shards, shard %v owns %d files out of 300", shards[n], c)
+		}
+	}
+}
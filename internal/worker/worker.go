@@ -0,0 +1,214 @@
+// Package worker simulates the maps of a job queue and writes the results as files
+package worker
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"bender/internal/bender"
+	"bender/internal/fsm"
+	"bender/internal/mapfile"
+	"bender/internal/render"
+	"bender/internal/replay"
+)
+
+// Job is a map to simulate
+type Job struct {
+	// name of the job, the artifacts are named after it
+	Name string
+	// map in the JSON format or as plain text rows, possibly gzip compressed
+	Data []byte
+}
+
+// Config is the configuration of a worker
+type Config struct {
+	// directory of the artifacts
+	Out string
+	// kind of the render written along the result: png, svg, cast or empty for none
+	Render string
+	// true to write the replay of the simulation along the result, as <name>.bdr
+	Replay bool
+	// labels of the directions in the renders
+	Labels render.Labels
+	// theme of the renders, classic if nil
+	Theme *render.Theme
+	// number of times a job failing for another reason than its map is retried
+	Retries int
+	// options of the simulations
+	Options []bender.Option
+	// memo shared by the simulations without render nor replay, which need every step, nil for none
+	Memo *bender.Memo
+	// part of the input directory simulated by the worker, the other jobs are left to other workers
+	Shard Shard
+	// maximum number of jobs of the input directory simulated at once, the number is tuned
+	// to the CPU and memory usage of the machine, zero or one simulates the jobs one by one
+	MaxWorkers int
+}
+
+// Artifact is the result of a job, written as <name>.json in the output directory
+type Artifact struct {
+	// name of the job
+	Name string `json:"name"`
+	// how the simulation ended
+	Outcome string `json:"outcome,omitempty"`
+	// path followed by Bender
+	Path []string `json:"path,omitempty"`
+	// number of steps and number of distinct cells entered by Bender
+	Steps   int `json:"steps,omitempty"`
+	Visited int `json:"visited,omitempty"`
+	// breakable walls destroyed by Bender, as (x,y)@step
+	Destroyed []string `json:"destroyed,omitempty"`
+	// rows of the map
+	Plan []string `json:"plan,omitempty"`
+	// replay file of the simulation, relative to the directory of the artifact
+	Replay string `json:"replay,omitempty"`
+	// error of a poison job
+	Error string `json:"error,omitempty"`
+}
+
+// PoisonError is the error of a job which fails whatever the number of retries, like an invalid map
+type PoisonError struct {
+	Err error
+}
+
+// Error returns the error of the job
+func (e *PoisonError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the error of the job
+func (e *PoisonError) Unwrap() error {
+	return e.Err
+}
+
+// Worker simulates jobs
+type Worker struct {
+	conf Config
+	// bound of the concurrent jobs of the input directory
+	slots *concurrency
+}
+
+// New returns a worker with the given configuration
+func New(conf Config) *Worker {
+	return &Worker{conf: conf}
+}
+
+// Process simulates the job and writes its artifacts
+// a poison job gets an artifact with its error, the error is returned as a PoisonError
+func (w *Worker) Process(j Job) error {
+	plan, err := mapfile.ParsePlan(j.Data)
+	if err != nil {
+		return w.poison(j, err)
+	}
+
+	var r render.Renderer = render.NopRenderer{}
+	img := &bytes.Buffer{}
+	if w.conf.Render != "" {
+		if r, err = render.NewRenderer(w.conf.Render, img, w.conf.Labels, w.conf.Theme); err != nil {
+			return err
+		}
+	}
+	if err := r.RenderBoard(plan); err != nil {
+		return err
+	}
+	var rec *replay.Writer
+	recorded := &bytes.Buffer{}
+	if w.conf.Replay {
+		if rec, err = replay.NewWriter(recorded, plan); err != nil {
+			return err
+		}
+	}
+	b := bender.NewBenderSimulator()
+	f, err := fsm.NewFSM(plan, bender.BeforeCallback, func(e *fsm.Event) {
+		bender.EnterCallback(e)
+		if err := r.RenderStep(e); err != nil {
+			e.Abort(err)
+		}
+		if rec != nil {
+			if err := rec.Record(e, b); err != nil {
+				e.Abort(err)
+			}
+		}
+	})
+	if err != nil {
+		return w.poison(j, err)
+	}
+	opts := w.conf.Options
+	if w.conf.Memo != nil && w.conf.Render == "" && rec == nil {
+		opts = append(opts[:len(opts):len(opts)], bender.WithMemo(w.conf.Memo))
+	}
+	res, err := bender.Resume(f, b, opts...)
+	if err != nil {
+		return w.poison(j, err)
+	}
+	if err := r.RenderPath(res.ClassicPath()); err != nil {
+		return err
+	}
+
+	a := Artifact{Name: j.Name, Outcome: res.Outcome.String(), Path: res.Path, Steps: res.Steps, Visited: len(res.Visited(plan)), Plan: plan}
+	for _, d := range res.Destroyed {
+		a.Destroyed = append(a.Destroyed, d.String())
+	}
+	if w.conf.Render != "" {
+		if err := writeFile(filepath.Join(w.conf.Out, j.Name+"."+w.conf.Render), img.Bytes()); err != nil {
+			return err
+		}
+	}
+	if rec != nil {
+		if err := rec.Close(res.Outcome); err != nil {
+			return err
+		}
+		a.Replay = j.Name + replay.Ext
+		if err := writeFile(filepath.Join(w.conf.Out, a.Replay), recorded.Bytes()); err != nil {
+			return err
+		}
+	}
+	return w.writeArtifact(a)
+}
+
+// poison writes the artifact of a poison job and returns its error
+func (w *Worker) poison(j Job, err error) error {
+	if werr := w.writeArtifact(Artifact{Name: j.Name, Error: err.Error()}); werr != nil {
+		return werr
+	}
+	return &PoisonError{Err: err}
+}
+
+// writeArtifact writes the artifact as JSON
+func (w *Worker) writeArtifact(a Artifact) error {
+	data, err := json.MarshalIndent(a, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFile(filepath.Join(w.conf.Out, a.Name+".json"), append(data, '\n'))
+}
+
+// writeFile writes the file atomically, readers never see it partially written
+func writeFile(name string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(name), "."+filepath.Base(name)+".*")
+	if err != nil {
+		return err
+	}
+	if err := tmp.Chmod(0644); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Rename(tmp.Name(), name); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write %s: %v", name, err)
+	}
+	return nil
+}
@@ -0,0 +1,120 @@
+package worker
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"bender/internal/bender"
+	"bender/internal/render"
+	"bender/internal/replay"
+)
+
+// readArtifact reads the artifact of the given job
+func readArtifact(t *testing.T, out, name string) Artifact {
+	data, err := os.ReadFile(filepath.Join(out, name+".json"))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	a := Artifact{}
+	if err := json.Unmarshal(data, &a); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	return a
+}
+
+func TestProcess(t *testing.T) {
+	testCases := []struct {
+		name     string
+		data     string
+		poison   bool
+		expected Artifact
+	}{
+		{
+			name:     "text",
+			data:     "#####\r\n#@ $#\r\n#####\r\n\r\n",
+			expected: Artifact{Name: "text", Outcome: "reached", Path: []string{"EAST", "EAST"}, Steps: 2, Visited: 2, Plan: []string{"#####", "#@ $#", "#####"}, Replay: "text.bdr"},
+		},
+		{
+			name:     "json",
+			data:     `{"name": "breaker", "plan": ["######", "#@BX$#", "######"]}`,
+			expected: Artifact{Name: "json", Outcome: "reached", Path: []string{"EAST", "EAST", "EAST"}, Steps: 3, Visited: 3, Destroyed: []string{"(3,1)@2"}, Plan: []string{"######", "#@BX$#", "######"}},
+		},
+		{
+			name:     "step limit",
+			data:     "#####\n#@ $#\n#####",
+			expected: Artifact{Name: "step limit", Outcome: "step limit exceeded", Path: []string{"EAST"}, Steps: 1, Visited: 1, Plan: []string{"#####", "#@ $#", "#####"}},
+		},
+		{
+			name:     "invalid map",
+			data:     "###\n#T@\n###",
+			poison:   true,
+			expected: Artifact{Name: "invalid map", Error: "2:2: teleport 'T' appears 1 time(s), expected exactly 2"},
+		},
+		{
+			name:     "empty",
+			data:     "\n\n",
+			poison:   true,
+			expected: Artifact{Name: "empty", Error: "empty map"},
+		},
+	}
+
+	out := t.TempDir()
+	for _, tc := range testCases {
+		conf := Config{Out: out, Render: "svg", Labels: render.Labels{}}
+		if tc.name == "step limit" {
+			conf.Options = []bender.Option{bender.WithMaxSteps(1)}
+		}
+		conf.Replay = tc.expected.Replay != ""
+		err := New(conf).Process(Job{Name: tc.name, Data: []byte(tc.data)})
+		var perr *PoisonError
+		if tc.poison != errors.As(err, &perr) || (!tc.poison && err != nil) {
+			t.Fatalf("Wrong error for %q: %v", tc.name, err)
+		}
+		if actual := readArtifact(t, out, tc.name); !reflect.DeepEqual(actual, tc.expected) {
+			t.Fatalf("Wrong artifact for %q. Expected %+v, got %+v", tc.name, tc.expected, actual)
+		}
+		if _, err := os.Stat(filepath.Join(out, tc.name+".svg")); (err == nil) == tc.poison {
+			t.Fatalf("Wrong render for %q: %v", tc.name, err)
+		}
+		if tc.expected.Replay == "" {
+			continue
+		}
+		r, err := replay.Open(filepath.Join(out, tc.expected.Replay))
+		if err != nil {
+			t.Fatalf("Unexpected error for %q: %v", tc.name, err)
+		}
+		if r.Len() != tc.expected.Steps || r.Outcome().String() != tc.expected.Outcome {
+			t.Fatalf("Wrong replay for %q, got %d steps and %v", tc.name, r.Len(), r.Outcome())
+		}
+		r.Close()
+	}
+}
+
+func TestProcessMemo(t *testing.T) {
+	out := t.TempDir()
+	memo := bender.NewMemo()
+	w := New(Config{Out: out, Memo: memo})
+	data := []byte("######\n#@BX$#\n######\n")
+	var expected Artifact
+	for _, name := range []string{"first", "second"} {
+		if err := w.Process(Job{Name: name, Data: data}); err != nil {
+			t.Fatalf("Unexpected error for %q: %v", name, err)
+		}
+		a := readArtifact(t, out, name)
+		a.Name = ""
+		if name == "first" {
+			expected = a
+			continue
+		}
+		if !reflect.DeepEqual(a, expected) {
+			t.Fatalf("Wrong memoized artifact. Expected %+v, got %+v", expected, a)
+		}
+	}
+	if s := memo.Stats(); s.Hits != 1 {
+		t.Fatalf("Identical job was not memoized: %v", s)
+	}
+}
@@ -1,403 +1,461 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
 	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+
+	"bender/internal/bender"
+	"bender/internal/compress"
+	"bender/internal/fsm"
+	"bender/internal/history"
+	"bender/internal/i18n"
+	"bender/internal/mapfile"
+	"bender/internal/publish"
+	"bender/internal/render"
+	"bender/internal/replay"
 )
 
-const (
-	// SOUTH direction
-	SOUTH = "SOUTH"
-	// NORTH direction
-	NORTH = "NORTH"
-	// EAST direction
-	EAST = "EAST"
-	// WEST direction
-	WEST = "WEST"
-	// LOOP indicator
-	LOOP = "LOOP"
-)
-
-// BenderSimulator simulates more rudimentary Bender
-type BenderSimulator struct {
-	done         bool
-	breaker      bool
-	boom         bool
-	resetDir     bool
-	invertPrio   bool
-	currDir      int
-	priorities   []string
-	pathModifier string
-	path         []string
-	cache        map[string]bool
-	loopCnt      int
-	maxNumStates int
-}
-
-// NewBenderSimulator returns an instance of a bender simulator
-// the number of valid (without the frame) states is expected as parameter
-func NewBenderSimulator(stateNum int) *BenderSimulator {
-	return &BenderSimulator{
-		priorities: []string{
-			SOUTH,
-			EAST,
-			NORTH,
-			WEST,
-		},
-		path:         []string{},
-		cache:        map[string]bool{},
-		maxNumStates: stateNum,
+// printError prints the error in the language of the catalog
+// the map errors are followed by an excerpt of the offending line
+func printError(w io.Writer, err error, c i18n.Catalog) {
+	var perrs fsm.ParseErrors
+	if !errors.As(err, &perrs) {
+		fmt.Fprintln(w, c.Sprintf("Failed with error: %v", err))
+		return
+	}
+	for _, pe := range perrs {
+		fmt.Fprintln(w, pe.Localize(c.Sprintf))
+		if excerpt := pe.Excerpt(); excerpt != "" {
+			fmt.Fprintln(w, excerpt)
+		}
 	}
 }
 
-// Done returns true if the suicide booth is reached
-func (b *BenderSimulator) Done() bool {
-	return b.done
+// loadCatalog returns the catalog of the given locale
+// from the given directory of catalogs, if any, or from the catalogs shipped with bender
+func loadCatalog(locale, dir string) (i18n.Catalog, error) {
+	loaders := []i18n.Loader{i18n.Builtin}
+	if dir != "" {
+		loaders = append([]i18n.Loader{i18n.Dir(dir)}, loaders...)
+	}
+	return i18n.Load(locale, loaders...)
 }
 
-// Loop returns true if an endless cycle is found
-func (b *BenderSimulator) Loop() bool {
-	if b.loopCnt > b.maxNumStates {
-		return true
+// loadPlan returns the map of the given file, read from stdin if the name is -, or the default map if it's empty
+func loadPlan(name string, stdin io.Reader) ([]string, error) {
+	switch name {
+	case "":
+		return defaultPlan, nil
+	case "-":
+		return mapfile.ReadPlan(stdin)
 	}
-	return false
+	data, err := compress.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	plan, err := mapfile.ParsePlan(data)
+	return plan, withFile(err, name)
 }
 
-// Direction gives the direction to be followed
-func (b *BenderSimulator) Direction() string {
-	if b.pathModifier != "" {
-		return b.pathModifier
+// withFile gives the name of the map file to the parse errors of err,
+// the other errors become an error of the whole map in the file
+func withFile(err error, name string) error {
+	var perrs fsm.ParseErrors
+	var pe *fsm.ParseError
+	switch {
+	case err == nil || name == "" || name == "-":
+		// no error, built-in map or stdin
+		return err
+	case errors.As(err, &perrs):
+		for _, pe := range perrs {
+			pe.File = name
+		}
+		return err
+	case errors.As(err, &pe):
+		pe.File = name
+		return err
 	}
-	return b.priorities[b.currDir]
+	return fsm.ParseErrors{{File: name, Msg: err.Error(), Kind: err}}
 }
 
-// ShowPath returns the recorded path
-func (b *BenderSimulator) ShowPath() []string {
-	if b.Loop() {
-		return []string{LOOP}
+// noArgs returns an error if arguments follow the flags of a command taking none
+func noArgs(flags *flag.FlagSet) error {
+	if flags.NArg() != 0 {
+		return fmt.Errorf("unexpected arguments %v", flags.Args())
 	}
-	return b.path
+	return nil
 }
 
-// Breaker returns true if the simulator went to the breaker mode
-func (b *BenderSimulator) Breaker() bool {
-	return b.breaker
+// printMemoStats prints the statistics of the memo shared by the simulations of a command,
+// to stderr so the output stays the results
+func printMemoStats(memo *bender.Memo) {
+	fmt.Fprintf(os.Stderr, "memo: %v\n", memo.Stats())
 }
 
-// InvertBreaker inverts the breaker mode
-func (b *BenderSimulator) InvertBreaker() {
-	if b.breaker {
-		b.breaker = false
-		return
+// usage prints the usage of the simulation and the list of the subcommands
+func usage() {
+	w := flag.CommandLine.Output()
+	names := make([]string, 0, len(subcommands))
+	for name := range subcommands {
+		names = append(names, name)
 	}
-	b.breaker = true
+	sort.Strings(names)
+	fmt.Fprintf(w, "Usage: bender [run] [flags]\n       bender <command> [flags]\n\nCommands, -h gives their flags:\n  run\n  %s\n\nFlags of run:\n", strings.Join(names, "\n  "))
+	flag.PrintDefaults()
 }
 
-// Reached signals that the suicide booth is reached
-func (b *BenderSimulator) Reached() {
-	b.done = true
+// defaultPlan is the map simulated when no other map is given
+var defaultPlan = []string{
+	"########",
+	"#     $#",
+	"#      #",
+	"#      #",
+	"#  @   #",
+	"#      #",
+	"#      #",
+	"########",
 }
 
-// InvertPriorities signals that the priorities needs to be inverted
-// when next obstacle is reached
-func (b *BenderSimulator) InvertPriorities() {
-	if b.invertPrio {
-		b.invertPrio = false
-		return
-	}
-	b.invertPrio = true
+// subcommands are the commands run instead of the simulation of the default map
+var subcommands = map[string]func(args []string) error{
+	"serve":     runServe,
+	"worker":    runWorker,
+	"bench":     runBench,
+	"merge":     runMerge,
+	"quiz":      runQuiz,
+	"render":    runRender,
+	"compare":   runCompare,
+	"whatif":    runWhatIf,
+	"advise":    runAdvise,
+	"synth":     runSynth,
+	"starts":    runStarts,
+	"breakers":  runBreakers,
+	"teleports": runTeleports,
+	"watch":     runWatch,
+	"runs":      runRuns,
+	"convert":   runConvert,
+	"replay":    runReplay,
+	"agent":     runAgent,
+	"validate":  runValidate,
+	"solve":     runSolve,
+	"gen":       runGen,
 }
 
-// turnoverPriorities turn the list of priorities up side down
-func (b *BenderSimulator) turnoverPriorities() {
-	for i, j := 0, len(b.priorities)-1; i < len(b.priorities)/2; i, j = i+1, j-1 {
-		b.priorities[i], b.priorities[j] = b.priorities[j], b.priorities[i]
+func main() {
+	args := os.Args[1:]
+	if len(args) > 0 {
+		if cmd, exist := subcommands[args[0]]; exist {
+			if err := cmd(args[1:]); err != nil {
+				fmt.Println("Failed with error: ", err)
+				os.Exit(1)
+			}
+			return
+		}
+		if args[0] == "run" {
+			// run is the explicit name of the simulation, its flags follow
+			args = args[1:]
+		}
 	}
-	b.invertPrio = false
-}
 
-// Remember records the given direction and the state
-// of course, they are supposed to be passed and visited
-func (b *BenderSimulator) Remember(dir, state string) {
-	b.path = append(b.path, dir)
-	if _, exist := b.cache[state]; exist {
-		// already visited this state: increment the loop counter
-		b.loopCnt++
-	} else {
-		// unknown state: reset the loop counter
-		b.cache[state] = true
-		b.loopCnt = 0
+	mapFile := flag.String("map", "", "file of the map to simulate, as text rows or JSON, - reads it from stdin (default the built-in map)")
+	flag.StringVar(mapFile, "f", "", "shorthand for -map")
+	renderKind := flag.String("render", "terminal", "renderer: terminal, png, svg, cast (asciinema) or none")
+	renderOut := flag.String("render-out", "", "file to write the render to (default stdout)")
+	steps := flag.Bool("steps", false, "print every step with the terminal renderer, or add the trace to the json and cbor reports")
+	format := flag.String("format", "text", "output format: text, json or cbor")
+	flag.StringVar(format, "o", "text", "shorthand for -format")
+	labelConf := flag.String("labels", "words", "direction labels: words, letters, arrows or a list like SOUTH=S,NORTH=N")
+	themeConf := flag.String("theme", "classic", "look of the tiles in the renders: classic, unicode, emoji, roguelike or a theme file like mine.json")
+	ckptConf := flag.String("checkpoint", "", "save the simulation periodically, like \"every=1000 file=ckpt.json\"")
+	resume := flag.String("resume", "", "resume the simulation from the given checkpoint file")
+	prefix := flag.String("prefix", "", "start the simulation after the given moves, separated by spaces or commas like \"S S E\"")
+	maxSteps := flag.Int("max-steps", 0, "stop the simulation after the given number of steps (0 means no limit)")
+	timeout := flag.Duration("timeout", 0, "stop the simulation after the given duration (0 means no limit)")
+	paranoid := flag.Bool("paranoid", false, "check the invariants of the rules after every step and abort with a report at the first violation")
+	stream := flag.Bool("stdin", false, "simulate the maps read from stdin and print a result line per map")
+	codingame := flag.Bool("codingame", false, "read the map from stdin and print the answer like the CodinGame puzzle: a direction per line or LOOP")
+	framing := flag.String("framing", "blank", "separation of the maps on stdin: blank (blank lines) or length (rows and columns header)")
+	natsAddr := flag.String("nats", "", "publish the steps and the results to the NATS server at the given address, like nats://localhost:4222")
+	natsSubject := flag.String("nats-subject", "bender", "subject prefix of the NATS messages: <prefix>.steps and <prefix>.results")
+	statsInterval := flag.Duration("stats-interval", 0, "print the statistics of the simulation to stderr at the given interval, like 10s (0 disables them)")
+	replayFile := flag.String("replay", "", "record the steps in the given replay file, like run.bdr")
+	traceFile := flag.String("trace", "", "record every event, the blocked ones included, in the given JSON lines file checked by the replay command, like trace.jsonl")
+	historyDir := flag.String("history", os.Getenv(historyEnv), "record the run in the history of the given directory, browsed with the runs command (default $"+historyEnv+")")
+	locale := flag.String("locale", "en", "language of the diagnostics, like fr or fr_FR.UTF-8")
+	catalogs := flag.String("catalogs", "", "directory of additional message catalogs, stored as <locale>.json")
+	flag.Usage = usage
+	flag.CommandLine.Parse(args)
+	if err := noArgs(flag.CommandLine); err != nil {
+		fmt.Println("Failed with error: ", err)
+		return
 	}
-}
 
-// PathModifier unsets the priority directions with the given one
-func (b *BenderSimulator) PathModifier(dir string) {
-	b.pathModifier = dir
-}
+	catalog, err := loadCatalog(*locale, *catalogs)
+	if err != nil {
+		fmt.Println("Failed with error: ", err)
+		return
+	}
 
-// NextDirection calculates the next direction to be given after an obstacle is hit
-func (b *BenderSimulator) NextDirection() {
-	if b.resetDir {
-		b.currDir = 0
-		b.resetDir = false
-	} else {
-		if b.currDir+1 >= len(b.priorities) {
-			b.currDir = 0
-		} else {
-			b.currDir++
+	labels, err := render.ParseLabels(*labelConf)
+	if err != nil {
+		fmt.Println("Failed with error: ", err)
+		return
+	}
+	theme, err := render.ParseTheme(*themeConf)
+	if err != nil {
+		fmt.Println("Failed with error: ", err)
+		return
+	}
+	if err := checkFormat(*format); err != nil {
+		fmt.Println("Failed with error: ", err)
+		return
+	}
+	var ev *publish.Events
+	if *natsAddr != "" {
+		nc, err := publish.DialNATS(*natsAddr)
+		if err != nil {
+			fmt.Println("Failed with error: ", err)
+			return
 		}
+		defer func() {
+			if err := nc.Close(); err != nil {
+				fmt.Println("Failed with error: ", err)
+			}
+		}()
+		ev = publish.NewEvents(nc, *natsSubject)
 	}
-}
-
-// Boom signals a hit against an obstacle
-func (b *BenderSimulator) Boom() {
-	b.boom = true
-	// back to priorities
-	b.pathModifier = ""
-	// turnover the priorities if passed by an inverted before
-	if b.invertPrio {
-		b.turnoverPriorities()
-		// we need to start from the top
-		b.resetDir = true
+	// Ctrl-C interrupts the simulation, the partial result is printed
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	simOpts := []bender.Option{bender.WithMaxSteps(*maxSteps), bender.WithTimeout(*timeout), bender.WithContext(ctx)}
+	if *paranoid {
+		simOpts = append(simOpts, bender.WithInvariants(bender.Invariants...))
+	}
+	if *codingame {
+		if *stream || *mapFile != "" {
+			fmt.Println("Failed with error: ", "-codingame reads the map from stdin, it excludes -stdin and -map")
+			return
+		}
+		if err := runCodinGame(os.Stdin, os.Stdout, simOpts...); err != nil {
+			// stdout is the answer of the puzzle
+			printError(os.Stderr, err, catalog)
+			os.Exit(1)
+		}
+		return
+	}
+	if *stream && *mapFile != "" {
+		fmt.Println("Failed with error: ", "-stdin and -map are exclusive")
+		return
+	}
+	if *stream {
+		conf := streamConf{framing: *framing, format: *format, labels: labels, events: ev}
+		// the maps of a stream often repeat or share their configurations
+		memo := bender.NewMemo()
+		if err := runStream(os.Stdin, os.Stdout, conf, append(simOpts, bender.WithMemo(memo))...); err != nil {
+			fmt.Println("Failed with error: ", err)
+		}
+		printMemoStats(memo)
+		return
+	}
+	var ckpt checkpointConf
+	if *ckptConf != "" {
+		if ckpt, err = parseCheckpointConf(*ckptConf); err != nil {
+			fmt.Println("Failed with error: ", err)
+			return
+		}
 	}
-}
-
-// Hurts returns true if the simulator just hit the obstacle
-func (b *BenderSimulator) Hurts() bool {
-	return b.boom
-}
-
-// BackOnTrack signals that the way out of the obstacles is found
-func (b *BenderSimulator) BackOnTrack() {
-	b.boom = false
-	b.resetDir = true
-}
-
-// Pair is a pair of coordinates
-type Pair struct {
-	x, y int
-}
-
-// FSM is a 2D array Finite State Machine.
-// Each item in the array is a state.
-// Transitions between the states are the cardinal directions.
-// Example:
-// [1,1] SOUTH [1,2]
-// [1,1] NORTH [1,0]
-// [1,1] EAST  [2,1]
-// [1,1] WEST  [0,1]
-type FSM struct {
-	states         [][]byte
-	curr           Pair
-	teleports      []Pair
-	beforeCallback Callback
-	enterCallback  Callback
-}
 
-// NewFSM returns an instance of FSM from given map
-// before callback is called when the state is not yet entered
-// enter callback is called when the state is already entered
-func NewFSM(plan []string, beforeCB, enterCB Callback) *FSM {
-	states := make([][]byte, 0, len(plan))
-	start := Pair{}
-	tp := []Pair{}
+	plan, err := loadPlan(*mapFile, os.Stdin)
+	if err != nil {
+		printError(os.Stdout, err, catalog)
+		os.Exit(1)
+	}
 
-	for i, s := range plan {
-		states = append(states, []byte(s))
-		for j, c := range s {
-			if len(tp) == 2 && (start != Pair{}) {
-				break
-			}
-			switch c {
-			case '@':
-				start = Pair{j, i}
-			case 'T':
-				tp = append(tp, Pair{j, i})
-			}
+	var m *fsm.FSM
+	var b *bender.BenderSimulator
+	events := 0
+	switch {
+	case *resume != "" && *prefix != "":
+		fmt.Println("Failed with error: ", "-resume and -prefix are exclusive")
+		return
+	case *traceFile != "" && (*resume != "" || *prefix != ""):
+		fmt.Println("Failed with error: ", "-trace records whole runs, it excludes -resume and -prefix")
+		return
+	case *resume != "" && *mapFile != "":
+		fmt.Println("Failed with error: ", "-resume and -map are exclusive, the map is the one of the checkpoint")
+		return
+	case *resume != "":
+		c, err := bender.LoadCheckpoint(*resume)
+		if err != nil {
+			fmt.Println("Failed with error: ", err)
+			return
 		}
+		plan, m, b, events = c.Plan, c.FSM, c.Simulator, c.Events
+	case *prefix != "":
+		moves, err := parsePath(*prefix, labels)
+		if err != nil {
+			fmt.Println("Failed with error: ", err)
+			return
+		}
+		c, err := bender.FastForward(plan, moves)
+		if err != nil {
+			printError(os.Stdout, withFile(err, *mapFile), catalog)
+			os.Exit(1)
+		}
+		m, b, events = c.FSM, c.Simulator, c.Events
+	default:
+		m, err = fsm.NewFSM(plan, nil, nil)
+		if err != nil {
+			printError(os.Stdout, withFile(err, *mapFile), catalog)
+			os.Exit(1)
+		}
+		b = bender.NewBenderSimulator()
 	}
 
-	return &FSM{
-		states:         states,
-		curr:           start,
-		teleports:      tp,
-		beforeCallback: beforeCB,
-		enterCallback:  enterCB,
+	var out io.Writer = os.Stdout
+	if *renderOut != "" {
+		f, err := os.Create(*renderOut)
+		if err != nil {
+			fmt.Println("Failed with error: ", err)
+			return
+		}
+		defer f.Close()
+		out = f
+	}
+	var r render.Renderer
+	switch {
+	case *renderKind == "terminal" && *format != "text":
+		// the report is printed instead
+		r = render.NopRenderer{}
+	case *renderKind == "terminal":
+		tr := render.NewTerminalRenderer(out, *steps, labels)
+		tr.SetTheme(theme)
+		r = tr
+	default:
+		r, err = render.NewRenderer(*renderKind, out, labels, theme)
+		if err != nil {
+			fmt.Println("Failed with error: ", err)
+			return
+		}
+	}
+	if err := r.RenderBoard(plan); err != nil {
+		fmt.Println("Failed with error: ", err)
+		return
 	}
-}
 
-// Event changes the state according to the direction given
-// runs the before and enter callbacks passing the given arguments to them
-func (f *FSM) Event(evt string, args ...interface{}) error {
-	var dst Pair
-	switch evt {
-	case SOUTH:
-		dst = Pair{f.curr.x, f.curr.y + 1}
-	case NORTH:
-		dst = Pair{f.curr.x, f.curr.y - 1}
-	case EAST:
-		dst = Pair{f.curr.x + 1, f.curr.y}
-	case WEST:
-		dst = Pair{f.curr.x - 1, f.curr.y}
+	var rec *replay.Writer
+	if *replayFile != "" {
+		if rec, err = replay.Create(*replayFile, plan); err != nil {
+			fmt.Println("Failed with error: ", err)
+			return
+		}
 	}
 
-	if dst.x < 0 || dst.x >= len(f.states[0]) || dst.y < 0 || dst.y >= len(f.states) {
-		return fmt.Errorf("unknown state %v", dst)
+	var tw *replay.TraceWriter
+	if *traceFile != "" {
+		if tw, err = replay.CreateTrace(*traceFile, plan, *maxSteps); err != nil {
+			fmt.Println("Failed with error: ", err)
+			return
+		}
 	}
 
-	e := &Event{
-		FSM:   f,
-		Event: evt,
-		Dst:   f.states[dst.y][dst.x],
-		dstC:  dst,
-		Args:  args,
+	var trace []traceStep
+	m.SetCallbacks(func(e *fsm.Event) {
+		bender.BeforeCallback(e)
+		if ev != nil {
+			if err := ev.Before(1, m.Steps(), e); err != nil {
+				e.Abort(err)
+			}
+		}
+	}, func(e *fsm.Event) {
+		bender.EnterCallback(e)
+		r.RenderStep(e)
+		if *steps && *format != "text" {
+			trace = append(trace, newTraceStep(m.Steps(), e, b))
+		}
+		if ev != nil {
+			if err := ev.Step(1, m.Steps(), e, b); err != nil {
+				e.Abort(err)
+			}
+		}
+		if rec != nil {
+			if err := rec.Record(e, b); err != nil {
+				e.Abort(err)
+			}
+		}
+	})
+	if tw != nil {
+		// the trace sees the events once all the callbacks are done
+		m.Use(tw.Middleware(m, b))
 	}
 
-	f.beforeCallback(e)
-	if e.Cancelled {
-		// don't enter the state
+	hook := func() error {
+		events++
+		if ckpt.every > 0 && events%ckpt.every == 0 {
+			return bender.SaveCheckpoint(ckpt.file, &bender.Checkpoint{Plan: plan, Events: events, FSM: m, Simulator: b})
+		}
 		return nil
 	}
-	f.curr = dst
-	f.enterCallback(e)
-	return nil
-}
-
-// SetState sets the current state of the machine
-func (f *FSM) SetState(p Pair) {
-	f.curr = p
-}
-
-// TeleportDst gives the destination coordinates of the given teleport
-func (f *FSM) TeleportDst(ps Pair) Pair {
-	if len(f.teleports) != 2 {
-		panic("teleports badly setup")
+	printStats := func(s bender.Stats) {
+		fmt.Fprintln(os.Stderr, s)
 	}
-
-	if f.teleports[0].x == ps.x && f.teleports[0].y == ps.y {
-		return f.teleports[1]
+	res, err := bender.Resume(m, b, append(simOpts, bender.WithEventHook(hook), bender.WithStats(*statsInterval, printStats))...)
+	if ev != nil {
+		if perr := ev.Result(1, res, err); perr != nil && err == nil {
+			err = perr
+		}
 	}
-	return f.teleports[0]
-}
-
-// Callback type to handle state actions
-type Callback func(e *Event)
-
-// Event represents the transition event
-type Event struct {
-	// pointer back to the finite state machine
-	FSM *FSM
-	// name of the event (direction)
-	Event string
-	// destination state
-	Dst byte
-	// destination state's coordinates
-	dstC Pair
-	// true if event was cancelled
-	Cancelled bool
-	// arguments for the callbacks
-	Args []interface{}
-}
-
-// Cancel cancels the event.
-// Events cancelled before entering the state will not be entered.
-func (e *Event) Cancel() {
-	e.Cancelled = true
-}
-
-// ChangeDst sets the destination state with the given value
-func (e *Event) ChangeDst(dst byte) {
-	e.FSM.states[e.dstC.y][e.dstC.x] = dst
-}
-
-// UniqueDst generates the unique destination id (value+coordinates)
-func (e *Event) UniqueDst() string {
-	return fmt.Sprintf("%c%d%d", e.Dst, e.dstC.x, e.dstC.y)
-}
-
-// before handles only obstacles
-// we cancel the event before entering it
-func beforeCallback(e *Event) {
-	bender := e.Args[0].(*BenderSimulator)
-
-	switch e.Dst {
-	case '#':
-		bender.Boom()
-		bender.NextDirection()
-		e.Cancel()
-	case 'X':
-		if bender.Breaker() {
-			// destroy the obstacle
-			e.ChangeDst(' ')
-		} else {
-			bender.Boom()
-			bender.NextDirection()
-			e.Cancel()
+	if rec != nil {
+		if rerr := rec.Close(res.Outcome); rerr != nil && err == nil {
+			err = rerr
 		}
 	}
-}
-
-// enter handles all non obstacle states
-func enterCallback(e *Event) {
-	bender := e.Args[0].(*BenderSimulator)
-
-	if bender.Hurts() {
-		// managed to enter the state: obstacle is behind
-		bender.BackOnTrack()
+	if tw != nil {
+		if terr := tw.Close(res); terr != nil && err == nil {
+			err = terr
+		}
 	}
-
-	switch e.Dst {
-	case 'B':
-		bender.InvertBreaker()
-	case 'S':
-		bender.PathModifier(SOUTH)
-	case 'N':
-		bender.PathModifier(NORTH)
-	case 'E':
-		bender.PathModifier(EAST)
-	case 'W':
-		bender.PathModifier(WEST)
-	case 'I':
-		bender.InvertPriorities()
-	case 'T':
-		e.FSM.SetState(e.FSM.TeleportDst(e.dstC))
-	case '$':
-		bender.Reached()
-	}
-	bender.Remember(e.Event, e.UniqueDst())
-}
-
-// returns the number of valid (frame excluded) states of a map
-func calcNumStates(plan []string) int {
-	l := len(plan[0])
-	w := len(plan)
-	return (w - 2) * (l - 2)
-}
-
-func main() {
-	plan := []string{
-		"########",
-		"#     $#",
-		"#      #",
-		"#      #",
-		"#  @   #",
-		"#      #",
-		"#      #",
-		"########",
-	}
-	fmt.Println("Plan:")
-	for _, s := range plan {
-		fmt.Println(s)
+	if *historyDir != "" && err == nil {
+		err = recordRun(*historyDir, history.Run{Time: time.Now(), Plan: plan, MaxSteps: *maxSteps, Outcome: res.Outcome.String(), Steps: res.Steps, Replay: *replayFile})
 	}
-
-	m := NewFSM(plan, beforeCallback, enterCallback)
-	bender := NewBenderSimulator(calcNumStates(plan))
-
-	for !bender.Done() && !bender.Loop() {
-		err := m.Event(bender.Direction(), bender)
-		if err != nil {
+	if err != nil {
+		var ierr *bender.InvariantError
+		if errors.As(err, &ierr) {
+			fmt.Print(ierr.Report())
+			os.Exit(1)
+		}
+		fmt.Println("Failed with error: ", err)
+		return
+	}
+	if *format != "text" {
+		rep := newReport(res, labels, trace)
+		rep.addBoard(m, b)
+		if err := writeReport(os.Stdout, *format, rep); err != nil {
 			fmt.Println("Failed with error: ", err)
-			return
 		}
+		if err := r.RenderPath(res.ClassicPath()); err != nil {
+			fmt.Println("Failed with error: ", err)
+		}
+		return
+	}
+	switch {
+	case res.Escape != nil:
+		e := *res.Escape
+		e.Direction = fsm.Direction(labels.Label(string(e.Direction)))
+		fmt.Printf("Simulation ended: %v, %v\n", res.Outcome, e)
+	case res.Outcome != bender.Reached && res.Outcome != bender.Loop:
+		fmt.Println("Simulation ended:", res.Outcome)
+	}
+	if err := r.RenderPath(res.ClassicPath()); err != nil {
+		fmt.Println("Failed with error: ", err)
 	}
-	fmt.Println(bender.ShowPath())
 }
@@ -1,338 +1,204 @@
 package main
 
 import (
+	"bytes"
+	"errors"
+	"math/rand"
+	"os"
+	"os/exec"
+	"path/filepath"
 	"reflect"
+	"strings"
 	"testing"
+
+	"bender/internal/fsm"
 )
 
-func TestFSM(t *testing.T) {
-	testArg := []interface{}{
-		"argument",
+func TestPrintError(t *testing.T) {
+	plan := []string{
+		"#####",
+		"#@ $#",
+		"#  T#",
+		"#####",
+	}
+	buf := &bytes.Buffer{}
+	_, err := fsm.NewFSM(plan, nil, nil)
+	printError(buf, err, nil)
+	expected := "3:4: teleport 'T' appears 1 time(s), expected exactly 2\n" +
+		"#  T#\n" +
+		"   ^\n"
+	if buf.String() != expected {
+		t.Fatalf("Wrong error output. Expected:\n%s\ngot:\n%s", expected, buf.String())
 	}
+
+	c, err := loadCatalog("fr_FR.UTF-8", "")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	buf.Reset()
+	_, err = fsm.NewFSM(plan, nil, nil)
+	printError(buf, err, c)
+	expected = "3:4: le téléporteur 'T' apparaît 1 fois, exactement 2 attendus\n" +
+		"#  T#\n" +
+		"   ^\n"
+	if buf.String() != expected {
+		t.Fatalf("Wrong error output in French. Expected:\n%s\ngot:\n%s", expected, buf.String())
+	}
+	if _, err := loadCatalog("xx", t.TempDir()); err == nil {
+		t.Fatalf("Expected an error for a locale without catalog")
+	}
+}
+
+func TestParseCheckpointConf(t *testing.T) {
 	testCases := []struct {
-		name                 string
-		plan                 []string
-		dirs                 []string
-		testCallbacks        testCallback
-		expectedBeforeEvents []Event
-		expectedEnterEvents  []Event
+		conf     string
+		expected checkpointConf
+		err      bool
 	}{
-		{
-			name: "nominal",
-			plan: []string{
-				"#####",
-				"#$ X#",
-				"# @B#",
-				"#####",
-			},
-			testCallbacks: newCallbackRecorder(),
-			dirs: []string{
-				EAST,
-				NORTH,
-				WEST,
-				WEST,
-			},
-			expectedBeforeEvents: []Event{
-				Event{Event: EAST, Dst: 'B', dstC: Pair{3, 2}, Args: testArg},
-				Event{Event: NORTH, Dst: 'X', dstC: Pair{3, 1}, Args: testArg},
-				Event{Event: WEST, Dst: ' ', dstC: Pair{2, 1}, Args: testArg},
-				Event{Event: WEST, Dst: '$', dstC: Pair{1, 1}, Args: testArg},
-			},
-			expectedEnterEvents: []Event{
-				Event{Event: EAST, Dst: 'B', dstC: Pair{3, 2}, Args: testArg},
-				Event{Event: NORTH, Dst: 'X', dstC: Pair{3, 1}, Args: testArg},
-				Event{Event: WEST, Dst: ' ', dstC: Pair{2, 1}, Args: testArg},
-				Event{Event: WEST, Dst: '$', dstC: Pair{1, 1}, Args: testArg},
-			},
-		},
-		{
-			name: "cancelled",
-			plan: []string{
-				"#####",
-				"#$  #",
-				"#@ X#",
-				"#####",
-			},
-			testCallbacks: newCallbackRecorderCancel(2),
-			dirs: []string{
-				EAST,
-				EAST,
-				NORTH,
-				WEST,
-			},
-			expectedBeforeEvents: []Event{
-				Event{Event: EAST, Dst: ' ', dstC: Pair{2, 2}, Args: testArg},
-				Event{Event: EAST, Dst: 'X', dstC: Pair{3, 2}, Args: testArg},
-				Event{Event: NORTH, Dst: ' ', dstC: Pair{2, 1}, Args: testArg},
-				Event{Event: WEST, Dst: '$', dstC: Pair{1, 1}, Args: testArg},
-			},
-			expectedEnterEvents: []Event{
-				Event{Event: EAST, Dst: ' ', dstC: Pair{2, 2}, Args: testArg},
-				Event{Event: NORTH, Dst: ' ', dstC: Pair{2, 1}, Args: testArg},
-				Event{Event: WEST, Dst: '$', dstC: Pair{1, 1}, Args: testArg},
-			},
-		},
+		{conf: "every=10 file=ckpt.json", expected: checkpointConf{every: 10, file: "ckpt.json"}},
+		{conf: "file=ckpt.json,every=5", expected: checkpointConf{every: 5, file: "ckpt.json"}},
+		{conf: "every=10", err: true},
+		{conf: "every=0 file=ckpt.json", err: true},
+		{conf: "every=ten file=ckpt.json", err: true},
+		{conf: "every=10 file=ckpt.json size=1", err: true},
+		{conf: "every", err: true},
 	}
 
 	for _, tc := range testCases {
-		t.Run(tc.name, func(t *testing.T) {
-			fsm := NewFSM(tc.plan, tc.testCallbacks.before, tc.testCallbacks.enter)
-			for _, d := range tc.dirs {
-				fsm.Event(d, testArg...)
+		c, err := parseCheckpointConf(tc.conf)
+		if tc.err {
+			if err == nil {
+				t.Errorf("Configuration %q: expected error", tc.conf)
 			}
-
-			for i, act := range tc.testCallbacks.beforeStack() {
-				exp := tc.expectedBeforeEvents[i]
-				if !eventEqual(exp, act, fsm) {
-					t.Errorf("Test case %q: event %v doesn't match expected %v", tc.name, act, exp)
-				}
-			}
-			for i, act := range tc.testCallbacks.enterStack() {
-				exp := tc.expectedEnterEvents[i]
-				if !eventEqual(exp, act, fsm) {
-					t.Errorf("Test case %q: event %v doesn't match expected %v", tc.name, act, exp)
-				}
-			}
-		})
+			continue
+		}
+		if err != nil {
+			t.Errorf("Configuration %q: unexpected error %v", tc.conf, err)
+			continue
+		}
+		if c != tc.expected {
+			t.Errorf("Configuration %q: expected %+v, got %+v", tc.conf, tc.expected, c)
+		}
 	}
 }
 
-func TestBenderSimulator(t *testing.T) {
-	stateNum := 9
-	bender := NewBenderSimulator(stateNum)
-
-	// start from the first priority
-	dir := bender.Direction()
-	if dir != SOUTH {
-		t.Fatalf("Wrong priority direction. Expected %s, got %s", SOUTH, dir)
-	}
-	// must continue the same direction if no path modifier or next direction
-	dir = bender.Direction()
-	if dir != SOUTH {
-		t.Fatalf("Wrong continuation of priority direction. Expected %s, got %s", SOUTH, dir)
-	}
-	// must choose the next priority direction
-	bender.NextDirection()
-	dir = bender.Direction()
-	if dir != EAST {
-		t.Fatalf("Wrong next priority direction. Expected %s, got %s", EAST, dir)
+func TestParseCheckpointConfAdversarial(t *testing.T) {
+	rnd := rand.New(rand.NewSource(42))
+	inputs := []string{"", "=", ",", "every==", "file= every="}
+	for i := 0; i < 1000; i++ {
+		buf := make([]byte, rnd.Intn(32))
+		rnd.Read(buf)
+		inputs = append(inputs, string(buf), strings.Replace(string(buf), " ", "=", -1))
 	}
-	// must get back to the first priority
-	bender.NextDirection()
-	bender.NextDirection()
-	bender.NextDirection()
-	dir = bender.Direction()
-	if dir != SOUTH {
-		t.Fatalf("No cycle in priority direction. Expected %s, got %s", SOUTH, dir)
-	}
-	// must stick with the path modifier
-	bender.PathModifier(NORTH)
-	dir = bender.Direction()
-	if dir != NORTH {
-		t.Fatalf("Wrong path modifier. Expected %s, got %s", NORTH, dir)
-	}
-	// obstacle case, must get back to the priorities
-	bender.Boom()
-	dir = bender.Direction()
-	if dir != SOUTH {
-		t.Fatalf("Failed to get back to priorities. Expected %s, got %s", SOUTH, dir)
-	}
-	// looking for a way out of the obstacles
-	bender.NextDirection()
-	if !bender.Hurts() {
-		t.Fatalf("Obstacle is not recorded")
-	}
-	if bender.Hurts() {
-		bender.BackOnTrack()
-	}
-	bender.NextDirection()
-	dir = bender.Direction()
-	if dir != SOUTH {
-		t.Fatalf("Priorities not reset. Expected %s, got %s", SOUTH, dir)
-	}
-	// invert priorities
-	bender.InvertPriorities()
-	bender.Boom()
-	bender.NextDirection()
-	dir = bender.Direction()
-	if dir != WEST {
-		t.Fatalf("Failed to invert priorities. Expected %s, got %s", WEST, dir)
-	}
-	bender.NextDirection()
-	bender.NextDirection()
-	dir = bender.Direction()
-	if dir != EAST {
-		t.Fatalf("Failed to continue on inverted priorities. Expected %s, got %s", EAST, dir)
-	}
-	bender.InvertPriorities()
-	bender.Boom()
-	bender.NextDirection()
-	dir = bender.Direction()
-	if dir != SOUTH {
-		t.Fatalf("Failed to invert back the priorities. Expected %s, got %s", SOUTH, dir)
-	}
-	// path
-	dirs := []string{
-		SOUTH,
-		SOUTH,
-		EAST,
-		EAST,
-	}
-	bender.Remember(dirs[0], " 11")
-	bender.Remember(dirs[1], " 12")
-	bender.Remember(dirs[2], " 22")
-	bender.Remember(dirs[3], "B32")
-	for i, p := range bender.ShowPath() {
-		if dirs[i] != p {
-			t.Fatalf("Wrong path. Expected %s, got %s", dirs[i], p)
-		}
-	}
-	bender.Remember(dirs[0], " 11")
-	bender.Remember(dirs[1], " 12")
-	bender.Remember(dirs[2], " 22")
-	bender.Remember(dirs[3], "B32")
-	bender.Remember(dirs[0], " 11")
-	bender.Remember(dirs[1], " 12")
-	bender.Remember(dirs[2], " 22")
-	bender.Remember(dirs[3], "B32")
-	if bender.Loop() {
-		t.Fatalf("False positive loop detection")
-	}
-	bender.Remember(dirs[0], " 11")
-	bender.Remember(dirs[1], " 12")
-	bender.Remember(dirs[2], " 22")
-	bender.Remember(dirs[3], "B32")
-	if !bender.Loop() {
-		t.Fatalf("Loop was not detected")
-	}
-	// breaker mode
-	br := bender.Breaker()
-	bender.InvertBreaker()
-	ibr := bender.Breaker()
-	if br != !ibr {
-		t.Fatalf("Failed to invert the breaker mode #1")
-	}
-	bender.InvertBreaker()
-	ibr = bender.Breaker()
-	if br != ibr {
-		t.Fatalf("Failed to invert the breaker mode #2")
-	}
-	// booth reached
-	bender.Reached()
-	if !bender.Done() {
-		t.Fatalf("Failed to become done")
+	for _, in := range inputs {
+		func() {
+			defer func() {
+				if r := recover(); r != nil {
+					t.Fatalf("parseCheckpointConf(%q) panicked: %v", in, r)
+				}
+			}()
+			parseCheckpointConf(in)
+		}()
 	}
 }
 
-func TestCalcNumStates(t *testing.T) {
-	plan := []string{
-		"#####",
-		"#   #",
-		"#   #",
-		"#   #",
-		"#####",
+func TestLoadPlan(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "map.txt")
+	if err := os.WriteFile(name, []byte("###\n#@$\n###\n\n"), 0644); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
 	}
-	num := calcNumStates(plan)
-	if num != 9 {
-		t.Fatalf("Wrong number of valid states. Expected %d, got %d.", 9, num)
+	empty := filepath.Join(t.TempDir(), "empty.txt")
+	if err := os.WriteFile(empty, []byte("\n\n"), 0644); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
 	}
-	plan = []string{
-		"#####",
-		"#   #",
-		"#   #",
-		"#####",
+	testCases := []struct {
+		name     string
+		file     string
+		stdin    string
+		expected []string
+		err      bool
+		msg      string
+	}{
+		{name: "default", expected: defaultPlan},
+		{name: "file", file: name, expected: []string{"###", "#@$", "###"}},
+		{name: "stdin", file: "-", stdin: "####\n#@ $\n####\n", expected: []string{"####", "#@ $", "####"}},
+		{name: "empty stdin", file: "-", err: true},
+		{name: "missing file", file: name + ".missing", err: true},
+		{name: "empty file", file: empty, err: true, msg: empty + ": empty map"},
 	}
-	num = calcNumStates(plan)
-	if num != 6 {
-		t.Fatalf("Wrong number of valid states. Expected %d, got %d.", 6, num)
+	for _, tc := range testCases {
+		plan, err := loadPlan(tc.file, strings.NewReader(tc.stdin))
+		if (err != nil) != tc.err {
+			t.Fatalf("Test case %q: unexpected error %v", tc.name, err)
+		}
+		if tc.msg != "" && err.Error() != tc.msg {
+			t.Fatalf("Test case %q: wrong error. Expected %q, got %q", tc.name, tc.msg, err)
+		}
+		if !reflect.DeepEqual(plan, tc.expected) {
+			t.Fatalf("Test case %q: wrong map. Expected %q, got %q", tc.name, tc.expected, plan)
+		}
 	}
 }
 
-type testCallback interface {
-	before(*Event)
-	enter(*Event)
-	beforeStack() []Event
-	enterStack() []Event
-}
-
-type callbackRecorder struct {
-	bStack []Event
-	eStack []Event
-}
-
-func newCallbackRecorder() *callbackRecorder {
-	return &callbackRecorder{
-		bStack: []Event{},
-		eStack: []Event{},
+func TestRunMapError(t *testing.T) {
+	if args := os.Getenv("BENDER_MAIN_ARGS"); args != "" {
+		// child process running the simulation
+		os.Args = append([]string{"bender"}, strings.Fields(args)...)
+		main()
+		return
 	}
-}
-
-func (c *callbackRecorder) before(e *Event) {
-	c.bStack = append(c.bStack, *e)
-}
-
-func (c *callbackRecorder) enter(e *Event) {
-	c.eStack = append(c.eStack, *e)
-}
-
-func (c *callbackRecorder) beforeStack() []Event {
-	return c.bStack
-}
-
-func (c *callbackRecorder) enterStack() []Event {
-	return c.eStack
-}
-
-type callbackRecorderCancel struct {
-	bStack    []Event
-	eStack    []Event
-	cancelIdx int
-	beforeCnt int
-}
-
-func newCallbackRecorderCancel(idx int) *callbackRecorderCancel {
-	return &callbackRecorderCancel{
-		bStack:    []Event{},
-		eStack:    []Event{},
-		cancelIdx: idx,
+	dir := t.TempDir()
+	testCases := []struct {
+		name     string
+		data     string
+		args     string
+		expected string
+	}{
+		{name: "unknown tile", data: "#####\n#@Z$#\n#####\n", expected: "bad.txt:2:3: unknown tile 'Z'\n#@Z$#\n  ^\n"},
+		{name: "no start", data: "####\n#  $#\n####\n", expected: "bad.txt: no start in the map\n"},
+		{name: "prefix", data: "#####\n#@Z$#\n#####\n", args: "-prefix E", expected: "bad.txt:2:3: unknown tile 'Z'"},
+		{name: "missing file", args: "-map missing.txt", expected: "missing.txt"},
 	}
-}
-
-func (c *callbackRecorderCancel) before(e *Event) {
-	c.bStack = append(c.bStack, *e)
-	c.beforeCnt++
-	if c.cancelIdx == c.beforeCnt {
-		e.Cancel()
+	for _, tc := range testCases {
+		name := filepath.Join(dir, "bad.txt")
+		if err := os.WriteFile(name, []byte(tc.data), 0644); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		args := tc.args
+		if !strings.Contains(args, "-map") {
+			args += " -map " + name
+		}
+		cmd := exec.Command(os.Args[0], "-test.run=^TestRunMapError$")
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(), "BENDER_MAIN_ARGS="+args)
+		out, err := cmd.CombinedOutput()
+		var exitErr *exec.ExitError
+		if !errors.As(err, &exitErr) || exitErr.ExitCode() != 1 {
+			t.Fatalf("Test case %q: wrong exit. Expected status 1, got %v: %s", tc.name, err, out)
+		}
+		if expected := strings.ReplaceAll(tc.expected, "bad.txt", name); !strings.Contains(string(out), expected) {
+			t.Fatalf("Test case %q: wrong output. Expected %q, got %q", tc.name, expected, out)
+		}
 	}
 }
 
-func (c *callbackRecorderCancel) enter(e *Event) {
-	c.eStack = append(c.eStack, *e)
-}
-
-func (c *callbackRecorderCancel) beforeStack() []Event {
-	return c.bStack
-}
-
-func (c *callbackRecorderCancel) enterStack() []Event {
-	return c.eStack
-}
-
-func eventEqual(exp, act Event, fsm *FSM) bool {
-	if act.FSM != fsm {
-		return false
-	}
-	if exp.Event != act.Event {
-		return false
-	}
-	if exp.Dst != act.Dst {
-		return false
-	}
-	if exp.dstC != act.dstC {
-		return false
+func TestPositionalArgs(t *testing.T) {
+	testCases := []struct {
+		name string
+		run  func(args []string) error
+	}{
+		{name: "advise", run: runAdvise},
+		{name: "breakers", run: runBreakers},
+		{name: "starts", run: runStarts},
+		{name: "teleports", run: runTeleports},
+		{name: "whatif", run: runWhatIf},
+		{name: "validate", run: runValidate},
+		{name: "watch", run: runWatch},
 	}
-	if !reflect.DeepEqual(exp.Args, act.Args) {
-		return false
+	for _, tc := range testCases {
+		err := tc.run([]string{"map.txt"})
+		if err == nil || !strings.Contains(err.Error(), "unexpected arguments") {
+			t.Fatalf("Test case %q: wrong error. Expected unexpected arguments, got %v", tc.name, err)
+		}
 	}
-	return true
 }
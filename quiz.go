@@ -0,0 +1,158 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"bender/internal/bender"
+	"bender/internal/compress"
+	"bender/internal/fsm"
+	"bender/internal/i18n"
+	"bender/internal/mapfile"
+	"bender/internal/render"
+)
+
+// runQuiz runs the quiz subcommand with the given arguments:
+// it asks the direction of every step of Bender before making it
+func runQuiz(args []string) error {
+	flags := flag.NewFlagSet("quiz", flag.ContinueOnError)
+	mapFile := flags.String("map", "", "file of the map, as text rows or JSON (default the built-in map)")
+	labelConf := flags.String("labels", "words", "direction labels: words, letters, arrows or a list like SOUTH=S,NORTH=N")
+	locale := flags.String("locale", "en", "language of the quiz, like fr or fr_FR.UTF-8")
+	catalogs := flags.String("catalogs", "", "directory of additional message catalogs, stored as <locale>.json")
+	prefix := flags.String("prefix", "", "moves made before the quiz starts, separated by spaces or commas like \"S S E\"")
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+	if err := noArgs(flags); err != nil {
+		return err
+	}
+	labels, err := render.ParseLabels(*labelConf)
+	if err != nil {
+		return err
+	}
+	catalog, err := loadCatalog(*locale, *catalogs)
+	if err != nil {
+		return err
+	}
+	plan := defaultPlan
+	if *mapFile != "" {
+		data, err := compress.ReadFile(*mapFile)
+		if err != nil {
+			return err
+		}
+		if plan, err = mapfile.ParsePlan(data); err != nil {
+			return err
+		}
+	}
+	moves, err := parsePath(*prefix, labels)
+	if err != nil {
+		return err
+	}
+	return quiz(os.Stdin, os.Stdout, plan, moves, labels, catalog)
+}
+
+// quiz asks the direction of every step after the given moves read from in until the simulation ends or the input does,
+// the answers are scored and the rule applied by the step is explained in the language of the catalog
+func quiz(in io.Reader, out io.Writer, plan []string, moves []string, labels render.Labels, c i18n.Catalog) error {
+	ckpt, err := bender.FastForward(plan, moves)
+	if err != nil {
+		return err
+	}
+	f, b := ckpt.FSM, ckpt.Simulator
+	answers := bufio.NewScanner(in)
+	score, questions := 0, 0
+	fmt.Fprintln(out, c.Sprintf("Predict the direction of every step of Bender, q to quit."))
+	for !b.Over() {
+		printBoard(out, f)
+		fmt.Fprint(out, c.Sprintf("Step %d, direction? ", f.Steps()+1))
+		if !answers.Scan() {
+			fmt.Fprintln(out)
+			break
+		}
+		answer := strings.TrimSpace(answers.Text())
+		if strings.EqualFold(answer, "q") {
+			break
+		}
+		guess, ok := parseDirection(answer, labels)
+		if !ok {
+			fmt.Fprintln(out, c.Sprintf("Unknown direction %q, answer %s, %s, %s or %s.",
+				answer, labels.Label(string(fsm.SOUTH)), labels.Label(string(fsm.NORTH)), labels.Label(string(fsm.EAST)), labels.Label(string(fsm.WEST))))
+			continue
+		}
+		steps := f.Steps()
+		if _, err := bender.Resume(f, b, bender.WithMaxSteps(steps+1)); err != nil {
+			return err
+		}
+		if f.Steps() == steps {
+			// Bender is stuck, no step was made
+			break
+		}
+		questions++
+		x := b.Explain()
+		if guess == x.Direction {
+			score++
+			fmt.Fprintln(out, c.Sprintf("Right! %s", x.Localize(c)))
+		} else {
+			fmt.Fprintln(out, c.Sprintf("Wrong, it's %s. %s", labels.Label(string(x.Direction)), x.Localize(c)))
+		}
+	}
+	if b.Over() {
+		res := bender.NewResult(f, b)
+		fmt.Fprintln(out, c.Sprintf("Simulation ended: %s", res.Outcome))
+	}
+	fmt.Fprintln(out, c.Sprintf("Score: %d/%d", score, questions))
+	return nil
+}
+
+// parseDirection returns the direction of the answer: its name, its first letter or its label, in any case
+func parseDirection(answer string, labels render.Labels) (fsm.Direction, bool) {
+	for _, dir := range []fsm.Direction{fsm.SOUTH, fsm.NORTH, fsm.EAST, fsm.WEST} {
+		if strings.EqualFold(answer, string(dir)) || strings.EqualFold(answer, string(dir[:1])) || (answer != "" && strings.EqualFold(answer, labels.Label(string(dir)))) {
+			return dir, true
+		}
+	}
+	return "", false
+}
+
+// parsePath returns the directions of the path, separated by spaces or commas
+// every direction is given as in the answers of the quiz
+func parsePath(conf string, labels render.Labels) ([]string, error) {
+	path := []string{}
+	for _, answer := range strings.FieldsFunc(conf, func(r rune) bool { return r == ' ' || r == ',' }) {
+		dir, ok := parseDirection(answer, labels)
+		if !ok {
+			return nil, fmt.Errorf("unknown direction %q", answer)
+		}
+		path = append(path, string(dir))
+	}
+	return path, nil
+}
+
+// printBoard prints the current board with Bender as @
+func printBoard(out io.Writer, f *fsm.FSM) {
+	board, pos := f.Board(), f.Position()
+	row := make([]byte, board.Width())
+	for y := 0; y < board.Height(); y++ {
+		row = row[:0]
+		for x := 0; x < board.Width(); x++ {
+			c := board.At(x, y)
+			switch {
+			case x == pos.X && y == pos.Y:
+				c = '@'
+			case c == '@' || c == 0:
+				c = ' '
+			}
+			row = append(row, c)
+		}
+		fmt.Fprintln(out, strings.TrimRight(string(row), " "))
+	}
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"bender/internal/fsm"
+	"bender/internal/i18n"
+	"bender/internal/render"
+)
+
+func TestQuiz(t *testing.T) {
+	plan := []string{"#####", "#@ $#", "#####"}
+	testCases := []struct {
+		name     string
+		answers  string
+		prefix   []string
+		catalog  i18n.Catalog
+		expected []string
+	}{
+		{
+			name:    "all steps",
+			answers: "south\nup\nE\neast\n",
+			expected: []string{
+				"Wrong, it's EAST. An obstacle blocked the way, Bender took EAST, the first free direction of its priorities SOUTH, EAST, NORTH, WEST.",
+				`Unknown direction "up"`,
+				"Right! Nothing blocked the way, Bender kept going EAST. It reached the suicide booth.",
+				"Simulation ended: reached",
+				"Score: 1/2",
+			},
+		},
+		{
+			name:     "quit",
+			answers:  "e\nq\n",
+			expected: []string{"Right! An obstacle blocked the way", "Score: 1/1"},
+		},
+		{
+			name:    "french",
+			answers: "e\ne\n",
+			catalog: mustCatalog(t, "fr"),
+			expected: []string{
+				"Juste ! Un obstacle bloquait le chemin, Bender a pris EAST",
+				"Juste ! Rien ne bloquait le chemin, Bender a continué vers EAST. Il a atteint la cabine de suicide.",
+				"Score : 2/2",
+			},
+		},
+		{
+			name:     "prefix",
+			answers:  "e\n",
+			prefix:   []string{"EAST"},
+			expected: []string{"Step 2, direction?", "Right! Nothing blocked the way", "Score: 1/1"},
+		},
+		{
+			name:     "end of input",
+			expected: []string{"Score: 0/0"},
+		},
+	}
+	for _, tc := range testCases {
+		out := &bytes.Buffer{}
+		if err := quiz(strings.NewReader(tc.answers), out, plan, tc.prefix, render.Labels{}, tc.catalog); err != nil {
+			t.Fatalf("Test case %q: unexpected error: %v", tc.name, err)
+		}
+		for _, e := range tc.expected {
+			if !strings.Contains(out.String(), e) {
+				t.Fatalf("Test case %q: %q missing from the output:\n%s", tc.name, e, out)
+			}
+		}
+	}
+
+	if err := quiz(strings.NewReader(""), &bytes.Buffer{}, []string{"###", "#T@", "###"}, nil, render.Labels{}, nil); err == nil {
+		t.Fatalf("Expected an error for an invalid map")
+	}
+	if err := quiz(strings.NewReader(""), &bytes.Buffer{}, plan, []string{"SOUTH"}, render.Labels{}, nil); err == nil {
+		t.Fatalf("Expected an error for a prefix diverging from the simulation")
+	}
+}
+
+// mustCatalog returns the catalog of the locale
+func mustCatalog(t *testing.T, locale string) i18n.Catalog {
+	c, err := loadCatalog(locale, "")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	return c
+}
+
+func TestParseDirection(t *testing.T) {
+	testCases := []struct {
+		answer   string
+		expected fsm.Direction
+	}{
+		{answer: "SOUTH", expected: "SOUTH"},
+		{answer: "n", expected: "NORTH"},
+		{answer: "→", expected: "EAST"},
+		{answer: "west", expected: "WEST"},
+		{answer: "", expected: ""},
+		{answer: "up", expected: ""},
+	}
+	for _, tc := range testCases {
+		if dir, ok := parseDirection(tc.answer, render.ArrowLabels); dir != tc.expected || ok != (tc.expected != "") {
+			t.Fatalf("Wrong direction of %q. Expected %q, got %q", tc.answer, tc.expected, dir)
+		}
+	}
+}
+
+func TestParsePath(t *testing.T) {
+	path, err := parsePath("S, east ↑", render.ArrowLabels)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if expected := []string{"SOUTH", "EAST", "NORTH"}; strings.Join(path, " ") != strings.Join(expected, " ") {
+		t.Fatalf("Wrong path. Expected %v, got %v", expected, path)
+	}
+	if path, err := parsePath("", nil); err != nil || len(path) != 0 {
+		t.Fatalf("Wrong empty path %v: %v", path, err)
+	}
+	if _, err := parsePath("S up", nil); err == nil {
+		t.Fatalf("Expected an error for an unknown direction")
+	}
+}
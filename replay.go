@@ -0,0 +1,88 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"bender/internal/bender"
+	"bender/internal/render"
+	"bender/internal/replay"
+)
+
+// runReplay runs the replay subcommand with the given arguments:
+// it simulates the map of a trace again with the current engine and checks every event, then renders the run with -render
+func runReplay(args []string) error {
+	flags := flag.NewFlagSet("replay", flag.ContinueOnError)
+	renderKind := flags.String("render", "", "render the verified run: terminal, png, svg or cast (asciinema), nothing by default")
+	renderOut := flags.String("o", "", "write the render to the given file instead of stdout")
+	labelConf := flags.String("labels", "words", "direction labels: words, letters, arrows or a list like SOUTH=S,NORTH=N")
+	themeConf := flags.String("theme", "classic", "look of the tiles: classic, unicode, emoji, roguelike or a theme file like mine.json")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: replay [flags] trace.jsonl")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+	if flags.NArg() != 1 {
+		return fmt.Errorf("expected a trace, got %d", flags.NArg())
+	}
+	t, err := replay.OpenTrace(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	if err := verifyTrace(os.Stdout, t); err != nil {
+		return err
+	}
+	if *renderKind == "" {
+		return nil
+	}
+	labels, err := render.ParseLabels(*labelConf)
+	if err != nil {
+		return err
+	}
+	theme, err := render.ParseTheme(*themeConf)
+	if err != nil {
+		return err
+	}
+	var out io.Writer = os.Stdout
+	if *renderOut != "" {
+		f, err := os.Create(*renderOut)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		out = f
+	}
+	var r render.Renderer
+	if *renderKind == "terminal" {
+		tr := render.NewTerminalRenderer(out, true, labels)
+		tr.SetTheme(theme)
+		r = tr
+	} else if r, err = render.NewRenderer(*renderKind, out, labels, theme); err != nil {
+		return err
+	}
+	return renderTrace(r, t)
+}
+
+// verifyTrace simulates the trace again and prints the number of events checked
+// the error tells the first event the current engine disagrees on
+func verifyTrace(w io.Writer, t *replay.Trace) error {
+	res, err := t.Verify()
+	if err != nil {
+		return fmt.Errorf("the current engine disagrees with the trace: %w", err)
+	}
+	fmt.Fprintf(w, "verified %d events: %v after %d steps\n", len(t.Events), res.Outcome, res.Steps)
+	return nil
+}
+
+// renderTrace renders the simulation of the map of the trace, once verified it's the traced run
+func renderTrace(r render.Renderer, t *replay.Trace) error {
+	return renderPlan(r, t.Plan, bender.WithMaxSteps(t.MaxSteps))
+}
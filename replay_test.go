@@ -0,0 +1,56 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"bender/internal/replay"
+)
+
+// traceText is the trace of the simulation of a map with a breaker
+const traceText = `{"trace":1,"plan":["#####","#@  #","# B$#","#####"]}
+{"event":1,"direction":"SOUTH","tile":" ","x":1,"y":2,"steps":1}
+{"event":2,"direction":"SOUTH","tile":"#","x":1,"y":3,"cancelled":true,"steps":1}
+{"event":3,"direction":"EAST","tile":"B","x":2,"y":2,"breaker":true,"steps":2}
+{"event":4,"direction":"EAST","tile":"$","x":3,"y":2,"breaker":true,"steps":3}
+{"outcome":"reached","steps":3}
+`
+
+func TestReplayTrace(t *testing.T) {
+	tr, err := replay.ReadTrace(strings.NewReader(traceText))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	buf := &bytes.Buffer{}
+	if err := verifyTrace(buf, tr); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if expected := "verified 4 events: reached after 3 steps\n"; buf.String() != expected {
+		t.Fatalf("Wrong verification. Expected %q, got %q", expected, buf.String())
+	}
+
+	dir := t.TempDir()
+	file := filepath.Join(dir, "trace.jsonl")
+	if err := os.WriteFile(file, []byte(traceText), 0644); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	svg := filepath.Join(dir, "run.svg")
+	if err := runReplay([]string{"-render", "svg", "-o", svg, file}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if data, err := os.ReadFile(svg); err != nil || !bytes.Contains(data, []byte("<svg")) {
+		t.Fatalf("Wrong render: %v", err)
+	}
+
+	// a trace of another engine disagreeing on the third event
+	other := filepath.Join(dir, "other.jsonl")
+	if err := os.WriteFile(other, []byte(strings.Replace(traceText, `"tile":"B","x":2,"y":2,"breaker":true`, `"tile":"B","x":2,"y":2`, 1)), 0644); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := runReplay([]string{other}); err == nil || !strings.Contains(err.Error(), "event 3:") {
+		t.Fatalf("Expected a disagreement on the event 3, got %v", err)
+	}
+}
@@ -0,0 +1,216 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strconv"
+	"text/tabwriter"
+
+	"bender/internal/bender"
+	"bender/internal/fsm"
+	"bender/internal/history"
+	"bender/internal/render"
+)
+
+// historyEnv is the environment variable enabling the history of the runs, it holds its directory
+const historyEnv = "BENDER_HISTORY"
+
+// runRuns runs the runs subcommand with the given arguments:
+// it lists, shows or compares the runs of the history
+func runRuns(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("expected list, show <id> or compare <id> <id>")
+	}
+	flags := flag.NewFlagSet("runs "+args[0], flag.ContinueOnError)
+	dir := flags.String("history", os.Getenv(historyEnv), "directory of the history of the runs (default $"+historyEnv+")")
+	renderKind := flags.String("render", "terminal", "renderer of show: terminal, png, svg, cast (asciinema), html or none")
+	renderOut := flags.String("render-out", "", "file to write the render of show to (default stdout)")
+	labelConf := flags.String("labels", "words", "direction labels: words, letters, arrows or a list like SOUTH=S,NORTH=N")
+	themeConf := flags.String("theme", "classic", "look of the tiles: classic, unicode, emoji, roguelike or a theme file like mine.json")
+	if err := flags.Parse(args[1:]); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+	if *dir == "" {
+		return fmt.Errorf("no history, set -history or $%s", historyEnv)
+	}
+	labels, err := render.ParseLabels(*labelConf)
+	if err != nil {
+		return err
+	}
+	theme, err := render.ParseTheme(*themeConf)
+	if err != nil {
+		return err
+	}
+	s, err := history.Open(*dir)
+	if err != nil {
+		return err
+	}
+	ids := make([]int, 0, flags.NArg())
+	for _, arg := range flags.Args() {
+		id, err := strconv.Atoi(arg)
+		if err != nil {
+			return fmt.Errorf("invalid run id %q", arg)
+		}
+		ids = append(ids, id)
+	}
+
+	switch args[0] {
+	case "list":
+		return listRuns(os.Stdout, s)
+	case "show":
+		if len(ids) != 1 {
+			return fmt.Errorf("expected a run id, got %d", len(ids))
+		}
+		var out io.Writer = os.Stdout
+		if *renderOut != "" {
+			f, err := os.Create(*renderOut)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			out = f
+		}
+		var r render.Renderer
+		if *renderKind == "terminal" {
+			tr := render.NewTerminalRenderer(out, false, labels)
+			tr.SetTheme(theme)
+			r = tr
+		} else if r, err = render.NewRenderer(*renderKind, out, labels, theme); err != nil {
+			return err
+		}
+		return showRun(os.Stdout, s, ids[0], r)
+	case "compare":
+		if len(ids) != 2 {
+			return fmt.Errorf("expected two run ids, got %d", len(ids))
+		}
+		return compareRuns(os.Stdout, s, ids[0], ids[1], labels, theme)
+	}
+	return fmt.Errorf("unknown runs command %q, expected list, show or compare", args[0])
+}
+
+// listRuns prints a line per run of the history
+func listRuns(w io.Writer, s *history.Store) error {
+	runs, err := s.List()
+	if err != nil {
+		return err
+	}
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintf(tw, "id\ttime\tsize\toutcome\tsteps\n")
+	for _, r := range runs {
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%d\n", r.ID, r.Time.Format("2006-01-02 15:04:05"), planSize(r.Plan), r.Outcome, r.Steps)
+	}
+	return tw.Flush()
+}
+
+// planSize returns the size of the map as columns x rows
+func planSize(plan []string) string {
+	width := 0
+	for _, row := range plan {
+		if len(row) > width {
+			width = len(row)
+		}
+	}
+	return fmt.Sprintf("%dx%d", width, len(plan))
+}
+
+// runOptions returns the options simulating the run again
+func runOptions(r history.Run) []bender.Option {
+	if r.MaxSteps > 0 {
+		return []bender.Option{bender.WithMaxSteps(r.MaxSteps)}
+	}
+	return nil
+}
+
+// showRun prints the metadata of the run to w and simulates it again with the renderer
+func showRun(w io.Writer, s *history.Store, id int, r render.Renderer) error {
+	run, err := s.Get(id)
+	if err != nil {
+		return err
+	}
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintf(tw, "id\t%d\n", run.ID)
+	fmt.Fprintf(tw, "time\t%s\n", run.Time.Format("2006-01-02 15:04:05"))
+	fmt.Fprintf(tw, "outcome\t%s\n", run.Outcome)
+	fmt.Fprintf(tw, "steps\t%d\n", run.Steps)
+	if run.MaxSteps > 0 {
+		fmt.Fprintf(tw, "max steps\t%d\n", run.MaxSteps)
+	}
+	if run.Replay != "" {
+		fmt.Fprintf(tw, "replay\t%s\n", run.Replay)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	return renderPlan(r, run.Plan, runOptions(run)...)
+}
+
+// moveRecorder records the moves of a simulation
+type moveRecorder struct {
+	render.NopRenderer
+	moves []render.Move
+}
+
+// RenderStep records the move
+func (m *moveRecorder) RenderStep(e *fsm.Event) error {
+	m.moves = append(m.moves, render.Move{Direction: e.Event, At: e.Position(), Destroyed: e.Dst == 'X'})
+	return nil
+}
+
+// compareRuns plays the runs side by side if they simulate the same map,
+// otherwise it prints their differences: the metadata and the rows of the maps
+func compareRuns(w io.Writer, s *history.Store, a, b int, labels render.Labels, theme *render.Theme) error {
+	ra, err := s.Get(a)
+	if err != nil {
+		return err
+	}
+	rb, err := s.Get(b)
+	if err != nil {
+		return err
+	}
+	if reflect.DeepEqual(ra.Plan, rb.Plan) {
+		ma, mb := &moveRecorder{}, &moveRecorder{}
+		if err := renderPlan(ma, ra.Plan, runOptions(ra)...); err != nil {
+			return err
+		}
+		if err := renderPlan(mb, rb.Plan, runOptions(rb)...); err != nil {
+			return err
+		}
+		return render.Compare(w, ra.Plan, ma.moves, mb.moves, labels, theme)
+	}
+
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "the runs %d and %d simulate different maps\n", a, b)
+	fmt.Fprintf(bw, "outcome: %s | %s\n", ra.Outcome, rb.Outcome)
+	fmt.Fprintf(bw, "steps: %d | %d\n", ra.Steps, rb.Steps)
+	fmt.Fprintf(bw, "size: %s | %s\n", planSize(ra.Plan), planSize(rb.Plan))
+	for y := 0; y < len(ra.Plan) || y < len(rb.Plan); y++ {
+		rowA, rowB := "", ""
+		if y < len(ra.Plan) {
+			rowA = ra.Plan[y]
+		}
+		if y < len(rb.Plan) {
+			rowB = rb.Plan[y]
+		}
+		if rowA != rowB {
+			fmt.Fprintf(bw, "row %d: %q | %q\n", y, rowA, rowB)
+		}
+	}
+	return bw.Flush()
+}
+
+// recordRun adds the run to the history of the directory
+func recordRun(dir string, r history.Run) error {
+	s, err := history.Open(dir)
+	if err != nil {
+		return err
+	}
+	return s.Add(&r)
+}
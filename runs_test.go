@@ -0,0 +1,100 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"bender/internal/history"
+	"bender/internal/render"
+)
+
+func TestRuns(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	other := []string{"#####", "#@ $#", "#####"}
+	for _, r := range []history.Run{
+		{Time: now, Plan: defaultPlan, Outcome: "reached", Steps: 10},
+		{Time: now, Plan: defaultPlan, MaxSteps: 3, Outcome: "step limit exceeded", Steps: 3},
+		{Time: now, Plan: other, Outcome: "reached", Steps: 2},
+	} {
+		if err := recordRun(dir, r); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+	s, err := history.Open(dir)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	buf := &bytes.Buffer{}
+	if err := listRuns(buf, s); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected := "id  time                 size  outcome              steps\n" +
+		"1   2024-01-02 03:04:05  8x8   reached              10\n" +
+		"2   2024-01-02 03:04:05  8x8   step limit exceeded  3\n" +
+		"3   2024-01-02 03:04:05  5x3   reached              2\n"
+	if buf.String() != expected {
+		t.Fatalf("Wrong list. Expected %q, got %q", expected, buf.String())
+	}
+
+	// the run is simulated again with its step limit
+	buf.Reset()
+	out := &bytes.Buffer{}
+	if err := showRun(buf, s, 2, render.NewTerminalRenderer(out, false, render.LetterLabels)); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !strings.Contains(buf.String(), "max steps  3\n") || !strings.HasSuffix(out.String(), "[S S E]\n") {
+		t.Fatalf("Wrong run:\n%s%s", buf.String(), out.String())
+	}
+	if err := showRun(buf, s, 4, render.NopRenderer{}); err == nil {
+		t.Fatalf("Expected an error for an unknown run")
+	}
+
+	buf.Reset()
+	if err := compareRuns(buf, s, 1, 2, render.LetterLabels, nil); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !strings.HasSuffix(buf.String(), "the runs of 10 and 3 steps diverge at step 4\n") {
+		t.Fatalf("Wrong comparison:\n%s", buf.String())
+	}
+
+	buf.Reset()
+	if err := compareRuns(buf, s, 1, 3, render.LetterLabels, nil); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected = "the runs 1 and 3 simulate different maps\n" +
+		"outcome: reached | reached\n" +
+		"steps: 10 | 2\n" +
+		"size: 8x8 | 5x3\n" +
+		"row 0: \"########\" | \"#####\"\n" +
+		"row 1: \"#     $#\" | \"#@ $#\"\n" +
+		"row 2: \"#      #\" | \"#####\"\n" +
+		"row 3: \"#      #\" | \"\"\n" +
+		"row 4: \"#  @   #\" | \"\"\n" +
+		"row 5: \"#      #\" | \"\"\n" +
+		"row 6: \"#      #\" | \"\"\n" +
+		"row 7: \"########\" | \"\"\n"
+	if buf.String() != expected {
+		t.Fatalf("Wrong comparison. Expected %q, got %q", expected, buf.String())
+	}
+}
+
+func TestRunsArgs(t *testing.T) {
+	dir := t.TempDir()
+	for _, args := range [][]string{
+		nil,
+		{"list"},
+		{"drop", "-history", dir},
+		{"show", "-history", dir},
+		{"show", "-history", dir, "one"},
+		{"compare", "-history", dir, "1"},
+	} {
+		t.Setenv(historyEnv, "")
+		if err := runRuns(args); err == nil {
+			t.Fatalf("Expected an error for the arguments %q", args)
+		}
+	}
+}
@@ -0,0 +1,27 @@
+// Package schema holds the JSON Schemas of the file formats, the Protocol Buffers messages and the OpenAPI document of the server
+package schema
+
+import (
+	// embed the schemas
+	_ "embed"
+)
+
+// Map is the JSON Schema of the JSON map format
+//
+//go:embed map.schema.json
+var Map []byte
+
+// Proto is the Protocol Buffers definition of the messages of the simulator
+//
+//go:embed bender.proto
+var Proto []byte
+
+// Events is the JSON Schema of the named events published for the front-ends
+//
+//go:embed events.schema.json
+var Events []byte
+
+// OpenAPI is the OpenAPI document of the API served by the server
+//
+//go:embed openapi.json
+var OpenAPI []byte
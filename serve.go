@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"bender/internal/bender"
+	"bender/internal/server"
+)
+
+// runServe runs the serve subcommand with the given arguments:
+// it serves the JSON API on TCP and/or on a Unix domain socket until interrupted
+func runServe(args []string) error {
+	flags := flag.NewFlagSet("serve", flag.ContinueOnError)
+	addr := flags.String("addr", "", "TCP address to listen on, like localhost:8080")
+	socket := flags.String("socket", "", "path of the Unix domain socket to listen on")
+	certFile := flags.String("tls-cert", "", "certificate file enabling TLS on the TCP address")
+	keyFile := flags.String("tls-key", "", "key file of the TLS certificate")
+	accessLog := flags.String("access-log", "-", "file to append the access log to as JSON lines, - for stderr, empty to disable")
+	maxRows := flags.Int("max-rows", 1000, "maximum number of rows of a map (0 means no limit)")
+	maxCols := flags.Int("max-cols", 1000, "maximum number of columns of a map (0 means no limit)")
+	maxSteps := flags.Int("max-steps", 1000000, "maximum and default number of steps of a simulation (0 means no limit)")
+	budgetCPU := flags.Duration("budget-cpu", 0, "CPU time allowed to every simulation (0 means no limit)")
+	budgetMemory := flags.Int("budget-memory", 0, "estimated memory in bytes allowed to every simulation (0 means no limit)")
+	budgetSteps := flags.Int("budget-steps", 0, "steps allowed to every simulation, beyond them the outcome is budget exceeded (0 means no limit)")
+	rate := flags.Float64("rate", 10, "requests allowed per second and per client (0 means no limit)")
+	burst := flags.Int("burst", 20, "requests a client can send at once")
+	shutdownTimeout := flags.Duration("shutdown-timeout", 10*time.Second, "time given to the running requests to complete on shutdown")
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+	if err := noArgs(flags); err != nil {
+		return err
+	}
+	if (*certFile == "") != (*keyFile == "") {
+		return fmt.Errorf("both -tls-cert and -tls-key are required for TLS")
+	}
+	if *certFile != "" && *addr == "" {
+		return fmt.Errorf("TLS is only served on the TCP address")
+	}
+
+	var log io.Writer
+	switch *accessLog {
+	case "":
+	case "-":
+		log = os.Stderr
+	default:
+		f, err := os.OpenFile(*accessLog, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		log = f
+	}
+
+	listeners := []server.Listener{}
+	defer func() {
+		for _, l := range listeners {
+			l.Close()
+		}
+	}()
+	if *addr != "" {
+		l, err := net.Listen("tcp", *addr)
+		if err != nil {
+			return err
+		}
+		listeners = append(listeners, server.Listener{Listener: l, CertFile: *certFile, KeyFile: *keyFile})
+	}
+	if *socket != "" {
+		l, err := server.ListenUnix(*socket)
+		if err != nil {
+			return err
+		}
+		listeners = append(listeners, server.Listener{Listener: l})
+	}
+	for _, l := range listeners {
+		fmt.Fprintln(os.Stderr, "Serving on", l.Addr().Network(), l.Addr())
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	conf := server.Config{
+		AccessLog: log,
+		Limits: server.Limits{
+			MaxRows:  *maxRows,
+			MaxCols:  *maxCols,
+			MaxSteps: *maxSteps,
+			Budget:   bender.Budget{CPUTime: *budgetCPU, Memory: *budgetMemory, Steps: *budgetSteps},
+			Rate:     *rate,
+			Burst:    *burst,
+		},
+	}
+	return server.NewService(conf).Serve(ctx, listeners, *shutdownTimeout)
+}
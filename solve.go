@@ -0,0 +1,45 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"io"
+	"os"
+
+	"bender/internal/bender"
+)
+
+// runSolve runs the solve subcommand with the given arguments:
+// it simulates a map and prints the answer of the CodinGame puzzle, a direction per line or LOOP
+func runSolve(args []string) error {
+	flags := flag.NewFlagSet("solve", flag.ContinueOnError)
+	mapFile := flags.String("map", "", "file of the map to solve, as text rows or JSON, - reads it from stdin (default the built-in map)")
+	maxSteps := flags.Int("max-steps", 0, "stop the simulation after the given number of steps (0 means no limit)")
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+	if err := noArgs(flags); err != nil {
+		return err
+	}
+	plan, err := loadPlan(*mapFile, os.Stdin)
+	if err != nil {
+		return err
+	}
+	return solve(os.Stdout, plan, *maxSteps)
+}
+
+// solve simulates the map and writes the answer of the puzzle, the simulation is unlimited if maxSteps is 0
+func solve(w io.Writer, plan []string, maxSteps int) error {
+	opts := []bender.Option{}
+	if maxSteps > 0 {
+		opts = append(opts, bender.WithMaxSteps(maxSteps))
+	}
+	res, err := bender.Run(plan, opts...)
+	if err != nil {
+		return err
+	}
+	return writeAnswer(w, res)
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestSolve(t *testing.T) {
+	testCases := []struct {
+		name     string
+		plan     []string
+		maxSteps int
+		expected string
+		err      bool
+	}{
+		{name: "reached", plan: []string{"#####", "#@  #", "#  $#", "#####"}, expected: "SOUTH\nEAST\nEAST\n"},
+		{name: "loop", plan: []string{"#####", "#@EW#", "#####"}, expected: "LOOP\n"},
+		{name: "step limit", plan: []string{"#####", "#@  #", "#  $#", "#####"}, maxSteps: 2, err: true},
+		{name: "no start", plan: []string{"####", "# $#", "####"}, err: true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			out := &bytes.Buffer{}
+			err := solve(out, tc.plan, tc.maxSteps)
+			if (err != nil) != tc.err {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if out.String() != tc.expected {
+				t.Fatalf("Wrong answer. Expected %q, got %q", tc.expected, out.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"image/color"
+	"io"
+	"os"
+	"strconv"
+
+	"bender/internal/analysis"
+	"bender/internal/bender"
+	"bender/internal/fsm"
+	"bender/internal/render"
+)
+
+// outcomeColors are the colors of the starts in the overlay by outcome, the other outcomes are gray
+var outcomeColors = map[bender.Outcome]color.RGBA{
+	bender.Reached: {0x2e, 0x8b, 0x57, 0xff},
+	bender.Loop:    {0xff, 0x8c, 0x00, 0xff},
+	bender.Died:    {0xdc, 0x14, 0x3c, 0xff},
+}
+
+// runStarts runs the starts subcommand with the given arguments:
+// it simulates a map from every floor cell and prints the matrix of the outcomes
+func runStarts(args []string) error {
+	flags := flag.NewFlagSet("starts", flag.ContinueOnError)
+	mapFile := flags.String("map", "", "file of the map to analyze, as text rows or JSON, - reads it from stdin (default the built-in map)")
+	maxSteps := flags.Int("max-steps", 0, "maximum number of steps of every simulation, unlimited if 0")
+	svg := flags.String("svg", "", "SVG file to write the outcomes overlaid on the board to, like starts.svg")
+	themeConf := flags.String("theme", "classic", "look of the tiles of the SVG image: classic, unicode, emoji, roguelike or a theme file like mine.json")
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+	if err := noArgs(flags); err != nil {
+		return err
+	}
+	plan, err := loadPlan(*mapFile, os.Stdin)
+	if err != nil {
+		return err
+	}
+	theme, err := render.ParseTheme(*themeConf)
+	if err != nil {
+		return err
+	}
+	// the simulations of the variants share their configurations
+	memo := bender.NewMemo()
+	opts := []bender.Option{bender.WithMemo(memo)}
+	if *maxSteps > 0 {
+		opts = append(opts, bender.WithMaxSteps(*maxSteps))
+	}
+	starts, err := analysis.Starts(plan, opts...)
+	if err != nil {
+		return err
+	}
+	printMemoStats(memo)
+	if *svg != "" {
+		f, err := os.Create(*svg)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		if err := render.Overlay(f, plan, startMarks(starts), theme); err != nil {
+			return err
+		}
+	}
+	return printStarts(os.Stdout, plan, starts)
+}
+
+// startMarks returns the marks of the starts colored by outcome, with the outcome and the steps as tooltip
+func startMarks(starts []analysis.Start) map[fsm.Pair]render.Mark {
+	marks := make(map[fsm.Pair]render.Mark, len(starts))
+	for _, s := range starts {
+		c, known := outcomeColors[s.Outcome]
+		if !known {
+			c = color.RGBA{0x80, 0x80, 0x80, 0xff}
+		}
+		marks[s.At] = render.Mark{Color: c, Opacity: 0.6, Title: fmt.Sprintf("%s %s in %d steps", s.At, s.Outcome, s.Steps)}
+	}
+	return marks
+}
+
+// startCell returns the text of the start in the matrix:
+// the number of steps to the booth, L for a loop, D for a death and ? for the simulations stopped by a limit
+func startCell(s analysis.Start) string {
+	switch s.Outcome {
+	case bender.Reached:
+		return strconv.Itoa(s.Steps)
+	case bender.Loop:
+		return "L"
+	case bender.Died:
+		return "D"
+	}
+	return "?"
+}
+
+// printStarts prints the matrix of the starts over the plan, the other cells keep their tile
+func printStarts(w io.Writer, plan []string, starts []analysis.Start) error {
+	cells := make(map[fsm.Pair]string, len(starts))
+	width := 1
+	for _, s := range starts {
+		cells[s.At] = startCell(s)
+		if len(cells[s.At]) > width {
+			width = len(cells[s.At])
+		}
+	}
+	bw := bufio.NewWriter(w)
+	for y, row := range plan {
+		for x := 0; x < len(row); x++ {
+			c, start := cells[fsm.Pair{X: x, Y: y}]
+			if !start {
+				c = string(row[x])
+			}
+			fmt.Fprintf(bw, "%*s", width+1, c)
+		}
+		fmt.Fprintln(bw)
+	}
+	fmt.Fprintln(bw, "steps to the booth, L: loop, D: death, ?: limit exceeded")
+	return bw.Flush()
+}
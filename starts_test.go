@@ -0,0 +1,32 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"bender/internal/analysis"
+	"bender/internal/bender"
+	"bender/internal/fsm"
+)
+
+func TestPrintStarts(t *testing.T) {
+	plan := []string{"######", "#@  $#", "######"}
+	starts := []analysis.Start{
+		{At: fsm.Pair{X: 1, Y: 1}, Outcome: bender.Reached, Steps: 3},
+		{At: fsm.Pair{X: 2, Y: 1}, Outcome: bender.Loop, Steps: 20},
+		{At: fsm.Pair{X: 3, Y: 1}, Outcome: bender.StepLimitExceeded, Steps: 10},
+	}
+	buf := &bytes.Buffer{}
+	if err := printStarts(buf, plan, starts); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected := " # # # # # #\n # 3 L ? $ #\n # # # # # #\nsteps to the booth, L: loop, D: death, ?: limit exceeded\n"
+	if buf.String() != expected {
+		t.Fatalf("Wrong matrix. Expected %q, got %q", expected, buf.String())
+	}
+
+	marks := startMarks(starts)
+	if len(marks) != 3 || marks[fsm.Pair{X: 1, Y: 1}].Color != outcomeColors[bender.Reached] || marks[fsm.Pair{X: 2, Y: 1}].Title != "(2,1) loop in 20 steps" {
+		t.Fatalf("Wrong marks %+v", marks)
+	}
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"bender/internal/bender"
+	"bender/internal/compress"
+	"bender/internal/fsm"
+	"bender/internal/publish"
+	"bender/internal/render"
+)
+
+// readMaps reads a stream of maps, possibly gzip compressed, and calls the given function for every map as soon as it's read
+// with the "blank" framing the maps are separated by blank lines,
+// with the "length" framing every map is preceded by a line with its number of rows and columns, like in the puzzle,
+// the short rows are padded with floors to the number of columns and the longer ones are rejected
+func readMaps(r io.Reader, framing string, fn func(plan []string) error) error {
+	zr, err := compress.NewReader(r)
+	if err != nil {
+		return err
+	}
+	sc := bufio.NewScanner(zr)
+	sc.Buffer(make([]byte, 0, 64*1024), 1<<30)
+	switch framing {
+	case "blank":
+		plan := []string{}
+		for sc.Scan() {
+			line := strings.TrimRight(sc.Text(), "\r")
+			if strings.TrimSpace(line) != "" {
+				plan = append(plan, line)
+				continue
+			}
+			if len(plan) > 0 {
+				if err := fn(plan); err != nil {
+					return err
+				}
+				plan = []string{}
+			}
+		}
+		if err := sc.Err(); err != nil {
+			return err
+		}
+		if len(plan) > 0 {
+			return fn(plan)
+		}
+		return nil
+	case "length":
+		for sc.Scan() {
+			header := strings.TrimSpace(sc.Text())
+			if header == "" {
+				continue
+			}
+			var rows, cols int
+			if _, err := fmt.Sscanf(header, "%d %d", &rows, &cols); err != nil || rows < 0 || cols < 0 {
+				return fmt.Errorf("bad map header %q, expected the number of rows and columns", header)
+			}
+			plan := make([]string, 0, rows)
+			for len(plan) < rows && sc.Scan() {
+				// the columns are the cells, the glyphs drawn with several bytes are decoded
+				row := fsm.DecodePlan([]string{strings.TrimRight(sc.Text(), "\r")})[0]
+				n := utf8.RuneCountInString(row)
+				if n > cols {
+					return fmt.Errorf("row %d of %d columns, expected %d", len(plan)+1, n, cols)
+				}
+				// the editors strip the trailing spaces, the header gives the columns
+				plan = append(plan, row+strings.Repeat(" ", cols-n))
+			}
+			if len(plan) < rows {
+				return fmt.Errorf("truncated map: %d row(s) out of %d", len(plan), rows)
+			}
+			if err := fn(plan); err != nil {
+				return err
+			}
+		}
+		return sc.Err()
+	}
+	return fmt.Errorf("unknown framing %q", framing)
+}
+
+// streamConf is the configuration of a stream of maps
+type streamConf struct {
+	// separation of the maps
+	framing string
+	// format of the results
+	format string
+	// labels of the directions
+	labels render.Labels
+	// events the results are published to, nil to publish nothing
+	events *publish.Events
+}
+
+// runStream simulates every map of the stream and writes its result as soon as it's simulated
+// with the text format it's a line with the number of the map, the outcome and the path separated by tabs,
+// or the error of the map, with the json and cbor formats it's a report
+func runStream(r io.Reader, w io.Writer, conf streamConf, opts ...bender.Option) error {
+	n := 0
+	return readMaps(r, conf.framing, func(plan []string) error {
+		n++
+		res, err := bender.Run(plan, opts...)
+		if conf.events != nil {
+			if perr := conf.events.Result(n, res, err); perr != nil {
+				return perr
+			}
+		}
+		if conf.format != "text" {
+			rep := report{Error: fmtError(err)}
+			if err == nil {
+				rep = newReport(res, conf.labels, nil)
+			}
+			rep.Map = n
+			return writeReport(w, conf.format, rep)
+		}
+		if err != nil {
+			_, werr := fmt.Fprintf(w, "%d\terror\t%s\n", n, strconv.Quote(err.Error()))
+			return werr
+		}
+		_, werr := fmt.Fprintf(w, "%d\t%s\t%s\n", n, res.Outcome, strings.Join(conf.labels.Path(res.ClassicPath()), " "))
+		return werr
+	})
+}
+
+// fmtError returns the message of the error, empty if nil
+func fmtError(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
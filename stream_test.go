@@ -0,0 +1,140 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+
+	"bender/internal/compress"
+	"bender/internal/publish"
+	"bender/internal/render"
+)
+
+func TestReadMaps(t *testing.T) {
+	testCases := []struct {
+		name     string
+		framing  string
+		input    string
+		expected [][]string
+		err      bool
+	}{
+		{
+			name:     "blank lines",
+			framing:  "blank",
+			input:    "\n###\n#@#\n\n  \n##\r\n#$\r\n",
+			expected: [][]string{{"###", "#@#"}, {"##", "#$"}},
+		},
+		{
+			name:     "length prefix",
+			framing:  "length",
+			input:    "2 3\n###\n   \n\n1 2\n#$\n",
+			expected: [][]string{{"###", "   "}, {"#$"}},
+		},
+		{
+			name:     "short rows padded",
+			framing:  "length",
+			input:    "3 4\n####\n#@$\n\n",
+			expected: [][]string{{"####", "#@$ ", "    "}},
+		},
+		{
+			name:     "unicode rows",
+			framing:  "length",
+			input:    "2 4\n┌──┐\n│@\n",
+			expected: [][]string{{"####", "#@  "}},
+		},
+		{
+			name:     "long row",
+			framing:  "length",
+			input:    "2 3\n###\n####\n",
+			expected: [][]string{},
+			err:      true,
+		},
+		{
+			name:     "truncated map",
+			framing:  "length",
+			input:    "3 3\n###\n",
+			expected: [][]string{},
+			err:      true,
+		},
+		{
+			name:     "bad header",
+			framing:  "length",
+			input:    "###\n",
+			expected: [][]string{},
+			err:      true,
+		},
+		{
+			name:     "unknown framing",
+			framing:  "json",
+			expected: [][]string{},
+			err:      true,
+		},
+	}
+
+	for _, tc := range testCases {
+		plans := [][]string{}
+		err := readMaps(strings.NewReader(tc.input), tc.framing, func(plan []string) error {
+			plans = append(plans, plan)
+			return nil
+		})
+		if (err != nil) != tc.err {
+			t.Fatalf("Test case %q: unexpected error %v", tc.name, err)
+		}
+		if !reflect.DeepEqual(plans, tc.expected) {
+			t.Fatalf("Test case %q: wrong maps. Expected %q, got %q", tc.name, tc.expected, plans)
+		}
+	}
+}
+
+func TestRunStream(t *testing.T) {
+	input := "#####\n#@  #\n#  $#\n#####\n\n#####\n#@#$#\n#####\n\n###\n#T@\n###\n"
+	buf := &bytes.Buffer{}
+	if err := runStream(strings.NewReader(input), buf, streamConf{framing: "blank", format: "text", labels: render.LetterLabels}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected := "1\treached\tS E E\n" +
+		"2\tdied\t\n" +
+		"3\terror\t\"2:2: teleport 'T' appears 1 time(s), expected exactly 2\"\n"
+	if buf.String() != expected {
+		t.Fatalf("Wrong output. Expected:\n%s\ngot:\n%s", expected, buf.String())
+	}
+}
+
+// recorder records the published messages
+type recorder []string
+
+func (r *recorder) Publish(subject string, data []byte) error {
+	*r = append(*r, subject+" "+string(data))
+	return nil
+}
+
+func TestRunStreamCompressed(t *testing.T) {
+	gz, err := compress.Compress("maps.gz", []byte("###\n#@$\n###\n"))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	buf := &bytes.Buffer{}
+	if err := runStream(bytes.NewReader(gz), buf, streamConf{framing: "blank", format: "text", labels: render.LetterLabels}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if expected := "1\treached\tE\n"; buf.String() != expected {
+		t.Fatalf("Wrong output. Expected %q, got %q", expected, buf.String())
+	}
+}
+
+func TestRunStreamPublish(t *testing.T) {
+	input := "###\n#@$\n###\n\n###\n#T@\n###\n"
+	rec := &recorder{}
+	if err := runStream(strings.NewReader(input), io.Discard, streamConf{framing: "blank", format: "text", labels: render.LetterLabels, events: publish.NewEvents(rec, "maps")}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected := &recorder{
+		`maps.results {"run":1,"outcome":"reached","path":["EAST"]}`,
+		`maps.results {"run":2,"outcome":"","path":[],"error":"2:2: teleport 'T' appears 1 time(s), expected exactly 2"}`,
+	}
+	if !reflect.DeepEqual(rec, expected) {
+		t.Fatalf("Wrong messages. Expected %q, got %q", *expected, *rec)
+	}
+}
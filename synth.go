@@ -0,0 +1,51 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"bender/internal/analysis"
+	"bender/internal/render"
+)
+
+// runSynth runs the synth subcommand with the given arguments:
+// it searches a map whose simulation is the given path, to build puzzles and tests
+func runSynth(args []string) error {
+	flags := flag.NewFlagSet("synth", flag.ContinueOnError)
+	pathConf := flags.String("path", "", "path to produce, directions separated by spaces or commas like \"S S E E\"")
+	width := flags.Int("width", 8, "number of columns of the map, frame included")
+	height := flags.Int("height", 8, "number of rows of the map, frame included")
+	labelConf := flags.String("labels", "words", "direction labels accepted in the path besides the names and their first letter: words, letters, arrows or a list like SOUTH=S,NORTH=N")
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+	if err := noArgs(flags); err != nil {
+		return err
+	}
+	labels, err := render.ParseLabels(*labelConf)
+	if err != nil {
+		return err
+	}
+	return synth(os.Stdout, *pathConf, *width, *height, labels)
+}
+
+// synth prints the map whose simulation is the path
+func synth(w io.Writer, conf string, width, height int, labels render.Labels) error {
+	path, err := parsePath(conf, labels)
+	if err != nil {
+		return err
+	}
+	plan, err := analysis.Synthesize(path, width, height)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(w, strings.Join(plan, "\n"))
+	return err
+}
@@ -0,0 +1,29 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"bender/internal/bender"
+	"bender/internal/render"
+)
+
+func TestSynth(t *testing.T) {
+	buf := &bytes.Buffer{}
+	if err := synth(buf, "E,e ↑ NORTH", 7, 7, render.ArrowLabels); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	plan := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	res, err := bender.Run(plan)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if expected := "EAST EAST NORTH NORTH"; strings.Join(res.Path, " ") != expected || res.Outcome != bender.Reached {
+		t.Fatalf("Wrong synthesized map:\n%s\nExpected %s, got %v %v", buf.String(), expected, res.Outcome, res.Path)
+	}
+
+	if err := synth(buf, "E up", 7, 7, nil); err == nil {
+		t.Fatalf("Expected an error for an unknown direction")
+	}
+}
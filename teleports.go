@@ -0,0 +1,57 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"bender/internal/analysis"
+)
+
+// runTeleports runs the teleports subcommand with the given arguments:
+// it reports the unreachable regions and the teleport ping-pongs of a map without simulating it
+func runTeleports(args []string) error {
+	flags := flag.NewFlagSet("teleports", flag.ContinueOnError)
+	mapFile := flags.String("map", "", "file of the map to analyze, as text rows or JSON, - reads it from stdin (default the built-in map)")
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+	if err := noArgs(flags); err != nil {
+		return err
+	}
+	plan, err := loadPlan(*mapFile, os.Stdin)
+	if err != nil {
+		return err
+	}
+	g, err := analysis.Teleports(plan)
+	if err != nil {
+		return err
+	}
+	return teleports(os.Stdout, g)
+}
+
+// teleports prints the graph of the regions, the regions are numbered from 1
+func teleports(w io.Writer, g analysis.TeleportGraph) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "%d region(s), %d teleport pair(s)\n", len(g.Regions), len(g.Links))
+	for _, l := range g.Links {
+		fmt.Fprintf(bw, "teleports %s and %s link the regions %d and %d\n", l.A, l.B, l.RegionA+1, l.RegionB+1)
+	}
+	for _, i := range g.Unreachable {
+		r := g.Regions[i]
+		fmt.Fprintf(bw, "region %d of %d cell(s) from %s is unreachable\n", i+1, len(r.Cells), r.Cells[0])
+	}
+	if !g.BoothReachable() {
+		fmt.Fprintln(bw, "the booth is unreachable")
+	}
+	for _, p := range g.PingPongs {
+		fmt.Fprintf(bw, "ping-pong: walking %s into %s leads out of %s towards it again\n", p.Direction, p.Enter, p.Exit)
+	}
+	return bw.Flush()
+}
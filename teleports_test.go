@@ -0,0 +1,28 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"bender/internal/analysis"
+)
+
+func TestTeleports(t *testing.T) {
+	g, err := analysis.Teleports([]string{"#######", "#@ #$ #", "#T T# #", "#######"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	buf := &bytes.Buffer{}
+	if err := teleports(buf, g); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected := "2 region(s), 1 teleport pair(s)\n" +
+		"teleports (1,2) and (3,2) link the regions 1 and 1\n" +
+		"region 2 of 3 cell(s) from (4,1) is unreachable\n" +
+		"the booth is unreachable\n" +
+		"ping-pong: walking EAST into (3,2) leads out of (1,2) towards it again\n" +
+		"ping-pong: walking WEST into (1,2) leads out of (3,2) towards it again\n"
+	if buf.String() != expected {
+		t.Fatalf("Wrong report. Expected %q, got %q", expected, buf.String())
+	}
+}
@@ -0,0 +1,409 @@
+// Package v1 is the supported API of the Bender simulator.
+//
+// The identifiers of this package are stable: within v1 they are never removed
+// nor changed in an incompatible way. The implementation lives in the internal
+// packages which may change at any time, only what is reachable from here is supported.
+package v1
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"bender/internal/analysis"
+	"bender/internal/bender"
+	"bender/internal/fsm"
+	"bender/internal/replay"
+)
+
+// directions of Bender, untyped so they're both Directions and the strings of the paths
+const (
+	SOUTH = "SOUTH"
+	NORTH = "NORTH"
+	EAST  = "EAST"
+	WEST  = "WEST"
+)
+
+// Direction is a cardinal direction, the event of the transitions of the machine
+type Direction = fsm.Direction
+
+// ParseDirection returns the direction of the given name, the error wraps ErrUnknownDirection for the other names
+func ParseDirection(s string) (Direction, error) {
+	return fsm.ParseDirection(s)
+}
+
+// LOOP indicator of the classic output
+const LOOP = bender.LOOP
+
+// Outcome tells how a simulation ended
+type Outcome = bender.Outcome
+
+// outcomes of a simulation
+const (
+	Interrupted       = bender.Interrupted
+	Reached           = bender.Reached
+	Loop              = bender.Loop
+	Died              = bender.Died
+	BudgetExceeded    = bender.BudgetExceeded
+	StepLimitExceeded = bender.StepLimitExceeded
+	TimeLimitExceeded = bender.TimeLimitExceeded
+	Escaped           = bender.Escaped
+)
+
+// resources of a budget, reported by the results exceeding it
+const (
+	ResourceCPUTime = bender.ResourceCPUTime
+	ResourceMemory  = bender.ResourceMemory
+	ResourceSteps   = bender.ResourceSteps
+)
+
+// Pair is a pair of coordinates on the board
+type Pair = fsm.Pair
+
+// StateID identifies a state visited by Bender, its tile and its position
+type StateID = fsm.StateID
+
+// Board is a read-only map of states
+type Board = fsm.Board
+
+// Annotations are the key/value pairs attached to the cells of a board
+type Annotations = fsm.Annotations
+
+// FSM is the state machine moving Bender on the board
+type FSM = fsm.FSM
+
+// Event is the transition of the machine passed to the callbacks
+type Event = fsm.Event
+
+// Callback is called on the transitions of the machine
+type Callback = fsm.Callback
+
+// Notification is the copy of an event sent to the subscribers of the machine
+type Notification = fsm.Notification
+
+// Middleware wraps the callbacks of the machine, added with FSM.Use
+type Middleware = fsm.Middleware
+
+// Simulator simulates the decisions of Bender
+type Simulator = bender.BenderSimulator
+
+// TileRule is the effect of an entered tile on Bender, registered with FSM.OnEnter and EnterRule
+type TileRule = bender.TileRule
+
+// rules of the tiles to register on a machine with FSM.OnBefore and FSM.OnEnter to add kinds of tiles
+var (
+	Obstacle          = bender.Obstacle
+	BreakableObstacle = bender.BreakableObstacle
+	EnterRule         = bender.EnterRule
+)
+
+// Result is the result of a simulation
+type Result = bender.Result
+
+// Step is a move of Bender made by Stepper.Next
+type Step = bender.Step
+
+// Stepper runs a simulation one step at a time
+type Stepper = bender.Stepper
+
+// Destruction is a breakable wall destroyed by Bender
+type Destruction = bender.Destruction
+
+// Escape is the step which would have taken Bender out of the board
+type Escape = bender.Escape
+
+// BreakerRules are the variants of the breaker mode, the zero value is the rules of the puzzle
+type BreakerRules = bender.BreakerRules
+
+// BREAK entry of the path before the steps destroying a wall, recorded with BreakerRules.RecordBreaks
+const BREAK = bender.BREAK
+
+// Invariant is a property of the simulation checked after every step with WithInvariants
+type Invariant = bender.Invariant
+
+// InvariantError is the violation of an invariant, with the state of the simulation
+type InvariantError = bender.InvariantError
+
+// Invariants are the properties of the rules of Bender
+var Invariants = bender.Invariants
+
+// errors wrapped by the returned errors, to be tested with errors.Is
+var (
+	ErrNoStart          = fsm.ErrNoStart
+	ErrBadTeleports     = fsm.ErrBadTeleports
+	ErrInvalidSymbol    = fsm.ErrInvalidSymbol
+	ErrOutOfBounds      = fsm.ErrOutOfBounds
+	ErrUnknownDirection = fsm.ErrUnknownDirection
+	ErrNoUndo           = fsm.ErrNoUndo
+	ErrLoop             = bender.ErrLoop
+)
+
+// Option configures a simulation
+type Option = bender.Option
+
+// Budget bounds the resources used by a simulation
+type Budget = bender.Budget
+
+// Stats are the statistics of a running simulation
+type Stats = bender.Stats
+
+// Memo memoizes the results of the simulations across runs
+type Memo = bender.Memo
+
+// MemoStats are the statistics of a memo
+type MemoStats = bender.MemoStats
+
+// Engine reruns a simulation incrementally after single tile edits of the map
+type Engine = bender.Engine
+
+// Branch is an alternative continuation of a simulation
+type Branch = bender.Branch
+
+// BranchResult is the result of a branch
+type BranchResult = bender.BranchResult
+
+// Toggle is a what-if edit of a cell of the map and its effect on the simulation
+type Toggle = analysis.Toggle
+
+// WhatIfReport is the effect of the edits of every cell of a map, the most impactful first
+type WhatIfReport = analysis.WhatIfReport
+
+// Fix is a set of edits making a looping map terminate with Bender in the booth
+type Fix = analysis.Fix
+
+// Edit is the change of a cell of the map
+type Edit = analysis.Edit
+
+// Start is the simulation of the map from a starting cell
+type Start = analysis.Start
+
+// Breakable is the use of a breakable wall by the simulation of the map
+type Breakable = analysis.Breakable
+
+// BreakerReport is the use of the breakable walls of a map
+type BreakerReport = analysis.BreakerReport
+
+// TeleportGraph is the connectivity of the regions of a map through its teleports
+type TeleportGraph = analysis.TeleportGraph
+
+// Run simulates Bender on the given map
+func Run(plan []string, opts ...Option) (Result, error) {
+	return bender.Run(plan, opts...)
+}
+
+// RunContext simulates Bender on the given map until the context is done, the outcome is Interrupted then
+func RunContext(ctx context.Context, plan []string, opts ...Option) (Result, error) {
+	return bender.RunContext(ctx, plan, opts...)
+}
+
+// NewStepper returns the stepper simulating Bender on the given map, every call to Next makes a move:
+//
+//	for step, ok := s.Next(); ok; step, ok = s.Next() { ... }
+//
+// the time limit and the budget apply to the whole simulation, the time between the steps isn't counted
+func NewStepper(plan []string, opts ...Option) (*Stepper, error) {
+	return bender.NewStepper(plan, opts...)
+}
+
+// Resume continues the simulation done by the given machine and simulator
+func Resume(f *FSM, s *Simulator, opts ...Option) (Result, error) {
+	return bender.Resume(f, s, opts...)
+}
+
+// NewMemo returns an empty memo
+func NewMemo() *Memo {
+	return bender.NewMemo()
+}
+
+// NewEngine returns an engine simulating the given map, call its Run method first
+func NewEngine(plan []string, opts ...Option) *Engine {
+	return bender.NewEngine(plan, opts...)
+}
+
+// WhatIf edits every cell of the map in turn and simulates the edited maps
+func WhatIf(plan []string, opts ...Option) (WhatIfReport, error) {
+	return analysis.WhatIf(plan, opts...)
+}
+
+// Advise returns the fixes of a looping map, the smallest first
+func Advise(plan []string, opts ...Option) ([]Fix, error) {
+	return analysis.Advise(plan, opts...)
+}
+
+// Synthesize searches a map of the given size whose simulation is exactly the given path, it's experimental
+func Synthesize(path []string, width, height int) ([]string, error) {
+	return analysis.Synthesize(path, width, height)
+}
+
+// Starts simulates the map from every floor cell, row by row
+func Starts(plan []string, opts ...Option) ([]Start, error) {
+	return analysis.Starts(plan, opts...)
+}
+
+// Breakers tells which breakable walls of the map must be destroyed to reach the booth
+func Breakers(plan []string, opts ...Option) (BreakerReport, error) {
+	return analysis.Breakers(plan, opts...)
+}
+
+// Teleports analyzes the regions and the teleports of the map without simulating it
+func Teleports(plan []string) (TeleportGraph, error) {
+	return analysis.Teleports(plan)
+}
+
+// Fork returns an independent copy of the simulation which can run in another goroutine
+func Fork(f *FSM, s *Simulator) (*FSM, *Simulator) {
+	return bender.Fork(f, s)
+}
+
+// Explore forks the simulation for every branch and runs the branches concurrently
+func Explore(f *FSM, s *Simulator, branches []Branch) []BranchResult {
+	return bender.Explore(f, s, branches)
+}
+
+// NewBoard returns the immutable board of the given map
+func NewBoard(plan []string) Board {
+	return fsm.NewBoard(plan)
+}
+
+// NewPackedBoard returns the immutable board of the given map storing a state in 4 bits
+// it's meant for very large maps, the unknown tiles are rejected
+func NewPackedBoard(plan []string) (Board, error) {
+	return fsm.NewPackedBoard(plan)
+}
+
+// MappedBoard is a board read from a map file mapped in memory
+type MappedBoard = fsm.MappedBoard
+
+// OpenMappedBoard maps the text map file in memory, for boards too large to be held as strings
+// the board must be closed once the simulation is done
+func OpenMappedBoard(name string) (*MappedBoard, error) {
+	return fsm.OpenMappedBoard(name)
+}
+
+// Validate checks that the map is a well formed puzzle: a single start, a booth, no teleport or a pair,
+// rectangular rows closed by walls and only the tiles of the game, the errors give the row and the column
+func Validate(plan []string) error {
+	return fsm.Validate(plan)
+}
+
+// NewFSM returns the machine applying the rules of Bender on the given board
+// the board can be shared by several machines, it holds the tiles of the game and the given new kinds of tiles
+func NewFSM(board Board, tiles ...byte) (*FSM, error) {
+	return fsm.NewFSMFromBoard(board, bender.BeforeCallback, bender.EnterCallback, tiles...)
+}
+
+// NewCustomFSM returns the machine calling the given callbacks instead of the rules of Bender,
+// to move on the boards with other rules: before is called before entering a state and may cancel the transition,
+// enter once it's entered, the board holds the tiles of the game and the given new kinds of tiles
+func NewCustomFSM(board Board, before, enter Callback, tiles ...byte) (*FSM, error) {
+	return fsm.NewFSMFromBoard(board, before, enter, tiles...)
+}
+
+// Annotate returns the board carrying the given annotations, read by the callbacks with Event.Annotation
+func Annotate(board Board, a Annotations) Board {
+	return fsm.Annotate(board, a)
+}
+
+// AnnotationsOf returns the annotations carried by the board, nil if none
+func AnnotationsOf(board Board) Annotations {
+	return fsm.AnnotationsOf(board)
+}
+
+// WalkableCells returns the number of cells of the board Bender can enter
+func WalkableCells(board Board) int {
+	return fsm.WalkableCells(board)
+}
+
+// NewSimulator returns the simulator of Bender for the given map
+// the simulator no longer depends on the map, the parameter is kept for compatibility
+func NewSimulator(plan []string) *Simulator {
+	return bender.NewBenderSimulator()
+}
+
+// NewBoardSimulator returns the simulator of Bender for the given board
+// like NewSimulator, the board is kept for compatibility
+func NewBoardSimulator(board Board) *Simulator {
+	return bender.NewBenderSimulator()
+}
+
+// WithDirector asks the direction of every step to the given function instead of the priorities of Bender,
+// the rules of the tiles still apply
+func WithDirector(direct func(f *FSM, s *Simulator) (string, error)) Option {
+	return bender.WithDirector(func(f *FSM, s *Simulator) (Direction, error) {
+		d, err := direct(f, s)
+		return Direction(d), err
+	})
+}
+
+// WithBreakerRules makes the simulation follow the given variants of the breaker mode
+func WithBreakerRules(r BreakerRules) Option {
+	return bender.WithBreakerRules(r)
+}
+
+// WithInvariants aborts the simulation with an InvariantError at the first violation of the given invariants
+func WithInvariants(invariants ...Invariant) Option {
+	return bender.WithInvariants(invariants...)
+}
+
+// WithMaxSteps stops the simulation after the given number of steps
+func WithMaxSteps(n int) Option {
+	return bender.WithMaxSteps(n)
+}
+
+// WithTimeout stops the simulation after the given duration
+func WithTimeout(d time.Duration) Option {
+	return bender.WithTimeout(d)
+}
+
+// WithEventHook calls the hook after every event
+// the simulation is aborted with the error returned by the hook
+func WithEventHook(hook func() error) Option {
+	return bender.WithEventHook(hook)
+}
+
+// WithContext stops the simulation once the context is done
+func WithContext(ctx context.Context) Option {
+	return bender.WithContext(ctx)
+}
+
+// WithBudget stops the simulation once it used more resources than the budget
+func WithBudget(b Budget) Option {
+	return bender.WithBudget(b)
+}
+
+// WithStats reports the statistics of the simulation to the given function periodically
+func WithStats(interval time.Duration, report func(Stats)) Option {
+	return bender.WithStats(interval, report)
+}
+
+// WithMemo completes the simulation from the given memo once it reaches a configuration already simulated
+func WithMemo(m *Memo) Option {
+	return bender.WithMemo(m)
+}
+
+// Replay is a recorded simulation read without decoding all its steps
+type Replay = replay.Replay
+
+// ReplayStep is a step of a replay
+type ReplayStep = replay.Step
+
+// Timeline materializes the frames of a replay at any step, for the scrubbers
+type Timeline = replay.Timeline
+
+// Frame is the state of a recorded run after a number of steps
+type Frame = replay.Frame
+
+// NewTimeline returns the timeline of the replay with a keyframe every interval steps, 256 if interval isn't positive
+func NewTimeline(r *Replay, interval int) *Timeline {
+	return replay.NewTimeline(r, interval)
+}
+
+// OpenReplay opens the replay file, it's mapped in memory unless it's compressed or in the text format
+func OpenReplay(name string) (*Replay, error) {
+	return replay.Open(name)
+}
+
+// WriteTextReplay writes the replay in the canonical text format, a step per line, made to be diffed in version control
+func WriteTextReplay(w io.Writer, r *Replay) error {
+	return replay.WriteText(w, r)
+}
@@ -0,0 +1,99 @@
+package v1
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRun(t *testing.T) {
+	plan := []string{
+		"#####",
+		"#@  #",
+		"#  $#",
+		"#####",
+	}
+	res, err := Run(plan)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected := Result{Outcome: Reached, Path: []string{SOUTH, EAST, EAST}, Steps: 3, Position: Pair{X: 3, Y: 2}, Destroyed: []Destruction{}}
+	if !reflect.DeepEqual(res, expected) {
+		t.Fatalf("Wrong result. Expected %+v, got %+v", expected, res)
+	}
+
+	board := NewBoard(plan)
+	f, err := NewFSM(board)
+	if err != nil {
+		t.Fatalf("Failed to create the FSM: %v", err)
+	}
+	s := NewSimulator(plan)
+	res, err = Resume(f, s, WithMaxSteps(2))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if res.Outcome != StepLimitExceeded || !reflect.DeepEqual(res.Path, expected.Path[:2]) {
+		t.Fatalf("Wrong partial result, got %+v", res)
+	}
+	res, err = Resume(f, s)
+	if err != nil || !reflect.DeepEqual(res, expected) {
+		t.Fatalf("Wrong resumed result, got %+v, %v", res, err)
+	}
+
+	name := filepath.Join(t.TempDir(), "map.txt")
+	if err := os.WriteFile(name, []byte(strings.Join(plan, "\n")), 0644); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	mb, err := OpenMappedBoard(name)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer mb.Close()
+	if f, err = NewFSM(mb); err != nil {
+		t.Fatalf("Failed to create the FSM: %v", err)
+	}
+	res, err = Resume(f, NewBoardSimulator(mb))
+	if err != nil || !reflect.DeepEqual(res, expected) {
+		t.Fatalf("Wrong result of the mapped board, got %+v, %v", res, err)
+	}
+
+	res, err = Run(plan, WithBudget(Budget{Steps: 1}))
+	if err != nil || res.Outcome != BudgetExceeded || res.Exceeded != ResourceSteps {
+		t.Fatalf("Wrong result over budget, got %+v, %v", res, err)
+	}
+}
+
+func TestCustomFSM(t *testing.T) {
+	board := NewBoard([]string{
+		"#####",
+		"#@ $#",
+		"#####",
+	})
+	var entered []byte
+	// walls stop the moves, the other tiles are floors
+	before := func(e *Event) {
+		if e.Dst == '#' {
+			e.Cancel()
+		}
+	}
+	enter := func(e *Event) {
+		entered = append(entered, e.Dst)
+	}
+	f, err := NewCustomFSM(board, before, enter)
+	if err != nil {
+		t.Fatalf("Failed to create the FSM: %v", err)
+	}
+	for _, dir := range []Direction{EAST, NORTH, EAST, EAST} {
+		if err := f.Event(dir); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+	if string(entered) != " $" || f.Position() != (Pair{X: 3, Y: 1}) {
+		t.Fatalf("Wrong moves. Expected \" $\" at (3,1), got %q at %v", entered, f.Position())
+	}
+	if n := WalkableCells(board); n != 3 {
+		t.Fatalf("Wrong number of walkable cells. Expected 3, got %d", n)
+	}
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"bender/internal/compress"
+	"bender/internal/fsm"
+	"bender/internal/mapfile"
+)
+
+// runValidate runs the validate subcommand with the given arguments:
+// it checks that the map files are well formed puzzles without simulating them, the errors give the file, the row and the column
+func runValidate(args []string) error {
+	flags := flag.NewFlagSet("validate", flag.ContinueOnError)
+	mapFile := flags.String("map", "", "file of the map to check, as text rows or JSON, or a pattern of files like 'maps/*.txt'")
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+	if err := noArgs(flags); err != nil {
+		return err
+	}
+	if *mapFile == "" {
+		return fmt.Errorf("-map is required")
+	}
+	names, err := filepath.Glob(*mapFile)
+	if err != nil {
+		return err
+	}
+	if len(names) == 0 {
+		// not a pattern, the error of the missing file is reported
+		names = []string{*mapFile}
+	}
+	return validateMaps(os.Stdout, names)
+}
+
+// validateMaps writes a line per valid map file and the errors of the invalid ones,
+// an error is returned if any of them is invalid
+func validateMaps(w io.Writer, names []string) error {
+	invalid := 0
+	for _, name := range names {
+		if err := validateMap(name); err != nil {
+			invalid++
+			var perrs fsm.ParseErrors
+			if !errors.As(err, &perrs) {
+				err = fmt.Errorf("%s: %v", name, err)
+			}
+			printError(w, err, nil)
+			continue
+		}
+		fmt.Fprintf(w, "%s: valid\n", name)
+	}
+	if invalid > 0 {
+		return fmt.Errorf("%d invalid map(s)", invalid)
+	}
+	return nil
+}
+
+// validateMap returns the errors of the map file, the parse errors are given the name of the file
+func validateMap(name string) error {
+	data, err := compress.ReadFile(name)
+	if err != nil {
+		return err
+	}
+	plan, err := mapfile.ParsePlan(data)
+	if err == nil {
+		err = fsm.Validate(plan)
+	}
+	return withFile(err, name)
+}
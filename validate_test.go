@@ -0,0 +1,65 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestValidateMaps(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"valid.txt":    "####\n#@$#\n####\n",
+		"teleport.txt": "#####\n#@T$#\n#####\n",
+		"nostart.txt":  "####\n# $#\n####\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+	testCases := []struct {
+		name     string
+		files    []string
+		expected string
+		err      bool
+	}{
+		{name: "valid", files: []string{"valid.txt"}, expected: "valid.txt: valid\n"},
+		{name: "teleport without pair", files: []string{"valid.txt", "teleport.txt"}, expected: "valid.txt: valid\nteleport.txt:2:3: teleport 'T' appears 1 time(s), expected exactly 2\n#@T$#\n  ^\n", err: true},
+		{name: "no start", files: []string{"nostart.txt"}, expected: "nostart.txt: no start @ in the map\n", err: true},
+		{name: "missing", files: []string{"missing.txt"}, err: true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cwd, _ := os.Getwd()
+			if err := os.Chdir(dir); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			defer os.Chdir(cwd)
+			out := &bytes.Buffer{}
+			err := validateMaps(out, tc.files)
+			if (err != nil) != tc.err {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if tc.expected != "" && out.String() != tc.expected {
+				t.Fatalf("Wrong output. Expected %q, got %q", tc.expected, out.String())
+			}
+		})
+	}
+}
+
+func TestRunValidatePattern(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"a.txt", "b.txt"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("####\n#@$#\n####\n"), 0644); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+	if err := runValidate([]string{"-map", filepath.Join(dir, "*.txt")}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := runValidate([]string{"-map", filepath.Join(dir, "missing.txt")}); err == nil {
+		t.Fatalf("Wrong error. Expected an error for the missing file, got nil")
+	}
+}
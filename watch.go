@@ -0,0 +1,287 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"html"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"bender/internal/bender"
+	"bender/internal/compress"
+	"bender/internal/mapfile"
+	"bender/internal/render"
+)
+
+// clearScreen moves the cursor of the terminal to the top left corner and clears the screen
+const clearScreen = "\x1b[H\x1b[2J"
+
+// watchPage is the page of the web UI of the watch mode, it reloads the render on every event
+const watchPage = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>bender watch</title>
+<style>body { margin: 0; } iframe { border: 0; width: 100vw; height: 100vh; }</style>
+</head>
+<body>
+<iframe id="render" src="/render"></iframe>
+<script>
+new EventSource("/events").addEventListener("render", () => {
+	document.getElementById("render").contentWindow.location.reload();
+});
+</script>
+</body>
+</html>
+`
+
+// runWatch runs the watch subcommand with the given arguments:
+// it simulates the map file again every time it's saved and renders the result in the terminal, and in the browser with -addr
+func runWatch(args []string) error {
+	flags := flag.NewFlagSet("watch", flag.ContinueOnError)
+	mapFile := flags.String("map", "", "file of the map to watch, as text rows or JSON")
+	interval := flags.Duration("interval", 200*time.Millisecond, "delay between two checks of the map file")
+	maxSteps := flags.Int("max-steps", 1000000, "stop every simulation after the given number of steps (0 means no limit)")
+	steps := flags.Bool("steps", false, "print the board after every step in the terminal")
+	addr := flags.String("addr", "", "TCP address to serve the web UI on, like localhost:8080, the browsers reload the render on every save")
+	labelConf := flags.String("labels", "words", "direction labels: words, letters, arrows or a list like SOUTH=S,NORTH=N")
+	themeConf := flags.String("theme", "classic", "look of the tiles: classic, unicode, emoji, roguelike or a theme file like mine.json")
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+	if err := noArgs(flags); err != nil {
+		return err
+	}
+	if *mapFile == "" {
+		return fmt.Errorf("-map is required")
+	}
+	if *interval <= 0 {
+		return fmt.Errorf("invalid interval %v", *interval)
+	}
+	labels, err := render.ParseLabels(*labelConf)
+	if err != nil {
+		return err
+	}
+	theme, err := render.ParseTheme(*themeConf)
+	if err != nil {
+		return err
+	}
+	conf := watchConf{steps: *steps, labels: labels, theme: theme}
+	if *maxSteps > 0 {
+		conf.opts = append(conf.opts, bender.WithMaxSteps(*maxSteps))
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	var page *livePage
+	if *addr != "" {
+		l, err := net.Listen("tcp", *addr)
+		if err != nil {
+			return err
+		}
+		page = newLivePage()
+		srv := &http.Server{Handler: page}
+		go srv.Serve(l)
+		defer srv.Close()
+		fmt.Fprintf(os.Stderr, "Serving the renders on http://%s\n", l.Addr())
+	}
+	return watchFile(ctx, *mapFile, *interval, func(data []byte) {
+		os.Stdout.Write(watchTerminal(data, conf))
+		if page != nil {
+			page.update(watchHTML(data, conf))
+		}
+	})
+}
+
+// watchConf is the configuration of the simulations of the watch mode
+type watchConf struct {
+	steps  bool
+	labels render.Labels
+	theme  *render.Theme
+	opts   []bender.Option
+}
+
+// watcher detects the saves of a file by its modification time and size, the standard library has no file notifications
+type watcher struct {
+	path    string
+	modTime time.Time
+	size    int64
+}
+
+// poll returns the content of the file if it was saved since the last poll, or if it's the first one
+// a missing file is unchanged as the editors may replace it on save, its absence is only an error on the first poll
+func (w *watcher) poll() ([]byte, bool, error) {
+	fi, err := os.Stat(w.path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) && !w.modTime.IsZero() {
+			return nil, false, nil
+		}
+		return nil, false, err
+	}
+	if fi.ModTime().Equal(w.modTime) && fi.Size() == w.size {
+		return nil, false, nil
+	}
+	data, err := compress.ReadFile(w.path)
+	if err != nil {
+		return nil, false, err
+	}
+	w.modTime, w.size = fi.ModTime(), fi.Size()
+	return data, true, nil
+}
+
+// watchFile calls fn with the content of the file now and after every save, until the context is done
+// the file is polled every interval
+func watchFile(ctx context.Context, path string, interval time.Duration, fn func(data []byte)) error {
+	w := &watcher{path: path}
+	data, _, err := w.poll()
+	if err != nil {
+		return err
+	}
+	fn(data)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+		data, changed, err := w.poll()
+		if err != nil {
+			// the file may be in the middle of a save, it's read again at the next one
+			continue
+		}
+		if changed {
+			fn(data)
+		}
+	}
+}
+
+// watchTerminal returns the terminal render of the simulation of the map held by data, on a cleared screen
+// the render is built before being printed at once so the screen doesn't flicker, the map errors are printed instead
+func watchTerminal(data []byte, conf watchConf) []byte {
+	buf := bytes.NewBufferString(clearScreen)
+	fmt.Fprintf(buf, "simulated at %s\n", time.Now().Format("15:04:05"))
+	plan, err := mapfile.ParsePlan(data)
+	if err == nil {
+		r := render.NewTerminalRenderer(buf, conf.steps, conf.labels)
+		r.SetTheme(conf.theme)
+		err = renderPlan(r, plan, conf.opts...)
+	}
+	if err != nil {
+		printError(buf, err, nil)
+	}
+	return buf.Bytes()
+}
+
+// watchHTML returns the HTML page of the simulation of the map held by data, or a page with the map errors
+func watchHTML(data []byte, conf watchConf) []byte {
+	buf := &bytes.Buffer{}
+	plan, err := mapfile.ParsePlan(data)
+	if err == nil {
+		r := render.NewHTMLRenderer(buf, conf.labels)
+		r.SetTheme(conf.theme)
+		err = renderPlan(r, plan, conf.opts...)
+	}
+	if err != nil {
+		msg := &bytes.Buffer{}
+		printError(msg, err, nil)
+		buf.Reset()
+		fmt.Fprintf(buf, "<!DOCTYPE html>\n<html>\n<body>\n<pre>%s</pre>\n</body>\n</html>\n", html.EscapeString(msg.String()))
+	}
+	return buf.Bytes()
+}
+
+// livePage serves the web UI of the watch mode: the page on /, the latest render on /render
+// and a render Server-Sent Event on /events every time the render is updated
+type livePage struct {
+	mux    *http.ServeMux
+	mu     sync.Mutex
+	render []byte
+	// number of updates
+	version int
+	// closed and replaced on every update
+	updated chan struct{}
+}
+
+// newLivePage returns the web UI without render
+func newLivePage() *livePage {
+	p := &livePage{mux: http.NewServeMux(), updated: make(chan struct{})}
+	p.mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		io.WriteString(w, watchPage)
+	})
+	p.mux.HandleFunc("/render", func(w http.ResponseWriter, r *http.Request) {
+		render, _, _ := p.latest()
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Write(render)
+	})
+	p.mux.HandleFunc("/events", p.events)
+	return p
+}
+
+// ServeHTTP serves the web UI
+func (p *livePage) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	p.mux.ServeHTTP(w, r)
+}
+
+// update replaces the render and notifies the browsers
+func (p *livePage) update(render []byte) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.render = render
+	p.version++
+	close(p.updated)
+	p.updated = make(chan struct{})
+}
+
+// latest returns the render, its version and the channel closed on its next update
+func (p *livePage) latest() ([]byte, int, chan struct{}) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.render, p.version, p.updated
+}
+
+// events sends a render event after every update until the browser leaves
+func (p *livePage) events(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher, _ := w.(http.Flusher)
+	_, seen, _ := p.latest()
+	for {
+		if flusher != nil {
+			flusher.Flush()
+		}
+		_, version, updated := p.latest()
+		if version == seen {
+			select {
+			case <-r.Context().Done():
+				return
+			case <-updated:
+			}
+			continue
+		}
+		seen = version
+		if _, err := fmt.Fprintf(w, "event: render\ndata: %d\n\n", version); err != nil {
+			return
+		}
+	}
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"bender/internal/render"
+)
+
+func TestWatcher(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "map.txt")
+	w := &watcher{path: path}
+	if _, _, err := w.poll(); err == nil {
+		t.Fatalf("Expected an error for a missing file")
+	}
+	if err := os.WriteFile(path, []byte("###\n#@$\n###\n"), 0644); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	testCases := []struct {
+		name     string
+		edit     func() error
+		expected string
+		changed  bool
+	}{
+		{name: "first", edit: func() error { return nil }, expected: "###\n#@$\n###\n", changed: true},
+		{name: "unchanged", edit: func() error { return nil }},
+		{name: "saved", edit: func() error { return os.WriteFile(path, []byte("####\n#@ $\n####\n"), 0644) }, expected: "####\n#@ $\n####\n", changed: true},
+		{name: "removed", edit: func() error { return os.Remove(path) }},
+		{name: "replaced", edit: func() error { return os.WriteFile(path, []byte("###\n#$@\n###\n"), 0644) }, expected: "###\n#$@\n###\n", changed: true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := tc.edit(); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			data, changed, err := w.poll()
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if changed != tc.changed || string(data) != tc.expected {
+				t.Fatalf("Wrong poll. Expected %v %q, got %v %q", tc.changed, tc.expected, changed, data)
+			}
+		})
+	}
+}
+
+func TestWatchFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "map.txt")
+	if err := os.WriteFile(path, []byte("###\n#@$\n###\n"), 0644); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	saves := make(chan string, 2)
+	done := make(chan error)
+	go func() {
+		done <- watchFile(ctx, path, time.Millisecond, func(data []byte) { saves <- string(data) })
+	}()
+	if s := <-saves; s != "###\n#@$\n###\n" {
+		t.Fatalf("Wrong content. Expected the initial map, got %q", s)
+	}
+	if err := os.WriteFile(path, []byte("####\n#@ $\n####\n"), 0644); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if s := <-saves; s != "####\n#@ $\n####\n" {
+		t.Fatalf("Wrong content. Expected the saved map, got %q", s)
+	}
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+}
+
+func TestWatchRender(t *testing.T) {
+	conf := watchConf{labels: render.LetterLabels}
+	testCases := []struct {
+		name     string
+		data     string
+		terminal string
+		html     string
+	}{
+		{name: "valid", data: "####\n#@ $\n####\n", terminal: "[E E]\n", html: "<script>"},
+		{name: "escaped", data: "###\n#@ \n###\n", terminal: "[E]\n", html: "<script>"},
+		{name: "invalid", data: "###\n# #\n###\n", terminal: "Failed with error: no start in the map\n", html: "<pre>Failed with error"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			out := string(watchTerminal([]byte(tc.data), conf))
+			if !strings.HasPrefix(out, clearScreen) || !strings.HasSuffix(out, tc.terminal) {
+				t.Fatalf("Wrong terminal render. Expected a cleared screen ending with %q, got %q", tc.terminal, out)
+			}
+			if page := string(watchHTML([]byte(tc.data), conf)); !strings.Contains(page, tc.html) {
+				t.Fatalf("Wrong HTML render. Expected %q in %q", tc.html, page)
+			}
+		})
+	}
+}
+
+func TestLivePage(t *testing.T) {
+	p := newLivePage()
+	p.update([]byte("first"))
+	srv := httptest.NewServer(p)
+	defer srv.Close()
+
+	res, err := http.Get(srv.URL + "/events")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer res.Body.Close()
+	if res.Header.Get("Content-Type") != "text/event-stream" {
+		t.Fatalf("Wrong content type. Expected text/event-stream, got %q", res.Header.Get("Content-Type"))
+	}
+	p.update([]byte("second"))
+	events := bufio.NewReader(res.Body)
+	for _, expected := range []string{"event: render\n", "data: 2\n"} {
+		line, err := events.ReadString('\n')
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if line != expected {
+			t.Fatalf("Wrong event. Expected %q, got %q", expected, line)
+		}
+	}
+
+	for path, expected := range map[string]string{"/": "EventSource", "/render": "second"} {
+		res, err := http.Get(srv.URL + path)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		body := &strings.Builder{}
+		bufio.NewReader(res.Body).WriteTo(body)
+		res.Body.Close()
+		if !strings.Contains(body.String(), expected) {
+			t.Fatalf("Wrong page %s. Expected %q in %q", path, expected, body.String())
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+
+	"bender/internal/analysis"
+	"bender/internal/bender"
+	"bender/internal/render"
+)
+
+// runWhatIf runs the whatif subcommand with the given arguments:
+// it toggles every cell of a map in turn and prints the most impactful edits
+func runWhatIf(args []string) error {
+	flags := flag.NewFlagSet("whatif", flag.ContinueOnError)
+	mapFile := flags.String("map", "", "file of the map to analyze, as text rows or JSON, - reads it from stdin (default the built-in map)")
+	top := flags.Int("top", 10, "number of edits printed, all of them if 0")
+	maxSteps := flags.Int("max-steps", 0, "maximum number of steps of every simulation, unlimited if 0")
+	heatmap := flags.String("heatmap", "", "SVG file to write the heatmap of the wall removals shortening the path or breaking loops to, like heat.svg")
+	themeConf := flags.String("theme", "classic", "look of the tiles of the heatmap: classic, unicode, emoji, roguelike or a theme file like mine.json")
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+	if err := noArgs(flags); err != nil {
+		return err
+	}
+	plan, err := loadPlan(*mapFile, os.Stdin)
+	if err != nil {
+		return err
+	}
+	theme, err := render.ParseTheme(*themeConf)
+	if err != nil {
+		return err
+	}
+	// the simulations of the variants share their configurations
+	memo := bender.NewMemo()
+	opts := []bender.Option{bender.WithMemo(memo)}
+	if *maxSteps > 0 {
+		opts = append(opts, bender.WithMaxSteps(*maxSteps))
+	}
+	if *top < 0 {
+		return fmt.Errorf("invalid number of edits %d", *top)
+	}
+	r, err := analysis.WhatIf(plan, opts...)
+	if err != nil {
+		return err
+	}
+	printMemoStats(memo)
+	if *heatmap != "" {
+		f, err := os.Create(*heatmap)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		if err := render.Heatmap(f, plan, r.Heat(), theme); err != nil {
+			return err
+		}
+	}
+	return whatIf(os.Stdout, r, *top)
+}
+
+// whatIf prints the top edits of the report ranked by impact, all of them if top is 0
+func whatIf(w io.Writer, r analysis.WhatIfReport, top int) error {
+	toggles := r.Toggles
+	if top > 0 && len(toggles) > top {
+		toggles = toggles[:top]
+	}
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintf(tw, "original\t\t%v\t%d\t\n", r.Outcome, r.Steps)
+	fmt.Fprintf(tw, "cell\tedit\toutcome\tsteps\tdelta\n")
+	for _, t := range toggles {
+		outcome := t.Outcome.String()
+		if t.Changed(r.Outcome) {
+			outcome += " *"
+		}
+		fmt.Fprintf(tw, "%s\t%q -> %q\t%s\t%d\t%+d\n", t.At, t.From, t.To, outcome, t.Steps, t.Delta)
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"bender/internal/analysis"
+)
+
+func TestWhatIf(t *testing.T) {
+	r, err := analysis.WhatIf(defaultPlan)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	buf := &bytes.Buffer{}
+	if err := whatIf(buf, r, 3); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != 5 {
+		t.Fatalf("Wrong number of lines. Expected 5, got %d:\n%s", len(lines), buf.String())
+	}
+	if !strings.HasPrefix(lines[0], "original") || !strings.Contains(lines[0], "reached") {
+		t.Fatalf("Wrong original run: %q", lines[0])
+	}
+	if !strings.Contains(lines[2], "'$' -> ' '") || !strings.Contains(lines[2], "loop *") {
+		t.Fatalf("Wrong most impactful edit: %q", lines[2])
+	}
+
+	buf.Reset()
+	if err := whatIf(buf, r, 0); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if n := strings.Count(buf.String(), "\n"); n != len(r.Toggles)+2 {
+		t.Fatalf("Wrong number of lines. Expected %d, got %d", len(r.Toggles)+2, n)
+	}
+}
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"syscall"
+
+	"bender/internal/bender"
+	"bender/internal/publish"
+	"bender/internal/render"
+	"bender/internal/worker"
+)
+
+// runWorker runs the worker subcommand with the given arguments:
+// it simulates the jobs of a directory or of a NATS queue until interrupted
+func runWorker(args []string) error {
+	flags := flag.NewFlagSet("worker", flag.ContinueOnError)
+	in := flags.String("in", "", "jobs to simulate: a directory or a NATS subject like nats://localhost:4222/maps")
+	out := flags.String("out", "", "directory of the results")
+	group := flags.String("queue-group", "bender-workers", "NATS queue group sharing the jobs among the workers")
+	renderKind := flags.String("render", "", "render written along every result: png, svg or cast")
+	replays := flags.Bool("replay", false, "write the replay of every simulation along its result, like map-1.bdr")
+	labelConf := flags.String("labels", "words", "direction labels of the renders: words, letters, arrows or a list like SOUTH=S,NORTH=N")
+	themeConf := flags.String("theme", "classic", "colors of the renders: classic, unicode, emoji, roguelike or a theme file like mine.json")
+	retries := flags.Int("retries", 3, "number of retries of a job failing for another reason than its map")
+	poll := flags.Duration("poll", 0, "interval between two scans of the input directory (0 means exit once it's empty)")
+	maxSteps := flags.Int("max-steps", 0, "stop the simulations after the given number of steps (0 means no limit)")
+	timeout := flags.Duration("timeout", 0, "stop the simulations after the given duration (0 means no limit)")
+	maxWorkers := flags.Int("max-workers", runtime.NumCPU(), "maximum number of jobs of the input directory simulated at once, tuned to the CPU and memory usage of the machine")
+	shardConf := flags.String("shard", "", "simulate only the shard i/n of the input directory, like 2/4, the results are combined by the merge command")
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+	if err := noArgs(flags); err != nil {
+		return err
+	}
+	if *in == "" || *out == "" {
+		return fmt.Errorf("both -in and -out are required")
+	}
+	labels, err := render.ParseLabels(*labelConf)
+	if err != nil {
+		return err
+	}
+	theme, err := render.ParseTheme(*themeConf)
+	if err != nil {
+		return err
+	}
+	var shard worker.Shard
+	if *shardConf != "" {
+		if strings.HasPrefix(*in, "nats://") {
+			return fmt.Errorf("-shard only applies to a directory, the workers of a queue group share its jobs")
+		}
+		if shard, err = worker.ParseShard(*shardConf); err != nil {
+			return err
+		}
+	}
+	// the corpora often hold the same maps or variants of a map
+	memo := bender.NewMemo()
+	defer printMemoStats(memo)
+	w := worker.New(worker.Config{
+		Out:        *out,
+		Render:     *renderKind,
+		Replay:     *replays,
+		Labels:     labels,
+		Theme:      theme,
+		Retries:    *retries,
+		Options:    []bender.Option{bender.WithMaxSteps(*maxSteps), bender.WithTimeout(*timeout)},
+		Memo:       memo,
+		Shard:      shard,
+		MaxWorkers: *maxWorkers,
+	})
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	if !strings.HasPrefix(*in, "nats://") {
+		return w.RunDir(ctx, *in, *poll)
+	}
+
+	addr, subject, _ := strings.Cut(strings.TrimPrefix(*in, "nats://"), "/")
+	if subject == "" {
+		return fmt.Errorf("no NATS subject in %q", *in)
+	}
+	if err := os.MkdirAll(*out, 0755); err != nil {
+		return err
+	}
+	nc, err := publish.DialNATS(addr)
+	if err != nil {
+		return err
+	}
+	defer nc.Close()
+	jobs, err := nc.Subscribe(subject, *group)
+	if err != nil {
+		return err
+	}
+	host, _ := os.Hostname()
+	err = w.RunQueue(ctx, jobs, fmt.Sprintf("%s-%s-%d", subject, host, os.Getpid()))
+	if dropped := nc.Dropped(); dropped > 0 {
+		// NATS delivers a job to a single worker of the group, the dropped ones are lost
+		fmt.Fprintf(os.Stderr, "dropped %d job(s) received while busy\n", dropped)
+	}
+	return err
+}
+
+// runMerge runs the merge subcommand with the given arguments:
+// it combines the results of the workers, like the shards of a corpus, into a single report
+func runMerge(args []string) error {
+	flags := flag.NewFlagSet("merge", flag.ContinueOnError)
+	out := flags.String("out", "", "file of the report (default stdout)")
+	htmlOut := flags.String("html", "", "file of a static HTML report of the jobs, with the thumbnails of the maps and the links to the replays")
+	themeConf := flags.String("theme", "classic", "colors of the thumbnails of the HTML report: classic, unicode, emoji, roguelike or a theme file like mine.json")
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+	if flags.NArg() == 0 {
+		return fmt.Errorf("no result directory to merge")
+	}
+	theme, err := render.ParseTheme(*themeConf)
+	if err != nil {
+		return err
+	}
+	rep, err := worker.Merge(flags.Args())
+	if err != nil {
+		return err
+	}
+	if *htmlOut != "" {
+		if err := writeHTMLReport(*htmlOut, rep, theme); err != nil {
+			return err
+		}
+	}
+	data, err := json.MarshalIndent(rep, "", "  ")
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+	if *out == "" {
+		_, err = os.Stdout.Write(data)
+		return err
+	}
+	return os.WriteFile(*out, data, 0644)
+}
+
+// writeHTMLReport writes the HTML report of the merged results to the file,
+// the links to the replays are relative to its directory
+func writeHTMLReport(name string, rep *worker.Report, theme *render.Theme) error {
+	linked := *rep
+	linked.Results = make([]worker.Artifact, len(rep.Results))
+	for i, a := range rep.Results {
+		if a.Replay != "" {
+			abs, err := filepath.Abs(a.Replay)
+			if err != nil {
+				return err
+			}
+			dir, err := filepath.Abs(filepath.Dir(name))
+			if err != nil {
+				return err
+			}
+			if a.Replay, err = filepath.Rel(dir, abs); err != nil {
+				return err
+			}
+			a.Replay = filepath.ToSlash(a.Replay)
+		}
+		linked.Results[i] = a
+	}
+	buf := &bytes.Buffer{}
+	if err := worker.WriteHTML(buf, &linked, theme); err != nil {
+		return err
+	}
+	return os.WriteFile(name, buf.Bytes(), 0644)
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"bender/internal/worker"
+)
+
+func TestRunWorker(t *testing.T) {
+	in, out := t.TempDir(), t.TempDir()
+	if err := os.WriteFile(filepath.Join(in, "map.txt"), []byte("####\n#@$#\n####\n"), 0644); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := runWorker([]string{"-in", in, "-out", out, "-render", "svg"}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	for _, f := range []string{filepath.Join(out, "map.json"), filepath.Join(out, "map.svg"), filepath.Join(in, "done", "map.txt")} {
+		if _, err := os.Stat(f); err != nil {
+			t.Fatalf("Expected %s to be written: %v", f, err)
+		}
+	}
+
+	for _, args := range [][]string{{"-in", in}, {"-in", "nats://localhost:4222", "-out", out}, {"-in", in, "-out", out, "-shard", "3/2"}, {"-in", "nats://localhost:4222/maps", "-out", out, "-shard", "1/2"}} {
+		if err := runWorker(args); err == nil {
+			t.Fatalf("Expected an error for the arguments %q", args)
+		}
+	}
+}
+
+func TestRunMerge(t *testing.T) {
+	in, outs := t.TempDir(), []string{t.TempDir(), t.TempDir()}
+	for _, name := range []string{"a.txt", "b.txt", "c.txt", "d.txt"} {
+		if err := os.WriteFile(filepath.Join(in, name), []byte("####\n#@$#\n####\n"), 0644); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+	for i, out := range outs {
+		if err := runWorker([]string{"-in", in, "-out", out, "-shard", fmt.Sprintf("%d/2", i+1), "-replay"}); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+	report := filepath.Join(t.TempDir(), "report.json")
+	page := filepath.Join(filepath.Dir(outs[0]), "report.html")
+	if err := runMerge(append([]string{"-out", report, "-html", page}, outs...)); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	data, err := os.ReadFile(report)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	rep := worker.Report{}
+	if err := json.Unmarshal(data, &rep); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if rep.Jobs != 4 || rep.Outcomes["reached"] != 4 {
+		t.Fatalf("Wrong report, got %+v", rep)
+	}
+	// the links to the replays are relative to the HTML report
+	html, err := os.ReadFile(page)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	for _, a := range rep.Results {
+		link := fmt.Sprintf(`href="%s/%s.bdr"`, filepath.Base(filepath.Dir(a.Replay)), a.Name)
+		if !strings.Contains(string(html), link) {
+			t.Fatalf("Expected the link %s in the HTML report:\n%s", link, html)
+		}
+	}
+	if err := runMerge(nil); err == nil {
+		t.Fatalf("Expected an error without directories")
+	}
+}